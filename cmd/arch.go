@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+// normalizeArch maps the kernel architecture names some images report to
+// the Go/OCI names used for the host.
+func normalizeArch(arch string) string {
+	switch arch {
+	case "x86_64", "x86-64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	default:
+		return arch
+	}
+}
+
+// imageArchMismatch describes how the image's architecture differs from
+// hostArch, or returns "" when they match or the image does not say.
+func imageArchMismatch(inspect image.InspectResponse, hostArch string) string {
+	if inspect.Architecture == "" || normalizeArch(inspect.Architecture) == normalizeArch(hostArch) {
+		return ""
+	}
+	platform := inspect.Architecture
+	if inspect.Os != "" {
+		platform = inspect.Os + "/" + platform
+	}
+	if inspect.Variant != "" {
+		platform += "/" + inspect.Variant
+	}
+	return fmt.Sprintf("image is %s but the host is %s", platform, normalizeArch(hostArch))
+}
+
+// checkImageArch warns when imageName would run under emulation on a host
+// of hostArch, which works but is slow; with strict set it fails instead.
+// An image that cannot be inspected is not reported here.
+func checkImageArch(ctx context.Context, cli imageInspectClient, imageName, hostArch string, strict bool) error {
+	inspect, err := cli.ImageInspect(ctx, imageName)
+	if err != nil {
+		debugf("Skipping architecture check for '%s': %v\n", imageName, err)
+		return nil
+	}
+	mismatch := imageArchMismatch(inspect, hostArch)
+	if mismatch == "" {
+		return nil
+	}
+
+	hint := fmt.Sprintf("use a multi-arch image or pull the native one with: docker pull --platform linux/%s %s", normalizeArch(hostArch), imageName)
+	if strict {
+		return fmt.Errorf("%s: %s (--strict-arch); %s", imageName, mismatch, hint)
+	}
+	warnf("%s: %s, so it runs under emulation and may be slow; %s", imageName, mismatch, hint)
+	return nil
+}
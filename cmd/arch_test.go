@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+)
+
+type fakeArchInspectClient struct {
+	inspect image.InspectResponse
+	err     error
+}
+
+func (f *fakeArchInspectClient) ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error) {
+	return f.inspect, f.err
+}
+
+func TestImageArchMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		inspect  image.InspectResponse
+		hostArch string
+		want     string
+	}{
+		{name: "same architecture", inspect: image.InspectResponse{Os: "linux", Architecture: "arm64"}, hostArch: "arm64", want: ""},
+		{name: "amd64 image on Apple Silicon", inspect: image.InspectResponse{Os: "linux", Architecture: "amd64"}, hostArch: "arm64", want: "image is linux/amd64 but the host is arm64"},
+		{name: "kernel names are normalized", inspect: image.InspectResponse{Os: "linux", Architecture: "aarch64"}, hostArch: "arm64", want: ""},
+		{name: "variant is reported", inspect: image.InspectResponse{Os: "linux", Architecture: "arm", Variant: "v7"}, hostArch: "amd64", want: "image is linux/arm/v7 but the host is amd64"},
+		{name: "unknown image architecture", inspect: image.InspectResponse{}, hostArch: "arm64", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageArchMismatch(tt.inspect, tt.hostArch); got != tt.want {
+				t.Errorf("imageArchMismatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckImageArch(t *testing.T) {
+	amd64Image := image.InspectResponse{Os: "linux", Architecture: "amd64"}
+
+	tests := []struct {
+		name    string
+		cli     *fakeArchInspectClient
+		strict  bool
+		wantErr bool
+	}{
+		{name: "mismatch only warns by default", cli: &fakeArchInspectClient{inspect: amd64Image}, strict: false, wantErr: false},
+		{name: "mismatch fails with --strict-arch", cli: &fakeArchInspectClient{inspect: amd64Image}, strict: true, wantErr: true},
+		{name: "match passes with --strict-arch", cli: &fakeArchInspectClient{inspect: image.InspectResponse{Architecture: "arm64"}}, strict: true, wantErr: false},
+		{name: "inspect failure is not reported", cli: &fakeArchInspectClient{err: fmt.Errorf("no such image")}, strict: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkImageArch(context.Background(), tt.cli, "ubuntu:22.04", "arm64", tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkImageArch() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "--platform linux/arm64") {
+				t.Errorf("error %q should suggest pulling with --platform", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/garaemon/devgo/pkg/dotfiles"
+)
+
+// defaultAttachSession is the session `devgo attach` uses without a name.
+const defaultAttachSession = "main"
+
+// defaultDetachKeys is used when --detach-keys is not given. Docker's
+// default ctrl-p,ctrl-q would take ctrl-p away from readline history.
+const defaultDetachKeys = "ctrl-@"
+
+// sessionHolders are the terminal multiplexers that keep `devgo attach`
+// sessions alive in the container, in order of preference. devgo does not
+// ship a binary into the container, so one of them has to be installed.
+var sessionHolders = []string{"tmux", "screen"}
+
+var attachSessionPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// detachKeyPattern matches one key of a docker detach sequence: a single
+// character or ctrl- with a letter or one of @[\]^_.
+var detachKeyPattern = regexp.MustCompile(`^(ctrl-[a-z@\[\\\]^_]|.)$`)
+
+// attachSession is the persistent session `devgo attach` opens; empty for a
+// plain `devgo shell`.
+var attachSession string
+
+func runAttachCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("attach takes at most one session name, got %q", args)
+	}
+	session := defaultAttachSession
+	if len(args) == 1 {
+		session = args[0]
+	}
+	if !attachSessionPattern.MatchString(session) {
+		return fmt.Errorf("invalid session name %q: use letters, digits, '.', '_' and '-'", session)
+	}
+
+	attachSession = session
+	defer func() { attachSession = "" }()
+	return runShellCommand(nil)
+}
+
+// validateDetachKeys accepts what `docker exec --detach-keys` does: a
+// comma-separated sequence of single characters and ctrl-<key>.
+func validateDetachKeys(keys string) error {
+	for _, key := range strings.Split(keys, ",") {
+		if !detachKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid --detach-keys %q: %q is neither a character nor ctrl-<key>", keys, key)
+		}
+	}
+	return nil
+}
+
+// resolveDetachKeys returns --detach-keys or defaultDetachKeys.
+func resolveDetachKeys() string {
+	if detachKeys != "" {
+		return detachKeys
+	}
+	return defaultDetachKeys
+}
+
+// detectSessionHolder returns the first of sessionHolders installed in the
+// container.
+func detectSessionHolder(ctx context.Context, exec dotfiles.Executor, user string) (string, error) {
+	for _, holder := range sessionHolders {
+		_, _, exitCode, err := exec.Exec(ctx, user, []string{"sh", "-c", "command -v " + holder})
+		if err == nil && exitCode == 0 {
+			return holder, nil
+		}
+	}
+	return "", fmt.Errorf("persistent sessions need %s in the container; install one in the image", strings.Join(sessionHolders, " or "))
+}
+
+// attachSessionName is the multiplexer session name of a devgo session.
+func attachSessionName(session string) string {
+	return "devgo-" + session
+}
+
+// attachShellCommand wraps shellCommand so it runs in the multiplexer
+// session, creating the session or attaching to it when it already runs.
+// Attaching detaches other clients, such as one left behind by a dropped
+// connection.
+func attachShellCommand(holder, session string, shellCommand []string) []string {
+	name := attachSessionName(session)
+	if holder == "screen" {
+		return append([]string{"screen", "-D", "-R", "-S", name}, shellCommand...)
+	}
+	return append([]string{"tmux", "new-session", "-A", "-D", "-s", name}, shellCommand...)
+}
+
+// attachSessionAlive reports whether the session still runs after the
+// client went away, i.e. it was detached rather than exited.
+func attachSessionAlive(ctx context.Context, exec dotfiles.Executor, user, holder, session string) bool {
+	name := attachSessionName(session)
+	if holder == "screen" {
+		stdout, _, _, err := exec.Exec(ctx, user, []string{"screen", "-ls", name})
+		return err == nil && strings.Contains(stdout, "."+name)
+	}
+	_, _, exitCode, err := exec.Exec(ctx, user, []string{"tmux", "has-session", "-t", "=" + name})
+	return err == nil && exitCode == 0
+}
+
+// printReattachHint tells the user how to get back to a detached session.
+func printReattachHint(session string) {
+	fmt.Fprintf(os.Stderr, "Detached from session '%s'; run 'devgo attach %s' to reattach\n", session, session)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeCommandExecutor answers execs by their space-joined command line.
+type fakeCommandExecutor struct {
+	exitCodes map[string]int
+	stdout    map[string]string
+}
+
+func (f *fakeCommandExecutor) Exec(ctx context.Context, user string, cmd []string) (string, string, int, error) {
+	line := strings.Join(cmd, " ")
+	code, ok := f.exitCodes[line]
+	if !ok {
+		code = 1
+	}
+	return f.stdout[line], "", code, nil
+}
+
+func TestValidateDetachKeys(t *testing.T) {
+	for _, keys := range []string{"ctrl-@", "ctrl-p,ctrl-q", "ctrl-x,x", "a", "ctrl-\\"} {
+		if err := validateDetachKeys(keys); err != nil {
+			t.Errorf("validateDetachKeys(%q) error = %v", keys, err)
+		}
+	}
+	for _, keys := range []string{"", "ctrl-", "ctrl-1", "alt-x", "ctrl-x,,x", "xy"} {
+		if err := validateDetachKeys(keys); err == nil {
+			t.Errorf("validateDetachKeys(%q) should fail", keys)
+		}
+	}
+}
+
+func TestDetectSessionHolder(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed []string
+		want      string
+		wantErr   bool
+	}{
+		{name: "tmux preferred", installed: []string{"tmux", "screen"}, want: "tmux"},
+		{name: "screen fallback", installed: []string{"screen"}, want: "screen"},
+		{name: "none installed", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &fakeCommandExecutor{exitCodes: map[string]int{}}
+			for _, holder := range tt.installed {
+				exec.exitCodes["sh -c command -v "+holder] = 0
+			}
+			got, err := detectSessionHolder(context.Background(), exec, "vscode")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "tmux or screen") {
+					t.Fatalf("detectSessionHolder() error = %v, want missing holder error", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("detectSessionHolder() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachShellCommand(t *testing.T) {
+	shell := []string{"/bin/bash", "-i"}
+	if got, want := attachShellCommand("tmux", "main", shell), []string{"tmux", "new-session", "-A", "-D", "-s", "devgo-main", "/bin/bash", "-i"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("attachShellCommand(tmux) = %v, want %v", got, want)
+	}
+	if got, want := attachShellCommand("screen", "build", shell), []string{"screen", "-D", "-R", "-S", "devgo-build", "/bin/bash", "-i"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("attachShellCommand(screen) = %v, want %v", got, want)
+	}
+}
+
+func TestAttachSessionAlive(t *testing.T) {
+	exec := &fakeCommandExecutor{
+		exitCodes: map[string]int{"tmux has-session -t =devgo-main": 0, "screen -ls devgo-main": 0, "screen -ls devgo-gone": 1},
+		stdout:    map[string]string{"screen -ls devgo-main": "There is a screen on:\n\t123.devgo-main\t(Detached)\n", "screen -ls devgo-gone": "No Sockets found"},
+	}
+	ctx := context.Background()
+
+	if !attachSessionAlive(ctx, exec, "vscode", "tmux", "main") {
+		t.Error("tmux session main should be alive")
+	}
+	if attachSessionAlive(ctx, exec, "vscode", "tmux", "gone") {
+		t.Error("tmux session gone should not be alive")
+	}
+	if !attachSessionAlive(ctx, exec, "vscode", "screen", "main") {
+		t.Error("screen session main should be alive")
+	}
+	if attachSessionAlive(ctx, exec, "vscode", "screen", "gone") {
+		t.Error("screen session gone should not be alive")
+	}
+}
+
+func TestRunAttachCommand_InvalidArgs(t *testing.T) {
+	if err := runAttachCommand([]string{"a", "b"}); err == nil {
+		t.Error("runAttachCommand() should reject two session names")
+	}
+	if err := runAttachCommand([]string{"my session"}); err == nil || !strings.Contains(err.Error(), "invalid session name") {
+		t.Errorf("runAttachCommand() error = %v, want invalid session name", err)
+	}
+}
@@ -1,15 +1,30 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+	"github.com/garaemon/devgo/pkg/constants"
 	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+	"github.com/garaemon/devgo/pkg/sshagent"
 )
 
+// imageInspectClient is the subset of the Docker API used to summarize a
+// built image.
+type imageInspectClient interface {
+	ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error)
+}
+
 func runBuildCommand(args []string) error {
 	workspaceDir, err := getWorkspaceDirectory()
 	if err != nil {
@@ -23,73 +38,247 @@ func runBuildCommand(args []string) error {
 
 	debugf("Using devcontainer config: %s\n", devcontainerPath)
 
-	devContainer, err := devcontainer.Parse(devcontainerPath)
+	resolved, err := resolveConfig(devcontainerPath, configOverrides)
 	if err != nil {
-		return fmt.Errorf("failed to parse devcontainer.json: %w", err)
+		return err
 	}
+	devContainer := resolved.DevContainer
 
 	if !devContainer.HasBuild() {
 		return fmt.Errorf("devcontainer.json does not have build configuration")
 	}
 
-	return buildDevContainer(devContainer, workspaceDir, devcontainerPath)
+	ctx := context.Background()
+
+	// initializeCommand may generate files the Dockerfile copies, so it runs
+	// before the build just as it does before up.
+	if !skipInitialize {
+		if err := executeInitializeCommand(ctx, devContainer, workspaceDir); err != nil {
+			return fmt.Errorf("failed to execute initialize command: %w", err)
+		}
+	}
+
+	if err := buildDevContainer(ctx, devContainer, workspaceDir, devcontainerPath); err != nil {
+		return err
+	}
+
+	cli, err := newEngineClient()
+	if err != nil {
+		warnf("failed to create Docker client to inspect the image: %v", err)
+		return nil
+	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+	if err := printBuiltImageSummary(ctx, cli, devContainer, workspaceDir, os.Stdout); err != nil {
+		warnf("failed to inspect built image: %v", err)
+	}
+	return nil
+}
+
+// printBuiltImageSummary prints the summary of the image buildDevContainer
+// built, under the first of its tags, so --tag is honoured.
+func printBuiltImageSummary(ctx context.Context, cli imageInspectClient, devContainer *devcontainer.DevContainer, workspaceDir string, w io.Writer) error {
+	return printImageSummary(ctx, cli, determineImageTags(devContainer, workspaceDir)[0], w)
+}
+
+// printImageSummary prints the size and layer count of the built image so
+// that bloat is noticed at build time.
+func printImageSummary(ctx context.Context, cli imageInspectClient, imageTag string, w io.Writer) error {
+	inspect, err := cli.ImageInspect(ctx, imageTag)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, formatImageSummary(imageTag, inspect))
+	return err
+}
+
+// formatImageSummary renders e.g. "myapp:latest: 1.2 GB, 12 layers".
+func formatImageSummary(imageTag string, inspect image.InspectResponse) string {
+	layers := len(inspect.RootFS.Layers)
+	unit := "layers"
+	if layers == 1 {
+		unit = "layer"
+	}
+	return fmt.Sprintf("%s: %s, %d %s", imageTag, formatImageSize(inspect.Size), layers, unit)
 }
 
-func buildDevContainer(devContainer *devcontainer.DevContainer, workspaceDir, devcontainerPath string) error {
+// formatImageSize formats a byte count with decimal units as docker does.
+func formatImageSize(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	value := float64(size)
+	i := 0
+	for value >= 1000 && i < len(units)-1 {
+		value /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+func buildDevContainer(ctx context.Context, devContainer *devcontainer.DevContainer, workspaceDir, devcontainerPath string) error {
+	imageTags := determineImageTags(devContainer, workspaceDir)
 	dockerfilePath := determineDockerfilePath(devContainer, devcontainerPath)
 	buildContext := determineBuildContext(devContainer, workspaceDir, devcontainerPath)
 
-	imageTag := determineImageTag(devContainer, workspaceDir)
-
-	debugf("Building image: %s\n", imageTag)
+	debugf("Building image: %s\n", strings.Join(imageTags, ", "))
 	debugf("Dockerfile: %s\n", dockerfilePath)
 	debugf("Build context: %s\n", buildContext)
 
-	buildArgs := []string{"build", "-t", imageTag, "-f", dockerfilePath}
-
-	// Add build arguments
-	args := devContainer.GetBuildArgs()
-	for key, value := range args {
-		buildArgs = append(buildArgs, "--build-arg", fmt.Sprintf("%s=%v", key, value))
+	hash, err := buildContextHash(dockerfilePath, buildContext, devContainer)
+	if err != nil {
+		// Without a hash the build simply always runs.
+		debugf("Not caching the build: %v\n", err)
 	}
 
-	// Add target stage for multi-stage builds
-	target := devContainer.GetBuildTarget()
-	if target != "" {
-		buildArgs = append(buildArgs, "--target", target)
+	if shouldSkipBuild(sharedBuildHash(ctx, imageTags), hash, forceBuild || noCache) {
+		debugf("Image %s is up to date with the build context, skipping the build\n", strings.Join(imageTags, ", "))
+	} else {
+		runtime := currentRuntime()
+		credentials := baseImageCredentials(runtime, dockerfilePath)
+		needsCredentials := runtime == devgoruntime.Docker && len(credentials) > 0
+		useCLI, err := useBuildCLI(containerEngine(), devContainer.GetBuildOptions(), buildSSH, sshagent.IsAvailable(), engineCLIAvailable(), needsCredentials)
+		if err != nil {
+			return err
+		}
+		if useCLI {
+			err = buildWithCLI(ctx, devContainer, workspaceDir, devcontainerPath, imageTags, hash)
+		} else {
+			if needsCredentials {
+				warnf("the base images need registry credentials, which BuildKit only takes from the docker CLI; building with the classic builder instead")
+			}
+			err = buildWithAPI(ctx, devContainer, buildContext, dockerfilePath, imageTags, hash, credentials)
+		}
+		if err != nil {
+			return err
+		}
+
+		debugf("Successfully built image: %s\n", strings.Join(imageTags, ", "))
 	}
 
-	// Add cache-from images
-	cacheFrom := devContainer.GetBuildCacheFrom()
-	for _, cache := range cacheFrom {
-		buildArgs = append(buildArgs, "--cache-from", cache)
+	if push {
+		for _, imageTag := range imageTags {
+			if err := pushImage(ctx, imageTag); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Add additional build options
-	options := devContainer.GetBuildOptions()
-	if options != nil {
-		buildArgs = append(buildArgs, options...)
+	return nil
+}
+
+// buildWithAPI builds the image through the engine API, sending credentials
+// for the registries of the base images.
+func buildWithAPI(ctx context.Context, devContainer *devcontainer.DevContainer, buildContext, dockerfilePath string, imageTags []string, hash string, credentials map[string]registry.AuthConfig) error {
+	cli, err := newImageBuildClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+
+	runtime := currentRuntime()
+	newOptions := func(dockerfile string) build.ImageBuildOptions {
+		return imageBuildOptions(devContainer, imageTags, dockerfile, hash, runtime, credentials)
+	}
+	debugf("Building through the %s API\n", runtime)
+	return buildImageWithAPI(ctx, cli, buildContext, dockerfilePath, newOptions, os.Stderr)
+}
 
-	buildArgs = append(buildArgs, buildContext)
+// buildWithCLI runs `<engine> build`, which builds that need SSH agent
+// forwarding or CLI build options go through.
+func buildWithCLI(ctx context.Context, devContainer *devcontainer.DevContainer, workspaceDir, devcontainerPath string, imageTags []string, hash string) error {
+	command := buildDockerCommand(containerEngine(), devContainer, workspaceDir, devcontainerPath, imageTags)
+	if hash != "" {
+		command = withBuildLabel(command, devgoLabel(constants.DevgoBuildHashLabel), hash)
+	}
 
-	cmd := exec.Command("docker", buildArgs...)
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	debugf("Running: docker %s\n", strings.Join(buildArgs, " "))
+	debugf("Running: %s\n", formatCommandLine(command))
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("docker build failed: %w", err)
+		return fmt.Errorf("%s build failed: %w", containerEngine(), err)
 	}
+	return nil
+}
 
-	debugf("Successfully built image: %s\n", imageTag)
+// buildDockerCommand returns the complete build command line, engine binary
+// first, that building devContainer runs. It has no side effects, so
+// `devgo inspect --resolve-build` can print it without building.
+func buildDockerCommand(engine string, devContainer *devcontainer.DevContainer, workspaceDir, devcontainerPath string, imageTags []string) []string {
+	return append([]string{engine}, buildDockerArgs(devContainer, workspaceDir, devcontainerPath, imageTags)...)
+}
 
-	if push {
-		return pushImage(imageTag)
+// buildDockerArgs returns the arguments passed to `docker build`: the
+// imageBuildOptions an API build uses, as flags, followed by the SSH
+// forwarding and "options" only the CLI has. Every entry of imageTags
+// becomes its own -t flag.
+func buildDockerArgs(devContainer *devcontainer.DevContainer, workspaceDir, devcontainerPath string, imageTags []string) []string {
+	dockerfilePath := determineDockerfilePath(devContainer, devcontainerPath)
+	buildContext := determineBuildContext(devContainer, workspaceDir, devcontainerPath)
+
+	opts := imageBuildOptions(devContainer, imageTags, dockerfilePath, "", currentRuntime(), nil)
+	buildArgs := append([]string{"build"}, buildOptionFlags(opts)...)
+
+	if resolveBuildSSH(buildSSH, sshagent.IsAvailable()) {
+		buildArgs = append(buildArgs, "--ssh", "default")
 	}
 
-	return nil
+	// Add additional build options
+	options := devContainer.GetBuildOptions()
+	if options != nil {
+		buildArgs = append(buildArgs, options...)
+	}
+
+	buildArgs = append(buildArgs, buildContext)
+	return buildArgs
+}
+
+// buildOptionFlags renders the API build options that have a `docker build`
+// flag, so a CLI build and the command inspect prints match an API build.
+// Remove and the builder version are the CLI's defaults already.
+func buildOptionFlags(opts build.ImageBuildOptions) []string {
+	var flags []string
+	for _, tag := range opts.Tags {
+		flags = append(flags, "-t", tag)
+	}
+	flags = append(flags, "-f", opts.Dockerfile)
+	for _, key := range sortedEnvKeys(opts.BuildArgs) {
+		flags = append(flags, "--build-arg", key+"="+*opts.BuildArgs[key])
+	}
+	if opts.Target != "" {
+		flags = append(flags, "--target", opts.Target)
+	}
+	for _, cache := range opts.CacheFrom {
+		flags = append(flags, "--cache-from", cache)
+	}
+	if opts.NoCache {
+		flags = append(flags, "--no-cache")
+	}
+	for _, key := range sortedEnvKeys(opts.Labels) {
+		flags = append(flags, "--label", key+"="+opts.Labels[key])
+	}
+	return flags
+}
+
+// resolveBuildSSH decides whether the build forwards the host SSH agent for
+// RUN --mount=type=ssh. An explicit --ssh wins; otherwise forwarding is on
+// whenever an agent is available, as it is for the container itself.
+func resolveBuildSSH(flag *bool, agentAvailable bool) bool {
+	if flag != nil {
+		return *flag
+	}
+	return agentAvailable
 }
 
 func determineDockerfilePath(devContainer *devcontainer.DevContainer, devcontainerPath string) string {
@@ -116,11 +305,28 @@ func determineBuildContext(devContainer *devcontainer.DevContainer, workspaceDir
 	return filepath.Join(filepath.Dir(devcontainerPath), context)
 }
 
+// determineImageTags returns every tag applied to the built image. Tags given
+// with --tag/-t are used as-is (in order); otherwise the single tag from
+// determineImageTag is used. The first entry is the image devgo runs.
+func determineImageTags(devContainer *devcontainer.DevContainer, workspaceDir string) []string {
+	if len(buildTags) > 0 {
+		return buildTags
+	}
+	return []string{determineImageTag(devContainer, workspaceDir)}
+}
+
+// determineImageTag returns the tag for the built image. The precedence is:
+// --image-name flag > "image" in devcontainer.json > a name derived from the
+// devcontainer name or workspace directory.
 func determineImageTag(devContainer *devcontainer.DevContainer, workspaceDir string) string {
 	if imageName != "" {
 		return imageName
 	}
 
+	if devContainer.Image != "" {
+		return devContainer.Image
+	}
+
 	if devContainer.Name != "" {
 		return fmt.Sprintf("devgo-%s:latest", sanitizeDockerName(devContainer.Name))
 	}
@@ -128,15 +334,15 @@ func determineImageTag(devContainer *devcontainer.DevContainer, workspaceDir str
 	return fmt.Sprintf("devgo-%s:latest", sanitizeDockerName(filepath.Base(workspaceDir)))
 }
 
-func pushImage(imageTag string) error {
+func pushImage(ctx context.Context, imageTag string) error {
 	debugf("Pushing image: %s\n", imageTag)
 
-	cmd := exec.Command("docker", "push", imageTag)
+	cmd := engineCommand(ctx, "push", imageTag)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("docker push failed: %w", err)
+		return fmt.Errorf("%s push failed: %w", containerEngine(), err)
 	}
 
 	debugf("Successfully pushed image: %s\n", imageTag)
@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
 	"github.com/garaemon/devgo/pkg/devcontainer"
 )
 
@@ -282,3 +289,369 @@ func TestRunBuildCommand(t *testing.T) {
 		})
 	}
 }
+
+// installFakeDocker puts a stub `docker` executable first on PATH that appends
+// each invocation's arguments as one line to the returned log file, so tests
+// can assert on the commands devgo shells out to without a Docker install.
+func installFakeDocker(t *testing.T) string {
+	t.Helper()
+	return installFakeEngine(t, "docker")
+}
+
+// installFakeEngine is installFakeDocker for an arbitrary engine binary name.
+func installFakeEngine(t *testing.T, name string) string {
+	t.Helper()
+
+	binDir := t.TempDir()
+	logPath := filepath.Join(t.TempDir(), name+".log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, name), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake %s: %v", name, err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+func TestDetermineImageTag_UsesImageFieldWithBuild(t *testing.T) {
+	originalImageName := imageName
+	defer func() { imageName = originalImageName }()
+	imageName = ""
+
+	devContainer := &devcontainer.DevContainer{
+		Name:  "myapp",
+		Image: "registry.example.com/team/app:dev",
+		Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+	}
+	if got := determineImageTag(devContainer, "/workspace/myproject"); got != "registry.example.com/team/app:dev" {
+		t.Errorf("determineImageTag() = %q, want the image field", got)
+	}
+
+	imageName = "override:latest"
+	if got := determineImageTag(devContainer, "/workspace/myproject"); got != "override:latest" {
+		t.Errorf("determineImageTag() = %q, want --image-name to win", got)
+	}
+}
+
+func TestBuildDockerArgs_MultipleTags(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	originalBuildTags := buildTags
+	defer func() { buildTags = originalBuildTags }()
+	buildTags = []string{"myapp:1.0", "myapp:latest"}
+
+	devContainer := &devcontainer.DevContainer{
+		Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+	}
+	devcontainerPath := "/workspace/.devcontainer/devcontainer.json"
+	tags := determineImageTags(devContainer, "/workspace")
+	args := buildDockerArgs(devContainer, "/workspace", devcontainerPath, tags)
+
+	want := []string{
+		"build",
+		"-t", "myapp:1.0",
+		"-t", "myapp:latest",
+		"-f", "/workspace/.devcontainer/Dockerfile",
+		"/workspace/.devcontainer",
+	}
+	if strings.Join(args, " ") != strings.Join(want, " ") {
+		t.Errorf("buildDockerArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestBuildOptionFlags(t *testing.T) {
+	value := "1.2"
+	opts := build.ImageBuildOptions{
+		Tags:       []string{"app:1", "app:latest"},
+		Dockerfile: "/src/.devcontainer/Dockerfile",
+		BuildArgs:  map[string]*string{"VERSION": &value},
+		Target:     "dev",
+		CacheFrom:  []string{"myorg/app:cache"},
+		NoCache:    true,
+		Labels:     map[string]string{"devgo.build-hash": "abc"},
+		Remove:     true,
+		Version:    build.BuilderBuildKit,
+	}
+
+	want := []string{
+		"-t", "app:1",
+		"-t", "app:latest",
+		"-f", "/src/.devcontainer/Dockerfile",
+		"--build-arg", "VERSION=1.2",
+		"--target", "dev",
+		"--cache-from", "myorg/app:cache",
+		"--no-cache",
+		"--label", "devgo.build-hash=abc",
+	}
+	if got := buildOptionFlags(opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("buildOptionFlags() = %v, want %v", got, want)
+	}
+}
+
+func TestDetermineImageTags_DefaultsToDerivedTag(t *testing.T) {
+	originalBuildTags := buildTags
+	originalImageName := imageName
+	defer func() {
+		buildTags = originalBuildTags
+		imageName = originalImageName
+	}()
+	buildTags = nil
+	imageName = ""
+
+	tags := determineImageTags(&devcontainer.DevContainer{Name: "myapp"}, "/workspace")
+	if len(tags) != 1 || tags[0] != "devgo-myapp:latest" {
+		t.Errorf("determineImageTags() = %v, want [devgo-myapp:latest]", tags)
+	}
+}
+
+func TestRunBuildCommand_RunsInitializeCommandFirst(t *testing.T) {
+	logPath := installFakeDocker(t)
+	fakeBuild := installFakeImageBuild(t)
+
+	tempDir := t.TempDir()
+	devcontainerDir := filepath.Join(tempDir, ".devcontainer")
+	if err := os.MkdirAll(devcontainerDir, 0755); err != nil {
+		t.Fatalf("failed to create devcontainer dir: %v", err)
+	}
+	devcontainerContent := `{
+		"name": "test-container",
+		"build": {"dockerfile": "Dockerfile"},
+		"initializeCommand": "echo initialize >> ` + logPath + `"
+	}`
+	devcontainerPath := filepath.Join(devcontainerDir, "devcontainer.json")
+	if err := os.WriteFile(devcontainerPath, []byte(devcontainerContent), 0644); err != nil {
+		t.Fatalf("failed to write devcontainer.json: %v", err)
+	}
+
+	originalWorkspaceFolder := workspaceFolder
+	originalConfigPath := configPath
+	originalSkipInitialize := skipInitialize
+	defer func() {
+		workspaceFolder = originalWorkspaceFolder
+		configPath = originalConfigPath
+		skipInitialize = originalSkipInitialize
+	}()
+	workspaceFolder = tempDir
+	configPath = devcontainerPath
+
+	tests := []struct {
+		name           string
+		skipInitialize bool
+		expected       []string
+	}{
+		{name: "initialize before build", expected: []string{"initialize"}},
+		{name: "skip initialize", skipInitialize: true, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+				t.Fatalf("failed to reset log: %v", err)
+			}
+			skipInitialize = tt.skipInitialize
+			// The fake build client checks the initialize log is complete
+			// by the time the build starts.
+			fakeBuild.builds = nil
+			originalNewClient := newImageBuildClient
+			defer func() { newImageBuildClient = originalNewClient }()
+			newImageBuildClient = func() (imageBuildClient, error) {
+				if data, _ := os.ReadFile(logPath); len(strings.Fields(string(data))) != len(tt.expected) {
+					t.Errorf("build started before initializeCommand finished, log = %q", data)
+				}
+				return fakeBuild, nil
+			}
+
+			if err := runBuildCommand([]string{}); err != nil {
+				t.Fatalf("runBuildCommand() error = %v", err)
+			}
+			if len(fakeBuild.builds) != 1 {
+				t.Fatalf("builds = %d, want 1", len(fakeBuild.builds))
+			}
+
+			logData, err := os.ReadFile(logPath)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatalf("failed to read log: %v", err)
+			}
+			lines := strings.Fields(string(logData))
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("log = %q, want entries starting with %v", lines, tt.expected)
+			}
+			for i, prefix := range tt.expected {
+				if !strings.HasPrefix(lines[i], prefix) {
+					t.Errorf("log line %d = %q, want prefix %q", i, lines[i], prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildDockerArgs_NoCache(t *testing.T) {
+	originalNoCache := noCache
+	defer func() { noCache = originalNoCache }()
+
+	devContainer := &devcontainer.DevContainer{
+		Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+	}
+	devcontainerPath := "/workspace/.devcontainer/devcontainer.json"
+
+	tests := []struct {
+		name    string
+		noCache bool
+		want    bool
+	}{
+		{name: "flag set", noCache: true, want: true},
+		{name: "flag unset", noCache: false, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noCache = tt.noCache
+			args := buildDockerArgs(devContainer, "/workspace", devcontainerPath, []string{"img"})
+			found := false
+			for _, arg := range args {
+				if arg == "--no-cache" {
+					found = true
+				}
+			}
+			if found != tt.want {
+				t.Errorf("buildDockerArgs() = %v, --no-cache present = %t, want %t", args, found, tt.want)
+			}
+		})
+	}
+}
+
+// recordingInspectClient answers every ImageInspect and records the IDs.
+type recordingInspectClient struct {
+	inspected []string
+}
+
+func (r *recordingInspectClient) ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error) {
+	r.inspected = append(r.inspected, imageID)
+	return image.InspectResponse{Size: 512}, nil
+}
+
+func TestPrintBuiltImageSummary(t *testing.T) {
+	originalBuildTags := buildTags
+	defer func() { buildTags = originalBuildTags }()
+	devContainer := &devcontainer.DevContainer{Name: "myapp", Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"}}
+
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{name: "default tag", want: "devgo-myapp:latest"},
+		{name: "--tag", tags: []string{"foo:1", "foo:latest"}, want: "foo:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buildTags = tt.tags
+			cli := &recordingInspectClient{}
+			var out bytes.Buffer
+			if err := printBuiltImageSummary(context.Background(), cli, devContainer, "/workspace/myapp", &out); err != nil {
+				t.Fatalf("printBuiltImageSummary() error = %v", err)
+			}
+			if !reflect.DeepEqual(cli.inspected, []string{tt.want}) {
+				t.Errorf("inspected %v, want [%s]", cli.inspected, tt.want)
+			}
+			if !strings.HasPrefix(out.String(), tt.want+": ") {
+				t.Errorf("summary = %q, want it for %s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatImageSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int64
+		layers   []string
+		expected string
+	}{
+		{
+			name:     "gigabytes",
+			size:     1_234_567_890,
+			layers:   []string{"sha256:a", "sha256:b", "sha256:c"},
+			expected: "myapp:latest: 1.2 GB, 3 layers",
+		},
+		{
+			name:     "megabytes",
+			size:     77_800_000,
+			layers:   []string{"sha256:a"},
+			expected: "myapp:latest: 77.8 MB, 1 layer",
+		},
+		{
+			name:     "bytes",
+			size:     512,
+			expected: "myapp:latest: 512 B, 0 layers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspect := image.InspectResponse{Size: tt.size, RootFS: image.RootFS{Layers: tt.layers}}
+			if got := formatImageSummary("myapp:latest", inspect); got != tt.expected {
+				t.Errorf("formatImageSummary() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveBuildSSH(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name      string
+		flag      *bool
+		available bool
+		want      bool
+	}{
+		{name: "defaults on with an agent", flag: nil, available: true, want: true},
+		{name: "defaults off without an agent", flag: nil, available: false, want: false},
+		{name: "--ssh forces it on", flag: &enabled, available: false, want: true},
+		{name: "--ssh=false turns it off", flag: &disabled, available: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveBuildSSH(tt.flag, tt.available); got != tt.want {
+				t.Errorf("resolveBuildSSH() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDockerArgs_SSH(t *testing.T) {
+	originalBuildSSH := buildSSH
+	defer func() { buildSSH = originalBuildSSH }()
+
+	agentSocket := filepath.Join(t.TempDir(), "agent.sock")
+	if err := os.WriteFile(agentSocket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	disabled := false
+
+	tests := []struct {
+		name     string
+		authSock string
+		flag     *bool
+		wantSSH  bool
+	}{
+		{name: "agent available", authSock: agentSocket, wantSSH: true},
+		{name: "no agent", authSock: "", wantSSH: false},
+		{name: "agent available but --ssh=false", authSock: agentSocket, flag: &disabled, wantSSH: false},
+	}
+
+	devContainer := &devcontainer.DevContainer{
+		Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SSH_AUTH_SOCK", tt.authSock)
+			buildSSH = tt.flag
+
+			args := buildDockerArgs(devContainer, "/workspace", "/workspace/.devcontainer/devcontainer.json", []string{"img"})
+			found := strings.Contains(strings.Join(args, " "), "--ssh default")
+			if found != tt.wantSSH {
+				t.Errorf("buildDockerArgs() = %v, --ssh default present = %t, want %t", args, found, tt.wantSSH)
+			}
+		})
+	}
+}
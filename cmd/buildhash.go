@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/garaemon/devgo/pkg/constants"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// dockerignoreRule is one line of a .dockerignore file.
+type dockerignoreRule struct {
+	pattern *regexp.Regexp
+	exclude bool
+}
+
+// loadDockerignore reads contextDir/.dockerignore. A missing file excludes
+// nothing.
+func loadDockerignore(contextDir string) ([]dockerignoreRule, error) {
+	f, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []dockerignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		exclude := true
+		if strings.HasPrefix(line, "!") {
+			exclude = false
+			line = strings.TrimSpace(line[1:])
+		}
+		line = strings.Trim(filepath.ToSlash(filepath.Clean(line)), "/")
+		pattern, err := dockerignorePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid .dockerignore pattern %q: %w", line, err)
+		}
+		rules = append(rules, dockerignoreRule{pattern: pattern, exclude: exclude})
+	}
+	return rules, scanner.Err()
+}
+
+// dockerignorePattern compiles a .dockerignore pattern: "*" and "?" stay
+// within one path element, "**" matches any number of them, "[...]" is a
+// character class as in filepath.Match and a backslash escapes the next
+// character.
+func dockerignorePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" also matches no directory at all.
+					i++
+					b.WriteString("(.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '\\':
+			// A backslash escapes the next character, e.g. `\[` for "[".
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		case '[':
+			class, n, err := dockerignoreClass(pattern[i:])
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(class)
+			i += n - 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// dockerignoreClass translates the character class pattern starts with,
+// such as [a-z], [!0-9] or [\]], into a regexp class and returns it with
+// the number of bytes of pattern it spans. Like "?", a negated class does
+// not match "/".
+func dockerignoreClass(pattern string) (string, int, error) {
+	var b strings.Builder
+	b.WriteString("[")
+	i := 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		b.WriteString("^/")
+		i++
+	}
+	start := i
+	for ; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == ']' && i > start {
+			b.WriteString("]")
+			return b.String(), i + 1, nil
+		}
+		escaped := c == '\\' && i+1 < len(pattern)
+		if escaped {
+			i++
+			c = pattern[i]
+		}
+		if c == '-' && !escaped && i > start && i+1 < len(pattern) && pattern[i+1] != ']' {
+			b.WriteByte('-')
+			continue
+		}
+		if strings.IndexByte(`\[]^-`, c) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return "", 0, fmt.Errorf("unterminated character class")
+}
+
+// dockerignored reports whether the context path rel (slash-separated) is
+// left out of the build context. As in docker, the last matching rule wins
+// and a rule matching a directory also covers everything below it.
+func dockerignored(rules []dockerignoreRule, rel string) bool {
+	ignored := false
+	for _, rule := range rules {
+		for p := rel; ; {
+			if rule.pattern.MatchString(p) {
+				ignored = rule.exclude
+				break
+			}
+			i := strings.LastIndex(p, "/")
+			if i < 0 {
+				break
+			}
+			p = p[:i]
+		}
+	}
+	return ignored
+}
+
+// buildContextHash hashes the inputs of a build: the Dockerfile, the build
+// args, target and options, and the path, mode and content of every file in
+// the build context that .dockerignore does not exclude. Equal hashes mean
+// a rebuild would start from the same inputs. The base images are not part
+// of the hash: a newer `FROM x:latest` on the registry needs --force-build.
+func buildContextHash(dockerfilePath, contextDir string, devContainer *devcontainer.DevContainer) (string, error) {
+	h := sha256.New()
+
+	dockerfile, err := os.ReadFile(dockerfilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+	fmt.Fprintf(h, "dockerfile\x00%d\x00", len(dockerfile))
+	h.Write(dockerfile)
+
+	args := devContainer.GetBuildArgs()
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "arg\x00%s=%v\x00", key, args[key])
+	}
+	fmt.Fprintf(h, "target\x00%s\x00", devContainer.GetBuildTarget())
+	for _, option := range devContainer.GetBuildOptions() {
+		fmt.Fprintf(h, "option\x00%s\x00", option)
+	}
+
+	rules, err := loadDockerignore(contextDir)
+	if err != nil {
+		return "", err
+	}
+	err = filepath.WalkDir(contextDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(contextDir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		// Exceptions ("!pattern") may re-include files below an ignored
+		// directory, so directories are always walked.
+		if d.IsDir() || dockerignored(rules, rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file\x00%s\x00%o\x00", rel, info.Mode())
+		if info.Mode()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00", link)
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash build context: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// shouldSkipBuild reports whether an image whose build hash label is
+// existingHash can be reused for a build with inputs hashing to hash.
+// force (--force-build or --no-cache) always builds.
+func shouldSkipBuild(existingHash, hash string, force bool) bool {
+	return !force && hash != "" && existingHash == hash
+}
+
+// sharedBuildHash returns the build hash label that every image in
+// imageTags carries, or "" when one of them is missing or was built from
+// other inputs, so a new tag added with -t still gets built.
+func sharedBuildHash(ctx context.Context, imageTags []string) string {
+	shared := ""
+	for i, tag := range imageTags {
+		hash := imageBuildHash(ctx, tag)
+		if hash == "" || (i > 0 && hash != shared) {
+			return ""
+		}
+		shared = hash
+	}
+	return shared
+}
+
+// imageBuildHash returns the build hash label of the local image imageName,
+// or "" when the image or the label does not exist. Tests replace it.
+var imageBuildHash = func(ctx context.Context, imageName string) string {
+	cli, err := newEngineClient()
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	inspect, err := cli.ImageInspect(ctx, imageName)
+	if err != nil || inspect.Config == nil {
+		return ""
+	}
+	return inspect.Config.Labels[devgoLabel(constants.DevgoBuildHashLabel)]
+}
+
+// withBuildLabel adds --label key=value to a build command line, right
+// before the trailing build context argument.
+func withBuildLabel(command []string, key, value string) []string {
+	last := len(command) - 1
+	labeled := append([]string{}, command[:last]...)
+	labeled = append(labeled, "--label", key+"="+value)
+	return append(labeled, command[last])
+}
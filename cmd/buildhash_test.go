@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func writeContextFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDockerignored(t *testing.T) {
+	dir := t.TempDir()
+	writeContextFiles(t, dir, map[string]string{
+		".dockerignore": "# build output\nnode_modules\n*.log\n**/tmp\nbuild\n!build/keep.txt\nfile[0-9].txt\n[!a].cache\n\\[x].md\n",
+	})
+	rules, err := loadDockerignore(dir)
+	if err != nil {
+		t.Fatalf("loadDockerignore() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "Dockerfile", want: false},
+		{path: "node_modules/pkg/index.js", want: true},
+		{path: "debug.log", want: true},
+		{path: "logs/debug.log", want: false},
+		{path: "tmp/scratch", want: true},
+		{path: "src/tmp/scratch", want: true},
+		{path: "build/out.bin", want: true},
+		{path: "build/keep.txt", want: false},
+		{path: "file1.txt", want: true},
+		{path: "file10.txt", want: false},
+		{path: "filea.txt", want: false},
+		{path: "a.cache", want: false},
+		{path: "b.cache", want: true},
+		{path: "[x].md", want: true},
+	}
+	for _, tt := range tests {
+		if got := dockerignored(rules, tt.path); got != tt.want {
+			t.Errorf("dockerignored(%q) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := dockerignorePattern("file[0-9.txt"); err == nil {
+		t.Error("dockerignorePattern() should reject an unterminated character class")
+	}
+}
+
+func TestBuildContextHash(t *testing.T) {
+	base := map[string]string{
+		"Dockerfile":    "FROM ubuntu\nCOPY . /src\n",
+		".dockerignore": "*.log\n",
+		"src/main.go":   "package main\n",
+		"debug.log":     "noise\n",
+	}
+	devContainer := &devcontainer.DevContainer{Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile", Args: map[string]interface{}{"VERSION": "1"}}}
+
+	hashOf := func(t *testing.T, files map[string]string, dc *devcontainer.DevContainer) string {
+		t.Helper()
+		dir := t.TempDir()
+		writeContextFiles(t, dir, files)
+		hash, err := buildContextHash(filepath.Join(dir, "Dockerfile"), dir, dc)
+		if err != nil {
+			t.Fatalf("buildContextHash() error = %v", err)
+		}
+		return hash
+	}
+	with := func(key, value string) map[string]string {
+		files := make(map[string]string)
+		for k, v := range base {
+			files[k] = v
+		}
+		files[key] = value
+		return files
+	}
+
+	baseHash := hashOf(t, base, devContainer)
+	if again := hashOf(t, base, devContainer); again != baseHash {
+		t.Error("identical inputs in another directory should hash the same")
+	}
+	if hashOf(t, with("debug.log", "other noise\n"), devContainer) != baseHash {
+		t.Error("a file excluded by .dockerignore should not change the hash")
+	}
+	if hashOf(t, with("src/main.go", "package main\n\nfunc main() {}\n"), devContainer) == baseHash {
+		t.Error("a changed context file should change the hash")
+	}
+	if hashOf(t, with("Dockerfile", "FROM debian\nCOPY . /src\n"), devContainer) == baseHash {
+		t.Error("a changed Dockerfile should change the hash")
+	}
+	otherArgs := &devcontainer.DevContainer{Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile", Args: map[string]interface{}{"VERSION": "2"}}}
+	if hashOf(t, base, otherArgs) == baseHash {
+		t.Error("changed build args should change the hash")
+	}
+}
+
+func TestShouldSkipBuild(t *testing.T) {
+	tests := []struct {
+		name         string
+		existingHash string
+		hash         string
+		force        bool
+		want         bool
+	}{
+		{name: "image built from the same inputs", existingHash: "abc", hash: "abc", want: true},
+		{name: "inputs changed", existingHash: "abc", hash: "def", want: false},
+		{name: "no image or no label", existingHash: "", hash: "abc", want: false},
+		{name: "hash unavailable", existingHash: "", hash: "", want: false},
+		{name: "--force-build", existingHash: "abc", hash: "abc", force: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldSkipBuild(tt.existingHash, tt.hash, tt.force); got != tt.want {
+				t.Errorf("shouldSkipBuild() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSharedBuildHash(t *testing.T) {
+	originalLookup := imageBuildHash
+	defer func() { imageBuildHash = originalLookup }()
+	labels := map[string]string{"app:1.0": "abc", "app:latest": "abc", "app:old": "def"}
+	imageBuildHash = func(ctx context.Context, imageName string) string { return labels[imageName] }
+
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{name: "single tag", tags: []string{"app:1.0"}, want: "abc"},
+		{name: "every tag built from the same inputs", tags: []string{"app:1.0", "app:latest"}, want: "abc"},
+		{name: "a tag does not exist yet", tags: []string{"app:1.0", "app:2.0"}, want: ""},
+		{name: "a tag was built from other inputs", tags: []string{"app:1.0", "app:old"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sharedBuildHash(context.Background(), tt.tags); got != tt.want {
+				t.Errorf("sharedBuildHash(%v) = %q, want %q", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithBuildLabel(t *testing.T) {
+	got := withBuildLabel([]string{"docker", "build", "-t", "img", "/ctx"}, "devgo.build-hash", "abc")
+	want := []string{"docker", "build", "-t", "img", "--label", "devgo.build-hash=abc", "/ctx"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("withBuildLabel() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildDevContainer_SkipsUnchangedImage(t *testing.T) {
+	dir := t.TempDir()
+	writeContextFiles(t, dir, map[string]string{"Dockerfile": "FROM ubuntu\n"})
+	devcontainerPath := filepath.Join(dir, "devcontainer.json")
+	devContainer := &devcontainer.DevContainer{Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"}}
+	hash, err := buildContextHash(filepath.Join(dir, "Dockerfile"), dir, devContainer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalLookup := imageBuildHash
+	originalForce := forceBuild
+	originalNoCache := noCache
+	defer func() {
+		imageBuildHash = originalLookup
+		forceBuild = originalForce
+		noCache = originalNoCache
+	}()
+	imageBuildHash = func(ctx context.Context, imageName string) string { return hash }
+
+	fakeBuild := installFakeImageBuild(t)
+	forceBuild = false
+	if err := buildDevContainer(context.Background(), devContainer, dir, devcontainerPath); err != nil {
+		t.Fatalf("buildDevContainer() error = %v", err)
+	}
+	if len(fakeBuild.builds) != 0 {
+		t.Errorf("expected the build to be skipped, built %d times", len(fakeBuild.builds))
+	}
+
+	forceBuild = true
+	if err := buildDevContainer(context.Background(), devContainer, dir, devcontainerPath); err != nil {
+		t.Fatalf("buildDevContainer() error = %v", err)
+	}
+	if len(fakeBuild.builds) != 1 {
+		t.Error("expected --force-build to run the build")
+	}
+
+	forceBuild = false
+	noCache = true
+	if err := buildDevContainer(context.Background(), devContainer, dir, devcontainerPath); err != nil {
+		t.Fatalf("buildDevContainer() error = %v", err)
+	}
+	if len(fakeBuild.builds) != 2 || !fakeBuild.builds[1].NoCache {
+		t.Error("expected --no-cache to run the build without the cache")
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// buildkitTraceID is the ID of the aux messages BuildKit reports build
+// progress in, each a protobuf encoded moby.buildkit.v1.StatusResponse.
+const buildkitTraceID = "moby.buildkit.trace"
+
+// Field numbers of the StatusResponse messages devgo prints.
+const (
+	statusVertexesField  = 1
+	statusLogsField      = 3
+	vertexDigestField    = 1
+	vertexNameField      = 3
+	vertexCachedField    = 4
+	vertexStartedField   = 5
+	vertexCompletedField = 6
+	vertexErrorField     = 7
+	logVertexField       = 1
+	logMsgField          = 4
+)
+
+// buildVertex is one step of a BuildKit build as far as the progress
+// output needs it.
+type buildVertex struct {
+	digest    string
+	name      string
+	cached    bool
+	started   bool
+	completed bool
+	err       string
+}
+
+// buildVertexLog is output of a step.
+type buildVertexLog struct {
+	vertex string
+	msg    []byte
+}
+
+// buildProgressPrinter prints BuildKit progress the way `docker build
+// --progress=plain` does: each step gets a number when it starts, and its
+// output and result are prefixed with it.
+type buildProgressPrinter struct {
+	out     io.Writer
+	steps   map[string]int
+	printed map[string]bool
+	done    map[string]bool
+	// err is the first progress message that could not be decoded.
+	err error
+}
+
+func newBuildProgressPrinter(out io.Writer) *buildProgressPrinter {
+	return &buildProgressPrinter{
+		out:     out,
+		steps:   map[string]int{},
+		printed: map[string]bool{},
+		done:    map[string]bool{},
+	}
+}
+
+// handleAux is the aux callback of jsonmessage.DisplayJSONMessagesStream.
+// Aux messages other than BuildKit traces, such as the image ID, are not
+// printed.
+func (p *buildProgressPrinter) handleAux(msg jsonmessage.JSONMessage) {
+	if msg.ID != buildkitTraceID || msg.Aux == nil || p.err != nil {
+		return
+	}
+	var data []byte
+	if err := json.Unmarshal(*msg.Aux, &data); err != nil {
+		p.err = fmt.Errorf("failed to read build progress: %w", err)
+		return
+	}
+	vertexes, logs, err := decodeBuildStatus(data)
+	if err != nil {
+		p.err = fmt.Errorf("failed to read build progress: %w", err)
+		return
+	}
+
+	for _, v := range vertexes {
+		if v.started || v.cached {
+			p.printStart(v)
+		}
+	}
+	for _, l := range logs {
+		step, ok := p.steps[l.vertex]
+		if !ok {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(l.msg), "\n"), "\n") {
+			fmt.Fprintf(p.out, "#%d %s\n", step, line)
+		}
+	}
+	for _, v := range vertexes {
+		if !v.completed || p.done[v.digest] {
+			continue
+		}
+		p.printStart(v)
+		p.done[v.digest] = true
+		step := p.steps[v.digest]
+		switch {
+		case v.err != "":
+			fmt.Fprintf(p.out, "#%d ERROR: %s\n", step, v.err)
+		case v.cached:
+			fmt.Fprintf(p.out, "#%d CACHED\n", step)
+		default:
+			fmt.Fprintf(p.out, "#%d DONE\n", step)
+		}
+	}
+}
+
+// printStart numbers v and prints its name the first time it is seen.
+func (p *buildProgressPrinter) printStart(v buildVertex) {
+	if p.printed[v.digest] {
+		return
+	}
+	step, ok := p.steps[v.digest]
+	if !ok {
+		step = len(p.steps) + 1
+		p.steps[v.digest] = step
+	}
+	p.printed[v.digest] = true
+	fmt.Fprintf(p.out, "#%d %s\n", step, v.name)
+}
+
+// decodeBuildStatus reads the vertexes and logs of a StatusResponse.
+// Statuses and warnings are skipped.
+func decodeBuildStatus(data []byte) ([]buildVertex, []buildVertexLog, error) {
+	var vertexes []buildVertex
+	var logs []buildVertexLog
+	err := decodeProtoFields(data, func(num protowire.Number, value []byte) error {
+		switch num {
+		case statusVertexesField:
+			v, err := decodeBuildVertex(value)
+			if err != nil {
+				return err
+			}
+			vertexes = append(vertexes, v)
+		case statusLogsField:
+			l, err := decodeBuildVertexLog(value)
+			if err != nil {
+				return err
+			}
+			logs = append(logs, l)
+		}
+		return nil
+	})
+	return vertexes, logs, err
+}
+
+func decodeBuildVertex(data []byte) (buildVertex, error) {
+	var v buildVertex
+	err := decodeProtoFields(data, func(num protowire.Number, value []byte) error {
+		switch num {
+		case vertexDigestField:
+			v.digest = string(value)
+		case vertexNameField:
+			v.name = string(value)
+		case vertexCachedField:
+			v.cached = len(value) > 0 && value[0] != 0
+		case vertexStartedField:
+			v.started = true
+		case vertexCompletedField:
+			v.completed = true
+		case vertexErrorField:
+			v.err = string(value)
+		}
+		return nil
+	})
+	return v, err
+}
+
+func decodeBuildVertexLog(data []byte) (buildVertexLog, error) {
+	var l buildVertexLog
+	err := decodeProtoFields(data, func(num protowire.Number, value []byte) error {
+		switch num {
+		case logVertexField:
+			l.vertex = string(value)
+		case logMsgField:
+			l.msg = value
+		}
+		return nil
+	})
+	return l, err
+}
+
+// decodeProtoFields calls fn for every field of the protobuf message data.
+// Length-delimited fields are passed as their bytes and varints as their
+// single-byte (bool) or raw encoding; fixed-width fields are skipped.
+func decodeProtoFields(data []byte, fn func(num protowire.Number, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(data)
+		case protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(data)
+			value = protowire.AppendVarint(nil, v)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if value == nil && typ != protowire.BytesType {
+			continue
+		}
+		if err := fn(num, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// encodeVertex encodes a BuildKit Vertex; started and completed become
+// empty timestamps.
+func encodeVertex(digest, name string, cached, started, completed bool, errMsg string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, vertexDigestField, protowire.BytesType)
+	b = protowire.AppendString(b, digest)
+	b = protowire.AppendTag(b, vertexNameField, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	if cached {
+		b = protowire.AppendTag(b, vertexCachedField, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	if started {
+		b = protowire.AppendTag(b, vertexStartedField, protowire.BytesType)
+		b = protowire.AppendBytes(b, nil)
+	}
+	if completed {
+		b = protowire.AppendTag(b, vertexCompletedField, protowire.BytesType)
+		b = protowire.AppendBytes(b, nil)
+	}
+	if errMsg != "" {
+		b = protowire.AppendTag(b, vertexErrorField, protowire.BytesType)
+		b = protowire.AppendString(b, errMsg)
+	}
+	return b
+}
+
+func encodeVertexLog(digest, msg string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, logVertexField, protowire.BytesType)
+	b = protowire.AppendString(b, digest)
+	b = protowire.AppendTag(b, 3, protowire.VarintType) // stream
+	b = protowire.AppendVarint(b, 1)
+	b = protowire.AppendTag(b, logMsgField, protowire.BytesType)
+	b = protowire.AppendBytes(b, []byte(msg))
+	return b
+}
+
+// traceMessage wraps vertexes and logs into a moby.buildkit.trace message.
+func traceMessage(t *testing.T, vertexes, logs [][]byte) jsonmessage.JSONMessage {
+	t.Helper()
+	var status []byte
+	for _, v := range vertexes {
+		status = protowire.AppendTag(status, statusVertexesField, protowire.BytesType)
+		status = protowire.AppendBytes(status, v)
+	}
+	for _, l := range logs {
+		status = protowire.AppendTag(status, statusLogsField, protowire.BytesType)
+		status = protowire.AppendBytes(status, l)
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aux := json.RawMessage(data)
+	return jsonmessage.JSONMessage{ID: buildkitTraceID, Aux: &aux}
+}
+
+func TestBuildProgressPrinter(t *testing.T) {
+	var out strings.Builder
+	p := newBuildProgressPrinter(&out)
+
+	p.handleAux(traceMessage(t, [][]byte{
+		encodeVertex("sha256:a", "[1/2] FROM alpine", true, true, true, ""),
+		encodeVertex("sha256:b", "[2/2] RUN make", false, true, false, ""),
+	}, nil))
+	p.handleAux(traceMessage(t, nil, [][]byte{encodeVertexLog("sha256:b", "compiling\nlinking\n")}))
+	p.handleAux(traceMessage(t, [][]byte{encodeVertex("sha256:b", "[2/2] RUN make", false, true, true, "")}, nil))
+	p.handleAux(traceMessage(t, [][]byte{encodeVertex("sha256:c", "exporting", false, true, true, "no space left")}, nil))
+
+	// Other aux messages, such as the image ID, are ignored.
+	imageID := json.RawMessage(`{"ID":"sha256:abc"}`)
+	p.handleAux(jsonmessage.JSONMessage{ID: "moby.image.id", Aux: &imageID})
+
+	if p.err != nil {
+		t.Fatalf("handleAux() error = %v", p.err)
+	}
+	want := strings.Join([]string{
+		"#1 [1/2] FROM alpine",
+		"#2 [2/2] RUN make",
+		"#1 CACHED",
+		"#2 compiling",
+		"#2 linking",
+		"#2 DONE",
+		"#3 exporting",
+		"#3 ERROR: no space left",
+	}, "\n") + "\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestBuildProgressPrinter_InvalidTrace(t *testing.T) {
+	p := newBuildProgressPrinter(&strings.Builder{})
+	data, _ := json.Marshal([]byte{0x0a, 0xff})
+	aux := json.RawMessage(data)
+	p.handleAux(jsonmessage.JSONMessage{ID: buildkitTraceID, Aux: &aux})
+	if p.err == nil {
+		t.Error("handleAux() should report a truncated trace")
+	}
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// parseCacheVolume parses a --cache-volume value of the form
+// "target=/path/in/container" and returns the target path.
+func parseCacheVolume(spec string) (string, error) {
+	key, target, ok := strings.Cut(spec, "=")
+	if !ok || key != "target" {
+		return "", fmt.Errorf("invalid --cache-volume %q: expected target=/path", spec)
+	}
+	if !path.IsAbs(target) {
+		return "", fmt.Errorf("invalid --cache-volume %q: target must be an absolute path", spec)
+	}
+	return path.Clean(target), nil
+}
+
+// cacheVolumeName returns the named volume used for target. Keying it by the
+// devcontainer ID keeps caches of different workspaces apart while letting a
+// recreated container pick up the same volume.
+func cacheVolumeName(devcontainerID, target string) string {
+	return fmt.Sprintf("devgo-cache-%s-%s", devcontainerID, sanitizeDockerName(strings.Trim(target, "/")))
+}
+
+// cacheVolumeBinds turns --cache-volume values into "volume:target" binds.
+// Docker creates a named volume the first time a bind refers to it.
+func cacheVolumeBinds(devcontainerID string, specs []string) ([]string, error) {
+	var binds []string
+	for _, spec := range specs {
+		target, err := parseCacheVolume(spec)
+		if err != nil {
+			return nil, err
+		}
+		binds = append(binds, fmt.Sprintf("%s:%s", cacheVolumeName(devcontainerID, target), target))
+	}
+	return binds, nil
+}
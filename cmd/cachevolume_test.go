@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCacheVolumeBinds(t *testing.T) {
+	tests := []struct {
+		name        string
+		specs       []string
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:     "no cache volumes",
+			specs:    nil,
+			expected: nil,
+		},
+		{
+			name:  "volumes keyed by devcontainer ID",
+			specs: []string{"target=/workspace/node_modules", "target=/root/.cache/go-build/"},
+			expected: []string{
+				"devgo-cache-abcd1234-workspace_node_modules:/workspace/node_modules",
+				"devgo-cache-abcd1234-root_.cache_go-build:/root/.cache/go-build",
+			},
+		},
+		{
+			name:        "missing target key",
+			specs:       []string{"/workspace/node_modules"},
+			expectError: true,
+		},
+		{
+			name:        "relative target",
+			specs:       []string{"target=node_modules"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binds, err := cacheVolumeBinds("abcd1234", tt.specs)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(binds, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("cacheVolumeBinds() = %v, want %v", binds, tt.expected)
+			}
+		})
+	}
+}
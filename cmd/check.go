@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// preflightDockerClient is the subset of the Docker API used by the
+// read-only checks of `devgo up --check-only`.
+type preflightDockerClient interface {
+	Ping(ctx context.Context) (types.Ping, error)
+	ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error)
+	DistributionInspect(ctx context.Context, imageRef, encodedRegistryAuth string) (registry.DistributionInspect, error)
+}
+
+// preflightCheck is a single read-only validation. Run must not pull, build,
+// or create anything.
+type preflightCheck struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// preflightResult is the outcome of one preflightCheck. Err is nil when the
+// check passed.
+type preflightResult struct {
+	Name string
+	Err  error
+}
+
+// runPreflightChecks runs every check (a failing check does not stop the
+// remaining ones) and reports whether all of them passed.
+func runPreflightChecks(ctx context.Context, checks []preflightCheck) ([]preflightResult, bool) {
+	results := make([]preflightResult, 0, len(checks))
+	ok := true
+	for _, check := range checks {
+		err := check.Run(ctx)
+		if err != nil {
+			ok = false
+		}
+		results = append(results, preflightResult{Name: check.Name, Err: err})
+	}
+	return results, ok
+}
+
+// printPreflightResults writes one PASS/FAIL line per result.
+func printPreflightResults(w io.Writer, results []preflightResult) error {
+	for _, result := range results {
+		var err error
+		if result.Err != nil {
+			_, err = fmt.Fprintf(w, "FAIL  %s: %v\n", result.Name, result.Err)
+		} else {
+			_, err = fmt.Fprintf(w, "PASS  %s\n", result.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write check result: %w", err)
+		}
+	}
+	return nil
+}
+
+// runUpCheckOnly implements `devgo up --check-only`: it validates the
+// configuration and environment without pulling or creating anything.
+func runUpCheckOnly(ctx context.Context, devcontainerPath string) error {
+	checks := []preflightCheck{}
+
+	var devContainer *devcontainer.DevContainer
+	resolved, parseErr := resolveEnvConfig(devcontainerPath, configOverrides)
+	if parseErr == nil {
+		devContainer = resolved.DevContainer
+	}
+	checks = append(checks, preflightCheck{
+		Name: "configuration",
+		Run:  func(context.Context) error { return parseErr },
+	})
+
+	cli, clientErr := newEngineClient()
+	if clientErr == nil {
+		defer func() {
+			if closeErr := cli.Close(); closeErr != nil {
+				warnf("failed to close Docker client: %v", closeErr)
+			}
+		}()
+	}
+	checks = append(checks, preflightCheck{
+		Name: "docker daemon",
+		Run: func(ctx context.Context) error {
+			if clientErr != nil {
+				return clientErr
+			}
+			_, err := cli.Ping(ctx)
+			return err
+		},
+	})
+
+	// The remaining checks need a parsed configuration.
+	if parseErr == nil {
+		workspaceDir := determineWorkspaceFolder(devcontainerPath)
+		var dockerClient preflightDockerClient
+		if clientErr == nil {
+			dockerClient = cli
+		}
+		checks = append(checks, buildPreflightChecks(devContainer, workspaceDir, devcontainerPath, dockerClient)...)
+	}
+
+	results, ok := runPreflightChecks(ctx, checks)
+	if err := printPreflightResults(os.Stdout, results); err != nil {
+		return err
+	}
+	if !ok {
+		failed := 0
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+			}
+		}
+		return fmt.Errorf("%d of %d preflight checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// buildPreflightChecks returns the configuration-dependent checks: image or
+// build inputs, compose files, forwarded host ports, and bind mount sources.
+// cli may be nil when no Docker client could be created; the image check then
+// fails instead of being skipped.
+func buildPreflightChecks(devContainer *devcontainer.DevContainer, workspaceDir, devcontainerPath string, cli preflightDockerClient) []preflightCheck {
+	var checks []preflightCheck
+
+	switch {
+	case devContainer.HasDockerCompose():
+		checks = append(checks, preflightCheck{
+			Name: "compose files",
+			Run: func(context.Context) error {
+				for _, file := range devContainer.GetDockerComposeFiles() {
+					if err := checkPathExists(filepath.Join(workspaceDir, file)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		})
+	case devContainer.HasBuild():
+		checks = append(checks, preflightCheck{
+			Name: "dockerfile",
+			Run: func(context.Context) error {
+				return checkPathExists(determineDockerfilePath(devContainer, devcontainerPath))
+			},
+		})
+	case devContainer.Image != "":
+		checks = append(checks, preflightCheck{
+			Name: "image " + devContainer.Image,
+			Run: func(ctx context.Context) error {
+				return checkImageResolvable(ctx, cli, devContainer.Image)
+			},
+		})
+	default:
+		checks = append(checks, preflightCheck{
+			Name: "image",
+			Run: func(context.Context) error {
+				return fmt.Errorf("devcontainer must specify an image, build configuration, or docker compose configuration")
+			},
+		})
+	}
+
+	for _, port := range forwardedHostPorts(devContainer) {
+		checks = append(checks, preflightCheck{
+			Name: fmt.Sprintf("port %d", port),
+			Run: func(context.Context) error {
+				// A taken port only fails the check when it cannot be remapped.
+				decision, err := decidePortPublish(port, port, portAttributesFor(devContainer, port), checkPortFree, os.Geteuid() == 0)
+				if err != nil {
+					return err
+				}
+				for _, warning := range decision.Warnings {
+					warnf("%s", warning)
+				}
+				return nil
+			},
+		})
+	}
+
+	checks = append(checks, preflightCheck{
+		Name: "workspace " + workspaceDir,
+		Run:  func(context.Context) error { return checkPathExists(workspaceDir) },
+	})
+	mounts := devContainer.Mounts
+	if workspaceMount, err := devContainer.GetWorkspaceMount(); err == nil && workspaceMount != nil {
+		mounts = append([]devcontainer.Mount{*workspaceMount}, mounts...)
+	}
+	for _, mount := range mounts {
+		if mount.Type != "" && mount.Type != "bind" {
+			continue
+		}
+		source := mount.Source
+		checks = append(checks, preflightCheck{
+			Name: "mount source " + source,
+			Run:  func(context.Context) error { return checkPathExists(source) },
+		})
+	}
+
+	return checks
+}
+
+// checkImageResolvable passes when the image is present locally or its
+// manifest can be fetched from the registry. Nothing is pulled.
+func checkImageResolvable(ctx context.Context, cli preflightDockerClient, imageName string) error {
+	if cli == nil {
+		return fmt.Errorf("no Docker client available to resolve the image")
+	}
+	if _, err := cli.ImageInspect(ctx, imageName); err == nil {
+		return nil
+	}
+	if _, err := cli.DistributionInspect(ctx, imageName, ""); err != nil {
+		return fmt.Errorf("image not found locally and manifest lookup failed: %w", err)
+	}
+	return nil
+}
+
+// forwardedHostPorts returns the host ports named in appPort and
+// forwardPorts. forwardPorts entries are numbers (3000) or "host:port"
+// strings; only the port part matters for the host-side check.
+func forwardedHostPorts(devContainer *devcontainer.DevContainer) []int {
+	var ports []int
+	for _, entry := range devContainer.GetAppPorts() {
+		if p, err := parseAppPort(entry); err == nil {
+			ports = append(ports, p.HostPort)
+		}
+	}
+	for _, entry := range devContainer.ForwardPorts {
+		if p, err := parseForwardPort(entry); err == nil {
+			ports = append(ports, p.Port)
+		}
+	}
+	return ports
+}
+
+// checkPortFree reports an error when the TCP port cannot be bound on the host.
+func checkPortFree(port int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("host port %d is not available: %w", port, err)
+	}
+	return listener.Close()
+}
+
+func checkPathExists(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("%s does not exist: %w", path, err)
+	}
+	return nil
+}
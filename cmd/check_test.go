@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestRunPreflightChecks_MixedResults(t *testing.T) {
+	ran := []string{}
+	check := func(name string, err error) preflightCheck {
+		return preflightCheck{
+			Name: name,
+			Run: func(context.Context) error {
+				ran = append(ran, name)
+				return err
+			},
+		}
+	}
+
+	results, ok := runPreflightChecks(context.Background(), []preflightCheck{
+		check("first", nil),
+		check("second", errors.New("boom")),
+		check("third", nil),
+	})
+
+	if ok {
+		t.Error("expected aggregate failure when one check fails")
+	}
+	if strings.Join(ran, ",") != "first,second,third" {
+		t.Errorf("expected every check to run in order after a failure, ran %v", ran)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("expected passing checks to have nil errors, got %v", results)
+	}
+	if results[1].Err == nil || results[1].Name != "second" {
+		t.Errorf("expected failing result for 'second', got %+v", results[1])
+	}
+
+	var out bytes.Buffer
+	if err := printPreflightResults(&out, results); err != nil {
+		t.Fatalf("printPreflightResults() error: %v", err)
+	}
+	want := "PASS  first\nFAIL  second: boom\nPASS  third\n"
+	if out.String() != want {
+		t.Errorf("printPreflightResults() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunPreflightChecks_AllPass(t *testing.T) {
+	results, ok := runPreflightChecks(context.Background(), []preflightCheck{
+		{Name: "a", Run: func(context.Context) error { return nil }},
+		{Name: "b", Run: func(context.Context) error { return nil }},
+	})
+	if !ok {
+		t.Errorf("expected aggregate success, got %+v", results)
+	}
+}
+
+func TestBuildPreflightChecks_PortsAndMounts(t *testing.T) {
+	workspaceDir := t.TempDir()
+	devcontainerDir := filepath.Join(workspaceDir, ".devcontainer")
+	if err := os.MkdirAll(devcontainerDir, 0755); err != nil {
+		t.Fatalf("failed to create devcontainer dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(devcontainerDir, "Dockerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+		t.Fatalf("failed to write Dockerfile: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	defer listener.Close()
+	busyPort := listener.Addr().(*net.TCPAddr).Port
+
+	remapListener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	defer remapListener.Close()
+	remappablePort := remapListener.Addr().(*net.TCPAddr).Port
+
+	devContainer := &devcontainer.DevContainer{
+		Build:        &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+		ForwardPorts: []interface{}{float64(busyPort), float64(remappablePort)},
+		PortsAttributes: map[string]devcontainer.PortAttributes{
+			fmt.Sprint(busyPort): {RequireLocalPort: true},
+		},
+		Mounts: []devcontainer.Mount{
+			{Type: "bind", Source: workspaceDir, Target: "/present"},
+			{Type: "bind", Source: filepath.Join(workspaceDir, "missing"), Target: "/missing"},
+			{Type: "volume", Source: "named-volume", Target: "/volume"},
+		},
+	}
+
+	checks := buildPreflightChecks(devContainer, workspaceDir, filepath.Join(devcontainerDir, "devcontainer.json"), nil)
+	results, ok := runPreflightChecks(context.Background(), checks)
+	if ok {
+		t.Fatal("expected aggregate failure for a required busy port and a missing mount source")
+	}
+
+	failed := map[string]bool{}
+	for _, result := range results {
+		failed[result.Name] = result.Err != nil
+	}
+	expectations := map[string]bool{
+		"dockerfile":                                             false,
+		fmt.Sprintf("port %d", busyPort):                         true,
+		fmt.Sprintf("port %d", remappablePort):                   false,
+		"workspace " + workspaceDir:                              false,
+		"mount source " + workspaceDir:                           false,
+		"mount source " + filepath.Join(workspaceDir, "missing"): true,
+	}
+	for name, wantFailed := range expectations {
+		gotFailed, present := failed[name]
+		if !present {
+			t.Errorf("expected a check named %q, got %v", name, results)
+			continue
+		}
+		if gotFailed != wantFailed {
+			t.Errorf("check %q failed=%t, want %t", name, gotFailed, wantFailed)
+		}
+	}
+	if _, present := failed["mount source named-volume"]; present {
+		t.Error("volume mounts should not be checked as host paths")
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// configChange is one difference reported by `devgo config diff`. Path is the
+// dotted field path; Old is nil for added fields and New is nil for removed
+// ones.
+type configChange struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+func runConfigCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("config requires a subcommand (available: diff, fmt)")
+	}
+
+	switch args[0] {
+	case "diff":
+		return runConfigDiffCommand(args[1:])
+	case "fmt":
+		return runConfigFmtCommand(args[1:])
+	default:
+		return fmt.Errorf("unknown config subcommand: %s", args[0])
+	}
+}
+
+func runConfigDiffCommand(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("config diff requires exactly two files: base and head")
+	}
+
+	base, err := loadConfigTree(args[0])
+	if err != nil {
+		return err
+	}
+	head, err := loadConfigTree(args[1])
+	if err != nil {
+		return err
+	}
+
+	printConfigChanges(os.Stdout, diffConfigValues("", base, head))
+	return nil
+}
+
+// loadConfigTree parses a devcontainer.json and returns it as a generic JSON
+// tree, so the diff is over the fields devgo understands rather than the
+// file's JSON5 formatting.
+func loadConfigTree(path string) (map[string]interface{}, error) {
+	resolved, err := resolveConfig(path, configOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	data, err := json.Marshal(resolved.DevContainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return tree, nil
+}
+
+// diffConfigValues walks base and head and returns their differences sorted
+// by path. Objects are compared key by key; any other value, including
+// arrays, is reported as a single change when it differs.
+func diffConfigValues(path string, base, head interface{}) []configChange {
+	baseMap, baseIsMap := base.(map[string]interface{})
+	headMap, headIsMap := head.(map[string]interface{})
+	if !baseIsMap || !headIsMap {
+		if reflect.DeepEqual(base, head) {
+			return nil
+		}
+		return []configChange{{Path: path, Old: base, New: head}}
+	}
+
+	keys := make(map[string]bool)
+	for key := range baseMap {
+		keys[key] = true
+	}
+	for key := range headMap {
+		keys[key] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var changes []configChange
+	for _, key := range sortedKeys {
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		changes = append(changes, diffConfigValues(childPath, baseMap[key], headMap[key])...)
+	}
+	return changes
+}
+
+// printConfigChanges writes one line per change: "+" for added fields, "-"
+// for removed fields and "~" for changed ones.
+func printConfigChanges(w io.Writer, changes []configChange) {
+	for _, change := range changes {
+		switch {
+		case change.Old == nil:
+			fmt.Fprintf(w, "+ %s: %s\n", change.Path, formatConfigValue(change.New))
+		case change.New == nil:
+			fmt.Fprintf(w, "- %s: %s\n", change.Path, formatConfigValue(change.Old))
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", change.Path, formatConfigValue(change.Old), formatConfigValue(change.New))
+		}
+	}
+}
+
+func formatConfigValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
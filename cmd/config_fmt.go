@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/titanous/json5"
+)
+
+func runConfigFmtCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("config fmt takes at most one file")
+	}
+
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		devcontainerPath, err := findDevcontainerConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to find devcontainer config: %w", err)
+		}
+		path = devcontainerPath
+	}
+
+	return formatConfigFile(path, fmtCheck, os.Stdout)
+}
+
+// formatConfigFile rewrites path in canonical form. With check set the file
+// is left alone; if it is not already canonical its path is printed to w and
+// an error is returned, so CI fails.
+func formatConfigFile(path string, check bool, w io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	formatted, err := formatConfig(data)
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %w", path, err)
+	}
+	if bytes.Equal(data, formatted) {
+		return nil
+	}
+
+	if check {
+		fmt.Fprintln(w, path)
+		return fmt.Errorf("%s is not formatted; run 'devgo config fmt'", path)
+	}
+
+	if hasJSON5Comments(data) {
+		warnf("comments in %s are removed by formatting", path)
+	}
+	if err := os.WriteFile(path, formatted, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// formatConfig returns the canonical form of a devcontainer.json: plain JSON
+// with two-space indentation, keys sorted, and a trailing newline. JSON5
+// syntax such as comments, trailing commas and unquoted keys does not
+// survive; numbers keep their spelling when it is valid JSON.
+func formatConfig(data []byte) ([]byte, error) {
+	decoder := json5.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	tree, err := toJSONNumbers(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(tree); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// toJSONNumbers replaces the json5.Number values in tree with json.Number so
+// they are encoded as numbers. JSON5-only spellings (hex, leading +, .5) are
+// rewritten in decimal; Infinity and NaN have no JSON form.
+func toJSONNumbers(tree interface{}) (interface{}, error) {
+	switch v := tree.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			converted, err := toJSONNumbers(value)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+	case []interface{}:
+		for i, value := range v {
+			converted, err := toJSONNumbers(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+	case json5.Number:
+		return jsonNumber(string(v))
+	}
+	return tree, nil
+}
+
+func jsonNumber(s string) (json.Number, error) {
+	if json.Valid([]byte(s)) {
+		return json.Number(s), nil
+	}
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return json.Number(strconv.FormatInt(n, 10)), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("number %s cannot be represented in JSON", s)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+// hasJSON5Comments reports whether data contains a // or /* comment outside
+// of string literals.
+func hasJSON5Comments(data []byte) bool {
+	var quote byte
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+			continue
+		}
+		if c == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*') {
+			return true
+		}
+	}
+	return false
+}
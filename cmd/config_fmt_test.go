@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "sorts keys and indents",
+			input: `{"name":"dev","image":"ubuntu","containerEnv":{"B":"2","A":"1"}}`,
+			want: `{
+  "containerEnv": {
+    "A": "1",
+    "B": "2"
+  },
+  "image": "ubuntu",
+  "name": "dev"
+}
+`,
+		},
+		{
+			name: "json5 syntax",
+			input: `{
+  // the image
+  image: 'ubuntu', /* trailing comma next */
+  forwardPorts: [3000, 0x1F90,],
+}`,
+			want: `{
+  "forwardPorts": [
+    3000,
+    8080
+  ],
+  "image": "ubuntu"
+}
+`,
+		},
+		{
+			name:  "numbers keep their spelling",
+			input: `{"a": 1.50, "b": 12345678901234567890}`,
+			want: `{
+  "a": 1.50,
+  "b": 12345678901234567890
+}
+`,
+		},
+		{
+			name:  "html characters are not escaped",
+			input: `{"postCreateCommand": "make && make test > out"}`,
+			want: `{
+  "postCreateCommand": "make && make test > out"
+}
+`,
+		},
+		{name: "infinity has no json form", input: `{"a": Infinity}`, wantErr: true},
+		{name: "invalid input", input: `{"a": }`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatConfig([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatConfig() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("formatConfig() =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			again, err := formatConfig(got)
+			if err != nil {
+				t.Fatalf("formatConfig() on formatted output error = %v", err)
+			}
+			if !bytes.Equal(again, got) {
+				t.Errorf("formatting is not idempotent:\n%s\nthen\n%s", got, again)
+			}
+		})
+	}
+}
+
+func TestFormatConfigFile_Check(t *testing.T) {
+	formatted := "{\n  \"image\": \"ubuntu\"\n}\n"
+	unformatted := `{"image": "ubuntu"}`
+
+	tests := []struct {
+		name       string
+		content    string
+		check      bool
+		wantErr    bool
+		wantOutput string
+		wantFile   string
+	}{
+		{name: "check formatted", content: formatted, check: true, wantErr: false, wantFile: formatted},
+		{name: "check unformatted", content: unformatted, check: true, wantErr: true, wantOutput: "devcontainer.json", wantFile: unformatted},
+		{name: "write unformatted", content: unformatted, check: false, wantErr: false, wantFile: formatted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "devcontainer.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			err := formatConfigFile(path, tt.check, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatConfigFile() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantOutput != "" && !bytes.Contains(out.Bytes(), []byte(tt.wantOutput)) {
+				t.Errorf("output = %q, want it to name %q", out.String(), tt.wantOutput)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.wantFile {
+				t.Errorf("file = %q, want %q", data, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestHasJSON5Comments(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: `{"a": 1}`, want: false},
+		{input: "{\n  // comment\n  \"a\": 1\n}", want: true},
+		{input: `{"a": 1 /* block */}`, want: true},
+		{input: `{"url": "https://example.com"}`, want: false},
+		{input: `{'glob': '/*.go'}`, want: false},
+		{input: `{"q": "a \" // b"}`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := hasJSON5Comments([]byte(tt.input)); got != tt.want {
+			t.Errorf("hasJSON5Comments(%q) = %t, want %t", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffConfigValues(t *testing.T) {
+	tests := []struct {
+		name string
+		base map[string]interface{}
+		head map[string]interface{}
+		want []configChange
+	}{
+		{
+			name: "identical",
+			base: map[string]interface{}{"image": "ubuntu"},
+			head: map[string]interface{}{"image": "ubuntu"},
+			want: nil,
+		},
+		{
+			name: "scalar change",
+			base: map[string]interface{}{"image": "ubuntu:22.04"},
+			head: map[string]interface{}{"image": "ubuntu:24.04"},
+			want: []configChange{{Path: "image", Old: "ubuntu:22.04", New: "ubuntu:24.04"}},
+		},
+		{
+			name: "added and removed keys",
+			base: map[string]interface{}{"remoteUser": "vscode"},
+			head: map[string]interface{}{"shell": "/bin/zsh"},
+			want: []configChange{
+				{Path: "remoteUser", Old: "vscode"},
+				{Path: "shell", New: "/bin/zsh"},
+			},
+		},
+		{
+			name: "nested map changes",
+			base: map[string]interface{}{
+				"containerEnv": map[string]interface{}{"A": "1", "B": "2"},
+			},
+			head: map[string]interface{}{
+				"containerEnv": map[string]interface{}{"A": "1", "B": "3", "C": "4"},
+			},
+			want: []configChange{
+				{Path: "containerEnv.B", Old: "2", New: "3"},
+				{Path: "containerEnv.C", New: "4"},
+			},
+		},
+		{
+			name: "arrays compared as a whole",
+			base: map[string]interface{}{"forwardPorts": []interface{}{float64(3000)}},
+			head: map[string]interface{}{"forwardPorts": []interface{}{float64(3000), float64(8080)}},
+			want: []configChange{{
+				Path: "forwardPorts",
+				Old:  []interface{}{float64(3000)},
+				New:  []interface{}{float64(3000), float64(8080)},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffConfigValues("", tt.base, tt.head)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffConfigValues() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTreeIgnoresFormatting(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.json")
+	headPath := filepath.Join(dir, "head.json")
+	if err := os.WriteFile(basePath, []byte(`{"name": "dev", "image": "ubuntu"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	head := `{
+  // same configuration, JSON5 style
+  image: "ubuntu",
+  name: "dev",
+}`
+	if err := os.WriteFile(headPath, []byte(head), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := loadConfigTree(basePath)
+	if err != nil {
+		t.Fatalf("loadConfigTree(base) error = %v", err)
+	}
+	headTree, err := loadConfigTree(headPath)
+	if err != nil {
+		t.Fatalf("loadConfigTree(head) error = %v", err)
+	}
+	if changes := diffConfigValues("", base, headTree); len(changes) != 0 {
+		t.Errorf("expected no changes, got %#v", changes)
+	}
+}
+
+func TestPrintConfigChanges(t *testing.T) {
+	var buf bytes.Buffer
+	printConfigChanges(&buf, []configChange{
+		{Path: "containerEnv.DEBUG", New: "1"},
+		{Path: "image", Old: "ubuntu:22.04", New: "ubuntu:24.04"},
+		{Path: "remoteUser", Old: "vscode"},
+	})
+
+	want := "+ containerEnv.DEBUG: \"1\"\n" +
+		"~ image: \"ubuntu:22.04\" -> \"ubuntu:24.04\"\n" +
+		"- remoteUser: \"vscode\"\n"
+	if buf.String() != want {
+		t.Errorf("printConfigChanges() = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// devcontainerConfigHash hashes the resolved configuration a container is
+// created from, plus the modification time of dockerfilePath when the image
+// is built, so a container can be recognised as stale after either
+// changes. Image must already name the image to run.
+func devcontainerConfigHash(devContainer *devcontainer.DevContainer, dockerfilePath string) (string, error) {
+	data, err := json.Marshal(devContainer)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash devcontainer config: %w", err)
+	}
+	h := sha256.New()
+	h.Write(data)
+	if dockerfilePath != "" {
+		if info, err := os.Stat(dockerfilePath); err == nil {
+			fmt.Fprintf(h, "\x00dockerfile\x00%d", info.ModTime().UnixNano())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// containerConfigChanged reports whether containerName was created from a
+// configuration other than the one hashing to hash. Containers created
+// before devgo recorded the hash are not reported.
+func containerConfigChanged(ctx context.Context, dockerClient DockerClient, containerName, hash string) (bool, error) {
+	existing, err := dockerClient.ContainerConfigHash(ctx, containerName)
+	if err != nil {
+		return false, err
+	}
+	return existing != "" && hash != "" && existing != hash, nil
+}
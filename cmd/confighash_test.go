@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestDevcontainerConfigHash(t *testing.T) {
+	dockerfile := filepath.Join(t.TempDir(), "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte("FROM ubuntu\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := func(dc *devcontainer.DevContainer, path string) string {
+		t.Helper()
+		h, err := devcontainerConfigHash(dc, path)
+		if err != nil {
+			t.Fatalf("devcontainerConfigHash() error = %v", err)
+		}
+		return h
+	}
+
+	base := hash(&devcontainer.DevContainer{Image: "ubuntu", ContainerEnv: map[string]string{"A": "1", "B": "2"}}, dockerfile)
+	if again := hash(&devcontainer.DevContainer{Image: "ubuntu", ContainerEnv: map[string]string{"B": "2", "A": "1"}}, dockerfile); again != base {
+		t.Errorf("hash of the same config changed: %s != %s", again, base)
+	}
+	if changed := hash(&devcontainer.DevContainer{Image: "ubuntu", ContainerEnv: map[string]string{"A": "1"}}, dockerfile); changed == base {
+		t.Error("hash did not change with containerEnv")
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(dockerfile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if touched := hash(&devcontainer.DevContainer{Image: "ubuntu", ContainerEnv: map[string]string{"A": "1", "B": "2"}}, dockerfile); touched == base {
+		t.Error("hash did not change with the Dockerfile modification time")
+	}
+}
+
+func TestStartContainerWithDocker_StaleConfig(t *testing.T) {
+	originalReuse := reuseStopped
+	originalRemove := removeExisting
+	defer func() {
+		reuseStopped = originalReuse
+		removeExisting = originalRemove
+	}()
+	reuseStopped = true
+
+	devContainer := &devcontainer.DevContainer{Image: "ubuntu:22.04"}
+	current, err := devcontainerConfigHash(devContainer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		existingHash   string
+		removeExisting bool
+		wantRecreate   bool
+	}{
+		{name: "unchanged config is reused", existingHash: current},
+		{name: "container without hash is reused", existingHash: ""},
+		{name: "changed config only warns", existingHash: "old"},
+		{name: "changed config with --remove-existing-container", existingHash: "old", removeExisting: true, wantRecreate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removeExisting = tt.removeExisting
+			mock := newMockDockerClient()
+			mock.addImage("ubuntu:22.04")
+			mock.addContainer("test-container", false)
+			mock.configHashes = map[string]string{"test-container": tt.existingHash}
+
+			if err := startContainerWithDocker(context.Background(), devContainer, "test-container", t.TempDir(), mock); err != nil {
+				t.Fatalf("startContainerWithDocker() error = %v", err)
+			}
+			recreated := len(mock.removedContainers) == 1 && len(mock.createdContainers) == 1
+			if recreated != tt.wantRecreate {
+				t.Fatalf("recreated = %t, want %t", recreated, tt.wantRecreate)
+			}
+			if recreated && mock.createdContainers[0].ConfigHash != current {
+				t.Errorf("ConfigHash = %q, want %q", mock.createdContainers[0].ConfigHash, current)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// containerCopyClient is the subset of the Docker API used to place files
+// into a container.
+type containerCopyClient interface {
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+}
+
+// tarSingleFile returns a tar archive holding one regular file, which is the
+// format CopyToContainer expects.
+func tarSingleFile(name string, data []byte, mode int64) (io.Reader, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return nil, fmt.Errorf("failed to write tar header for %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write tar content for %s: %w", name, err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish tar archive for %s: %w", name, err)
+	}
+	return &buf, nil
+}
+
+// tarDirectory returns a tar archive of the host directory root with every
+// entry under prefix, so extracting it creates prefix/... . Entries are
+// written by writeTarEntry.
+func tarDirectory(root, prefix string) (io.Reader, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return writeTarEntry(tw, p, path.Join(prefix, filepath.ToSlash(rel)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish tar archive for %s: %w", root, err)
+	}
+	return &buf, nil
+}
+
+// writeTarEntry archives the host path p as name. Regular files,
+// directories and symlinks are archived; other file types are skipped.
+func writeTarEntry(tw *tar.Writer, p, name string) error {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() && !info.IsDir() && info.Mode()&fs.ModeSymlink == 0 {
+		return nil
+	}
+
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(p); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", p, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to write tar content for %s: %w", p, err)
+	}
+	return nil
+}
+
+// copyFileToContainer writes data to containerPath inside the container. The
+// parent directory must already exist.
+func copyFileToContainer(ctx context.Context, cli containerCopyClient, containerName, containerPath string, data []byte, mode int64) error {
+	archive, err := tarSingleFile(path.Base(containerPath), data, mode)
+	if err != nil {
+		return err
+	}
+	if err := cli.CopyToContainer(ctx, containerName, path.Dir(containerPath), archive, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy %s into container: %w", containerPath, err)
+	}
+	return nil
+}
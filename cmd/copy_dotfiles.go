@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+	"github.com/garaemon/devgo/pkg/dotfiles"
+)
+
+// copyDotfilesClient is the subset of the Docker API used by
+// --copy-dotfiles: copying the directory in and running commands there.
+type copyDotfilesClient interface {
+	containerCopyClient
+	dotfilesDockerClient
+}
+
+// copyDotfilesTarget returns where --copy-dotfiles places hostDir: a
+// directory of the same name in the $HOME of user, e.g. ~/.dotfiles, as the
+// container reports it through exec.
+func copyDotfilesTarget(ctx context.Context, exec dotfiles.Executor, user, hostDir string) (string, error) {
+	return dotfiles.ResolveHome(ctx, exec, user, "~/"+filepath.Base(filepath.Clean(hostDir)))
+}
+
+// findDotfilesInstallScript returns the first of the install scripts the
+// dotfiles feature looks for (install.sh, install, bootstrap.sh, ...) that
+// is a regular file at the top of hostDir, or "" when there is none.
+func findDotfilesInstallScript(hostDir string) string {
+	for _, candidate := range dotfiles.DefaultInstallScripts {
+		info, err := os.Stat(filepath.Join(hostDir, candidate))
+		if err == nil && info.Mode().IsRegular() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// copyDotfiles copies hostDir into the home of user in the container, hands
+// it to user, and runs its install script from there when it has one.
+func copyDotfiles(ctx context.Context, cli copyDotfilesClient, containerID, user, hostDir string) error {
+	info, err := os.Stat(hostDir)
+	if err != nil {
+		return fmt.Errorf("failed to read dotfiles directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", hostDir)
+	}
+
+	executor := newDotfilesExecutor(cli, containerID)
+	target, err := copyDotfilesTarget(ctx, executor, user, hostDir)
+	if err != nil {
+		return fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	archive, err := tarDirectory(hostDir, path.Base(target))
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", hostDir, err)
+	}
+	if err := cli.CopyToContainer(ctx, containerID, path.Dir(target), archive, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy %s into container: %w", hostDir, err)
+	}
+
+	if user != "" && user != "root" {
+		// CopyToContainer extracts the files as root.
+		if err := runDotfilesStep(ctx, executor, "root", []string{"chown", "-R", user + ":", target}); err != nil {
+			return fmt.Errorf("failed to chown %s: %w", target, err)
+		}
+	}
+
+	script := findDotfilesInstallScript(hostDir)
+	if script == "" {
+		debugf("Copied dotfiles to %s (no install script)\n", target)
+		return nil
+	}
+	debugf("Running %s from %s\n", script, target)
+	if err := runDotfilesStep(ctx, executor, user, []string{"sh", "-c", "cd " + shellJoin([]string{target}) + " && ./" + script}); err != nil {
+		return fmt.Errorf("failed to run %s: %w", script, err)
+	}
+	return nil
+}
+
+// runDotfilesStep runs cmd as user and turns a non-zero exit into an error.
+func runDotfilesStep(ctx context.Context, executor dotfiles.Executor, user string, cmd []string) error {
+	_, stderr, exitCode, err := executor.Exec(ctx, user, cmd)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("exited with %d: %s", exitCode, stderr)
+	}
+	return nil
+}
+
+// applyCopyDotfiles runs --copy-dotfiles against the running container.
+func applyCopyDotfiles(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, hostDir string) error {
+	cli, err := newEngineClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client for dotfiles: %w", err)
+	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+
+	containerID, err := findRunningContainer(ctx, cli, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to find running container for dotfiles: %w", err)
+	}
+	if containerID == "" {
+		return fmt.Errorf("container %s is not running, cannot copy dotfiles", containerName)
+	}
+	return copyDotfiles(ctx, cli, containerID, devContainer.GetTargetUser(), hostDir)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCopyDotfilesTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		home    string
+		hostDir string
+		want    string
+	}{
+		{name: "remote user", home: "/home/vscode", hostDir: "/home/me/.dotfiles", want: "/home/vscode/.dotfiles"},
+		{name: "home outside /home", home: "/workspaces/home", hostDir: "/home/me/dotfiles", want: "/workspaces/home/dotfiles"},
+		{name: "trailing slash", home: "/root", hostDir: "/home/me/.dotfiles/", want: "/root/.dotfiles"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := copyDotfilesTarget(context.Background(), &homeExecutor{home: tt.home}, "vscode", tt.hostDir)
+			if err != nil {
+				t.Fatalf("copyDotfilesTarget() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("copyDotfilesTarget(%q) = %q, want %q", tt.hostDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDotfilesInstallScript(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		dirs  []string
+		want  string
+	}{
+		{name: "install.sh", files: []string{"install.sh", ".bashrc"}, want: "install.sh"},
+		{name: "install.sh wins over setup.sh", files: []string{"setup.sh", "install.sh"}, want: "install.sh"},
+		{name: "bootstrap fallback", files: []string{"bootstrap.sh"}, want: "bootstrap.sh"},
+		{name: "no script", files: []string{".bashrc"}, want: ""},
+		{name: "directory named install is ignored", dirs: []string{"install"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range tt.dirs {
+				if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := findDotfilesInstallScript(dir); got != tt.want {
+				t.Errorf("findDotfilesInstallScript() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTarDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "config", "nvim"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".bashrc"), []byte("alias ll='ls -l'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config", "nvim", "init.lua"), []byte("-- nvim\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(".bashrc", filepath.Join(dir, ".profile")); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := tarDirectory(dir, ".dotfiles")
+	if err != nil {
+		t.Fatalf("tarDirectory() error = %v", err)
+	}
+
+	var names []string
+	contents := make(map[string]string)
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+		if header.Typeflag == tar.TypeSymlink {
+			contents[header.Name] = "-> " + header.Linkname
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[header.Name] = string(data)
+	}
+	sort.Strings(names)
+
+	wantNames := []string{".dotfiles/", ".dotfiles/.bashrc", ".dotfiles/.profile", ".dotfiles/config/", ".dotfiles/config/nvim/", ".dotfiles/config/nvim/init.lua"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("archive entries = %v, want %v", names, wantNames)
+	}
+	if contents[".dotfiles/config/nvim/init.lua"] != "-- nvim\n" {
+		t.Errorf("init.lua content = %q", contents[".dotfiles/config/nvim/init.lua"])
+	}
+	if contents[".dotfiles/.profile"] != "-> .bashrc" {
+		t.Errorf(".profile = %q, want a symlink to .bashrc", contents[".dotfiles/.profile"])
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// defaultDevicePermissions grants read, write and mknod, as `docker run
+// --device` does.
+const defaultDevicePermissions = "rwm"
+
+// parseDeviceMapping parses a --device value of the form
+// HOST[:CONTAINER[:PERMISSIONS]]. The container path defaults to the host
+// path; a second field that is not a path is taken as the permissions
+// ("/dev/ttyUSB0:rw"). Permissions are letters from "rwm".
+func parseDeviceMapping(spec string) (container.DeviceMapping, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 {
+		return container.DeviceMapping{}, fmt.Errorf("invalid device %q: want HOST[:CONTAINER[:PERMISSIONS]]", spec)
+	}
+
+	mapping := container.DeviceMapping{
+		PathOnHost:        parts[0],
+		PathInContainer:   parts[0],
+		CgroupPermissions: defaultDevicePermissions,
+	}
+	switch len(parts) {
+	case 2:
+		if path.IsAbs(parts[1]) {
+			mapping.PathInContainer = parts[1]
+		} else {
+			mapping.CgroupPermissions = parts[1]
+		}
+	case 3:
+		mapping.PathInContainer = parts[1]
+		mapping.CgroupPermissions = parts[2]
+	}
+
+	if !path.IsAbs(mapping.PathOnHost) || !path.IsAbs(mapping.PathInContainer) {
+		return container.DeviceMapping{}, fmt.Errorf("invalid device %q: paths must be absolute", spec)
+	}
+	if !validDevicePermissions(mapping.CgroupPermissions) {
+		return container.DeviceMapping{}, fmt.Errorf("invalid device %q: permissions must be letters from %q", spec, defaultDevicePermissions)
+	}
+	return mapping, nil
+}
+
+func validDevicePermissions(permissions string) bool {
+	if permissions == "" {
+		return false
+	}
+	for _, c := range permissions {
+		if !strings.ContainsRune(defaultDevicePermissions, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// deviceMappings parses every --device spec, in order.
+func deviceMappings(specs []string) ([]container.DeviceMapping, error) {
+	var mappings []container.DeviceMapping
+	for _, spec := range specs {
+		mapping, err := parseDeviceMapping(spec)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestParseDeviceMapping(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    container.DeviceMapping
+		wantErr bool
+	}{
+		{
+			spec: "/dev/ttyUSB0",
+			want: container.DeviceMapping{PathOnHost: "/dev/ttyUSB0", PathInContainer: "/dev/ttyUSB0", CgroupPermissions: "rwm"},
+		},
+		{
+			spec: "/dev/ttyUSB0:/dev/serial",
+			want: container.DeviceMapping{PathOnHost: "/dev/ttyUSB0", PathInContainer: "/dev/serial", CgroupPermissions: "rwm"},
+		},
+		{
+			spec: "/dev/video0:r",
+			want: container.DeviceMapping{PathOnHost: "/dev/video0", PathInContainer: "/dev/video0", CgroupPermissions: "r"},
+		},
+		{
+			spec: "/dev/video0:/dev/camera:rw",
+			want: container.DeviceMapping{PathOnHost: "/dev/video0", PathInContainer: "/dev/camera", CgroupPermissions: "rw"},
+		},
+		{spec: "ttyUSB0", wantErr: true},
+		{spec: "/dev/ttyUSB0:serial:rw", wantErr: true},
+		{spec: "/dev/ttyUSB0:/dev/serial:rx", wantErr: true},
+		{spec: "/dev/ttyUSB0:/dev/serial:", wantErr: true},
+		{spec: "/a:/b:rw:extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseDeviceMapping(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDeviceMapping(%q) error = %v, wantErr %t", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseDeviceMapping(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceMappings(t *testing.T) {
+	mappings, err := deviceMappings([]string{"/dev/ttyUSB0", "/dev/video0:/dev/camera:r"})
+	if err != nil {
+		t.Fatalf("deviceMappings() error = %v", err)
+	}
+	if len(mappings) != 2 || mappings[0].PathOnHost != "/dev/ttyUSB0" || mappings[1].PathInContainer != "/dev/camera" {
+		t.Errorf("deviceMappings() = %+v, want both devices in order", mappings)
+	}
+
+	if _, err := deviceMappings([]string{"/dev/ttyUSB0", "bad"}); err == nil {
+		t.Error("deviceMappings() should fail on an invalid spec")
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/garaemon/devgo/pkg/dockersocket"
+)
+
+// dockerSocketMount returns the bind and supplementary group for
+// --mount-docker-socket. When the host socket cannot be found the mount is
+// skipped with a warning rather than failing `devgo up`.
+func dockerSocketMount(findSocket func() (string, error), groupOf func(string) (string, bool)) ([]string, []string) {
+	hostSocket, err := findSocket()
+	if err != nil {
+		warnf("--mount-docker-socket: %v, skipping the mount", err)
+		return nil, nil
+	}
+
+	mount := dockersocket.CreateMount(hostSocket)
+	binds := []string{fmt.Sprintf("%s:%s", mount.Source, mount.Target)}
+	debugf("Docker socket mounted: %s -> %s\n", mount.Source, mount.Target)
+
+	var groups []string
+	if gid, ok := groupOf(hostSocket); ok && gid != "0" {
+		groups = append(groups, gid)
+	}
+	return binds, groups
+}
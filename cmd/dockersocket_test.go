@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDockerSocketMount(t *testing.T) {
+	tests := []struct {
+		name         string
+		findSocket   func() (string, error)
+		gid          string
+		expectBinds  []string
+		expectGroups []string
+	}{
+		{
+			name:         "socket present",
+			findSocket:   func() (string, error) { return "/var/run/docker.sock", nil },
+			gid:          "998",
+			expectBinds:  []string{"/var/run/docker.sock:/var/run/docker.sock"},
+			expectGroups: []string{"998"},
+		},
+		{
+			name:        "root-owned group is not added",
+			findSocket:  func() (string, error) { return "/Users/me/.docker/run/docker.sock", nil },
+			gid:         "0",
+			expectBinds: []string{"/Users/me/.docker/run/docker.sock:/var/run/docker.sock"},
+		},
+		{
+			name:       "socket absent is skipped",
+			findSocket: func() (string, error) { return "", errors.New("docker socket not found") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groupOf := func(string) (string, bool) { return tt.gid, tt.gid != "" }
+			binds, groups := dockerSocketMount(tt.findSocket, groupOf)
+			if strings.Join(binds, ",") != strings.Join(tt.expectBinds, ",") {
+				t.Errorf("binds = %v, want %v", binds, tt.expectBinds)
+			}
+			if strings.Join(groups, ",") != strings.Join(tt.expectGroups, ",") {
+				t.Errorf("groups = %v, want %v", groups, tt.expectGroups)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// doctorProblem is an issue found by `devgo doctor` together with the safe
+// remediation `--fix` applies for it.
+type doctorProblem struct {
+	Description string
+	Fix         func() error
+}
+
+// doctorRemediations are the actions `devgo doctor --fix` may take. They are
+// fields so tests can observe which remediation a problem maps to.
+type doctorRemediations struct {
+	CreateDir        func(path string) error
+	GenerateTemplate func(dir string) error
+}
+
+func defaultDoctorRemediations() doctorRemediations {
+	return doctorRemediations{
+		CreateDir: func(path string) error { return os.MkdirAll(path, 0755) },
+		GenerateTemplate: func(dir string) error {
+			_, err := writeDefaultTemplate(dir)
+			return err
+		},
+	}
+}
+
+func runDoctorCommand(args []string) error {
+	var devContainer *devcontainer.DevContainer
+	var rootDir string
+
+	devcontainerPath, err := findDevcontainerConfig(configPath)
+	if err != nil {
+		rootDir, err = determineInitDirectory(args)
+		if err != nil {
+			return fmt.Errorf("failed to determine target directory: %w", err)
+		}
+	} else {
+		resolved, err := resolveConfig(devcontainerPath, configOverrides)
+		if err != nil {
+			return err
+		}
+		devContainer = resolved.DevContainer
+	}
+
+	problems := detectDoctorProblems(rootDir, devContainer, defaultDoctorRemediations())
+	return reportDoctorProblems(os.Stdout, problems, doctorFix)
+}
+
+// detectDoctorProblems looks for issues devgo can remediate safely. Without a
+// configuration (devContainer is nil) it reports the missing .devcontainer
+// directory and template under rootDir; otherwise it reports bind mount
+// sources that do not exist on the host.
+func detectDoctorProblems(rootDir string, devContainer *devcontainer.DevContainer, fixes doctorRemediations) []doctorProblem {
+	var problems []doctorProblem
+
+	if devContainer == nil {
+		devcontainerDir := filepath.Join(rootDir, ".devcontainer")
+		if _, err := os.Stat(devcontainerDir); err != nil {
+			problems = append(problems, doctorProblem{
+				Description: "missing directory " + devcontainerDir,
+				Fix:         func() error { return fixes.CreateDir(devcontainerDir) },
+			})
+		}
+		problems = append(problems, doctorProblem{
+			Description: "no devcontainer.json found",
+			Fix:         func() error { return fixes.GenerateTemplate(rootDir) },
+		})
+		return problems
+	}
+
+	for _, mount := range devContainer.Mounts {
+		if mount.Type != "" && mount.Type != "bind" {
+			continue
+		}
+		source := mount.Source
+		// Sources with unresolved variables are not real paths yet.
+		if source == "" || strings.Contains(source, "${") {
+			continue
+		}
+		if _, err := os.Stat(source); err == nil {
+			continue
+		}
+		problems = append(problems, doctorProblem{
+			Description: "missing bind mount source " + source,
+			Fix:         func() error { return fixes.CreateDir(source) },
+		})
+	}
+	return problems
+}
+
+// reportDoctorProblems prints every problem and, with fix set, applies its
+// remediation and reports the outcome. It returns an error while any problem
+// is left unresolved.
+func reportDoctorProblems(w io.Writer, problems []doctorProblem, fix bool) error {
+	if len(problems) == 0 {
+		_, err := fmt.Fprintln(w, "No problems found")
+		return err
+	}
+
+	unresolved := 0
+	for _, problem := range problems {
+		if !fix {
+			unresolved++
+			if _, err := fmt.Fprintf(w, "PROBLEM     %s\n", problem.Description); err != nil {
+				return err
+			}
+			continue
+		}
+		if fixErr := problem.Fix(); fixErr != nil {
+			unresolved++
+			if _, err := fmt.Fprintf(w, "FIX FAILED  %s: %v\n", problem.Description, fixErr); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "FIXED       %s\n", problem.Description); err != nil {
+			return err
+		}
+	}
+
+	if unresolved > 0 {
+		if !fix {
+			return fmt.Errorf("found %d problem(s); run 'devgo doctor --fix' to remediate them", unresolved)
+		}
+		return fmt.Errorf("%d problem(s) could not be fixed", unresolved)
+	}
+	return nil
+}
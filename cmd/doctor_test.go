@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// recordingRemediations returns remediations that record their calls.
+func recordingRemediations(calls *[]string, failDir bool) doctorRemediations {
+	return doctorRemediations{
+		CreateDir: func(path string) error {
+			*calls = append(*calls, "mkdir "+path)
+			if failDir {
+				return errors.New("permission denied")
+			}
+			return nil
+		},
+		GenerateTemplate: func(dir string) error {
+			*calls = append(*calls, "template "+dir)
+			return nil
+		},
+	}
+}
+
+func TestDoctorFix_InvokesRemediations(t *testing.T) {
+	rootDir := t.TempDir()
+	missingSource := filepath.Join(rootDir, "cache")
+
+	tests := []struct {
+		name         string
+		devContainer *devcontainer.DevContainer
+		fix          bool
+		failDir      bool
+		expectCalls  []string
+		expectOutput []string
+		expectError  bool
+	}{
+		{
+			name:        "no configuration is fixed by creating the directory and template",
+			fix:         true,
+			expectCalls: []string{"mkdir " + filepath.Join(rootDir, ".devcontainer"), "template " + rootDir},
+			expectOutput: []string{
+				"FIXED       missing directory " + filepath.Join(rootDir, ".devcontainer"),
+				"FIXED       no devcontainer.json found",
+			},
+		},
+		{
+			name: "missing bind mount source is created",
+			devContainer: &devcontainer.DevContainer{Mounts: []devcontainer.Mount{
+				{Type: "bind", Source: missingSource, Target: "/cache"},
+				{Type: "volume", Source: "named", Target: "/data"},
+				{Type: "bind", Source: "${localEnv:HOME}/.ssh", Target: "/ssh"},
+			}},
+			fix:          true,
+			expectCalls:  []string{"mkdir " + missingSource},
+			expectOutput: []string{"FIXED       missing bind mount source " + missingSource},
+		},
+		{
+			name: "without --fix nothing is remediated",
+			devContainer: &devcontainer.DevContainer{Mounts: []devcontainer.Mount{
+				{Type: "bind", Source: missingSource, Target: "/cache"},
+			}},
+			expectOutput: []string{"PROBLEM     missing bind mount source " + missingSource},
+			expectError:  true,
+		},
+		{
+			name: "failed remediation is reported",
+			devContainer: &devcontainer.DevContainer{Mounts: []devcontainer.Mount{
+				{Type: "bind", Source: missingSource, Target: "/cache"},
+			}},
+			fix:          true,
+			failDir:      true,
+			expectCalls:  []string{"mkdir " + missingSource},
+			expectOutput: []string{"FIX FAILED  missing bind mount source " + missingSource + ": permission denied"},
+			expectError:  true,
+		},
+		{
+			name:         "no problems",
+			devContainer: &devcontainer.DevContainer{},
+			fix:          true,
+			expectOutput: []string{"No problems found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			problems := detectDoctorProblems(rootDir, tt.devContainer, recordingRemediations(&calls, tt.failDir))
+
+			var out bytes.Buffer
+			err := reportDoctorProblems(&out, problems, tt.fix)
+			if (err != nil) != tt.expectError {
+				t.Errorf("reportDoctorProblems() error = %v, expectError %v", err, tt.expectError)
+			}
+			if strings.Join(calls, "\n") != strings.Join(tt.expectCalls, "\n") {
+				t.Errorf("remediation calls = %v, want %v", calls, tt.expectCalls)
+			}
+			for _, line := range tt.expectOutput {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("output %q does not contain %q", out.String(), line)
+				}
+			}
+		})
+	}
+}
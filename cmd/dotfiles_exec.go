@@ -47,14 +47,22 @@ func newDotfilesExecutor(cli dotfilesDockerClient, containerID string) *dotfiles
 //
 // TODO: pass Env explicitly (e.g. HOME from `getent passwd <user>`) for
 // images where switching User via docker exec does not re-export $HOME.
-// pkg/dotfiles.resolveHome currently relies on the shell expanding $HOME.
+// pkg/dotfiles.ResolveHome currently relies on the shell expanding $HOME.
 func (d *dotfilesExecutor) Exec(ctx context.Context, user string, cmd []string) (string, string, int, error) {
+	return d.ExecIn(ctx, user, "", nil, cmd)
+}
+
+// ExecIn is Exec with an explicit working directory and environment; empty
+// values keep the container defaults.
+func (d *dotfilesExecutor) ExecIn(ctx context.Context, user, workdir string, env []string, cmd []string) (string, string, int, error) {
 	execConfig := container.ExecOptions{
 		User:         user,
 		Tty:          false,
 		AttachStdout: true,
 		AttachStderr: true,
 		Cmd:          cmd,
+		WorkingDir:   workdir,
+		Env:          env,
 	}
 
 	create, err := d.cli.ContainerExecCreate(ctx, d.containerID, execConfig)
@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/client"
+	"github.com/garaemon/devgo/pkg/constants"
 	"github.com/garaemon/devgo/pkg/devcontainer"
 )
 
@@ -20,6 +22,21 @@ type DownDockerClient interface {
 }
 
 func runDownCommand(args []string) error {
+	opts := container.RemoveOptions{Force: force, RemoveVolumes: downVolumes}
+
+	if downAll {
+		cli, err := newEngineClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Docker client: %w", err)
+		}
+		defer func() {
+			if closeErr := cli.Close(); closeErr != nil {
+				warnf("failed to close Docker client: %v", closeErr)
+			}
+		}()
+		return removeManagedContainers(context.Background(), cli, sessionName, opts)
+	}
+
 	devcontainerPath, err := findDevcontainerConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to find devcontainer config: %w", err)
@@ -27,14 +44,23 @@ func runDownCommand(args []string) error {
 
 	workspaceDir := determineWorkspaceFolder(devcontainerPath)
 
-	devContainer, err := devcontainer.Parse(devcontainerPath)
+	resolved, err := resolveConfig(devcontainerPath, configOverrides)
 	if err != nil {
-		return fmt.Errorf("failed to parse devcontainer.json: %w", err)
+		return err
+	}
+	devContainer := resolved.DevContainer
+
+	if devContainer.HasDockerCompose() {
+		var downArgs []string
+		if downVolumes {
+			downArgs = append(downArgs, "--volumes")
+		}
+		return composeTeardown(context.Background(), devContainer, workspaceDir, "down", downArgs...)
 	}
 
 	containerName := determineContainerName(devContainer, workspaceDir)
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -45,10 +71,14 @@ func runDownCommand(args []string) error {
 	}()
 
 	ctx := context.Background()
-	return stopAndRemoveContainer(ctx, cli, containerName)
+	return stopAndRemoveContainer(ctx, cli, containerName, opts)
 }
 
-func stopAndRemoveContainer(ctx context.Context, cli DownDockerClient, containerName string) error {
+// stopAndRemoveContainer stops and removes the named container. With
+// opts.Force the graceful stop is skipped and the container is killed and
+// removed in one ContainerRemove call, for containers that do not respond to
+// stop; opts.RemoveVolumes also deletes its anonymous volumes.
+func stopAndRemoveContainer(ctx context.Context, cli DownDockerClient, containerName string, opts container.RemoveOptions) error {
 	// Check if container exists
 	filter := filters.NewArgs()
 	filter.Add("name", containerName)
@@ -61,46 +91,91 @@ func stopAndRemoveContainer(ctx context.Context, cli DownDockerClient, container
 		return fmt.Errorf("failed to list containers: %w", err)
 	}
 
-	var found bool
-	var containerID string
-	var isRunning bool
-
 	for _, c := range containers {
 		for _, name := range c.Names {
 			if strings.TrimPrefix(name, "/") == containerName {
-				found = true
-				containerID = c.ID
-				isRunning = c.State == "running"
-				break
+				return stopAndRemove(ctx, cli, c, containerName, opts)
 			}
 		}
-		if found {
-			break
-		}
 	}
 
-	if !found {
-		debugf("Container '%s' does not exist\n", containerName)
-		return nil
+	debugf("Container '%s' does not exist\n", containerName)
+	return nil
+}
+
+// removeManagedContainers implements `devgo down --all`: it stops and
+// removes every devgo-managed container, or with session set only the
+// containers of that session, across all workspaces. The names of removed
+// containers are printed to stdout. A failure does not stop the others from
+// being removed.
+func removeManagedContainers(ctx context.Context, cli DownDockerClient, session string, opts container.RemoveOptions) error {
+	filter := filters.NewArgs()
+	filter.Add("label", managedLabelFilter())
+	if session != "" {
+		filter.Add("label", fmt.Sprintf("%s=%s", devgoLabel(constants.DevgoSessionLabel), session))
+	}
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filter,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var errs []error
+	for _, c := range containers {
+		name := c.ID
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		if err := stopAndRemove(ctx, cli, c, name, opts); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Println(name)
 	}
+	return errors.Join(errs...)
+}
 
-	// Stop container if it's running
-	if isRunning {
+// stopAndRemove stops c unless opts.Force is set, then removes it.
+func stopAndRemove(ctx context.Context, cli DownDockerClient, c container.Summary, containerName string, opts container.RemoveOptions) error {
+	if c.State == "running" && !opts.Force {
 		debugf("Stopping container '%s'\n", containerName)
-		err = cli.ContainerStop(ctx, containerID, container.StopOptions{})
-		if err != nil {
+		if err := cli.ContainerStop(ctx, c.ID, container.StopOptions{}); err != nil {
 			return fmt.Errorf("failed to stop container '%s': %w", containerName, err)
 		}
 		debugf("Container '%s' stopped\n", containerName)
 	}
 
-	// Remove container
 	debugf("Removing container '%s'\n", containerName)
-	err = cli.ContainerRemove(ctx, containerID, container.RemoveOptions{})
-	if err != nil {
+	if err := cli.ContainerRemove(ctx, c.ID, opts); err != nil {
 		return fmt.Errorf("failed to remove container '%s': %w", containerName, err)
 	}
 
 	debugf("Container '%s' removed successfully\n", containerName)
 	return nil
 }
+
+// composeTeardown runs `docker compose <action>` (down or stop) for the
+// project `devgo up` started: the same compose files from the same
+// directory, so compose resolves the same project name.
+func composeTeardown(ctx context.Context, devContainer *devcontainer.DevContainer, workspaceDir, action string, actionArgs ...string) error {
+	composeFiles := devContainer.GetDockerComposeFiles()
+	if len(composeFiles) == 0 {
+		return fmt.Errorf("no docker compose files specified")
+	}
+
+	args := append(composeFileArgs(composeFiles, workspaceDir), action)
+	args = append(args, actionArgs...)
+	cmd := composeCommand(ctx, args...)
+	cmd.Dir = workspaceDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	debugf("Running docker compose %s in %s\n", action, workspaceDir)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run docker compose %s: %w", action, err)
+	}
+	return nil
+}
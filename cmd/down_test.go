@@ -3,9 +3,15 @@ package cmd
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/garaemon/devgo/pkg/constants"
 )
 
 // mockDownDockerClient implements a mock Docker client for down command testing
@@ -17,9 +23,13 @@ type mockDownDockerClient struct {
 	closeError        error
 	stoppedContainers []string
 	removedContainers []string
+	removeOptions     []container.RemoveOptions
+	listOptions       container.ListOptions
+	failRemove        map[string]bool
 }
 
 func (m *mockDownDockerClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	m.listOptions = options
 	if m.listError != nil {
 		return nil, m.listError
 	}
@@ -38,7 +48,11 @@ func (m *mockDownDockerClient) ContainerRemove(ctx context.Context, containerID
 	if m.removeError != nil {
 		return m.removeError
 	}
+	if m.failRemove[containerID] {
+		return errors.New("removal in progress")
+	}
 	m.removedContainers = append(m.removedContainers, containerID)
+	m.removeOptions = append(m.removeOptions, options)
 	return nil
 }
 
@@ -189,7 +203,7 @@ func TestStopAndRemoveContainer(t *testing.T) {
 			}
 
 			ctx := context.Background()
-			err := stopAndRemoveContainer(ctx, mockClient, tt.containerName)
+			err := stopAndRemoveContainer(ctx, mockClient, tt.containerName, container.RemoveOptions{})
 
 			if tt.expectError {
 				if err == nil {
@@ -240,3 +254,136 @@ func containsSubstringDown(s, substr string) bool {
 	}
 	return false
 }
+
+func TestStopAndRemoveContainer_Force(t *testing.T) {
+	mockClient := &mockDownDockerClient{
+		containers: []container.Summary{
+			{ID: "abc123", Names: []string{"/test-container"}, State: "running"},
+		},
+	}
+
+	if err := stopAndRemoveContainer(context.Background(), mockClient, "test-container", container.RemoveOptions{Force: true}); err != nil {
+		t.Fatalf("stopAndRemoveContainer() error = %v", err)
+	}
+	if len(mockClient.stoppedContainers) != 0 {
+		t.Errorf("force should skip the stop step, stopped %v", mockClient.stoppedContainers)
+	}
+	if len(mockClient.removeOptions) != 1 || !mockClient.removeOptions[0].Force {
+		t.Errorf("expected one removal with Force: true, got %+v", mockClient.removeOptions)
+	}
+}
+
+func TestRemoveManagedContainers(t *testing.T) {
+	tests := []struct {
+		name        string
+		session     string
+		wantFilters []string
+	}{
+		{name: "all sessions", session: "", wantFilters: []string{managedLabelFilter()}},
+		{name: "one session", session: "feature", wantFilters: []string{managedLabelFilter(), devgoLabel(constants.DevgoSessionLabel) + "=feature"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockDownDockerClient{
+				containers: []container.Summary{
+					{ID: "run1", Names: []string{"/app-feature-1234"}, State: "running"},
+					{ID: "old1", Names: []string{"/api-feature-5678"}, State: "exited"},
+				},
+			}
+
+			opts := container.RemoveOptions{RemoveVolumes: true}
+			if err := removeManagedContainers(context.Background(), mockClient, tt.session, opts); err != nil {
+				t.Fatalf("removeManagedContainers() error = %v", err)
+			}
+
+			if !mockClient.listOptions.All {
+				t.Error("stopped containers should be listed too")
+			}
+			got := mockClient.listOptions.Filters.Get("label")
+			sort.Strings(got)
+			want := append([]string(nil), tt.wantFilters...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("label filters = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(mockClient.stoppedContainers, []string{"run1"}) {
+				t.Errorf("stopped = %v, want only the running container", mockClient.stoppedContainers)
+			}
+			if !reflect.DeepEqual(mockClient.removedContainers, []string{"run1", "old1"}) {
+				t.Errorf("removed = %v, want both containers", mockClient.removedContainers)
+			}
+			for _, o := range mockClient.removeOptions {
+				if !o.RemoveVolumes {
+					t.Errorf("remove options = %+v, want RemoveVolumes", o)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveManagedContainers_ContinuesAfterFailure(t *testing.T) {
+	mockClient := &mockDownDockerClient{
+		containers: []container.Summary{
+			{ID: "busy", Names: []string{"/busy"}, State: "exited"},
+			{ID: "idle", Names: []string{"/idle"}, State: "exited"},
+		},
+		failRemove: map[string]bool{"busy": true},
+	}
+
+	err := removeManagedContainers(context.Background(), mockClient, "", container.RemoveOptions{})
+	if err == nil || !containsSubstringDown(err.Error(), "busy") {
+		t.Errorf("removeManagedContainers() error = %v, want the failed container named", err)
+	}
+	if !reflect.DeepEqual(mockClient.removedContainers, []string{"idle"}) {
+		t.Errorf("removed = %v, want the remaining container removed", mockClient.removedContainers)
+	}
+}
+
+// useComposeWorkspace writes a compose-based devcontainer.json into a new
+// workspace, points configPath at it and returns the workspace directory.
+func useComposeWorkspace(t *testing.T) string {
+	t.Helper()
+	workspace := t.TempDir()
+	devcontainerDir := filepath.Join(workspace, ".devcontainer")
+	if err := os.MkdirAll(devcontainerDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"dockerComposeFile": ["compose.yml", "compose.dev.yml"], "service": "app"}`
+	devcontainerPath := filepath.Join(devcontainerDir, "devcontainer.json")
+	if err := os.WriteFile(devcontainerPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	originalConfigPath := configPath
+	originalWorkspaceFolder := workspaceFolder
+	t.Cleanup(func() {
+		configPath = originalConfigPath
+		workspaceFolder = originalWorkspaceFolder
+	})
+	configPath = devcontainerPath
+	workspaceFolder = ""
+	return workspace
+}
+
+func TestRunDownCommand_ComposeProject(t *testing.T) {
+	logPath := installFakeDocker(t)
+	workspace := useComposeWorkspace(t)
+
+	originalVolumes := downVolumes
+	defer func() { downVolumes = originalVolumes }()
+	downVolumes = true
+
+	if err := runDownCommand(nil); err != nil {
+		t.Fatalf("runDownCommand() error = %v", err)
+	}
+
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read docker log: %v", err)
+	}
+	want := "compose -f " + filepath.Join(workspace, "compose.yml") + " -f " + filepath.Join(workspace, "compose.dev.yml") + " down --volumes"
+	if got := strings.TrimSpace(string(logged)); got != want {
+		t.Errorf("docker called with %q, want %q", got, want)
+	}
+}
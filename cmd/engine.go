@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/docker/docker/client"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+// validateContainerEngine rejects a --runtime (--container-engine) value
+// devgo does not know how to drive.
+func validateContainerEngine(engine string) error {
+	_, err := devgoruntime.Parse(engine)
+	return err
+}
+
+// resolveContainerEngine picks the runtime: the flag (or DEVGO_RUNTIME) when
+// given, podman when DOCKER_HOST points at a Podman socket, and docker
+// otherwise.
+func resolveContainerEngine(flag, dockerHost string) string {
+	if flag != "" {
+		return flag
+	}
+	return string(devgoruntime.Detect(dockerHost))
+}
+
+// currentRuntime returns the runtime for this invocation. Both the flag and
+// DEVGO_RUNTIME are validated while parsing flags.
+func currentRuntime() devgoruntime.Runtime {
+	flag := containerEngineFlag
+	if flag == "" {
+		flag = os.Getenv(devgoruntime.EnvVar)
+	}
+	return devgoruntime.Runtime(resolveContainerEngine(flag, os.Getenv("DOCKER_HOST")))
+}
+
+// containerEngine returns the CLI binary for this invocation.
+func containerEngine() string {
+	return currentRuntime().Binary()
+}
+
+// newEngineClient connects to the API of the current runtime. Every command
+// goes through it rather than client.FromEnv alone, which only finds
+// Podman when DOCKER_HOST is set.
+func newEngineClient() (*client.Client, error) {
+	r := currentRuntime()
+	return devgoruntime.NewClient(r.Host(os.Getenv("DOCKER_HOST"), os.Getenv("XDG_RUNTIME_DIR"), os.Getuid()))
+}
+
+// rootlessRuntime reports whether containers run in a user namespace owned
+// by the invoking user (rootless Podman). There container UIDs map to
+// subordinate host UIDs, so bind-mounted workspace files would not belong
+// to the container user without keeping the host UID, see
+// DockerRunArgs.KeepUserID.
+func rootlessRuntime() bool {
+	return currentRuntime().Rootless(os.Getuid())
+}
+
+// engineCommand prepares `<engine> args...`.
+func engineCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, containerEngine(), args...)
+}
+
+// composeCommand prepares `<engine> compose args...`.
+func composeCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return engineCommand(ctx, append([]string{"compose"}, args...)...)
+}
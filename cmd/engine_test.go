@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestResolveContainerEngine(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		dockerHost string
+		want       string
+	}{
+		{name: "default", flag: "", dockerHost: "", want: "docker"},
+		{name: "docker socket", flag: "", dockerHost: "unix:///var/run/docker.sock", want: "docker"},
+		{name: "rootless podman socket", flag: "", dockerHost: "unix:///run/user/1000/podman/podman.sock", want: "podman"},
+		{name: "flag wins over socket", flag: "docker", dockerHost: "unix:///run/podman/podman.sock", want: "docker"},
+		{name: "flag selects podman", flag: "podman", dockerHost: "", want: "podman"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveContainerEngine(tt.flag, tt.dockerHost); got != tt.want {
+				t.Errorf("resolveContainerEngine(%q, %q) = %q, want %q", tt.flag, tt.dockerHost, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateContainerEngine(t *testing.T) {
+	for _, engine := range []string{"docker", "podman"} {
+		if err := validateContainerEngine(engine); err != nil {
+			t.Errorf("validateContainerEngine(%q) error = %v", engine, err)
+		}
+	}
+	if err := validateContainerEngine("nerdctl"); err == nil {
+		t.Error("validateContainerEngine(\"nerdctl\") should fail")
+	}
+}
+
+func TestCurrentRuntime(t *testing.T) {
+	originalEngine := containerEngineFlag
+	defer func() { containerEngineFlag = originalEngine }()
+
+	tests := []struct {
+		name       string
+		flag       string
+		env        string
+		dockerHost string
+		want       string
+	}{
+		{name: "default", want: "docker"},
+		{name: "env selects podman", env: "podman", want: "podman"},
+		{name: "flag wins over env", flag: "docker", env: "podman", want: "docker"},
+		{name: "env wins over socket", env: "docker", dockerHost: "unix:///run/podman/podman.sock", want: "docker"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			containerEngineFlag = tt.flag
+			t.Setenv("DEVGO_RUNTIME", tt.env)
+			t.Setenv("DOCKER_HOST", tt.dockerHost)
+			if got := string(currentRuntime()); got != tt.want {
+				t.Errorf("currentRuntime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineCommands_UseSelectedEngine(t *testing.T) {
+	originalEngine := containerEngineFlag
+	originalBuildSSH := buildSSH
+	defer func() {
+		containerEngineFlag = originalEngine
+		buildSSH = originalBuildSSH
+	}()
+	// --ssh builds through the CLI rather than the API.
+	enabled := true
+	buildSSH = &enabled
+
+	tests := []struct {
+		engine string
+		other  string
+	}{
+		{engine: "docker", other: "podman"},
+		{engine: "podman", other: "docker"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			logPath := installFakeEngine(t, tt.engine)
+			otherLogPath := installFakeEngine(t, tt.other)
+			containerEngineFlag = tt.engine
+
+			ctx := context.Background()
+			devContainer := &devcontainer.DevContainer{
+				Name:  "myapp",
+				Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+			}
+			if err := buildDevContainer(ctx, devContainer, "/workspace", "/workspace/.devcontainer/devcontainer.json"); err != nil {
+				t.Fatalf("buildDevContainer() error = %v", err)
+			}
+			if err := composeCommand(ctx, "-f", "compose.yml", "up", "-d").Run(); err != nil {
+				t.Fatalf("compose command error = %v", err)
+			}
+
+			logData, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatalf("failed to read %s log: %v", tt.engine, err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(logData)), "\n")
+			if len(lines) != 2 || !strings.HasPrefix(lines[0], "build ") || lines[1] != "compose -f compose.yml up -d" {
+				t.Errorf("%s invocations = %q, want a build and a compose up", tt.engine, lines)
+			}
+			if _, err := os.Stat(otherLogPath); !os.IsNotExist(err) {
+				t.Errorf("%s should not have been invoked", tt.other)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// entrypointScriptPath is where `devgo up --entrypoint-script` places the
+// generated wrapper. The root directory always exists, so the copy needs no
+// parent directory to be created first.
+const entrypointScriptPath = "/devgo-entrypoint.sh"
+
+// keepAliveScript keeps a container whose command devgo overrides running
+// until it is stopped. Unlike `sleep infinity` as PID 1 it exits on
+// SIGTERM, so stopping the container does not wait for the kill timeout.
+const keepAliveScript = `echo Container started
+trap "exit 0" TERM
+while sleep 1 & wait $!; do :; done`
+
+var envReferencePattern = regexp.MustCompile(`\${(containerEnv|localEnv):([^}]+)}`)
+
+// selfReferentialEnv returns the containerEnv entries whose value refers to
+// the variable itself through ${containerEnv:NAME}, such as a PATH append.
+// Docker sets Env literally, so these are only correct when evaluated by a
+// shell inside the running container.
+func selfReferentialEnv(containerEnv map[string]string) map[string]string {
+	result := make(map[string]string)
+	for name, value := range containerEnv {
+		if strings.Contains(value, "${containerEnv:"+name+"}") {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// entrypointScript generates the wrapper that exports env in sorted order and
+// then execs the container command. ${containerEnv:VAR} becomes a shell
+// reference to the container's own VAR; ${localEnv:VAR} is resolved on the
+// host with lookupEnv now.
+func entrypointScript(env map[string]string, lookupEnv func(string) string) string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Generated by devgo: set up the environment, then run the container command.\n")
+	for _, name := range names {
+		b.WriteString("export " + name + "=\"" + shellEnvValue(env[name], lookupEnv) + "\"\n")
+	}
+	b.WriteString("exec \"$@\"\n")
+	return b.String()
+}
+
+// shellEnvValue renders a containerEnv value for use inside double quotes.
+func shellEnvValue(value string, lookupEnv func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range envReferencePattern.FindAllStringSubmatchIndex(value, -1) {
+		b.WriteString(escapeDoubleQuoted(value[last:match[0]]))
+		kind := value[match[2]:match[3]]
+		name := value[match[4]:match[5]]
+		if kind == "containerEnv" {
+			b.WriteString("${" + name + "}")
+		} else {
+			b.WriteString(escapeDoubleQuoted(lookupEnv(name)))
+		}
+		last = match[1]
+	}
+	b.WriteString(escapeDoubleQuoted(value[last:]))
+	return b.String()
+}
+
+// escapeDoubleQuoted escapes the characters the shell still interprets
+// inside double quotes.
+func escapeDoubleQuoted(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+	return replacer.Replace(s)
+}
+
+// planEntrypointScript returns the wrapper script for --entrypoint-script and
+// env without the variables the script now sets. It returns an empty script
+// and env unchanged when there is nothing for the script to do.
+func planEntrypointScript(containerEnv, env map[string]string) (string, map[string]string) {
+	scripted := selfReferentialEnv(containerEnv)
+	if len(scripted) == 0 {
+		return "", env
+	}
+
+	remaining := make(map[string]string, len(env))
+	for name, value := range env {
+		if _, ok := scripted[name]; !ok {
+			remaining[name] = value
+		}
+	}
+	return entrypointScript(scripted, os.Getenv), remaining
+}
+
+// containerCommand returns the entrypoint and command of the container.
+// The keep-alive loop replaces the image's command unless keepImageCommand
+// (overrideCommand: false) is set, while the image's entrypoint stays and
+// runs ahead of it, since images often do required setup there; nil values
+// leave the image's own entrypoint or command in place. The
+// --entrypoint-script wrapper takes the entrypoint's place, so the image's
+// entrypoint, and a kept command, have to be read from the image and run
+// by the wrapper.
+func containerCommand(ctx context.Context, cli imageInspectClient, imageName string, keepImageCommand, entrypointScript bool) (entrypoint, cmd []string, err error) {
+	keepAlive := []string{"/bin/sh", "-c", keepAliveScript}
+	if !entrypointScript {
+		if keepImageCommand {
+			return nil, nil, nil
+		}
+		return nil, keepAlive, nil
+	}
+
+	wrapper := []string{"/bin/sh", entrypointScriptPath}
+	inspect, err := cli.ImageInspect(ctx, imageName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the command of image '%s': %w", imageName, err)
+	}
+	if inspect.Config != nil {
+		cmd = append(cmd, inspect.Config.Entrypoint...)
+	}
+	if !keepImageCommand {
+		return wrapper, append(cmd, keepAlive...), nil
+	}
+	if inspect.Config != nil {
+		cmd = append(cmd, inspect.Config.Cmd...)
+	}
+	if len(cmd) == 0 {
+		return nil, nil, fmt.Errorf("image '%s' has no command to keep with overrideCommand set to false", imageName)
+	}
+	return wrapper, cmd, nil
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestSelfReferentialEnv(t *testing.T) {
+	containerEnv := map[string]string{
+		"PATH":      "${containerEnv:PATH}:/opt/tools/bin",
+		"JAVA_HOME": "/usr/lib/jvm",
+		"MY_PATH":   "${containerEnv:PATH}",
+	}
+	want := map[string]string{"PATH": "${containerEnv:PATH}:/opt/tools/bin"}
+	if got := selfReferentialEnv(containerEnv); !reflect.DeepEqual(got, want) {
+		t.Errorf("selfReferentialEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestEntrypointScript(t *testing.T) {
+	lookupEnv := func(name string) string {
+		if name == "HOST_TOOLS" {
+			return "/host/$tools"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "no operations",
+			env:  map[string]string{},
+			want: "#!/bin/sh\n" +
+				"# Generated by devgo: set up the environment, then run the container command.\n" +
+				"exec \"$@\"\n",
+		},
+		{
+			name: "path append",
+			env:  map[string]string{"PATH": "${containerEnv:PATH}:/opt/tools/bin"},
+			want: "#!/bin/sh\n" +
+				"# Generated by devgo: set up the environment, then run the container command.\n" +
+				"export PATH=\"${PATH}:/opt/tools/bin\"\n" +
+				"exec \"$@\"\n",
+		},
+		{
+			name: "prepend with local env, sorted",
+			env: map[string]string{
+				"PYTHONPATH": "/src:${containerEnv:PYTHONPATH}",
+				"PATH":       "${localEnv:HOST_TOOLS}:${containerEnv:PATH}",
+			},
+			want: "#!/bin/sh\n" +
+				"# Generated by devgo: set up the environment, then run the container command.\n" +
+				"export PATH=\"/host/\\$tools:${PATH}\"\n" +
+				"export PYTHONPATH=\"/src:${PYTHONPATH}\"\n" +
+				"exec \"$@\"\n",
+		},
+		{
+			name: "literal quotes are escaped",
+			env:  map[string]string{"GREETING": "say \"hi\" ${containerEnv:GREETING}"},
+			want: "#!/bin/sh\n" +
+				"# Generated by devgo: set up the environment, then run the container command.\n" +
+				"export GREETING=\"say \\\"hi\\\" ${GREETING}\"\n" +
+				"exec \"$@\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entrypointScript(tt.env, lookupEnv); got != tt.want {
+				t.Errorf("entrypointScript() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanEntrypointScript(t *testing.T) {
+	containerEnv := map[string]string{
+		"PATH":      "${containerEnv:PATH}:/opt/tools/bin",
+		"JAVA_HOME": "/usr/lib/jvm",
+	}
+	env := map[string]string{
+		"PATH":      "/usr/bin:/opt/tools/bin",
+		"JAVA_HOME": "/usr/lib/jvm",
+	}
+
+	script, remaining := planEntrypointScript(containerEnv, env)
+	if script == "" {
+		t.Fatal("expected a script for the PATH append")
+	}
+	if want := map[string]string{"JAVA_HOME": "/usr/lib/jvm"}; !reflect.DeepEqual(remaining, want) {
+		t.Errorf("remaining env = %v, want %v", remaining, want)
+	}
+
+	script, remaining = planEntrypointScript(map[string]string{"JAVA_HOME": "/usr/lib/jvm"}, env)
+	if script != "" {
+		t.Errorf("expected no script without self-referential env, got %q", script)
+	}
+	if !reflect.DeepEqual(remaining, env) {
+		t.Errorf("remaining env = %v, want it unchanged", remaining)
+	}
+}
+
+func TestContainerCommand(t *testing.T) {
+	keepAlive := []string{"/bin/sh", "-c", keepAliveScript}
+	wrapper := []string{"/bin/sh", entrypointScriptPath}
+	imageConfig := &dockerspec.DockerOCIImageConfig{
+		ImageConfig: ocispec.ImageConfig{Entrypoint: []string{"/docker-entrypoint.sh"}, Cmd: []string{"postgres"}},
+	}
+
+	tests := []struct {
+		name             string
+		keepImageCommand bool
+		entrypointScript bool
+		imageConfig      *dockerspec.DockerOCIImageConfig
+		wantEntrypoint   []string
+		wantCmd          []string
+		wantErr          bool
+	}{
+		{name: "keep-alive by default keeps the image entrypoint", imageConfig: imageConfig, wantCmd: keepAlive},
+		{name: "image command kept", keepImageCommand: true},
+		{name: "wrapper runs the keep-alive", entrypointScript: true, wantEntrypoint: wrapper, wantCmd: keepAlive},
+		{
+			name:             "wrapper runs the image entrypoint with the keep-alive",
+			entrypointScript: true,
+			imageConfig:      imageConfig,
+			wantEntrypoint:   wrapper,
+			wantCmd:          append([]string{"/docker-entrypoint.sh"}, keepAlive...),
+		},
+		{
+			name:             "wrapper runs the image command",
+			keepImageCommand: true,
+			entrypointScript: true,
+			imageConfig:      imageConfig,
+			wantEntrypoint:   wrapper,
+			wantCmd:          []string{"/docker-entrypoint.sh", "postgres"},
+		},
+		{name: "image without command", keepImageCommand: true, entrypointScript: true, imageConfig: &dockerspec.DockerOCIImageConfig{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &mockDockerAPIClient{imageInspect: image.InspectResponse{Config: tt.imageConfig}}
+			entrypoint, cmd, err := containerCommand(context.Background(), cli, "app:latest", tt.keepImageCommand, tt.entrypointScript)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("containerCommand() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(entrypoint, tt.wantEntrypoint) || !reflect.DeepEqual(cmd, tt.wantCmd) {
+				t.Errorf("containerCommand() = %q, %q, want %q, %q", entrypoint, cmd, tt.wantEntrypoint, tt.wantCmd)
+			}
+		})
+	}
+}
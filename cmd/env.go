@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// sortedEnvKeys returns the keys of env in ascending order. Go randomizes map
+// iteration, so every place that turns an env map into an ordered list goes
+// through here to keep container configs and docker inspect output stable.
+func sortedEnvKeys[V any](env map[string]V) []string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// envMapToSlice converts env to KEY=VALUE entries sorted by key.
+func envMapToSlice(env map[string]string) []string {
+	entries := make([]string, 0, len(env))
+	for _, key := range sortedEnvKeys(env) {
+		entries = append(entries, fmt.Sprintf("%s=%s", key, env[key]))
+	}
+	return entries
+}
+
+// remoteUserEnv returns the environment for processes devgo starts in the
+// container as the remote user: containerEnv overlaid with remoteEnv, both
+// expanded against baseEnv, the environment of the running container.
+func remoteUserEnv(devContainer *devcontainer.DevContainer, baseEnv map[string]string) map[string]string {
+	env := make(map[string]string)
+	for k, v := range devContainer.GetContainerEnv(baseEnv) {
+		env[k] = v
+	}
+	for k, v := range devContainer.GetRemoteEnv(baseEnv) {
+		env[k] = v
+	}
+	return env
+}
+
+// parseKeyValueLines parses "key=value" lines as used by env and label
+// files. Blank lines and lines starting with # are ignored; the value is
+// everything after the first '=' and is kept verbatim.
+func parseKeyValueLines(content string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		idx := strings.IndexByte(trimmed, '=')
+		if idx <= 0 {
+			return nil, fmt.Errorf("line %d: expected key=value, got %q", i+1, trimmed)
+		}
+		pairs[strings.TrimSpace(trimmed[:idx])] = strings.TrimRight(trimmed[idx+1:], "\r")
+	}
+	return pairs, nil
+}
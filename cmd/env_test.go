@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvMapToSlice_Sorted(t *testing.T) {
+	env := map[string]string{"PATH": "/usr/bin", "A": "1", "LANG": "C.UTF-8", "B": "x=y"}
+	want := "A=1,B=x=y,LANG=C.UTF-8,PATH=/usr/bin"
+
+	// Run several times: map iteration order changes between runs.
+	for i := 0; i < 10; i++ {
+		if got := strings.Join(envMapToSlice(env), ","); got != want {
+			t.Fatalf("envMapToSlice() = %q, want %q", got, want)
+		}
+	}
+}
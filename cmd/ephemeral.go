@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// createdTracker records whether `devgo up` created its container, so
+// --remove-on-exit only removes a container of this invocation and never one
+// it merely found running or restarted.
+type createdTracker struct {
+	mu      sync.Mutex
+	created bool
+}
+
+func (c *createdTracker) set() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.created = true
+}
+
+func (c *createdTracker) get() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.created
+}
+
+type createdTrackerKey struct{}
+
+// withCreatedTracker returns a context whose markContainerCreated calls are
+// recorded on the returned tracker.
+func withCreatedTracker(ctx context.Context) (context.Context, *createdTracker) {
+	tracker := &createdTracker{}
+	return context.WithValue(ctx, createdTrackerKey{}, tracker), tracker
+}
+
+// markContainerCreated records on the tracker carried by ctx, if any, that
+// the container now exists because of this invocation.
+func markContainerCreated(ctx context.Context) {
+	if tracker, ok := ctx.Value(createdTrackerKey{}).(*createdTracker); ok {
+		tracker.set()
+	}
+}
+
+// removeOnExitSession starts the session of `devgo up --remove-on-exit`
+// before anything is created: it ends on SIGINT or SIGTERM, which also
+// cancels a create or lifecycle command still in progress.
+func removeOnExitSession() (session context.Context, tracker *createdTracker, stop context.CancelFunc) {
+	session, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	ctx, tracker := withCreatedTracker(session)
+	return ctx, tracker, stop
+}
+
+// runRemoveOnExit finishes `devgo up --remove-on-exit` once up returned
+// upErr: a container created by this invocation is removed right away when
+// up failed or was interrupted, and otherwise when session ends.
+func runRemoveOnExit(session context.Context, tracker *createdTracker, containerName string, upErr error) error {
+	if !tracker.get() {
+		if upErr == nil {
+			warnf("container '%s' was not created by this invocation; not removing it on exit", containerName)
+		}
+		return upErr
+	}
+
+	cli, err := newEngineClient()
+	if err != nil {
+		return errors.Join(upErr, fmt.Errorf("failed to create Docker client: %w", err))
+	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+
+	return removeOnExit(session, cli, containerName, upErr, os.Stderr)
+}
+
+// removeOnExit blocks until session ends, then stops and removes the
+// container as `devgo down` does. When up failed with upErr the container is
+// removed at once and upErr returned. The cleanup uses its own context
+// because session may already be cancelled.
+func removeOnExit(session context.Context, cli DownDockerClient, containerName string, upErr error, notice io.Writer) error {
+	if upErr != nil {
+		debugf("Up failed, removing container '%s'\n", containerName)
+		if err := stopAndRemoveContainer(context.Background(), cli, containerName, container.RemoveOptions{}); err != nil {
+			return errors.Join(upErr, err)
+		}
+		return upErr
+	}
+
+	fmt.Fprintf(notice, "Container '%s' is running; press Ctrl-C to stop and remove it\n", containerName)
+	<-session.Done()
+
+	debugf("Session ended, removing container '%s'\n", containerName)
+	return stopAndRemoveContainer(context.Background(), cli, containerName, container.RemoveOptions{})
+}
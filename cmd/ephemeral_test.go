@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestRemoveOnExit(t *testing.T) {
+	mock := &mockDownDockerClient{
+		containers: []container.Summary{
+			{ID: "abc", Names: []string{"/test-container"}, State: "running"},
+		},
+	}
+	session, cancel := context.WithCancel(context.Background())
+
+	var notice bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- removeOnExit(session, mock, "test-container", nil, &notice)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("removeOnExit() returned before the session ended: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("removeOnExit() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("removeOnExit() did not return after the session ended")
+	}
+
+	if len(mock.stoppedContainers) != 1 || mock.stoppedContainers[0] != "abc" {
+		t.Errorf("stopped = %v, want [abc]", mock.stoppedContainers)
+	}
+	if len(mock.removedContainers) != 1 || mock.removedContainers[0] != "abc" {
+		t.Errorf("removed = %v, want [abc]", mock.removedContainers)
+	}
+	if !strings.Contains(notice.String(), "test-container") {
+		t.Errorf("notice = %q, want it to name the container", notice.String())
+	}
+}
+
+func TestRemoveOnExit_UpFailed(t *testing.T) {
+	mock := &mockDownDockerClient{
+		containers: []container.Summary{
+			{ID: "abc", Names: []string{"/test-container"}, State: "running"},
+		},
+	}
+	upErr := errors.New("postCreateCommand failed")
+
+	// The session is still open: a failed up must not wait for Ctrl-C.
+	var notice bytes.Buffer
+	err := removeOnExit(context.Background(), mock, "test-container", upErr, &notice)
+	if !errors.Is(err, upErr) {
+		t.Fatalf("removeOnExit() error = %v, want %v", err, upErr)
+	}
+	if len(mock.removedContainers) != 1 || mock.removedContainers[0] != "abc" {
+		t.Errorf("removed = %v, want [abc]", mock.removedContainers)
+	}
+	if notice.Len() != 0 {
+		t.Errorf("notice = %q, want none after a failed up", notice.String())
+	}
+}
+
+func TestRunRemoveOnExit_NotCreated(t *testing.T) {
+	// Nothing was created, so no engine is contacted and the error of up is
+	// returned as is.
+	_, tracker := withCreatedTracker(context.Background())
+	upErr := errors.New("container 'test-container' is already running")
+	if err := runRemoveOnExit(context.Background(), tracker, "test-container", upErr); err != upErr {
+		t.Errorf("runRemoveOnExit() error = %v, want %v", err, upErr)
+	}
+	if err := runRemoveOnExit(context.Background(), tracker, "test-container", nil); err != nil {
+		t.Errorf("runRemoveOnExit() error = %v, want nil", err)
+	}
+}
+
+func TestCreateAndStartContainer_MarksCreated(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	tests := []struct {
+		name       string
+		startError error
+	}{
+		{name: "started"},
+		// A container that failed to start still exists and must be removed.
+		{name: "start failed", startError: errors.New("port is already allocated")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerClient := &realDockerClient{client: &mockDockerAPIClient{startError: tt.startError}}
+			ctx, tracker := withCreatedTracker(context.Background())
+			err := dockerClient.CreateAndStartContainer(ctx, DockerRunArgs{
+				Name:            "test-container",
+				Image:           "ubuntu:22.04",
+				WorkspaceDir:    "/host/workspace",
+				WorkspaceFolder: "/workspace",
+			})
+			if (err != nil) != (tt.startError != nil) {
+				t.Fatalf("CreateAndStartContainer() error = %v", err)
+			}
+			if !tracker.get() {
+				t.Error("container not marked as created")
+			}
+		})
+	}
+}
@@ -2,18 +2,23 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/garaemon/devgo/pkg/constants"
 	"github.com/garaemon/devgo/pkg/devcontainer"
+	"golang.org/x/term"
 )
 
 // DockerExecClient interface for Docker exec operations
@@ -23,29 +28,41 @@ type DockerExecClient interface {
 	ContainerExecCreate(ctx context.Context, containerID string, config container.ExecOptions) (container.ExecCreateResponse, error)
 	ContainerExecStart(ctx context.Context, execID string, config container.ExecStartOptions) error
 	ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error)
+	execInspectClient
+	execResizeClient
 	Close() error
 }
 
 func runExecCommand(args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("exec command requires at least one argument")
+	if len(args) == 0 && !printID {
+		return execWithoutCommand(term.IsTerminal(int(os.Stdin.Fd())), runShellCommand)
 	}
-
-	devcontainerPath, err := findDevcontainerConfig(configPath)
-	if err != nil {
-		return fmt.Errorf("failed to find devcontainer config: %w", err)
+	if err := validateExecFlags(); err != nil {
+		return err
 	}
 
-	workspaceDir := determineWorkspaceFolder(devcontainerPath)
+	// A container picked with --name-prefix need not belong to this
+	// workspace, so its devcontainer.json is neither required nor used.
+	var devContainer *devcontainer.DevContainer
+	var workspaceDir, containerName string
+	if execNamePrefix == "" {
+		devcontainerPath, err := findDevcontainerConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to find devcontainer config: %w", err)
+		}
 
-	devContainer, err := devcontainer.Parse(devcontainerPath)
-	if err != nil {
-		return fmt.Errorf("failed to parse devcontainer.json: %w", err)
-	}
+		workspaceDir = determineWorkspaceFolder(devcontainerPath)
 
-	containerName := determineContainerName(devContainer, workspaceDir)
+		resolved, err := resolveEnvConfig(devcontainerPath, configOverrides)
+		if err != nil {
+			return err
+		}
+		devContainer = resolved.DevContainer
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		containerName = determineContainerName(devContainer, workspaceDir)
+	}
+
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -56,41 +73,436 @@ func runExecCommand(args []string) error {
 	}()
 
 	ctx := context.Background()
-	return executeCommandInContainer(ctx, cli, containerName, args, devContainer)
+	if execNamePrefix != "" {
+		containerName, err = findContainerByPrefix(ctx, cli, execNamePrefix)
+		if err != nil {
+			return err
+		}
+	}
+	opts := newExecOptions()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	opts.Signals = signals
+
+	if teeFile != "" {
+		f, err := os.Create(teeFile)
+		if err != nil {
+			return fmt.Errorf("failed to create tee file: %w", err)
+		}
+		defer func() {
+			if closeErr := f.Close(); closeErr != nil {
+				warnf("failed to close tee file: %v", closeErr)
+			}
+		}()
+		opts = teeExecOutput(opts, f)
+	}
+
+	if shellOverride != "" && len(args) > 0 {
+		args = shellScriptCommand(shellOverride, args)
+	}
+
+	if execService != "" {
+		return runExecInComposeService(ctx, cli, workspaceDir, execService, args, devContainer, opts)
+	}
+
+	if printID {
+		return printContainerID(ctx, cli, containerName, os.Stdout)
+	}
+	if devContainer == nil {
+		debugf("Container '%s' was picked with --name-prefix, running with its own user and directory\n", containerName)
+		if execWorkdir != "" {
+			opts.WorkingDir = resolveExecWorkdir("/", execWorkdir)
+		}
+	} else {
+		opts.WorkingDir = devContainer.GetWorkspaceFolder()
+		if execWorkdir != "" {
+			opts.WorkingDir = resolveExecWorkdir(opts.WorkingDir, execWorkdir)
+		} else if hostCwd, err := os.Getwd(); err == nil {
+			relative := resolveCwdRelative(cwdRelative, term.IsTerminal(int(os.Stdin.Fd())))
+			opts.WorkingDir = hostCwdWorkdir(workspaceDir, opts.WorkingDir, hostCwd, relative)
+		}
+		if autoStart {
+			if err := ensureContainerRunning(ctx, cli, containerName, func() error { return runUpCommand(nil) }); err != nil {
+				return err
+			}
+		}
+		if err := checkContainerWorkdir(ctx, cli, containerName, devContainer.GetTargetUser(), opts.WorkingDir); err != nil {
+			return err
+		}
+	}
+	if resultJSON {
+		return runExecResultJSON(ctx, cli, containerName, args, devContainer, opts, os.Stdout)
+	}
+	return executeCommandInContainerWithOptions(ctx, cli, containerName, args, devContainer, opts)
 }
 
-func executeCommandInContainer(ctx context.Context, cli DockerExecClient, containerName string, args []string, devContainer *devcontainer.DevContainer) error {
+// validateExecFlags rejects flag combinations of `devgo exec` that cannot
+// work together, before anything is looked up or started.
+func validateExecFlags() error {
+	if execNamePrefix != "" && execService != "" {
+		return fmt.Errorf("--name-prefix cannot be combined with --service")
+	}
+	if (execInteractive || execTTY) && (execRaw || resultJSON) {
+		return fmt.Errorf("-i and -t cannot be combined with --raw or --result-json")
+	}
+	if execRaw && resultJSON {
+		return fmt.Errorf("--raw cannot be combined with --result-json")
+	}
+	if teeFile != "" && resultJSON {
+		return fmt.Errorf("--tee cannot be combined with --result-json")
+	}
+	return nil
+}
+
+// execWithoutCommand handles `devgo exec` with no command: on a terminal it
+// opens an interactive shell like `devgo shell`; a script gets an error
+// rather than a shell waiting for input.
+func execWithoutCommand(interactive bool, openShell func(args []string) error) error {
+	if !interactive {
+		return fmt.Errorf("exec command requires at least one argument")
+	}
+	return openShell(nil)
+}
+
+// execCapturer runs a command to completion and returns its stdout, stderr
+// and exit code.
+type execCapturer interface {
+	ExecIn(ctx context.Context, user, workdir string, env []string, cmd []string) (string, string, int, error)
+}
+
+// execResult is the envelope printed by `devgo exec --result-json`.
+type execResult struct {
+	Exit   int    `json:"exit"`
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+}
+
+// runExecResultJSON implements `devgo exec --result-json`: the command's
+// output is captured instead of streamed and reported as one JSON object.
+// A non-zero exit is part of the result, not an error of devgo.
+func runExecResultJSON(ctx context.Context, cli workdirDockerClient, containerName string, args []string, devContainer *devcontainer.DevContainer, opts execOptions, w io.Writer) error {
 	containerID, err := findRunningContainer(ctx, cli, containerName)
 	if err != nil {
 		return fmt.Errorf("failed to find running container: %w", err)
 	}
+	if containerID == "" {
+		return fmt.Errorf("container '%s' is not running. Use 'devgo up' to start it first", containerName)
+	}
+
+	user, workdir, env, err := execSettings(ctx, cli, containerID, devContainer, opts)
+	if err != nil {
+		return err
+	}
+	return writeExecResultJSON(ctx, newDotfilesExecutor(cli, containerID), user, workdir, env, wrapWithGroups(opts.Groups, args), w)
+}
+
+func writeExecResultJSON(ctx context.Context, capturer execCapturer, user, workdir string, env, args []string, w io.Writer) error {
+	stdout, stderr, exitCode, err := capturer.ExecIn(ctx, user, workdir, env, args)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(execResult{Exit: exitCode, Stdout: stdout, Stderr: stderr})
+}
+
+// runExecInComposeService implements `devgo exec --service NAME`. The
+// devcontainer's user, workspace folder and containerEnv only apply to its
+// own service; other services run the command with their image defaults.
+func runExecInComposeService(ctx context.Context, cli DockerExecClient, workspaceDir, service string, args []string, devContainer *devcontainer.DevContainer, opts execOptions) error {
+	if !devContainer.HasDockerCompose() {
+		return fmt.Errorf("--service requires a docker compose configuration")
+	}
+	containerID, err := findComposeServiceContainer(ctx, cli, workspaceDir, service)
+	if err != nil {
+		return err
+	}
+	if printID {
+		_, err := fmt.Fprintln(os.Stdout, containerID)
+		return err
+	}
+
+	target := devContainer
+	if service != devContainer.GetService() {
+		target = nil
+	}
+	return execInContainer(ctx, cli, containerID, args, target, opts)
+}
+
+// findComposeServiceContainer returns the ID of the running container of a
+// compose service in the project for workspaceDir. Containers are matched by
+// the compose service label and project directory, falling back to the name
+// devgo computes for the service.
+func findComposeServiceContainer(ctx context.Context, cli DockerExecClient, workspaceDir, service string) (string, error) {
+	filter := filters.NewArgs()
+	filter.Add("label", fmt.Sprintf("%s=%s", constants.ComposeServiceLabel, service))
+	filter.Add("status", "running")
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		Filters: filter,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var candidates []container.Summary
+	for _, c := range containers {
+		if c.Labels[constants.ComposeServiceLabel] == service {
+			candidates = append(candidates, c)
+		}
+	}
+
+	for _, c := range candidates {
+		if c.Labels[constants.ComposeWorkingDirLabel] == workspaceDir {
+			return c.ID, nil
+		}
+	}
 
+	expectedName := composeServiceContainerName(workspaceDir, service)
+	for _, c := range candidates {
+		for _, name := range c.Names {
+			if strings.TrimPrefix(name, "/") == expectedName {
+				return c.ID, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no running container for compose service '%s'. Use 'devgo up' to start it first", service)
+}
+
+// printContainerID implements `devgo exec --print-id`: it writes the ID of
+// the running container for the workspace so it can be piped into docker.
+func printContainerID(ctx context.Context, cli DockerExecClient, containerName string, w io.Writer) error {
+	containerID, err := findRunningContainer(ctx, cli, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to find running container: %w", err)
+	}
 	if containerID == "" {
 		return fmt.Errorf("container '%s' is not running. Use 'devgo up' to start it first", containerName)
 	}
+	if _, err := fmt.Fprintln(w, containerID); err != nil {
+		return fmt.Errorf("failed to write container ID: %w", err)
+	}
+	return nil
+}
+
+// containerStartClient is the subset of the Docker API used by --start to
+// find the target container and start it when it is stopped.
+type containerStartClient interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+}
+
+// startAction is what --start has to do before a command can run.
+type startAction int
+
+const (
+	startActionNone   startAction = iota // container already running
+	startActionStart                     // container exists but is stopped
+	startActionCreate                    // no container yet; run the up flow
+)
+
+// decideStartAction maps the state of the target container to a startAction.
+func decideStartAction(exists, running bool) startAction {
+	switch {
+	case running:
+		return startActionNone
+	case exists:
+		return startActionStart
+	default:
+		return startActionCreate
+	}
+}
+
+// ensureContainerRunning implements --start: a stopped container is started
+// in place, and a missing one is created by calling runUp.
+func ensureContainerRunning(ctx context.Context, cli containerStartClient, containerName string, runUp func() error) error {
+	containerID, running, err := findContainerState(ctx, cli, containerName)
+	if err != nil {
+		return err
+	}
 
-	// Get base environment variables from running container
-	inspect, err := cli.ContainerInspect(ctx, containerID)
+	switch decideStartAction(containerID != "", running) {
+	case startActionStart:
+		debugf("Starting stopped container %s\n", containerName)
+		if err := cli.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
+			return fmt.Errorf("failed to start container: %w", err)
+		}
+	case startActionCreate:
+		debugf("Container %s does not exist, running up\n", containerName)
+		if err := runUp(); err != nil {
+			return fmt.Errorf("failed to start container: %w", err)
+		}
+	}
+	return nil
+}
+
+// findContainerState looks up a devgo-managed container by exact name in any
+// state. An empty ID means no such container exists.
+func findContainerState(ctx context.Context, cli containerStartClient, containerName string) (string, bool, error) {
+	filter := filters.NewArgs()
+	filter.Add("name", containerName)
+	filter.Add("label", managedLabelFilter())
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filter,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to inspect container: %w", err)
+		return "", false, fmt.Errorf("failed to list containers: %w", err)
 	}
 
-	baseEnv := make(map[string]string)
-	for _, e := range inspect.Config.Env {
-		parts := strings.SplitN(e, "=", 2)
-		if len(parts) == 2 {
-			baseEnv[parts[0]] = parts[1]
+	for _, c := range containers {
+		for _, name := range c.Names {
+			if strings.TrimPrefix(name, "/") == containerName {
+				return c.ID, c.State == "running", nil
+			}
 		}
 	}
+	return "", false, nil
+}
+
+// execOptions controls where the demultiplexed output of a non-TTY exec is
+// written. Lifecycle commands use the process stdout/stderr; `devgo exec`
+// derives its options from the command-line flags.
+type execOptions struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	// Env holds extra variables set on top of the container environment.
+	Env map[string]string
+	// WorkingDir overrides the workspace folder as the exec working directory.
+	WorkingDir string
+	// Groups are supplementary groups the command runs with (--group-add).
+	Groups []string
+	// Signals, when set, delivers the signals devgo should forward to the
+	// running command instead of dying on them.
+	Signals <-chan os.Signal
+	// Raw copies the attached stream to Stdout as is instead of splitting it
+	// into stdout and stderr. See copyExecOutput.
+	Raw bool
+	// Interactive attaches the host stdin and TTY allocates a TTY (-i/-t).
+	// See execWithTerminal.
+	Interactive bool
+	TTY         bool
+	// Stdin replaces the host stdin copied to the command with Interactive.
+	Stdin io.Reader
+}
+
+// defaultExecOptions streams the command output to the process stdout/stderr.
+func defaultExecOptions() execOptions {
+	return execOptions{Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+// newExecOptions builds the options for `devgo exec` from the parsed flags.
+// --no-stderr discards the command's stderr while stdout is kept. Unless
+// --no-size-env is given, COLUMNS/LINES follow the host terminal so tools
+// that format output by width wrap sensibly without a TTY.
+func newExecOptions() execOptions {
+	opts := defaultExecOptions()
+	if noStderr {
+		opts.Stderr = io.Discard
+	}
+	if !noSizeEnv {
+		opts.Env = terminalSizeEnv(stdoutTerminalSize)
+	}
+	opts.Groups = execGroups
+	opts.Raw = execRaw
+	opts.Interactive = execInteractive
+	opts.TTY = execTTY
+	return opts
+}
+
+// teeExecOutput copies the command output that reaches the terminal to w as
+// well. Stderr discarded by --no-stderr stays discarded.
+func teeExecOutput(opts execOptions, w io.Writer) execOptions {
+	opts.Stdout = io.MultiWriter(opts.Stdout, w)
+	if opts.Stderr != io.Discard {
+		opts.Stderr = io.MultiWriter(opts.Stderr, w)
+	}
+	return opts
+}
+
+// wrapWithGroups runs args under each supplementary group. Docker exec has
+// no option for supplementary groups, so the command is wrapped in one `sg`
+// per group, outermost first. sg only switches to groups the exec user is a
+// member of (root may use any).
+func wrapWithGroups(groups, args []string) []string {
+	wrapped := args
+	for i := len(groups) - 1; i >= 0; i-- {
+		wrapped = []string{"sg", groups[i], "-c", shellJoin(wrapped)}
+	}
+	return wrapped
+}
+
+// shellScriptCommand runs args[0] as a script with `shell -c`. The script
+// stays a single argument, so the newlines of a multi-line script reach the
+// shell unchanged; the remaining args become its positional parameters, with
+// $0 set to the shell.
+func shellScriptCommand(shell string, args []string) []string {
+	command := []string{shell, "-c", args[0]}
+	if len(args) > 1 {
+		command = append(command, shell)
+		command = append(command, args[1:]...)
+	}
+	return command
+}
+
+// shellJoin quotes every argument for `sh -c` and joins them with spaces.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", "'\\''") + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// stdoutTerminalSize returns the size of the terminal attached to stdout.
+func stdoutTerminalSize() (int, int, error) {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0, 0, fmt.Errorf("stdout is not a terminal")
+	}
+	return term.GetSize(fd)
+}
+
+// terminalSizeEnv returns COLUMNS and LINES for the size reported by getSize,
+// or nil when no usable size is available.
+func terminalSizeEnv(getSize func() (int, int, error)) map[string]string {
+	width, height, err := getSize()
+	if err != nil || width <= 0 || height <= 0 {
+		return nil
+	}
+	return map[string]string{
+		"COLUMNS": strconv.Itoa(width),
+		"LINES":   strconv.Itoa(height),
+	}
+}
+
+func executeCommandInContainer(ctx context.Context, cli DockerExecClient, containerName string, args []string, devContainer *devcontainer.DevContainer) error {
+	return executeCommandInContainerWithOptions(ctx, cli, containerName, args, devContainer, defaultExecOptions())
+}
+
+func executeCommandInContainerWithOptions(ctx context.Context, cli DockerExecClient, containerName string, args []string, devContainer *devcontainer.DevContainer, opts execOptions) error {
+	containerID, err := findRunningContainer(ctx, cli, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to find running container: %w", err)
+	}
 
-	expandedEnv := devContainer.GetContainerEnv(baseEnv)
-	var env []string
-	for k, v := range expandedEnv {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	if containerID == "" {
+		return fmt.Errorf("container '%s' is not running. Use 'devgo up' to start it first", containerName)
 	}
 
-	user := devContainer.GetTargetUser()
-	workspaceFolder := devContainer.GetWorkspaceFolder()
+	return execInContainer(ctx, cli, containerID, args, devContainer, opts)
+}
+
+// execInContainer runs args in the container with the given ID. A nil
+// devContainer runs the command as the image's default user and directory
+// without containerEnv, which is what other compose services expect.
+func execInContainer(ctx context.Context, cli DockerExecClient, containerID string, args []string, devContainer *devcontainer.DevContainer, opts execOptions) error {
+	user, workspaceFolder, env, err := execSettings(ctx, cli, containerID, devContainer, opts)
+	if err != nil {
+		return err
+	}
+	token := newExecToken()
 
 	execConfig := container.ExecOptions{
 		User:         user,
@@ -98,9 +510,12 @@ func executeCommandInContainer(ctx context.Context, cli DockerExecClient, contai
 		AttachStdin:  false,
 		AttachStdout: true,
 		AttachStderr: true,
-		Cmd:          args,
+		Cmd:          wrapWithGroups(opts.Groups, args),
 		WorkingDir:   workspaceFolder,
-		Env:          env,
+		Env:          append(env, execTokenEnv+"="+token),
+	}
+	if opts.Interactive || opts.TTY {
+		return execWithTerminal(ctx, cli, containerID, token, execConfig, opts)
 	}
 
 	execCreateResp, err := cli.ContainerExecCreate(ctx, containerID, execConfig)
@@ -122,20 +537,106 @@ func executeCommandInContainer(ctx context.Context, cli DockerExecClient, contai
 		return fmt.Errorf("failed to start exec instance: %w", err)
 	}
 
-	// Demultiplex the output stream (Docker uses multiplexed stdout/stderr)
-	_, err = stdcopy.StdCopy(os.Stdout, os.Stderr, execAttachResp.Reader)
+	done := make(chan struct{})
+	defer close(done)
+	interrupted := forwardSignal(opts.Signals, done, func(sig os.Signal) {
+		stopExec(ctx, cli, containerID, token, sig, execAttachResp.Close)
+	})
+	killOnCancel(ctx, done, func() {
+		stopExec(ctx, cli, containerID, token, os.Kill, execAttachResp.Close)
+	})
+
+	err = copyExecOutput(opts, execAttachResp.Reader)
+	if ctx.Err() != nil {
+		return fmt.Errorf("exec stopped: %w", ctx.Err())
+	}
+	select {
+	case sig := <-interrupted:
+		return fmt.Errorf("exec interrupted by %s", sig)
+	default:
+	}
 	if err != nil && err != io.EOF {
 		return fmt.Errorf("failed to copy output: %w", err)
 	}
 
-	return nil
+	return execExitError(ctx, cli, execCreateResp.ID)
+}
+
+// copyExecOutput copies the attached exec stream to the writers in opts.
+// Without a TTY Docker multiplexes stdout and stderr into one stream of
+// frames, each starting with an 8-byte header (stream type and length), which
+// stdcopy splits apart. With opts.Raw the stream is copied to Stdout byte for
+// byte with io.Copy instead, headers included, for consumers that
+// demultiplex it themselves.
+func copyExecOutput(opts execOptions, r io.Reader) error {
+	if opts.Raw {
+		_, err := io.Copy(opts.Stdout, r)
+		return err
+	}
+	_, err := stdcopy.StdCopy(opts.Stdout, opts.Stderr, r)
+	return err
+}
+
+// execSettings returns the user, working directory and environment for an
+// exec. A nil devContainer keeps the image defaults; opts.WorkingDir and
+// opts.Env apply either way.
+func execSettings(ctx context.Context, cli DockerExecClient, containerID string, devContainer *devcontainer.DevContainer, opts execOptions) (string, string, []string, error) {
+	expandedEnv := make(map[string]string)
+	var user, workspaceFolder string
+	if devContainer != nil {
+		// Get base environment variables from running container
+		inspect, err := cli.ContainerInspect(ctx, containerID)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("failed to inspect container: %w", err)
+		}
+
+		baseEnv := make(map[string]string)
+		for _, e := range inspect.Config.Env {
+			parts := strings.SplitN(e, "=", 2)
+			if len(parts) == 2 {
+				baseEnv[parts[0]] = parts[1]
+			}
+		}
+
+		expandedEnv = remoteUserEnv(devContainer, baseEnv)
+		user = devContainer.GetTargetUser()
+		workspaceFolder = devContainer.GetWorkspaceFolder()
+	}
+	if opts.WorkingDir != "" {
+		workspaceFolder = opts.WorkingDir
+	}
+	for k, v := range opts.Env {
+		expandedEnv[k] = v
+	}
+	return user, workspaceFolder, envMapToSlice(expandedEnv), nil
+}
+
+// forwardSignal waits for the first signal on signals and then calls stop
+// with it, which delivers it to the command (see stopExec). The received
+// signal is reported on the returned channel. Nothing happens once done is
+// closed, and a nil signals channel disables forwarding.
+func forwardSignal(signals <-chan os.Signal, done <-chan struct{}, stop func(os.Signal)) <-chan os.Signal {
+	interrupted := make(chan os.Signal, 1)
+	if signals == nil {
+		return interrupted
+	}
+	go func() {
+		select {
+		case sig := <-signals:
+			debugf("Received %s, forwarding it to the exec\n", sig)
+			interrupted <- sig
+			stop(sig)
+		case <-done:
+		}
+	}()
+	return interrupted
 }
 
 func findRunningContainer(ctx context.Context, cli DockerExecClient, containerName string) (string, error) {
 	filter := filters.NewArgs()
 	filter.Add("name", containerName)
 	filter.Add("status", "running")
-	filter.Add("label", fmt.Sprintf("%s=%s", constants.DevgoManagedLabel, constants.DevgoManagedValue))
+	filter.Add("label", managedLabelFilter())
 
 	containers, err := cli.ContainerList(ctx, container.ListOptions{
 		Filters: filter,
@@ -154,3 +655,42 @@ func findRunningContainer(ctx context.Context, cli DockerExecClient, containerNa
 
 	return "", nil
 }
+
+// findContainerByPrefix returns the name of the running devgo container
+// whose name starts with prefix, for --name-prefix.
+func findContainerByPrefix(ctx context.Context, cli DockerExecClient, prefix string) (string, error) {
+	filter := filters.NewArgs()
+	filter.Add("status", "running")
+	filter.Add("label", managedLabelFilter())
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		Filters: filter,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list containers: %w", err)
+	}
+	return matchContainerPrefix(containers, prefix)
+}
+
+// matchContainerPrefix picks the one container in containers whose name
+// starts with prefix. No match and several matches are both errors; the
+// latter lists the candidates so the prefix can be extended.
+func matchContainerPrefix(containers []container.Summary, prefix string) (string, error) {
+	var matches []string
+	for _, c := range containers {
+		name := getContainerName(c.Names)
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no running devgo container name starts with %q", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		sort.Strings(matches)
+		return "", fmt.Errorf("%q matches several running devgo containers: %s", prefix, strings.Join(matches, ", "))
+	}
+}
@@ -4,14 +4,22 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/garaemon/devgo/pkg/constants"
 	"github.com/garaemon/devgo/pkg/devcontainer"
 )
@@ -72,6 +80,16 @@ type mockExecClient struct {
 	execAttachError    error
 	inspectResponse    types.ContainerJSON
 	inspectError       error
+	lastExecConfig     container.ExecOptions
+	// exitCode is reported by ContainerExecInspect.
+	exitCode int
+
+	mu sync.Mutex
+	// execConfigs records every exec created, in order.
+	execConfigs []container.ExecOptions
+	// attach, when set, returns the stream of the exec created last instead
+	// of execAttachResponse, so helper execs get streams of their own.
+	attach func(config container.ExecOptions) types.HijackedResponse
 }
 
 func (m *mockExecClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
@@ -89,6 +107,10 @@ func (m *mockExecClient) ContainerInspect(ctx context.Context, containerID strin
 }
 
 func (m *mockExecClient) ContainerExecCreate(ctx context.Context, containerID string, config container.ExecOptions) (container.ExecCreateResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastExecConfig = config
+	m.execConfigs = append(m.execConfigs, config)
 	if m.execCreateError != nil {
 		return container.ExecCreateResponse{}, m.execCreateError
 	}
@@ -103,9 +125,22 @@ func (m *mockExecClient) ContainerExecAttach(ctx context.Context, execID string,
 	if m.execAttachError != nil {
 		return types.HijackedResponse{}, m.execAttachError
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.attach != nil {
+		return m.attach(m.lastExecConfig), nil
+	}
 	return m.execAttachResponse, nil
 }
 
+func (m *mockExecClient) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	return container.ExecInspect{ExecID: execID, ExitCode: m.exitCode}, nil
+}
+
+func (m *mockExecClient) ContainerExecResize(ctx context.Context, execID string, options container.ResizeOptions) error {
+	return nil
+}
+
 func (m *mockExecClient) Close() error {
 	return nil
 }
@@ -542,3 +577,828 @@ func TestExecCommandContainerNameLogic(t *testing.T) {
 		})
 	}
 }
+
+// createMockHijackedResponseWithStreams returns a HijackedResponse whose
+// Reader holds one stdcopy frame for stdout followed by one for stderr.
+func createMockHijackedResponseWithStreams(stdout, stderr string) types.HijackedResponse {
+	buf := &bytes.Buffer{}
+	writer := stdcopy.NewStdWriter(buf, stdcopy.Stdout)
+	_, _ = writer.Write([]byte(stdout))
+	writer = stdcopy.NewStdWriter(buf, stdcopy.Stderr)
+	_, _ = writer.Write([]byte(stderr))
+	return types.HijackedResponse{
+		Conn:   &mockConn{Buffer: &bytes.Buffer{}},
+		Reader: bufio.NewReader(buf),
+	}
+}
+
+func newRunningExecMock(attach types.HijackedResponse) *mockExecClient {
+	return &mockExecClient{
+		containers: []container.Summary{
+			{
+				ID:    "abc",
+				Names: []string{"/test-container"},
+				Labels: map[string]string{
+					constants.DevgoManagedLabel: constants.DevgoManagedValue,
+				},
+			},
+		},
+		execCreateResponse: container.ExecCreateResponse{ID: "exec1"},
+		execAttachResponse: attach,
+		inspectResponse: types.ContainerJSON{
+			Config: &container.Config{Env: []string{"PATH=/usr/bin"}},
+		},
+	}
+}
+
+func TestNewExecOptions_NoStderr(t *testing.T) {
+	originalNoStderr := noStderr
+	defer func() { noStderr = originalNoStderr }()
+
+	noStderr = false
+	if opts := newExecOptions(); opts.Stderr == io.Discard {
+		t.Error("stderr should not be discarded without --no-stderr")
+	}
+
+	noStderr = true
+	opts := newExecOptions()
+	if opts.Stderr != io.Discard {
+		t.Error("stderr should be discarded with --no-stderr")
+	}
+	if opts.Stdout == io.Discard {
+		t.Error("stdout must be kept with --no-stderr")
+	}
+}
+
+func TestExecuteCommandInContainerWithOptions_DiscardsStderr(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{WorkspaceFolder: "/workspace"}
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("out\n", "err\n"))
+
+	var stdout bytes.Buffer
+	opts := execOptions{Stdout: &stdout, Stderr: io.Discard}
+	err := executeCommandInContainerWithOptions(context.Background(), mock, "test-container", []string{"cmd"}, devContainer, opts)
+	if err != nil {
+		t.Fatalf("executeCommandInContainerWithOptions error = %v", err)
+	}
+	if stdout.String() != "out\n" {
+		t.Errorf("stdout = %q, want %q", stdout.String(), "out\n")
+	}
+}
+
+// mockStartClient records ContainerStart calls for the --start tests.
+type mockStartClient struct {
+	containers []container.Summary
+	started    []string
+}
+
+func (m *mockStartClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	return m.containers, nil
+}
+
+func (m *mockStartClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	m.started = append(m.started, containerID)
+	return nil
+}
+
+func TestDecideStartAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		exists   bool
+		running  bool
+		expected startAction
+	}{
+		{name: "running container", exists: true, running: true, expected: startActionNone},
+		{name: "stopped container", exists: true, running: false, expected: startActionStart},
+		{name: "absent container", exists: false, running: false, expected: startActionCreate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decideStartAction(tt.exists, tt.running); got != tt.expected {
+				t.Errorf("decideStartAction(%t, %t) = %d, want %d", tt.exists, tt.running, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEnsureContainerRunning(t *testing.T) {
+	tests := []struct {
+		name          string
+		containers    []container.Summary
+		expectStarted []string
+		expectUp      bool
+	}{
+		{
+			name: "stopped container is started in place",
+			containers: []container.Summary{
+				{ID: "abc123", Names: []string{"/test-container"}, State: "exited"},
+			},
+			expectStarted: []string{"abc123"},
+		},
+		{
+			name:     "absent container runs the up flow",
+			expectUp: true,
+		},
+		{
+			name: "running container is left alone",
+			containers: []container.Summary{
+				{ID: "abc123", Names: []string{"/test-container"}, State: "running"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockStartClient{containers: tt.containers}
+			upCalled := false
+			runUp := func() error {
+				upCalled = true
+				return nil
+			}
+
+			if err := ensureContainerRunning(context.Background(), mock, "test-container", runUp); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if upCalled != tt.expectUp {
+				t.Errorf("up flow called = %t, want %t", upCalled, tt.expectUp)
+			}
+			if strings.Join(mock.started, ",") != strings.Join(tt.expectStarted, ",") {
+				t.Errorf("started containers = %v, want %v", mock.started, tt.expectStarted)
+			}
+		})
+	}
+}
+
+func TestPrintContainerID(t *testing.T) {
+	tests := []struct {
+		name        string
+		containers  []container.Summary
+		expectError bool
+		expected    string
+	}{
+		{
+			name: "running container",
+			containers: []container.Summary{
+				{ID: "abc123", Names: []string{"/test-container"}},
+			},
+			expected: "abc123\n",
+		},
+		{
+			name:        "container not running",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockExecClient{containers: tt.containers}
+			var out bytes.Buffer
+			err := printContainerID(context.Background(), mock, "test-container", &out)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("output = %q, want %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestTerminalSizeEnv(t *testing.T) {
+	got := terminalSizeEnv(func() (int, int, error) { return 120, 40, nil })
+	if got["COLUMNS"] != "120" || got["LINES"] != "40" {
+		t.Errorf("terminalSizeEnv() = %v, want COLUMNS=120 LINES=40", got)
+	}
+
+	if got := terminalSizeEnv(func() (int, int, error) { return 0, 0, errors.New("not a terminal") }); got != nil {
+		t.Errorf("terminalSizeEnv() = %v, want nil without a terminal", got)
+	}
+}
+
+func TestExecuteCommandInContainerWithOptions_SizeEnv(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{WorkspaceFolder: "/workspace"}
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("", ""))
+
+	opts := execOptions{
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+		Env:    terminalSizeEnv(func() (int, int, error) { return 120, 40, nil }),
+	}
+	if err := executeCommandInContainerWithOptions(context.Background(), mock, "test-container", []string{"ls"}, devContainer, opts); err != nil {
+		t.Fatalf("executeCommandInContainerWithOptions error = %v", err)
+	}
+
+	env := strings.Join(mock.lastExecConfig.Env, " ")
+	if !strings.Contains(env, "COLUMNS=120") || !strings.Contains(env, "LINES=40") {
+		t.Errorf("exec env = %v, want COLUMNS and LINES from the terminal size", mock.lastExecConfig.Env)
+	}
+}
+
+func TestExecuteCommandInContainerWithOptions_SignalStopsProcess(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{WorkspaceFolder: "/workspace", RemoteUser: "vscode"}
+	mock, sent := hungExecMock(t)
+
+	signals := make(chan os.Signal, 1)
+	opts := execOptions{Stdout: io.Discard, Stderr: io.Discard, Signals: signals}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- executeCommandInContainerWithOptions(context.Background(), mock, "test-container", []string{"sleep", "infinity"}, devContainer, opts)
+	}()
+
+	signals <- os.Interrupt
+
+	select {
+	case err := <-result:
+		if err == nil || !strings.Contains(err.Error(), "interrupted") {
+			t.Errorf("error = %v, want an interrupted error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("exec did not return after SIGINT")
+	}
+	// The hung command only goes away through the signal exec.
+	if len(*sent) != 1 {
+		t.Fatalf("signal execs = %d, want one", len(*sent))
+	}
+	signal := (*sent)[0]
+	token := findEnv(mock.execConfigs[0].Env, execTokenEnv)
+	if signal.User != "root" || signal.Cmd[4] != token || signal.Cmd[5] != "INT" {
+		t.Errorf("signal exec = user %q cmd %q, want INT for %s as root", signal.User, signal.Cmd[4:], token)
+	}
+}
+
+func TestFindComposeServiceContainer(t *testing.T) {
+	workspaceDir := "/home/user/project"
+	composeName := "/" + composeServiceContainerName(workspaceDir, "db")
+
+	tests := []struct {
+		name        string
+		containers  []container.Summary
+		service     string
+		expectID    string
+		expectError bool
+	}{
+		{
+			name: "matched by service label and project directory",
+			containers: []container.Summary{
+				{ID: "other-project", Names: []string{"/x-db-1"}, Labels: map[string]string{
+					constants.ComposeServiceLabel:    "db",
+					constants.ComposeWorkingDirLabel: "/home/user/other",
+				}},
+				{ID: "app", Names: []string{"/p-app-1"}, Labels: map[string]string{
+					constants.ComposeServiceLabel:    "app",
+					constants.ComposeWorkingDirLabel: workspaceDir,
+				}},
+				{ID: "db", Names: []string{"/p-db-1"}, Labels: map[string]string{
+					constants.ComposeServiceLabel:    "db",
+					constants.ComposeWorkingDirLabel: workspaceDir,
+				}},
+			},
+			service:  "db",
+			expectID: "db",
+		},
+		{
+			name: "falls back to the computed compose name",
+			containers: []container.Summary{
+				{ID: "db", Names: []string{composeName}, Labels: map[string]string{
+					constants.ComposeServiceLabel: "db",
+				}},
+			},
+			service:  "db",
+			expectID: "db",
+		},
+		{
+			name: "service not running",
+			containers: []container.Summary{
+				{ID: "app", Names: []string{"/p-app-1"}, Labels: map[string]string{
+					constants.ComposeServiceLabel:    "app",
+					constants.ComposeWorkingDirLabel: workspaceDir,
+				}},
+			},
+			service:     "db",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockExecClient{containers: tt.containers}
+			id, err := findComposeServiceContainer(context.Background(), mock, workspaceDir, tt.service)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tt.expectID {
+				t.Errorf("findComposeServiceContainer() = %q, want %q", id, tt.expectID)
+			}
+		})
+	}
+}
+
+func TestExecInContainer_OtherServiceUsesContainerDefaults(t *testing.T) {
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("", ""))
+
+	opts := execOptions{Stdout: io.Discard, Stderr: io.Discard}
+	if err := execInContainer(context.Background(), mock, "db-id", []string{"psql"}, nil, opts); err != nil {
+		t.Fatalf("execInContainer() error = %v", err)
+	}
+	if mock.lastExecConfig.User != "" || mock.lastExecConfig.WorkingDir != "" {
+		t.Errorf("exec config = %+v, want the image's default user and directory", mock.lastExecConfig)
+	}
+}
+
+func TestWrapWithGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		groups   []string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "no groups",
+			args:     []string{"ls", "-la"},
+			expected: []string{"ls", "-la"},
+		},
+		{
+			name:     "one group",
+			groups:   []string{"docker"},
+			args:     []string{"docker", "ps"},
+			expected: []string{"sg", "docker", "-c", "'docker' 'ps'"},
+		},
+		{
+			name:     "multiple groups nest with the first outermost",
+			groups:   []string{"docker", "video"},
+			args:     []string{"id"},
+			expected: []string{"sg", "docker", "-c", `'sg' 'video' '-c' ''\''id'\'''`},
+		},
+		{
+			name:     "single quotes in arguments are escaped",
+			groups:   []string{"docker"},
+			args:     []string{"echo", "it's"},
+			expected: []string{"sg", "docker", "-c", `'echo' 'it'\''s'`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapWithGroups(tt.groups, tt.args)
+			if strings.Join(got, "\x00") != strings.Join(tt.expected, "\x00") {
+				t.Errorf("wrapWithGroups() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// fakeExecCapturer returns canned output and records the exec settings.
+type fakeExecCapturer struct {
+	stdout, stderr string
+	exitCode       int
+	gotWorkdir     string
+	gotCmd         []string
+}
+
+func (f *fakeExecCapturer) ExecIn(_ context.Context, _ string, workdir string, _ []string, cmd []string) (string, string, int, error) {
+	f.gotWorkdir = workdir
+	f.gotCmd = cmd
+	return f.stdout, f.stderr, f.exitCode, nil
+}
+
+func TestWriteExecResultJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		capturer *fakeExecCapturer
+		expected execResult
+	}{
+		{
+			name:     "success",
+			capturer: &fakeExecCapturer{stdout: "hello\n"},
+			expected: execResult{Exit: 0, Stdout: "hello\n"},
+		},
+		{
+			name:     "non-zero exit",
+			capturer: &fakeExecCapturer{stderr: "no such file\n", exitCode: 2},
+			expected: execResult{Exit: 2, Stderr: "no such file\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeExecResultJSON(context.Background(), tt.capturer, "vscode", "/workspace", nil, []string{"ls"}, &out)
+			if err != nil {
+				t.Fatalf("writeExecResultJSON() error = %v", err)
+			}
+
+			var got execResult
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("output %q is not JSON: %v", out.String(), err)
+			}
+			if got != tt.expected {
+				t.Errorf("result = %+v, want %+v", got, tt.expected)
+			}
+			for _, key := range []string{`"exit"`, `"stdout"`, `"stderr"`} {
+				if !strings.Contains(out.String(), key) {
+					t.Errorf("output %q is missing %s", out.String(), key)
+				}
+			}
+			if tt.capturer.gotWorkdir != "/workspace" {
+				t.Errorf("workdir = %q, want /workspace", tt.capturer.gotWorkdir)
+			}
+		})
+	}
+}
+
+func TestShellScriptCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "single line",
+			args: []string{"echo hello"},
+			want: []string{"/bin/sh", "-c", "echo hello"},
+		},
+		{
+			name: "multi-line script",
+			args: []string{"cd /tmp\necho \"$PWD\""},
+			want: []string{"/bin/sh", "-c", "cd /tmp\necho \"$PWD\""},
+		},
+		{
+			name: "extra args become positional parameters",
+			args: []string{"echo \"$1\"", "a b"},
+			want: []string{"/bin/sh", "-c", "echo \"$1\"", "/bin/sh", "a b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shellScriptCommand("/bin/sh", tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shellScriptCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecShellScript_PreservesNewlines(t *testing.T) {
+	originalShell := shellOverride
+	defer func() { shellOverride = originalShell }()
+	shellOverride = ""
+
+	script := "echo one\necho two"
+	args, err := parseAllFlags([]string{"exec", "--shell", "/bin/sh", script})
+	if err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if len(args) != 2 || args[1] != script {
+		t.Fatalf("non-flag args = %q, want [exec %q]", args, script)
+	}
+
+	devContainer := &devcontainer.DevContainer{WorkspaceFolder: "/workspace"}
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("", ""))
+	opts := execOptions{Stdout: io.Discard, Stderr: io.Discard}
+	command := shellScriptCommand(shellOverride, args[1:])
+	if err := executeCommandInContainerWithOptions(context.Background(), mock, "test-container", command, devContainer, opts); err != nil {
+		t.Fatalf("executeCommandInContainerWithOptions error = %v", err)
+	}
+
+	want := []string{"/bin/sh", "-c", "echo one\necho two"}
+	if got := []string(mock.lastExecConfig.Cmd); !reflect.DeepEqual(got, want) {
+		t.Errorf("exec Cmd = %q, want %q", got, want)
+	}
+}
+
+func TestExecWithoutCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		interactive bool
+		wantShell   bool
+		wantErr     bool
+	}{
+		{name: "interactive opens a shell", interactive: true, wantShell: true, wantErr: false},
+		{name: "non-interactive errors", interactive: false, wantShell: false, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shellOpened := false
+			err := execWithoutCommand(tt.interactive, func(args []string) error {
+				shellOpened = true
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("execWithoutCommand() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if shellOpened != tt.wantShell {
+				t.Errorf("shell opened = %t, want %t", shellOpened, tt.wantShell)
+			}
+		})
+	}
+}
+
+func TestTeeExecOutput(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{WorkspaceFolder: "/workspace"}
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("out\n", "err\n"))
+
+	teePath := filepath.Join(t.TempDir(), "out.log")
+	f, err := os.Create(teePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var stdout, stderr bytes.Buffer
+	opts := teeExecOutput(execOptions{Stdout: &stdout, Stderr: &stderr}, f)
+	if err := executeCommandInContainerWithOptions(context.Background(), mock, "test-container", []string{"cmd"}, devContainer, opts); err != nil {
+		t.Fatalf("executeCommandInContainerWithOptions error = %v", err)
+	}
+
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Errorf("terminal output = %q / %q, want out and err", stdout.String(), stderr.String())
+	}
+	data, err := os.ReadFile(teePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "out\n") || !strings.Contains(string(data), "err\n") {
+		t.Errorf("tee file = %q, want both streams", data)
+	}
+}
+
+func TestTeeExecOutput_KeepsDiscardedStderr(t *testing.T) {
+	var tee bytes.Buffer
+	opts := teeExecOutput(execOptions{Stdout: io.Discard, Stderr: io.Discard}, &tee)
+	if opts.Stderr != io.Discard {
+		t.Error("stderr discarded by --no-stderr should not be teed")
+	}
+	if _, err := io.WriteString(opts.Stdout, "x"); err != nil || tee.String() != "x" {
+		t.Errorf("stdout should be teed, got %q (err %v)", tee.String(), err)
+	}
+}
+
+func TestCopyExecOutput_Raw(t *testing.T) {
+	binary := "\x00\x01\xff\xfebinary\r\n"
+	frames := &bytes.Buffer{}
+	if _, err := stdcopy.NewStdWriter(frames, stdcopy.Stdout).Write([]byte(binary)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdcopy.NewStdWriter(frames, stdcopy.Stderr).Write([]byte("warning\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := frames.Bytes()
+
+	tests := []struct {
+		name       string
+		raw        bool
+		wantStdout []byte
+		wantStderr string
+	}{
+		// Raw output is the stream as Docker sent it, frame headers included.
+		{name: "raw", raw: true, wantStdout: want, wantStderr: ""},
+		{name: "demultiplexed", raw: false, wantStdout: []byte(binary), wantStderr: "warning\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			opts := execOptions{Stdout: &stdout, Stderr: &stderr, Raw: tt.raw}
+			if err := copyExecOutput(opts, bytes.NewReader(want)); err != nil {
+				t.Fatalf("copyExecOutput() error = %v", err)
+			}
+			if !bytes.Equal(stdout.Bytes(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want %q", stdout.Bytes(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestMatchContainerPrefix(t *testing.T) {
+	containers := []container.Summary{
+		{Names: []string{"/myproj-default-1a2b3c"}},
+		{Names: []string{"/myproj-feature-1a2b3c"}},
+		{Names: []string{"/other-default-4d5e6f"}},
+	}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		want    string
+		wantErr string
+	}{
+		{name: "unique prefix", prefix: "other", want: "other-default-4d5e6f"},
+		{name: "unique longer prefix", prefix: "myproj-f", want: "myproj-feature-1a2b3c"},
+		{name: "ambiguous prefix", prefix: "myproj", wantErr: "myproj-default-1a2b3c, myproj-feature-1a2b3c"},
+		{name: "no match", prefix: "missing", wantErr: "no running devgo container"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchContainerPrefix(containers, tt.prefix)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("matchContainerPrefix() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchContainerPrefix() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("matchContainerPrefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateExecFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		interactive bool
+		tty         bool
+		raw         bool
+		resultJSON  bool
+		tee         string
+		wantErr     string
+	}{
+		{name: "no flags"},
+		{name: "-i alone", interactive: true},
+		{name: "--raw alone", raw: true},
+		{name: "-t with --raw", tty: true, raw: true, wantErr: "-i and -t cannot be combined"},
+		{name: "-i with --result-json", interactive: true, resultJSON: true, wantErr: "-i and -t cannot be combined"},
+		{name: "--raw with --result-json", raw: true, resultJSON: true, wantErr: "--raw cannot be combined"},
+		{name: "--tee alone", tee: "out.log"},
+		{name: "--tee with --result-json", resultJSON: true, tee: "out.log", wantErr: "--tee cannot be combined"},
+	}
+	origInteractive, origTTY, origRaw, origResultJSON, origTee := execInteractive, execTTY, execRaw, resultJSON, teeFile
+	defer func() {
+		execInteractive, execTTY, execRaw, resultJSON, teeFile = origInteractive, origTTY, origRaw, origResultJSON, origTee
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execInteractive, execTTY, execRaw, resultJSON, teeFile = tt.interactive, tt.tty, tt.raw, tt.resultJSON, tt.tee
+			err := validateExecFlags()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateExecFlags() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateExecFlags() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunExecCommand_RejectsFlagsBeforeTee(t *testing.T) {
+	origTTY, origRaw, origTee := execTTY, execRaw, teeFile
+	defer func() { execTTY, execRaw, teeFile = origTTY, origRaw, origTee }()
+	execTTY, execRaw = true, true
+	teeFile = filepath.Join(t.TempDir(), "out.log")
+
+	if err := runExecCommand([]string{"ls"}); err == nil {
+		t.Fatal("runExecCommand(-t, --raw) error = nil, want the combination rejected")
+	}
+	if _, err := os.Stat(teeFile); !os.IsNotExist(err) {
+		t.Errorf("tee file exists after a rejected flag combination (stat error = %v)", err)
+	}
+}
+
+func TestExecInContainer_NamePrefixUsesContainerDefaults(t *testing.T) {
+	// A container picked with --name-prefix runs the command without a
+	// workspace config, so with its own user, directory and env.
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("", ""))
+	opts := execOptions{Stdout: io.Discard, Stderr: io.Discard}
+	if err := executeCommandInContainerWithOptions(context.Background(), mock, "test-container", []string{"true"}, nil, opts); err != nil {
+		t.Fatalf("executeCommandInContainerWithOptions() error = %v", err)
+	}
+	if mock.lastExecConfig.User != "" || mock.lastExecConfig.WorkingDir != "" {
+		t.Errorf("exec ran as %q in %q, want the container defaults", mock.lastExecConfig.User, mock.lastExecConfig.WorkingDir)
+	}
+}
+
+func TestRunExecCommand_NamePrefixWithoutWorkspace(t *testing.T) {
+	origPrefix, origService, origConfig := execNamePrefix, execService, configPath
+	defer func() { execNamePrefix, execService, configPath = origPrefix, origService, origConfig }()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(t.TempDir())
+	configPath = ""
+
+	execNamePrefix = "myproj"
+	execService = "db"
+	err := runExecCommand([]string{"ls"})
+	if err == nil || !strings.Contains(err.Error(), "--name-prefix cannot be combined with --service") {
+		t.Errorf("runExecCommand(--name-prefix, --service) error = %v, want the combination rejected", err)
+	}
+
+	// Without --service the devcontainer.json lookup is skipped; only the
+	// container lookup can fail here.
+	execService = ""
+	err = runExecCommand([]string{"ls"})
+	if err != nil && strings.Contains(err.Error(), "devcontainer") {
+		t.Errorf("runExecCommand(--name-prefix) error = %v, want no devcontainer.json lookup", err)
+	}
+}
+
+func TestFindContainerByPrefix_FiltersManagedRunning(t *testing.T) {
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("", ""))
+	var lastOptions container.ListOptions
+	client := &listOptionsRecorder{mockExecClient: mock, options: &lastOptions}
+
+	got, err := findContainerByPrefix(context.Background(), client, "test")
+	if err != nil {
+		t.Fatalf("findContainerByPrefix() error = %v", err)
+	}
+	if got != "test-container" {
+		t.Errorf("findContainerByPrefix() = %q, want test-container", got)
+	}
+	if status := lastOptions.Filters.Get("status"); len(status) != 1 || status[0] != "running" {
+		t.Errorf("status filter = %v, want running", status)
+	}
+	if labels := lastOptions.Filters.Get("label"); len(labels) != 1 || labels[0] != managedLabelFilter() {
+		t.Errorf("label filter = %v, want the managed label", labels)
+	}
+}
+
+// listOptionsRecorder records the options of ContainerList calls.
+type listOptionsRecorder struct {
+	*mockExecClient
+	options *container.ListOptions
+}
+
+func (r *listOptionsRecorder) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	*r.options = options
+	return r.mockExecClient.ContainerList(ctx, options)
+}
+
+func TestExecSettings_RemoteEnv(t *testing.T) {
+	cli := &mockExecClient{
+		inspectResponse: types.ContainerJSON{
+			Config: &container.Config{Env: []string{"PATH=/usr/bin", "LANG=C"}},
+		},
+	}
+	dc := &devcontainer.DevContainer{
+		ContainerEnv: map[string]string{"LANG": "C.UTF-8", "MODE": "container"},
+		RemoteEnv:    map[string]string{"PATH": "${containerEnv:PATH}:/home/dev/bin", "MODE": "remote", "EDITOR": "vim"},
+	}
+	opts := defaultExecOptions()
+	opts.Env = map[string]string{"EDITOR": "nano"}
+
+	_, _, env, err := execSettings(context.Background(), cli, "abc", dc, opts)
+	if err != nil {
+		t.Fatalf("execSettings() error = %v", err)
+	}
+
+	// remoteEnv overrides containerEnv; per-exec variables override both.
+	want := []string{"EDITOR=nano", "LANG=C.UTF-8", "MODE=remote", "PATH=/usr/bin:/home/dev/bin"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("env = %v, want %v", env, want)
+	}
+}
+
+func TestExecInContainer_ExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		wantCode int
+	}{
+		{name: "success", exitCode: 0},
+		{name: "failure", exitCode: 3, wantCode: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newRunningExecMock(createMockHijackedResponseWithStreams("", ""))
+			mock.exitCode = tt.exitCode
+
+			opts := execOptions{Stdout: io.Discard, Stderr: io.Discard}
+			err := execInContainer(context.Background(), mock, "abc123", []string{"false"}, nil, opts)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("execInContainer() error = %v", err)
+				}
+				return
+			}
+			exitErr, ok := err.(*ExitError)
+			if !ok || exitErr.Code != tt.wantCode {
+				t.Errorf("execInContainer() error = %v, want an unwrapped *ExitError with code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"time"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// execTokenEnv marks the processes of an exec with a token unique to that
+// exec, so signalExec can find them again from a second exec. The Docker API
+// only knows the host PID of an exec, which means nothing inside the
+// container's PID namespace.
+const execTokenEnv = "DEVGO_EXEC_TOKEN"
+
+// signalExecScript sends signal $2 to every process whose environment holds
+// $1. Processes started by the command inherit the marker, so the whole tree
+// is signalled, much like a terminal signals its foreground process group.
+const signalExecScript = `for p in /proc/[0-9]*; do tr '\0' '\n' 2>/dev/null < "$p/environ" | grep -qxF "$1" && kill -s "$2" "${p#/proc/}" 2>/dev/null; done; true`
+
+// signalExecTimeout bounds the exec that delivers a signal, which runs after
+// the context of the command may already be over.
+const signalExecTimeout = 10 * time.Second
+
+// rootDevContainer runs devgo's own helper execs as root, whatever user the
+// configuration picks for commands.
+var rootDevContainer = &devcontainer.DevContainer{ContainerUser: "root"}
+
+// newExecToken returns a random token for execTokenEnv.
+func newExecToken() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "devgo"
+	}
+	return hex.EncodeToString(b)
+}
+
+// signalExec delivers sig to the processes of the exec marked with token.
+// The Docker API has no call to signal an exec, so `kill` runs as root in a
+// second exec.
+func signalExec(ctx context.Context, cli DockerExecClient, containerID, token string, sig os.Signal) error {
+	killCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), signalExecTimeout)
+	defer cancel()
+	args := []string{"/bin/sh", "-c", signalExecScript, "sh", execTokenEnv + "=" + token, signalName(sig)}
+	opts := execOptions{Stdout: io.Discard, Stderr: io.Discard, WorkingDir: "/"}
+	return execInContainer(killCtx, cli, containerID, args, rootDevContainer, opts)
+}
+
+// stopExec delivers sig to the exec marked with token and then closes its
+// attached stream with closeStream.
+func stopExec(ctx context.Context, cli DockerExecClient, containerID, token string, sig os.Signal, closeStream func()) {
+	if err := signalExec(ctx, cli, containerID, token, sig); err != nil {
+		warnf("failed to send %s to the command: %v", signalName(sig), err)
+	}
+	closeStream()
+}
+
+// signalName returns the name `kill -s` takes for sig.
+func signalName(sig os.Signal) string {
+	switch sig {
+	case os.Interrupt:
+		return "INT"
+	case os.Kill:
+		return "KILL"
+	default:
+		return "TERM"
+	}
+}
+
+// killOnCancel calls kill once ctx ends, unless done is closed first. The
+// attached exec stream does not watch ctx after it is dialed, so without
+// this a hung command outlives any deadline on ctx.
+func killOnCancel(ctx context.Context, done <-chan struct{}, kill func()) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			// ctx is often cancelled right after the exec finished.
+			select {
+			case <-done:
+				return
+			default:
+			}
+			debugf("Context ended (%v), killing exec\n", ctx.Err())
+			kill()
+		case <-done:
+		}
+	}()
+}
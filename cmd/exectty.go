@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"golang.org/x/term"
+)
+
+// parseExecTerminalFlag reports what arg asks of `devgo exec`: -i or
+// --interactive attaches stdin, -t or --tty allocates a TTY, and -it or -ti
+// does both. preceding are the non-flag arguments before arg; the short
+// forms are only taken right after "exec", so that `devgo exec grep -i x`
+// still passes -i to grep.
+func parseExecTerminalFlag(arg string, preceding []string) (interactive, tty, ok bool) {
+	if !strings.HasPrefix(arg, "--") && (len(preceding) != 1 || preceding[0] != "exec") {
+		return false, false, false
+	}
+	switch arg {
+	case "-i", "--interactive":
+		return true, false, true
+	case "-t", "--tty":
+		return false, true, true
+	case "-it", "-ti":
+		return true, true, true
+	}
+	return false, false, false
+}
+
+// execResizeClient is the subset of the Docker API used to keep an exec TTY
+// the size of the host terminal.
+type execResizeClient interface {
+	ContainerExecResize(ctx context.Context, execID string, options container.ResizeOptions) error
+}
+
+// resizeExecTTY sets the TTY of execID to the size getSize reports. An
+// unknown size leaves the TTY alone.
+func resizeExecTTY(ctx context.Context, cli execResizeClient, execID string, getSize func() (int, int, error)) error {
+	width, height, err := getSize()
+	if err != nil || width <= 0 || height <= 0 {
+		return nil
+	}
+	return cli.ContainerExecResize(ctx, execID, container.ResizeOptions{Height: uint(height), Width: uint(width)})
+}
+
+// initialResizeAttempts bounds the retries of the first resize, which fails
+// until the exec, started concurrently with the attach, is running.
+const initialResizeAttempts = 5
+
+// followTerminalSize keeps the TTY of execID the size of the terminal on fd
+// in the background until done is closed. See followExecSize.
+func followTerminalSize(ctx context.Context, cli execResizeClient, execID string, fd int, done <-chan struct{}) {
+	getSize := func() (int, int, error) { return term.GetSize(fd) }
+	go followExecSize(ctx, cli, execID, getSize, watchTerminalSize(fd, done), done)
+}
+
+// followExecSize resizes the TTY of execID once the exec runs, retrying
+// with a short backoff, and again on every value from resized, until done
+// is closed.
+func followExecSize(ctx context.Context, cli execResizeClient, execID string, getSize func() (int, int, error), resized <-chan struct{}, done <-chan struct{}) {
+	for attempt := 1; attempt <= initialResizeAttempts; attempt++ {
+		err := resizeExecTTY(ctx, cli, execID, getSize)
+		if err == nil {
+			break
+		}
+		debugf("Initial exec TTY resize failed (attempt %d): %v\n", attempt, err)
+		select {
+		case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+		case <-done:
+			return
+		}
+	}
+	for {
+		select {
+		case <-resized:
+			if err := resizeExecTTY(ctx, cli, execID, getSize); err != nil {
+				debugf("Failed to resize exec TTY: %v\n", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// execWithTerminal runs cmd in the container the way `docker exec -i/-t`
+// does. With opts.Interactive the host stdin, or opts.Stdin, is copied to
+// the command and closed on EOF; with opts.TTY the command gets a TTY the
+// size of the host terminal, and both together put the host terminal in raw
+// mode like `devgo shell`. token is the execTokenEnv marker set in execConfig.
+func execWithTerminal(ctx context.Context, cli DockerExecClient, containerID, token string, execConfig container.ExecOptions, opts execOptions) error {
+	stdinFd := int(os.Stdin.Fd())
+	stdoutFd := int(os.Stdout.Fd())
+	rawMode := opts.Interactive && opts.TTY
+	if rawMode && !term.IsTerminal(stdinFd) {
+		return fmt.Errorf("the input device is not a TTY; drop -t to pipe input to the command")
+	}
+
+	execConfig.Tty = opts.TTY
+	execConfig.AttachStdin = opts.Interactive
+	if opts.TTY {
+		if width, height, err := stdoutTerminalSize(); err == nil {
+			execConfig.ConsoleSize = &[2]uint{uint(height), uint(width)}
+		}
+	}
+	if rawMode {
+		execConfig.DetachKeys = resolveDetachKeys()
+	}
+
+	execCreateResp, err := cli.ContainerExecCreate(ctx, containerID, execConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create exec instance: %w", err)
+	}
+
+	if rawMode {
+		oldState, err := term.MakeRaw(stdinFd)
+		if err != nil {
+			return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+		}
+		defer func() {
+			if restoreErr := term.Restore(stdinFd, oldState); restoreErr != nil {
+				warnf("failed to restore terminal: %v", restoreErr)
+			}
+		}()
+	}
+
+	execAttachResp, err := cli.ContainerExecAttach(ctx, execCreateResp.ID, container.ExecAttachOptions{
+		Tty: opts.TTY,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach to exec instance: %w", err)
+	}
+	defer execAttachResp.Close()
+
+	// As in `devgo shell`, the exec starts after the attach and runs
+	// concurrently with the I/O below.
+	go func() {
+		if startErr := cli.ContainerExecStart(ctx, execCreateResp.ID, container.ExecStartOptions{Tty: opts.TTY}); startErr != nil {
+			debugf("ExecStart error: %v\n", startErr)
+		}
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	interrupted := forwardSignal(opts.Signals, done, func(sig os.Signal) {
+		stopExec(ctx, cli, containerID, token, sig, execAttachResp.Close)
+	})
+	killOnCancel(ctx, done, func() {
+		stopExec(ctx, cli, containerID, token, os.Kill, execAttachResp.Close)
+	})
+	if opts.TTY && term.IsTerminal(stdoutFd) {
+		followTerminalSize(ctx, cli, execCreateResp.ID, stdoutFd, done)
+	}
+	if opts.Interactive {
+		var stdin io.Reader = os.Stdin
+		if opts.Stdin != nil {
+			stdin = opts.Stdin
+		}
+		go func() {
+			_, _ = io.Copy(execAttachResp.Conn, stdin)
+			if closeErr := execAttachResp.CloseWrite(); closeErr != nil {
+				debugf("Failed to close exec stdin: %v\n", closeErr)
+			}
+		}()
+	}
+
+	if opts.TTY {
+		// A TTY merges stdout and stderr into one unframed stream.
+		_, err = io.Copy(opts.Stdout, execAttachResp.Reader)
+	} else {
+		err = copyExecOutput(opts, execAttachResp.Reader)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("exec stopped: %w", ctx.Err())
+	}
+	select {
+	case sig := <-interrupted:
+		return fmt.Errorf("exec interrupted by %s", sig)
+	default:
+	}
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to copy output: %w", err)
+	}
+	return execExitError(ctx, cli, execCreateResp.ID)
+}
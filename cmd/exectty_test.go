@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestParseExecTerminalFlag(t *testing.T) {
+	exec := []string{"exec"}
+	tests := []struct {
+		name            string
+		arg             string
+		preceding       []string
+		wantInteractive bool
+		wantTTY         bool
+		wantOK          bool
+	}{
+		{name: "-i after exec", arg: "-i", preceding: exec, wantInteractive: true, wantOK: true},
+		{name: "-t after exec", arg: "-t", preceding: exec, wantTTY: true, wantOK: true},
+		{name: "-it after exec", arg: "-it", preceding: exec, wantInteractive: true, wantTTY: true, wantOK: true},
+		{name: "-ti after exec", arg: "-ti", preceding: exec, wantInteractive: true, wantTTY: true, wantOK: true},
+		{name: "--tty anywhere", arg: "--tty", preceding: []string{"exec", "python"}, wantTTY: true, wantOK: true},
+		{name: "--interactive before exec", arg: "--interactive", wantInteractive: true, wantOK: true},
+		{name: "-i of the command", arg: "-i", preceding: []string{"exec", "grep"}},
+		{name: "-t of build", arg: "-t", preceding: []string{"build"}},
+		{name: "other flag", arg: "-e", preceding: exec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interactive, tty, ok := parseExecTerminalFlag(tt.arg, tt.preceding)
+			if interactive != tt.wantInteractive || tty != tt.wantTTY || ok != tt.wantOK {
+				t.Errorf("parseExecTerminalFlag(%q, %v) = %t, %t, %t, want %t, %t, %t", tt.arg, tt.preceding, interactive, tty, ok, tt.wantInteractive, tt.wantTTY, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseAllFlags_ExecTerminalFlags(t *testing.T) {
+	defer func() {
+		execInteractive, execTTY, buildTags = false, false, nil
+	}()
+
+	args, err := parseAllFlags([]string{"exec", "-it", "python3", "-i"})
+	if err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if !execInteractive || !execTTY {
+		t.Errorf("execInteractive = %t, execTTY = %t, want both set", execInteractive, execTTY)
+	}
+	if len(args) != 3 || args[1] != "python3" || args[2] != "-i" {
+		t.Errorf("args = %v, want [exec python3 -i]", args)
+	}
+
+	execTTY = false
+	if _, err := parseAllFlags([]string{"build", "-t", "app:1"}); err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if execTTY || len(buildTags) != 1 || buildTags[0] != "app:1" {
+		t.Errorf("execTTY = %t, buildTags = %v, want -t to stay --tag for build", execTTY, buildTags)
+	}
+}
+
+func TestExecWithTerminal_TTY(t *testing.T) {
+	mockClient := &mockExecClient{
+		execCreateResponse: container.ExecCreateResponse{ID: "exec1"},
+		execAttachResponse: createMockHijackedResponse(),
+	}
+	var stdout bytes.Buffer
+	opts := execOptions{Stdout: &stdout, Stderr: &bytes.Buffer{}, TTY: true}
+
+	err := execWithTerminal(context.Background(), mockClient, "abc123", "token", container.ExecOptions{Cmd: []string{"top"}}, opts)
+	if err != nil {
+		t.Fatalf("execWithTerminal() error = %v", err)
+	}
+	if !mockClient.lastExecConfig.Tty || mockClient.lastExecConfig.AttachStdin {
+		t.Errorf("exec config Tty = %t, AttachStdin = %t, want a TTY without stdin", mockClient.lastExecConfig.Tty, mockClient.lastExecConfig.AttachStdin)
+	}
+	if stdout.String() != "mock output" {
+		t.Errorf("stdout = %q, want the unframed TTY stream", stdout.String())
+	}
+}
+
+type fakeExecResizeClient struct {
+	mu       sync.Mutex
+	failures int
+	sizes    []container.ResizeOptions
+	resized  chan struct{}
+}
+
+func (f *fakeExecResizeClient) ContainerExecResize(ctx context.Context, execID string, options container.ResizeOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("exec is not running")
+	}
+	f.sizes = append(f.sizes, options)
+	f.resized <- struct{}{}
+	return nil
+}
+
+func TestFollowExecSize(t *testing.T) {
+	cli := &fakeExecResizeClient{failures: 2, resized: make(chan struct{}, 2)}
+	width, height := 120, 40
+	var sizeMu sync.Mutex
+	getSize := func() (int, int, error) {
+		sizeMu.Lock()
+		defer sizeMu.Unlock()
+		return width, height, nil
+	}
+	resized := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go followExecSize(context.Background(), cli, "exec1", getSize, resized, done)
+
+	waitResize := func() {
+		t.Helper()
+		select {
+		case <-cli.resized:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a resize")
+		}
+	}
+	waitResize()
+	sizeMu.Lock()
+	width, height = 200, 50
+	sizeMu.Unlock()
+	resized <- struct{}{}
+	waitResize()
+
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+	want := []container.ResizeOptions{{Height: 40, Width: 120}, {Height: 50, Width: 200}}
+	if len(cli.sizes) != 2 || cli.sizes[0] != want[0] || cli.sizes[1] != want[1] {
+		t.Errorf("resizes = %+v, want %+v after two failed initial attempts", cli.sizes, want)
+	}
+}
+
+func TestResizeExecTTY_UnknownSize(t *testing.T) {
+	cli := &fakeExecResizeClient{resized: make(chan struct{}, 1)}
+	noTerminal := func() (int, int, error) { return 0, 0, errors.New("not a terminal") }
+	if err := resizeExecTTY(context.Background(), cli, "exec1", noTerminal); err != nil || len(cli.sizes) != 0 {
+		t.Errorf("resizeExecTTY() = %v after %d resizes, want no resize", err, len(cli.sizes))
+	}
+}
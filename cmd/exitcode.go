@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ExitError reports that a command run in the container exited with a
+// non-zero Code. `devgo exec` returns it unwrapped so that devgo exits with
+// the same code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.Code)
+}
+
+// execInspectClient is the subset of the Docker API used to read the exit
+// code of a finished exec.
+type execInspectClient interface {
+	ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error)
+}
+
+// execExitError returns an *ExitError when the finished exec execID exited
+// non-zero, and nil when it succeeded.
+func execExitError(ctx context.Context, cli execInspectClient, execID string) error {
+	inspect, err := cli.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect exec instance: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return &ExitError{Code: inspect.ExitCode}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// portRelayScript connects its stdin and stdout to TCP port $2 of host $1,
+// seen from inside the container, with whichever of socat, bash or nc the
+// image has. Like socat, the bash relay gives the reply half a second once
+// the client is done before it stops.
+const portRelayScript = `h=$1 p=$2
+if command -v socat >/dev/null 2>&1; then exec socat - "TCP:$h:$p"; fi
+if command -v bash >/dev/null 2>&1; then exec bash -c 'exec 3<>"/dev/tcp/$0/$1" || exit 1; exec 4<&0; cat <&3 & r=$!; cat <&4 >&3 & w=$!; wait -n; kill -0 $r 2>/dev/null && sleep 0.5; kill $r $w 2>/dev/null; exit 0' "$h" "$p"; fi
+if command -v nc >/dev/null 2>&1; then exec nc "$h" "$p"; fi
+echo "socat, bash or nc is needed in the container to forward ports" >&2
+exit 127`
+
+// portProxy is a forwardPorts entry that `devgo forward-ports` serves on
+// the host by relaying each connection through an exec.
+type portProxy struct {
+	Target forwardPort
+	// HostPort is the port to listen on, 0 for any free one.
+	HostPort int
+	Label    string
+}
+
+// name returns how the proxy is shown: the port, or host:port for another
+// host, followed by the portsAttributes label.
+func (p portProxy) name() string {
+	name := strconv.Itoa(p.Target.Port)
+	if !p.Target.isLocal() {
+		name = fmt.Sprintf("%s:%d", p.Target.Host, p.Target.Port)
+	}
+	if p.Label != "" {
+		name = fmt.Sprintf("%s (%s)", name, p.Label)
+	}
+	return name
+}
+
+// relayHost returns the host the relay in the container connects to.
+func (p portProxy) relayHost() string {
+	if p.Target.isLocal() {
+		return "127.0.0.1"
+	}
+	return p.Target.Host
+}
+
+func runForwardPortsCommand(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("forward-ports takes no arguments, got %q", args)
+	}
+
+	devcontainerPath, err := findDevcontainerConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to find devcontainer config: %w", err)
+	}
+
+	workspaceDir := determineWorkspaceFolder(devcontainerPath)
+
+	resolved, err := resolveConfig(devcontainerPath, configOverrides)
+	if err != nil {
+		return err
+	}
+	containerName := determineContainerName(resolved.DevContainer, workspaceDir)
+
+	cli, err := newEngineClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	containerID, err := findRunningContainer(ctx, cli, containerName)
+	if err != nil {
+		return err
+	}
+	if containerID == "" {
+		return fmt.Errorf("container '%s' is not running; start it with 'devgo up'", containerName)
+	}
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	var bound nat.PortMap
+	if inspect.NetworkSettings != nil {
+		bound = inspect.NetworkSettings.Ports
+	}
+
+	proxies, published, err := planPortProxies(resolved.DevContainer, bound, checkPortFree, os.Geteuid() == 0)
+	if err != nil {
+		return err
+	}
+	for _, line := range describeForwardedPorts(published, bound) {
+		fmt.Println(line)
+	}
+	if len(proxies) == 0 {
+		debugln("Every forwarded port is already published")
+		return nil
+	}
+	return servePortProxies(ctx, proxies, os.Stdout, func(ctx context.Context, p portProxy, conn net.Conn) error {
+		return relayConnection(ctx, cli, containerID, p, conn)
+	})
+}
+
+// planPortProxies splits the forwardPorts entries of devContainer into the
+// ports Docker already publishes (per bound, from the container inspect)
+// and the proxies to serve for the rest: ports of another host, and ports
+// of a container created before they were added. Host ports are picked as
+// in decidePortPublish.
+func planPortProxies(devContainer *devcontainer.DevContainer, bound nat.PortMap, checkFree func(int) error, isRoot bool) ([]portProxy, []publishedPort, error) {
+	var proxies []portProxy
+	var published []publishedPort
+	seen := make(map[forwardPort]bool)
+	for _, entry := range devContainer.ForwardPorts {
+		p, err := parseForwardPort(entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p.isLocal() {
+			p.Host = ""
+		}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		attrs := portAttributesFor(devContainer, p.Port)
+		if p.isLocal() && len(bound[nat.Port(fmt.Sprintf("%d/tcp", p.Port))]) > 0 {
+			published = append(published, publishedPort{ContainerPort: p.Port, Label: attrs.Label})
+			continue
+		}
+		decision, err := decidePortPublish(p.Port, p.Port, attrs, checkFree, isRoot)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, warning := range decision.Warnings {
+			warnf("%s", warning)
+		}
+		proxies = append(proxies, portProxy{Target: p, HostPort: decision.HostPort, Label: attrs.Label})
+	}
+	return proxies, published, nil
+}
+
+// servePortProxies listens on 127.0.0.1 for every proxy, prints where each
+// one is forwarded to out, and hands each connection to relay until ctx
+// ends.
+func servePortProxies(ctx context.Context, proxies []portProxy, out io.Writer, relay func(ctx context.Context, p portProxy, conn net.Conn) error) error {
+	listeners := make([]net.Listener, 0, len(proxies))
+	closeAll := func() {
+		for _, l := range listeners {
+			_ = l.Close()
+		}
+	}
+	for _, p := range proxies {
+		l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", p.HostPort))
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("failed to forward port %s: %w", p.name(), err)
+		}
+		listeners = append(listeners, l)
+		fmt.Fprintf(out, "Forwarding port %s to %s\n", p.name(), l.Addr())
+	}
+
+	var wg sync.WaitGroup
+	for i, l := range listeners {
+		p := proxies[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					if !errors.Is(err, net.ErrClosed) {
+						warnf("stopped forwarding port %s: %v", p.name(), err)
+					}
+					return
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer conn.Close()
+					if err := relay(ctx, p, conn); err != nil {
+						debugf("Connection to port %s ended: %v\n", p.name(), err)
+					}
+				}()
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	closeAll()
+	wg.Wait()
+	return nil
+}
+
+// relayConnection copies conn to and from the proxy's target through
+// portRelayScript in an exec, which the exec machinery kills once ctx
+// ends.
+func relayConnection(ctx context.Context, cli DockerExecClient, containerID string, p portProxy, conn net.Conn) error {
+	var stderr bytes.Buffer
+	opts := execOptions{
+		Stdin:       conn,
+		Stdout:      conn,
+		Stderr:      &stderr,
+		WorkingDir:  "/",
+		Interactive: true,
+	}
+	args := []string{"/bin/sh", "-c", portRelayScript, "sh", p.relayHost(), strconv.Itoa(p.Target.Port)}
+	if err := execInContainer(ctx, cli, containerID, args, nil, opts); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestPlanPortProxies(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{
+		ForwardPorts:    []interface{}{float64(3000), "localhost:3000", float64(8080), "db:5432", "localhost:9000"},
+		PortsAttributes: map[string]devcontainer.PortAttributes{"5432": {Label: "Postgres"}},
+	}
+	bound := nat.PortMap{"3000/tcp": {{HostIP: "127.0.0.1", HostPort: "3000"}}}
+	taken := func(port int) error {
+		if port == 9000 {
+			return errors.New("in use")
+		}
+		return nil
+	}
+
+	proxies, published, err := planPortProxies(devContainer, bound, taken, false)
+	if err != nil {
+		t.Fatalf("planPortProxies() error = %v", err)
+	}
+	wantProxies := []portProxy{
+		{Target: forwardPort{Port: 8080}, HostPort: 8080},
+		{Target: forwardPort{Host: "db", Port: 5432}, HostPort: 5432, Label: "Postgres"},
+		{Target: forwardPort{Port: 9000}, HostPort: 0},
+	}
+	if !reflect.DeepEqual(proxies, wantProxies) {
+		t.Errorf("proxies = %+v, want %+v", proxies, wantProxies)
+	}
+	if len(published) != 1 || published[0].ContainerPort != 3000 {
+		t.Errorf("published = %+v, want port 3000 only", published)
+	}
+
+	if got := proxies[1].name(); got != "db:5432 (Postgres)" {
+		t.Errorf("name() = %q, want db:5432 (Postgres)", got)
+	}
+	if got := proxies[0].relayHost(); got != "127.0.0.1" {
+		t.Errorf("relayHost() = %q, want 127.0.0.1", got)
+	}
+}
+
+func TestServePortProxies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	proxies := []portProxy{{Target: forwardPort{Host: "db", Port: 5432}}}
+	var mu sync.Mutex
+	var out bytes.Buffer
+	writer := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.Write(p)
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- servePortProxies(ctx, proxies, writer, func(ctx context.Context, p portProxy, conn net.Conn) error {
+			line, err := io.ReadAll(io.LimitReader(conn, 5))
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(conn, "%s:%d got %s", p.Target.Host, p.Target.Port, line)
+			return err
+		})
+	}()
+
+	var address string
+	for deadline := time.Now().Add(time.Second); address == "" && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		mu.Lock()
+		if rest, ok := strings.CutPrefix(out.String(), "Forwarding port db:5432 to "); ok {
+			address = strings.TrimSpace(rest)
+		}
+		mu.Unlock()
+	}
+	if address == "" {
+		t.Fatalf("no forwarding line printed, got %q", out.String())
+	}
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", address, err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := io.ReadAll(conn)
+	conn.Close()
+	if err != nil || string(reply) != "db:5432 got hello" {
+		t.Errorf("reply = %q, %v, want the relay's answer", reply, err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("servePortProxies() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("servePortProxies() did not return after the context ended")
+	}
+}
+
+// writerFunc adapts a function to io.Writer.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestPortRelayScript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		_, _ = conn.Write(append([]byte("echo:"), buf...))
+	}()
+
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	cmd := exec.Command("sh", "-c", portRelayScript, "sh", "127.0.0.1", port)
+	cmd.Stdin = strings.NewReader("hello")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "is needed") {
+			t.Skip("no relay tool on this host")
+		}
+		t.Fatalf("relay failed: %v: %s", err, stderr.String())
+	}
+	if string(out) != "echo:hello" {
+		t.Errorf("relay output = %q, want echo:hello", out)
+	}
+}
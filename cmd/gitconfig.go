@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/garaemon/devgo/pkg/dotfiles"
+)
+
+// gitConfigMountPath is where the host ~/.gitconfig is bind-mounted; the
+// user's ~/.gitconfig becomes a link to it.
+const gitConfigMountPath = "/etc/devgo/gitconfig"
+
+// gitConfigPlan says how the host ~/.gitconfig reaches the container: as a
+// read-only bind mount (the default), or as a copy made after the container
+// starts (--copy-git-config), so edits inside the container stay there.
+type gitConfigPlan struct {
+	Binds    []string
+	CopyFrom string
+	Link     bool
+}
+
+// hostGitConfigPath returns ~/.gitconfig on the host, or "" when it does not
+// exist.
+func hostGitConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(home, ".gitconfig")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// planGitConfig decides between bind-mounting and copying hostPath. An
+// empty hostPath yields an empty plan.
+func planGitConfig(hostPath string, copyConfig bool) gitConfigPlan {
+	if hostPath == "" {
+		return gitConfigPlan{}
+	}
+	if copyConfig {
+		return gitConfigPlan{CopyFrom: hostPath}
+	}
+	return gitConfigPlan{
+		Binds: []string{fmt.Sprintf("%s:%s:ro", engineHostPath(hostPath, currentRuntime()), gitConfigMountPath)},
+		Link:  true,
+	}
+}
+
+// copyGitConfig carries out a gitConfigPlan once the container is running.
+// See installGitConfig.
+func copyGitConfig(ctx context.Context, plan gitConfigPlan, containerName, user string) error {
+	if plan.CopyFrom == "" && !plan.Link {
+		return nil
+	}
+
+	cli, err := newEngineClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+
+	containerID, err := findRunningContainer(ctx, cli, containerName)
+	if err != nil || containerID == "" {
+		return fmt.Errorf("failed to find running container to install ~/.gitconfig")
+	}
+	return installGitConfig(ctx, newDotfilesExecutor(cli, containerID), cli, containerID, user, plan)
+}
+
+// installGitConfig puts the plan's ~/.gitconfig in the $HOME of user, as the
+// container reports it: a copy handed to user so it can be edited in place,
+// or a link to the bind mount at gitConfigMountPath.
+func installGitConfig(ctx context.Context, exec dotfiles.Executor, cli containerCopyClient, containerID, user string, plan gitConfigPlan) error {
+	target, err := dotfiles.ResolveHome(ctx, exec, user, "~/.gitconfig")
+	if err != nil {
+		return fmt.Errorf("failed to resolve ~/.gitconfig in the container: %w", err)
+	}
+
+	if plan.Link {
+		if err := runDotfilesStep(ctx, exec, user, []string{"ln", "-sfn", gitConfigMountPath, target}); err != nil {
+			return fmt.Errorf("failed to link %s: %w", target, err)
+		}
+		return nil
+	}
+
+	data, err := os.ReadFile(plan.CopyFrom)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", plan.CopyFrom, err)
+	}
+	if err := copyFileToContainer(ctx, cli, containerID, target, data, 0644); err != nil {
+		return err
+	}
+	debugf("Copied %s to %s\n", plan.CopyFrom, target)
+
+	if user == "" || user == "root" {
+		return nil
+	}
+	if err := runDotfilesStep(ctx, exec, "root", []string{"chown", user, target}); err != nil {
+		return fmt.Errorf("failed to chown %s: %w", target, err)
+	}
+	return nil
+}
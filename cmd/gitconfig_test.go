@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanGitConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		hostPath    string
+		copy        bool
+		expectBinds []string
+		expectCopy  string
+		expectLink  bool
+	}{
+		{
+			name:        "bind mount by default",
+			hostPath:    "/home/me/.gitconfig",
+			expectBinds: []string{"/home/me/.gitconfig:/etc/devgo/gitconfig:ro"},
+			expectLink:  true,
+		},
+		{
+			name:       "copy with --copy-git-config",
+			hostPath:   "/home/me/.gitconfig",
+			copy:       true,
+			expectCopy: "/home/me/.gitconfig",
+		},
+		{
+			name: "no host gitconfig",
+			copy: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planGitConfig(tt.hostPath, tt.copy)
+			if !reflect.DeepEqual(plan.Binds, tt.expectBinds) {
+				t.Errorf("Binds = %v, want %v", plan.Binds, tt.expectBinds)
+			}
+			if plan.CopyFrom != tt.expectCopy {
+				t.Errorf("CopyFrom = %q, want %q", plan.CopyFrom, tt.expectCopy)
+			}
+			if plan.Link != tt.expectLink {
+				t.Errorf("Link = %t, want %t", plan.Link, tt.expectLink)
+			}
+		})
+	}
+}
+
+// homeExecutor reports home as $HOME and records every other command as
+// "user: command line".
+type homeExecutor struct {
+	home     string
+	commands []string
+}
+
+func (h *homeExecutor) Exec(ctx context.Context, user string, cmd []string) (string, string, int, error) {
+	if len(cmd) == 3 && cmd[2] == `printf %s "$HOME"` {
+		return h.home, "", 0, nil
+	}
+	h.commands = append(h.commands, user+": "+strings.Join(cmd, " "))
+	return "", "", 0, nil
+}
+
+func TestInstallGitConfig(t *testing.T) {
+	hostPath := filepath.Join(t.TempDir(), ".gitconfig")
+	if err := os.WriteFile(hostPath, []byte("[user]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		user         string
+		plan         gitConfigPlan
+		expectCopyTo []string
+		expectRun    []string
+	}{
+		{
+			// The home is whatever the container says, not /home/<user>.
+			name:         "copy into $HOME",
+			user:         "dev",
+			plan:         gitConfigPlan{CopyFrom: hostPath},
+			expectCopyTo: []string{"/workspaces/home"},
+			expectRun:    []string{"root: chown dev /workspaces/home/.gitconfig"},
+		},
+		{
+			name:         "copy for root",
+			user:         "root",
+			plan:         gitConfigPlan{CopyFrom: hostPath},
+			expectCopyTo: []string{"/workspaces/home"},
+		},
+		{
+			name:      "link the bind mount",
+			user:      "dev",
+			plan:      gitConfigPlan{Link: true},
+			expectRun: []string{"dev: ln -sfn /etc/devgo/gitconfig /workspaces/home/.gitconfig"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &homeExecutor{home: "/workspaces/home"}
+			cli := &mockDockerAPIClient{}
+			if err := installGitConfig(context.Background(), exec, cli, "abc", tt.user, tt.plan); err != nil {
+				t.Fatalf("installGitConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(cli.copiedTo, tt.expectCopyTo) {
+				t.Errorf("copied to %v, want %v", cli.copiedTo, tt.expectCopyTo)
+			}
+			if !reflect.DeepEqual(exec.commands, tt.expectRun) {
+				t.Errorf("ran %v, want %v", exec.commands, tt.expectRun)
+			}
+		})
+	}
+}
+
+func TestTarSingleFile(t *testing.T) {
+	archive, err := tarSingleFile(".gitconfig", []byte("[user]\n"), 0644)
+	if err != nil {
+		t.Fatalf("tarSingleFile() error: %v", err)
+	}
+
+	tr := tar.NewReader(archive)
+	header, err := tr.Next()
+	if err != nil {
+		t.Fatalf("failed to read tar header: %v", err)
+	}
+	if header.Name != ".gitconfig" || header.Mode != 0644 {
+		t.Errorf("header = %s %o, want .gitconfig 644", header.Name, header.Mode)
+	}
+	content, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatalf("failed to read tar content: %v", err)
+	}
+	if string(content) != "[user]\n" {
+		t.Errorf("content = %q", content)
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("expected a single entry, got err %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/system"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+// nvidiaRuntimeName is the runtime the NVIDIA Container Toolkit registers
+// with the Docker daemon (`nvidia-ctk runtime configure`).
+const nvidiaRuntimeName = "nvidia"
+
+// systemInfoClient is the subset of the Docker API used to inspect the
+// engine.
+type systemInfoClient interface {
+	Info(ctx context.Context) (system.Info, error)
+}
+
+// parseGPUs parses a --gpus value the way `docker run --gpus` does: "all",
+// a number of GPUs, count=N, or device=ID[,ID...] (optionally quoted).
+func parseGPUs(value string) (container.DeviceRequest, error) {
+	request := container.DeviceRequest{Capabilities: [][]string{{"gpu"}}}
+	spec := strings.Trim(value, `"`)
+	switch {
+	case spec == "all" || spec == "count=all":
+		request.Count = -1
+	case strings.HasPrefix(spec, "device="):
+		for _, id := range strings.Split(strings.TrimPrefix(spec, "device="), ",") {
+			if id == "" {
+				return container.DeviceRequest{}, fmt.Errorf("invalid --gpus %q: empty device ID", value)
+			}
+			request.DeviceIDs = append(request.DeviceIDs, id)
+		}
+	default:
+		count, err := strconv.Atoi(strings.TrimPrefix(spec, "count="))
+		if err != nil || count <= 0 {
+			return container.DeviceRequest{}, fmt.Errorf("invalid --gpus %q: want all, a number of GPUs or device=ID[,ID...]", value)
+		}
+		request.Count = count
+	}
+	return request, nil
+}
+
+// planGPUs picks the GPUs the container asks for: --gpus, then --gpus in
+// runArgs, then hostRequirements.gpu, which requests all GPUs. optional
+// reports that the container may start without them ("gpu": "optional").
+// It returns nil when no GPUs are requested.
+func planGPUs(devContainer *devcontainer.DevContainer, flagValue, runArgsValue string) (request *container.DeviceRequest, optional bool, err error) {
+	for _, value := range []string{flagValue, runArgsValue} {
+		if value == "" {
+			continue
+		}
+		parsed, err := parseGPUs(value)
+		if err != nil {
+			return nil, false, err
+		}
+		return &parsed, false, nil
+	}
+
+	requirement := devContainer.GPURequirement()
+	if requirement == devcontainer.GPUNone {
+		return nil, false, nil
+	}
+	all, _ := parseGPUs("all")
+	return &all, requirement == devcontainer.GPUOptional, nil
+}
+
+// gpuDeviceRequests returns the device requests for request after checking
+// that Docker has the NVIDIA runtime to satisfy them. Without it an optional
+// request is dropped with a warning, and a required one is kept with a
+// warning: CDI or another setup may still provide the GPUs, and otherwise
+// ContainerCreate reports the real error. Podman hands GPUs to containers
+// through CDI specs rather than a registered runtime, so it is not checked.
+func gpuDeviceRequests(ctx context.Context, cli systemInfoClient, request *container.DeviceRequest, optional bool, runtime devgoruntime.Runtime) []container.DeviceRequest {
+	if request == nil {
+		return nil
+	}
+	if runtime == devgoruntime.Docker {
+		info, err := cli.Info(ctx)
+		if err != nil {
+			debugf("Skipping NVIDIA runtime check: %v\n", err)
+		} else if _, ok := info.Runtimes[nvidiaRuntimeName]; !ok {
+			hint := "install the NVIDIA Container Toolkit and run 'sudo nvidia-ctk runtime configure --runtime=docker'"
+			if optional {
+				warnf("starting without GPUs: the Docker engine has no NVIDIA runtime; %s", hint)
+				return nil
+			}
+			warnf("GPUs were requested but the Docker engine has no NVIDIA runtime; if the container fails to start, %s", hint)
+		}
+	}
+	return []container.DeviceRequest{*request}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/system"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+type fakeSystemInfoClient struct {
+	info  system.Info
+	calls int
+}
+
+func (f *fakeSystemInfoClient) Info(ctx context.Context) (system.Info, error) {
+	f.calls++
+	return f.info, nil
+}
+
+func TestParseGPUs(t *testing.T) {
+	gpu := [][]string{{"gpu"}}
+	tests := []struct {
+		value   string
+		want    container.DeviceRequest
+		wantErr bool
+	}{
+		{value: "all", want: container.DeviceRequest{Count: -1, Capabilities: gpu}},
+		{value: "2", want: container.DeviceRequest{Count: 2, Capabilities: gpu}},
+		{value: "count=1", want: container.DeviceRequest{Count: 1, Capabilities: gpu}},
+		{value: "device=0", want: container.DeviceRequest{DeviceIDs: []string{"0"}, Capabilities: gpu}},
+		{value: `"device=0,GPU-3a23c669"`, want: container.DeviceRequest{DeviceIDs: []string{"0", "GPU-3a23c669"}, Capabilities: gpu}},
+		{value: "0", wantErr: true},
+		{value: "some", wantErr: true},
+		{value: "device=0,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseGPUs(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGPUs(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGPUs(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanGPUs(t *testing.T) {
+	required := &devcontainer.HostRequirements{GPU: true}
+	optional := &devcontainer.HostRequirements{GPU: "optional"}
+	tests := []struct {
+		name         string
+		requirements *devcontainer.HostRequirements
+		flagValue    string
+		runArgsValue string
+		wantCount    int
+		wantNil      bool
+		wantOptional bool
+	}{
+		{name: "nothing requested", wantNil: true},
+		{name: "gpu true", requirements: required, wantCount: -1},
+		{name: "gpu optional", requirements: optional, wantCount: -1, wantOptional: true},
+		{name: "runArgs over hostRequirements", requirements: optional, runArgsValue: "1", wantCount: 1},
+		{name: "flag over runArgs", requirements: optional, flagValue: "2", runArgsValue: "1", wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &devcontainer.DevContainer{HostRequirements: tt.requirements}
+			request, gotOptional, err := planGPUs(dc, tt.flagValue, tt.runArgsValue)
+			if err != nil {
+				t.Fatalf("planGPUs() error = %v", err)
+			}
+			if tt.wantNil {
+				if request != nil {
+					t.Errorf("planGPUs() = %+v, want no request", request)
+				}
+				return
+			}
+			if request == nil || request.Count != tt.wantCount || gotOptional != tt.wantOptional {
+				t.Errorf("planGPUs() = %+v, optional %t, want count %d, optional %t", request, gotOptional, tt.wantCount, tt.wantOptional)
+			}
+		})
+	}
+
+	if _, _, err := planGPUs(&devcontainer.DevContainer{}, "", "lots"); err == nil {
+		t.Error("planGPUs() with an invalid runArgs --gpus should fail")
+	}
+}
+
+func TestGPUDeviceRequests(t *testing.T) {
+	request := &container.DeviceRequest{Count: -1, Capabilities: [][]string{{"gpu"}}}
+	withNvidia := system.Info{Runtimes: map[string]system.RuntimeWithStatus{"runc": {}, "nvidia": {}}}
+	withoutNvidia := system.Info{Runtimes: map[string]system.RuntimeWithStatus{"runc": {}}}
+	tests := []struct {
+		name      string
+		info      system.Info
+		optional  bool
+		runtime   devgoruntime.Runtime
+		wantCount int
+	}{
+		{name: "nvidia runtime", info: withNvidia, runtime: devgoruntime.Docker, wantCount: 1},
+		{name: "required without nvidia runtime", info: withoutNvidia, runtime: devgoruntime.Docker, wantCount: 1},
+		{name: "optional without nvidia runtime", info: withoutNvidia, optional: true, runtime: devgoruntime.Docker, wantCount: 0},
+		{name: "podman is not checked", info: withoutNvidia, runtime: devgoruntime.Podman, wantCount: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &fakeSystemInfoClient{info: tt.info}
+			got := gpuDeviceRequests(context.Background(), cli, request, tt.optional, tt.runtime)
+			if len(got) != tt.wantCount {
+				t.Errorf("gpuDeviceRequests() = %+v, want %d requests", got, tt.wantCount)
+			}
+		})
+	}
+
+	cli := &fakeSystemInfoClient{}
+	if got := gpuDeviceRequests(context.Background(), cli, nil, false, devgoruntime.Docker); got != nil || cli.calls != 0 {
+		t.Errorf("gpuDeviceRequests(nil) = %v after %d Info calls, want nothing", got, cli.calls)
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// healthPollInterval is how often --wait-for-healthy-service inspects the
+// service container while its healthcheck is still starting.
+const healthPollInterval = time.Second
+
+// containerInspectClient is the subset of the Docker API used to read a
+// container's health.
+type containerInspectClient interface {
+	ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error)
+}
+
+// waitForHealthy polls containerID every interval until its healthcheck
+// reports healthy. A container without a healthcheck is treated as ready,
+// one that turns unhealthy or stops is an error, and the wait is otherwise
+// bounded only by ctx, so `devgo up --timeout` applies to it.
+func waitForHealthy(ctx context.Context, cli containerInspectClient, containerID string, interval time.Duration) error {
+	for {
+		inspect, err := cli.ContainerInspect(ctx, containerID)
+		if err != nil {
+			return fmt.Errorf("failed to inspect container: %w", err)
+		}
+		if inspect.ContainerJSONBase == nil || inspect.State == nil {
+			return fmt.Errorf("container %s has no state", containerID)
+		}
+
+		state := inspect.State
+		if !state.Running {
+			return fmt.Errorf("container %s is %s, not running", containerID, state.Status)
+		}
+		if state.Health == nil || state.Health.Status == container.NoHealthcheck {
+			debugf("Container %s has no healthcheck, not waiting\n", containerID)
+			return nil
+		}
+
+		switch state.Health.Status {
+		case container.Healthy:
+			debugf("Container %s is healthy\n", containerID)
+			return nil
+		case container.Unhealthy:
+			msg := ""
+			if n := len(state.Health.Log); n > 0 {
+				msg = fmt.Sprintf(": %s", state.Health.Log[n-1].Output)
+			}
+			return fmt.Errorf("container %s is unhealthy%s", containerID, msg)
+		}
+
+		debugf("Waiting for container %s to become healthy (status: %s)\n", containerID, state.Health.Status)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("container %s did not become healthy: %w", containerID, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// waitForHealthyService implements `devgo up --wait-for-healthy-service`:
+// it waits for the primary compose service container to be healthy so the
+// lifecycle commands do not run while the service is still starting.
+func waitForHealthyService(ctx context.Context, workspaceDir, service string) error {
+	cli, err := newEngineClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+
+	containerID, err := findComposeServiceContainer(ctx, cli, workspaceDir, service)
+	if err != nil {
+		return err
+	}
+	setPhase(ctx, "wait for healthy service")
+	return waitForHealthy(ctx, cli, containerID, healthPollInterval)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// fakeHealthInspectClient returns one state per ContainerInspect call,
+// repeating the last one once the sequence runs out.
+type fakeHealthInspectClient struct {
+	states []*container.State
+	calls  int
+}
+
+func (f *fakeHealthInspectClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	i := f.calls
+	if i >= len(f.states) {
+		i = len(f.states) - 1
+	}
+	f.calls++
+	return container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{State: f.states[i]}}, nil
+}
+
+func healthState(status container.HealthStatus) *container.State {
+	return &container.State{Running: true, Status: "running", Health: &container.Health{Status: status}}
+}
+
+func TestWaitForHealthy(t *testing.T) {
+	unhealthy := healthState(container.Unhealthy)
+	unhealthy.Health.Log = []*container.HealthcheckResult{{Output: "connection refused"}}
+
+	tests := []struct {
+		name      string
+		states    []*container.State
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "starting then healthy",
+			states:    []*container.State{healthState(container.Starting), healthState(container.Starting), healthState(container.Healthy)},
+			wantCalls: 3,
+		},
+		{
+			name:      "no healthcheck is ready",
+			states:    []*container.State{{Running: true, Status: "running"}},
+			wantCalls: 1,
+		},
+		{
+			name:      "unhealthy reports the last check",
+			states:    []*container.State{healthState(container.Starting), unhealthy},
+			wantCalls: 2,
+			wantErr:   "unhealthy: connection refused",
+		},
+		{
+			name:      "exited container",
+			states:    []*container.State{{Running: false, Status: "exited"}},
+			wantCalls: 1,
+			wantErr:   "is exited, not running",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &fakeHealthInspectClient{states: tt.states}
+			err := waitForHealthy(context.Background(), cli, "db", time.Millisecond)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("waitForHealthy() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("waitForHealthy() error = %v, want containing %q", err, tt.wantErr)
+			}
+			if cli.calls != tt.wantCalls {
+				t.Errorf("ContainerInspect calls = %d, want %d", cli.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWaitForHealthy_StopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	cli := &fakeHealthInspectClient{states: []*container.State{healthState(container.Starting)}}
+	err := waitForHealthy(ctx, cli, "db", time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("waitForHealthy() error = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+// windowsDrivePath matches an absolute Windows path such as C:\src\app.
+var windowsDrivePath = regexp.MustCompile(`^([A-Za-z]):[\\/]`)
+
+// engineHostPath rewrites a host path into the form the engine expects as a
+// bind mount source. Windows paths carry a drive letter whose colon would
+// split a "source:target" bind, so they become POSIX paths: /c/src/app for
+// Docker Desktop and /mnt/c/src/app inside a Podman machine. Other paths
+// are returned unchanged.
+func engineHostPath(path string, runtime devgoruntime.Runtime) string {
+	match := windowsDrivePath.FindStringSubmatch(path)
+	if match == nil {
+		return path
+	}
+	rest := strings.ReplaceAll(path[len(match[0]):], `\`, "/")
+	drive := "/" + strings.ToLower(match[1]) + "/"
+	if runtime == devgoruntime.Podman {
+		drive = "/mnt" + drive
+	}
+	return strings.TrimSuffix(drive+rest, "/")
+}
+
+// hostShellArgs adapts a command meant for the host to goos. String
+// commands are parsed as `/bin/sh -c CMD`, which Windows does not have, so
+// there they run through cmd.exe instead.
+func hostShellArgs(args []string, goos string) []string {
+	if goos != "windows" || len(args) != 3 || args[0] != "/bin/sh" || args[1] != "-c" {
+		return args
+	}
+	return []string{"cmd.exe", "/c", args[2]}
+}
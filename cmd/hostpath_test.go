@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+func TestEngineHostPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		runtime devgoruntime.Runtime
+		want    string
+	}{
+		{path: "/home/me/project", runtime: devgoruntime.Docker, want: "/home/me/project"},
+		{path: `C:\Users\me\project`, runtime: devgoruntime.Docker, want: "/c/Users/me/project"},
+		{path: "D:/src/app", runtime: devgoruntime.Docker, want: "/d/src/app"},
+		{path: `C:\`, runtime: devgoruntime.Docker, want: "/c"},
+		{path: `C:\Users\me\project`, runtime: devgoruntime.Podman, want: "/mnt/c/Users/me/project"},
+		{path: "relative/dir", runtime: devgoruntime.Docker, want: "relative/dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := engineHostPath(tt.path, tt.runtime); got != tt.want {
+				t.Errorf("engineHostPath(%q, %s) = %q, want %q", tt.path, tt.runtime, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostShellArgs(t *testing.T) {
+	shell := []string{"/bin/sh", "-c", "npm ci && npm run build"}
+	tests := []struct {
+		name string
+		args []string
+		goos string
+		want []string
+	}{
+		{name: "linux keeps sh", args: shell, goos: "linux", want: shell},
+		{name: "windows uses cmd.exe", args: shell, goos: "windows", want: []string{"cmd.exe", "/c", "npm ci && npm run build"}},
+		{name: "array command untouched", args: []string{"npm", "ci"}, goos: "windows", want: []string{"npm", "ci"}},
+		{name: "no command", goos: "windows", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostShellArgs(tt.args, tt.goos); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hostShellArgs(%q, %s) = %q, want %q", tt.args, tt.goos, got, tt.want)
+			}
+		})
+	}
+}
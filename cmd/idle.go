@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// idleTimer tracks the last input of an interactive session for
+// --idle-timeout. now is the clock, replaceable in tests.
+type idleTimer struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+func newIdleTimer(timeout time.Duration, now func() time.Time) *idleTimer {
+	return &idleTimer{timeout: timeout, now: now, last: now()}
+}
+
+// touch records input activity, restarting the idle period.
+func (t *idleTimer) touch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = t.now()
+}
+
+// expired reports whether no input arrived for the whole timeout.
+func (t *idleTimer) expired() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.now().Sub(t.last) >= t.timeout
+}
+
+// activityReader touches timer on every read that returns input.
+type activityReader struct {
+	r     io.Reader
+	timer *idleTimer
+}
+
+func (a *activityReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.timer.touch()
+	}
+	return n, err
+}
+
+// idleCheckInterval is how often an --idle-timeout session is checked: a
+// tenth of the timeout, and no more often than once a second.
+func idleCheckInterval(timeout time.Duration) time.Duration {
+	interval := timeout / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// watchIdle checks timer on every tick and calls expire once the session
+// has been idle for the timeout. It returns after expire, or when done is
+// closed.
+func watchIdle(timer *idleTimer, ticks <-chan time.Time, done <-chan struct{}, expire func()) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticks:
+			if timer.expired() {
+				expire()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock tests advance by hand.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestIdleTimer(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	timer := newIdleTimer(10*time.Minute, clock.now)
+
+	clock.advance(9 * time.Minute)
+	if timer.expired() {
+		t.Fatal("timer expired before the timeout")
+	}
+
+	// Input through the reader restarts the idle period.
+	reader := &activityReader{r: strings.NewReader("ls\n"), timer: timer}
+	buf := make([]byte, 16)
+	if _, err := reader.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(9 * time.Minute)
+	if timer.expired() {
+		t.Fatal("timer expired although input arrived within the timeout")
+	}
+
+	clock.advance(time.Minute)
+	if !timer.expired() {
+		t.Fatal("timer did not expire after the timeout without input")
+	}
+}
+
+func TestActivityReader_EmptyReadDoesNotReset(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	timer := newIdleTimer(time.Minute, clock.now)
+	clock.advance(time.Minute)
+
+	reader := &activityReader{r: strings.NewReader(""), timer: timer}
+	_, _ = reader.Read(make([]byte, 4))
+	if !timer.expired() {
+		t.Error("a read without input should not restart the idle period")
+	}
+}
+
+func TestWatchIdle(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	timer := newIdleTimer(time.Minute, clock.now)
+	ticks := make(chan time.Time)
+	expired := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		watchIdle(timer, ticks, make(chan struct{}), func() { close(expired) })
+		close(finished)
+	}()
+
+	clock.advance(30 * time.Second)
+	ticks <- clock.now()
+	select {
+	case <-expired:
+		t.Fatal("session closed before the timeout")
+	default:
+	}
+
+	clock.advance(30 * time.Second)
+	ticks <- clock.now()
+	<-finished
+	select {
+	case <-expired:
+	default:
+		t.Fatal("session not closed after the timeout")
+	}
+}
+
+func TestIdleCheckInterval(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{timeout: 30 * time.Minute, want: 3 * time.Minute},
+		{timeout: 5 * time.Second, want: time.Second},
+	}
+	for _, tt := range tests {
+		if got := idleCheckInterval(tt.timeout); got != tt.want {
+			t.Errorf("idleCheckInterval(%s) = %s, want %s", tt.timeout, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/garaemon/devgo/pkg/constants"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+// outsideContextDockerfile is the name a Dockerfile that lives outside the
+// build context gets inside the uploaded context, as `docker build` does.
+const outsideContextDockerfile = ".devgo.Dockerfile"
+
+// imageBuildClient is the subset of the Docker API used to build images.
+type imageBuildClient interface {
+	ImageBuild(ctx context.Context, buildContext io.Reader, options build.ImageBuildOptions) (build.ImageBuildResponse, error)
+	Close() error
+}
+
+// newImageBuildClient connects to the API builds go through. Tests replace
+// it.
+var newImageBuildClient = func() (imageBuildClient, error) {
+	cli, err := newEngineClient()
+	if err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
+
+// useBuildCLI decides whether the build runs through the engine CLI instead
+// of the API. The API has no session to forward the SSH agent over and
+// "options" are CLI flags, so those builds need the CLI; everything else is
+// built through the API, which also works when only the socket is
+// reachable. Forwarding the agent by default only applies when the CLI is
+// installed, while an explicit --ssh or build options without it are an
+// error. needsCredentials marks a Docker build whose base images need
+// registry credentials: BuildKit only takes those through a session too, so
+// such builds prefer the CLI when it is installed.
+func useBuildCLI(engine string, options []string, sshFlag *bool, agentAvailable, cliAvailable, needsCredentials bool) (bool, error) {
+	if len(options) > 0 {
+		if !cliAvailable {
+			return false, fmt.Errorf("build options %q need the %s CLI, which is not installed", options, engine)
+		}
+		return true, nil
+	}
+	if sshFlag != nil {
+		if *sshFlag && !cliAvailable {
+			return false, fmt.Errorf("--ssh needs the %s CLI, which is not installed", engine)
+		}
+		return *sshFlag || needsCredentials && cliAvailable, nil
+	}
+	return (agentAvailable || needsCredentials) && cliAvailable, nil
+}
+
+// engineCLIAvailable reports whether the CLI of the current runtime is on
+// PATH.
+func engineCLIAvailable() bool {
+	_, err := exec.LookPath(containerEngine())
+	return err == nil
+}
+
+// imageBuildOptions translates the build section of devContainer into API
+// build options. hash, when set, becomes the build hash label. dockerfile
+// is the Dockerfile path inside the uploaded context. credentials are the
+// registry credentials the base images need (see baseImageCredentials).
+func imageBuildOptions(devContainer *devcontainer.DevContainer, imageTags []string, dockerfile, hash string, runtime devgoruntime.Runtime, credentials map[string]registry.AuthConfig) build.ImageBuildOptions {
+	opts := build.ImageBuildOptions{
+		Tags:        imageTags,
+		Dockerfile:  dockerfile,
+		Target:      devContainer.GetBuildTarget(),
+		CacheFrom:   devContainer.GetBuildCacheFrom(),
+		NoCache:     noCache,
+		Remove:      true,
+		AuthConfigs: credentials,
+	}
+	// Podman's compatible API always builds with Buildah and does not
+	// know the builder version. Docker's BuildKit ignores AuthConfigs, so
+	// builds that need credentials go to the classic builder, which reads
+	// them.
+	if runtime == devgoruntime.Docker {
+		opts.Version = build.BuilderBuildKit
+		if len(credentials) > 0 {
+			opts.Version = build.BuilderV1
+		}
+	}
+
+	if args := devContainer.GetBuildArgs(); len(args) > 0 {
+		opts.BuildArgs = make(map[string]*string, len(args))
+		for key, value := range args {
+			s := fmt.Sprintf("%v", value)
+			opts.BuildArgs[key] = &s
+		}
+	}
+	if hash != "" {
+		opts.Labels = map[string]string{devgoLabel(constants.DevgoBuildHashLabel): hash}
+	}
+	return opts
+}
+
+// buildImageWithAPI builds the image through the engine API, uploading
+// contextDir as a tar stream and printing the build progress to out.
+func buildImageWithAPI(ctx context.Context, cli imageBuildClient, contextDir, dockerfilePath string, newOptions func(dockerfile string) build.ImageBuildOptions, out io.Writer) error {
+	buildContext, dockerfile, err := archiveBuildContext(contextDir, dockerfilePath)
+	if err != nil {
+		return err
+	}
+	defer buildContext.Close()
+
+	resp, err := cli.ImageBuild(ctx, buildContext, newOptions(dockerfile))
+	if err != nil {
+		return fmt.Errorf("failed to start image build: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			warnf("failed to close build output: %v", closeErr)
+		}
+	}()
+
+	progress := newBuildProgressPrinter(out)
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, out, 0, false, progress.handleAux); err != nil {
+		return fmt.Errorf("image build failed: %w", err)
+	}
+	return progress.err
+}
+
+// archiveBuildContext streams contextDir as a tar archive, leaving out what
+// .dockerignore excludes. The Dockerfile and .dockerignore are always sent,
+// as docker does; a Dockerfile outside the context is added as
+// outsideContextDockerfile. It returns the Dockerfile path to build with,
+// relative to the context.
+func archiveBuildContext(contextDir, dockerfilePath string) (io.ReadCloser, string, error) {
+	rules, err := loadDockerignore(contextDir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dockerfile, err := filepath.Rel(contextDir, dockerfilePath)
+	outside := err != nil || dockerfile == ".." || strings.HasPrefix(dockerfile, ".."+string(filepath.Separator))
+	if outside {
+		dockerfile = outsideContextDockerfile
+	}
+	dockerfile = filepath.ToSlash(dockerfile)
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := filepath.WalkDir(contextDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(contextDir, p)
+			if err != nil || rel == "." {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			// Exceptions ("!pattern") may re-include files below an ignored
+			// directory, so directories are always walked.
+			if rel != dockerfile && rel != ".dockerignore" && dockerignored(rules, rel) {
+				return nil
+			}
+			return writeTarEntry(tw, p, rel)
+		})
+		if err == nil && outside {
+			err = writeTarEntry(tw, dockerfilePath, outsideContextDockerfile)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to archive build context %s: %w", contextDir, err)
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, dockerfile, nil
+}
@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/garaemon/devgo/pkg/constants"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+// fakeImageBuildClient records API builds and the files of their contexts,
+// and answers with output as the build's JSON message stream.
+type fakeImageBuildClient struct {
+	builds   []build.ImageBuildOptions
+	contexts [][]string
+	output   string
+}
+
+func (f *fakeImageBuildClient) ImageBuild(ctx context.Context, buildContext io.Reader, options build.ImageBuildOptions) (build.ImageBuildResponse, error) {
+	var names []string
+	tr := tar.NewReader(buildContext)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return build.ImageBuildResponse{}, err
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	f.builds = append(f.builds, options)
+	f.contexts = append(f.contexts, names)
+	return build.ImageBuildResponse{Body: io.NopCloser(strings.NewReader(f.output))}, nil
+}
+
+func (f *fakeImageBuildClient) Close() error {
+	return nil
+}
+
+// installFakeImageBuild makes API builds go to the returned fake. SSH
+// agent forwarding is turned off so builds do not fall back to the CLI.
+func installFakeImageBuild(t *testing.T) *fakeImageBuildClient {
+	t.Helper()
+	fake := &fakeImageBuildClient{}
+	original := newImageBuildClient
+	t.Cleanup(func() { newImageBuildClient = original })
+	newImageBuildClient = func() (imageBuildClient, error) { return fake, nil }
+	t.Setenv("SSH_AUTH_SOCK", "")
+	return fake
+}
+
+func TestUseBuildCLI(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name           string
+		options        []string
+		flag           *bool
+		agentAvailable bool
+		cliAvailable   bool
+		// needsCredentials marks a Docker build with private base images.
+		needsCredentials bool
+		want             bool
+		wantErr          bool
+	}{
+		{name: "API by default", cliAvailable: true, want: false},
+		{name: "agent forwarding uses the CLI", agentAvailable: true, cliAvailable: true, want: true},
+		{name: "agent without CLI uses the API", agentAvailable: true, want: false},
+		{name: "--ssh=false uses the API", flag: &disabled, agentAvailable: true, cliAvailable: true, want: false},
+		{name: "--ssh uses the CLI", flag: &enabled, cliAvailable: true, want: true},
+		{name: "--ssh without CLI", flag: &enabled, wantErr: true},
+		{name: "build options use the CLI", options: []string{"--network=host"}, cliAvailable: true, want: true},
+		{name: "build options without CLI", options: []string{"--network=host"}, wantErr: true},
+		{name: "credentials use the CLI", needsCredentials: true, cliAvailable: true, want: true},
+		{name: "credentials with --ssh=false use the CLI", flag: &disabled, needsCredentials: true, cliAvailable: true, want: true},
+		{name: "credentials without CLI use the API", needsCredentials: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := useBuildCLI("docker", tt.options, tt.flag, tt.agentAvailable, tt.cliAvailable, tt.needsCredentials)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "docker CLI") {
+					t.Fatalf("useBuildCLI() error = %v, want missing CLI error", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("useBuildCLI() = %t, %v, want %t", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageBuildOptions(t *testing.T) {
+	originalNoCache := noCache
+	defer func() { noCache = originalNoCache }()
+	noCache = true
+
+	devContainer := &devcontainer.DevContainer{
+		Build: &devcontainer.BuildConfig{
+			Dockerfile: "Dockerfile",
+			Args:       map[string]interface{}{"VERSION": "1.2", "DEBUG": true},
+			Target:     "dev",
+			CacheFrom:  "myorg/app:cache",
+		},
+	}
+
+	opts := imageBuildOptions(devContainer, []string{"app:1", "app:latest"}, "Dockerfile", "abc", devgoruntime.Docker, nil)
+	if !reflect.DeepEqual(opts.Tags, []string{"app:1", "app:latest"}) || opts.Dockerfile != "Dockerfile" {
+		t.Errorf("Tags = %v, Dockerfile = %q", opts.Tags, opts.Dockerfile)
+	}
+	if opts.Target != "dev" || !reflect.DeepEqual(opts.CacheFrom, []string{"myorg/app:cache"}) || !opts.NoCache {
+		t.Errorf("Target = %q, CacheFrom = %v, NoCache = %t", opts.Target, opts.CacheFrom, opts.NoCache)
+	}
+	if len(opts.BuildArgs) != 2 || *opts.BuildArgs["VERSION"] != "1.2" || *opts.BuildArgs["DEBUG"] != "true" {
+		t.Errorf("BuildArgs = %v", opts.BuildArgs)
+	}
+	if opts.Labels[devgoLabel(constants.DevgoBuildHashLabel)] != "abc" {
+		t.Errorf("Labels = %v, want the build hash", opts.Labels)
+	}
+	if opts.Version != build.BuilderBuildKit {
+		t.Errorf("Version = %q, want BuildKit", opts.Version)
+	}
+
+	if opts := imageBuildOptions(devContainer, []string{"app"}, "Dockerfile", "", devgoruntime.Podman, nil); opts.Version != "" || opts.Labels != nil {
+		t.Errorf("podman options: Version = %q, Labels = %v, want neither", opts.Version, opts.Labels)
+	}
+
+	credentials := map[string]registry.AuthConfig{"ghcr.io": {Username: "me", Password: "secret"}}
+	if opts := imageBuildOptions(devContainer, []string{"app"}, "Dockerfile", "", devgoruntime.Docker, credentials); opts.Version != build.BuilderV1 || opts.AuthConfigs["ghcr.io"].Password != "secret" {
+		t.Errorf("options with credentials: Version = %q, AuthConfigs = %v, want the classic builder with them", opts.Version, opts.AuthConfigs)
+	}
+}
+
+func TestBuildDevContainer_PrivateBaseImage(t *testing.T) {
+	dir := t.TempDir()
+	writeContextFiles(t, dir, map[string]string{"Dockerfile": "FROM ghcr.io/me/private:1 AS base\nFROM base\n"})
+	devcontainerPath := filepath.Join(dir, "devcontainer.json")
+	devContainer := &devcontainer.DevContainer{Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"}}
+
+	configDir := t.TempDir()
+	config := `{"auths": {"ghcr.io": {"auth": "bWU6c2VjcmV0"}, "https://index.docker.io/v1/": {"auth": "aHViOnB3"}}}`
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", configDir)
+	t.Setenv(devgoruntime.EnvVar, "docker")
+	// Without the docker CLI the build has to go through the API.
+	t.Setenv("PATH", t.TempDir())
+
+	originalLookup := imageBuildHash
+	defer func() { imageBuildHash = originalLookup }()
+	imageBuildHash = func(ctx context.Context, imageName string) string { return "" }
+
+	fakeBuild := installFakeImageBuild(t)
+	if err := buildDevContainer(context.Background(), devContainer, dir, devcontainerPath); err != nil {
+		t.Fatalf("buildDevContainer() error = %v", err)
+	}
+	if len(fakeBuild.builds) != 1 {
+		t.Fatalf("builds = %d, want 1", len(fakeBuild.builds))
+	}
+	opts := fakeBuild.builds[0]
+	if opts.Version != build.BuilderV1 {
+		t.Errorf("Version = %q, want the classic builder, which reads AuthConfigs", opts.Version)
+	}
+	if len(opts.AuthConfigs) != 1 || opts.AuthConfigs["ghcr.io"].Username != "me" || opts.AuthConfigs["ghcr.io"].Password != "secret" {
+		t.Errorf("AuthConfigs = %v, want only the ghcr.io credentials", opts.AuthConfigs)
+	}
+}
+
+func TestDockerfileRegistries(t *testing.T) {
+	tests := []struct {
+		name           string
+		dockerfile     string
+		wantRegistries []string
+		wantUnresolved bool
+	}{
+		{name: "Docker Hub", dockerfile: "FROM ubuntu:22.04\n", wantRegistries: []string{"docker.io"}},
+		{name: "private registry", dockerfile: "from --platform=linux/amd64 ghcr.io/me/private:1 as build\nFROM build\n", wantRegistries: []string{"ghcr.io"}},
+		{name: "several registries", dockerfile: "FROM localhost:5000/a AS a\nFROM myorg/b\nFROM scratch\nCOPY --from=a / /\n", wantRegistries: []string{"localhost:5000", "docker.io"}},
+		{name: "build argument", dockerfile: "ARG BASE=ubuntu\nFROM ${BASE}\n", wantUnresolved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registries, unresolved := dockerfileRegistries([]byte(tt.dockerfile))
+			if !reflect.DeepEqual(registries, tt.wantRegistries) || unresolved != tt.wantUnresolved {
+				t.Errorf("dockerfileRegistries() = %v, %t, want %v, %t", registries, unresolved, tt.wantRegistries, tt.wantUnresolved)
+			}
+		})
+	}
+}
+
+func TestCredentialsFor(t *testing.T) {
+	configs := map[string]registry.AuthConfig{
+		"https://index.docker.io/v1/": {Username: "hub"},
+		"ghcr.io":                     {Username: "gh"},
+	}
+	if got := credentialsFor(configs, []string{"docker.io"}, false); len(got) != 1 || got["https://index.docker.io/v1/"].Username != "hub" {
+		t.Errorf("credentialsFor(docker.io) = %v, want the Docker Hub entry", got)
+	}
+	if got := credentialsFor(configs, []string{"quay.io"}, false); len(got) != 0 {
+		t.Errorf("credentialsFor(quay.io) = %v, want none", got)
+	}
+	if got := credentialsFor(configs, nil, true); len(got) != 2 {
+		t.Errorf("credentialsFor(unresolved) = %v, want every entry", got)
+	}
+}
+
+func TestArchiveBuildContext(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".devcontainer/Dockerfile": "FROM alpine",
+		".dockerignore":            "node_modules\n*.log\n.devcontainer\n",
+		"main.go":                  "package main",
+		"debug.log":                "noise",
+		"node_modules/a/index.js":  "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "Dockerfile")
+	if err := os.WriteFile(outside, []byte("FROM alpine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		dockerfile     string
+		wantDockerfile string
+		wantFiles      []string
+	}{
+		{
+			name:           "Dockerfile kept despite .dockerignore",
+			dockerfile:     filepath.Join(dir, ".devcontainer", "Dockerfile"),
+			wantDockerfile: ".devcontainer/Dockerfile",
+			wantFiles:      []string{".devcontainer/Dockerfile", ".dockerignore", "main.go"},
+		},
+		{
+			name:           "Dockerfile outside the context",
+			dockerfile:     outside,
+			wantDockerfile: outsideContextDockerfile,
+			wantFiles:      []string{".devgo.Dockerfile", ".dockerignore", "main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeImageBuildClient{}
+			newOptions := func(dockerfile string) build.ImageBuildOptions {
+				if dockerfile != tt.wantDockerfile {
+					t.Errorf("dockerfile = %q, want %q", dockerfile, tt.wantDockerfile)
+				}
+				return build.ImageBuildOptions{Dockerfile: dockerfile}
+			}
+			if err := buildImageWithAPI(context.Background(), fake, dir, tt.dockerfile, newOptions, io.Discard); err != nil {
+				t.Fatalf("buildImageWithAPI() error = %v", err)
+			}
+
+			var got []string
+			for _, name := range fake.contexts[0] {
+				if !strings.HasSuffix(name, "/") {
+					got = append(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantFiles) {
+				t.Errorf("context files = %v, want %v", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestBuildImageWithAPI_Output(t *testing.T) {
+	dir := t.TempDir()
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte("FROM alpine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	newOptions := func(dockerfile string) build.ImageBuildOptions { return build.ImageBuildOptions{} }
+
+	tests := []struct {
+		name       string
+		output     string
+		wantOutput string
+		wantErr    string
+	}{
+		{
+			name:       "classic builder stream",
+			output:     `{"stream":"Step 1/1 : FROM alpine\n"}` + "\n" + `{"aux":{"ID":"sha256:abc"}}` + "\n",
+			wantOutput: "Step 1/1 : FROM alpine\n",
+		},
+		{
+			name:    "build error",
+			output:  `{"errorDetail":{"message":"dockerfile parse error"},"error":"dockerfile parse error"}` + "\n",
+			wantErr: "dockerfile parse error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeImageBuildClient{output: tt.output}
+			var out strings.Builder
+			err := buildImageWithAPI(context.Background(), fake, dir, dockerfile, newOptions, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildImageWithAPI() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildImageWithAPI() error = %v", err)
+			}
+			if out.String() != tt.wantOutput {
+				t.Errorf("output = %q, want %q", out.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestBuildImageWithAPI_MissingContext(t *testing.T) {
+	fake := &fakeImageBuildClient{}
+	newOptions := func(dockerfile string) build.ImageBuildOptions { return build.ImageBuildOptions{} }
+	missing := filepath.Join(t.TempDir(), "missing")
+	err := buildImageWithAPI(context.Background(), fake, missing, filepath.Join(missing, "Dockerfile"), newOptions, io.Discard)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("buildImageWithAPI() error = %v, want a missing context error", err)
+	}
+}
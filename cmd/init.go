@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -17,16 +18,33 @@ func runInitCommand(args []string) error {
 
 	debugf("Target directory: %s\n", targetDir)
 
+	if initTemplate != "" {
+		return applyInitTemplate(context.Background(), targetDir)
+	}
+
+	devcontainerPath, err := writeDefaultTemplate(targetDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created devcontainer.json at %s\n", devcontainerPath)
+	return nil
+}
+
+// writeDefaultTemplate creates .devcontainer/devcontainer.json under
+// targetDir from the default template and returns its path. An existing
+// devcontainer.json is never overwritten.
+func writeDefaultTemplate(targetDir string) (string, error) {
 	// Create .devcontainer directory
 	devcontainerDir := filepath.Join(targetDir, ".devcontainer")
 	if err := os.MkdirAll(devcontainerDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .devcontainer directory: %w", err)
+		return "", fmt.Errorf("failed to create .devcontainer directory: %w", err)
 	}
 
 	// Check if devcontainer.json already exists
 	devcontainerPath := filepath.Join(devcontainerDir, "devcontainer.json")
 	if _, err := os.Stat(devcontainerPath); err == nil {
-		return fmt.Errorf("devcontainer.json already exists at %s", devcontainerPath)
+		return "", fmt.Errorf("devcontainer.json already exists at %s", devcontainerPath)
 	}
 
 	// Create default devcontainer.json template
@@ -34,11 +52,9 @@ func runInitCommand(args []string) error {
 
 	// Write to file
 	if err := os.WriteFile(devcontainerPath, []byte(template), 0644); err != nil {
-		return fmt.Errorf("failed to write devcontainer.json: %w", err)
+		return "", fmt.Errorf("failed to write devcontainer.json: %w", err)
 	}
-
-	fmt.Printf("Created devcontainer.json at %s\n", devcontainerPath)
-	return nil
+	return devcontainerPath, nil
 }
 
 func determineInitDirectory(args []string) (string, error) {
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/garaemon/devgo/pkg/templates"
+	"golang.org/x/term"
+)
+
+// applyInitTemplate downloads the Template named by --template and expands
+// it into targetDir. Options come from --template-option; in a terminal the
+// others are asked for, elsewhere they keep their defaults.
+func applyInitTemplate(ctx context.Context, targetDir string) error {
+	ref, err := templates.ParseRef(initTemplate)
+	if err != nil {
+		return err
+	}
+	values, err := parseTemplateOptions(templateOptions)
+	if err != nil {
+		return err
+	}
+
+	debugf("Fetching template %s\n", ref)
+	client := &templates.Client{Credentials: templateCredentials}
+	archive, err := client.Fetch(ctx, ref)
+	if err != nil {
+		return err
+	}
+	tpl, err := templates.Load(archive)
+	if err != nil {
+		return fmt.Errorf("invalid template %s: %w", ref, err)
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		if values, err = promptTemplateOptions(os.Stdin, os.Stderr, tpl, values); err != nil {
+			return err
+		}
+	}
+	options, err := tpl.ResolveOptions(values)
+	if err != nil {
+		return err
+	}
+	written, err := tpl.Apply(targetDir, options)
+	for _, path := range written {
+		fmt.Printf("Created %s\n", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply template %s: %w", ref, err)
+	}
+	return nil
+}
+
+// parseTemplateOptions parses --template-option ID=VALUE arguments.
+func parseTemplateOptions(args []string) (map[string]string, error) {
+	values := make(map[string]string, len(args))
+	for _, arg := range args {
+		id, value, ok := strings.Cut(arg, "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid --template-option %q: want ID=VALUE", arg)
+		}
+		values[id] = value
+	}
+	return values, nil
+}
+
+// templateCredentials returns the credentials the container engine CLI
+// keeps for registry, so Templates can come from private registries.
+func templateCredentials(registry string) (string, string) {
+	config, ok := registryAuthConfigs(currentRuntime())[registry]
+	if !ok {
+		return "", ""
+	}
+	return config.Username, config.Password
+}
+
+// promptTemplateOptions asks on out for every option of tpl that values does
+// not set and reads the answers from in. An empty answer, or the end of in,
+// keeps the default; an invalid answer is asked again.
+func promptTemplateOptions(in io.Reader, out io.Writer, tpl *templates.Template, values map[string]string) (map[string]string, error) {
+	answers := make(map[string]string, len(tpl.Options))
+	for id, value := range values {
+		answers[id] = value
+	}
+
+	reader := bufio.NewReader(in)
+	for _, id := range tpl.OptionIDs() {
+		if _, ok := answers[id]; ok {
+			continue
+		}
+		option := tpl.Options[id]
+		if option.Description != "" {
+			fmt.Fprintf(out, "%s: %s\n", id, option.Description)
+		}
+		choices := option.Enum
+		if len(choices) == 0 {
+			choices = option.Proposals
+		}
+		if option.Type == "boolean" {
+			choices = []string{"true", "false"}
+		}
+		for {
+			fmt.Fprintf(out, "%s", id)
+			if len(choices) > 0 {
+				fmt.Fprintf(out, " (%s)", strings.Join(choices, ", "))
+			}
+			fmt.Fprintf(out, " [%s]: ", option.DefaultValue())
+
+			line, err := reader.ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("failed to read option %s: %w", id, err)
+			}
+			answer := strings.TrimSpace(line)
+			if answer == "" {
+				if errors.Is(err, io.EOF) {
+					fmt.Fprintln(out)
+				}
+				break
+			}
+			if validateErr := option.Validate(answer); validateErr != nil {
+				fmt.Fprintf(out, "Invalid value: %v\n", validateErr)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				continue
+			}
+			answers[id] = answer
+			break
+		}
+	}
+	return answers, nil
+}
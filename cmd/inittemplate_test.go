@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/templates"
+)
+
+func TestParseTemplateOptions(t *testing.T) {
+	got, err := parseTemplateOptions([]string{"imageVariant=1.23", "args=a=b", "empty="})
+	if err != nil {
+		t.Fatalf("parseTemplateOptions() error = %v", err)
+	}
+	want := map[string]string{"imageVariant": "1.23", "args": "a=b", "empty": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTemplateOptions() = %v, want %v", got, want)
+	}
+	for _, arg := range []string{"imageVariant", "=1.23"} {
+		if _, err := parseTemplateOptions([]string{arg}); err == nil {
+			t.Errorf("parseTemplateOptions(%q) should fail", arg)
+		}
+	}
+}
+
+func TestPromptTemplateOptions(t *testing.T) {
+	tpl := &templates.Template{
+		ID: "go",
+		Options: map[string]templates.Option{
+			"distro":       {Type: "string", Enum: []string{"bookworm", "bullseye"}, Default: "bookworm"},
+			"imageVariant": {Type: "string", Description: "Go version", Proposals: []string{"1.22", "1.23"}, Default: "1.23"},
+			"installTools": {Type: "boolean", Default: true},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		given  map[string]string
+		want   map[string]string
+		prompt string
+	}{
+		{
+			name:   "answers and defaults",
+			input:  "bullseye\n\nfalse\n",
+			want:   map[string]string{"distro": "bullseye", "installTools": "false"},
+			prompt: "imageVariant: Go version\nimageVariant (1.22, 1.23) [1.23]: ",
+		},
+		{
+			name:  "given options are not asked",
+			input: "\n\n",
+			given: map[string]string{"imageVariant": "1.21"},
+			want:  map[string]string{"imageVariant": "1.21"},
+		},
+		{
+			name:   "invalid answer is asked again",
+			input:  "alpine\nbullseye\n\n\n",
+			want:   map[string]string{"distro": "bullseye"},
+			prompt: "Invalid value:",
+		},
+		{
+			name:  "end of input keeps defaults",
+			input: "",
+			want:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := promptTemplateOptions(strings.NewReader(tt.input), &out, tpl, tt.given)
+			if err != nil {
+				t.Fatalf("promptTemplateOptions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("promptTemplateOptions() = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(out.String(), tt.prompt) {
+				t.Errorf("prompt %q does not contain %q", out.String(), tt.prompt)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func runInspectCommand(args []string) error {
+	if !resolveBuild {
+		return fmt.Errorf("inspect requires --resolve-build")
+	}
+
+	workspaceDir, err := getWorkspaceDirectory()
+	if err != nil {
+		return fmt.Errorf("failed to get workspace directory: %w", err)
+	}
+
+	devcontainerPath, err := findDevcontainerConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to find devcontainer config: %w", err)
+	}
+
+	resolved, err := resolveConfig(devcontainerPath, configOverrides)
+	if err != nil {
+		return err
+	}
+	devContainer := resolved.DevContainer
+
+	return printResolvedBuild(os.Stdout, devContainer, workspaceDir, devcontainerPath)
+}
+
+// printResolvedBuild writes the build command `devgo build` would run for
+// devContainer, without running it.
+func printResolvedBuild(w io.Writer, devContainer *devcontainer.DevContainer, workspaceDir, devcontainerPath string) error {
+	if !devContainer.HasBuild() {
+		return fmt.Errorf("devcontainer.json does not have build configuration")
+	}
+
+	imageTags := determineImageTags(devContainer, workspaceDir)
+	command := buildDockerCommand(containerEngine(), devContainer, workspaceDir, devcontainerPath, imageTags)
+	_, err := fmt.Fprintln(w, formatCommandLine(command))
+	return err
+}
+
+var plainShellWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// formatCommandLine renders args as a line that can be pasted into a shell,
+// quoting only the arguments that need it.
+func formatCommandLine(args []string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		if plainShellWord.MatchString(arg) {
+			words[i] = arg
+		} else {
+			words[i] = shellJoin([]string{arg})
+		}
+	}
+	return strings.Join(words, " ")
+}
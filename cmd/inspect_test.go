@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestPrintResolvedBuild(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	originalBuildTags := buildTags
+	originalImageName := imageName
+	originalNoCache := noCache
+	originalEngine := containerEngineFlag
+	defer func() {
+		buildTags = originalBuildTags
+		imageName = originalImageName
+		noCache = originalNoCache
+		containerEngineFlag = originalEngine
+	}()
+	buildTags = nil
+	imageName = ""
+	containerEngineFlag = "docker"
+
+	devcontainerPath := "/src/myapp/.devcontainer/devcontainer.json"
+
+	tests := []struct {
+		name         string
+		devContainer *devcontainer.DevContainer
+		noCache      bool
+		want         string
+	}{
+		{
+			name: "dockerfile only",
+			devContainer: &devcontainer.DevContainer{
+				Name:  "myapp",
+				Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+			},
+			want: "docker build -t devgo-myapp:latest -f /src/myapp/.devcontainer/Dockerfile /src/myapp/.devcontainer",
+		},
+		{
+			name: "args, target and parent context",
+			devContainer: &devcontainer.DevContainer{
+				Name: "myapp",
+				Build: &devcontainer.BuildConfig{
+					Dockerfile: "Dockerfile",
+					Context:    "..",
+					Args:       map[string]interface{}{"VARIANT": "3.12", "GREETING": "hello world"},
+					Target:     "dev",
+				},
+			},
+			want: "docker build -t devgo-myapp:latest -f /src/myapp/.devcontainer/Dockerfile " +
+				"--build-arg 'GREETING=hello world' --build-arg VARIANT=3.12 --target dev /src/myapp",
+		},
+		{
+			name: "cache, options and no-cache",
+			devContainer: &devcontainer.DevContainer{
+				Name: "myapp",
+				Build: &devcontainer.BuildConfig{
+					Dockerfile: "Dockerfile",
+					CacheFrom:  "ghcr.io/acme/myapp:cache",
+					Options:    []string{"--platform=linux/amd64"},
+				},
+			},
+			noCache: true,
+			want: "docker build -t devgo-myapp:latest -f /src/myapp/.devcontainer/Dockerfile " +
+				"--cache-from ghcr.io/acme/myapp:cache --no-cache --platform=linux/amd64 /src/myapp/.devcontainer",
+		},
+		{
+			name: "image field names the tag",
+			devContainer: &devcontainer.DevContainer{
+				Image: "registry.example.com/team/app:dev",
+				Build: &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+			},
+			want: "docker build -t registry.example.com/team/app:dev -f /src/myapp/.devcontainer/Dockerfile /src/myapp/.devcontainer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noCache = tt.noCache
+			var buf bytes.Buffer
+			if err := printResolvedBuild(&buf, tt.devContainer, "/src/myapp", devcontainerPath); err != nil {
+				t.Fatalf("printResolvedBuild() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want+"\n" {
+				t.Errorf("printResolvedBuild() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintResolvedBuild_RequiresBuild(t *testing.T) {
+	var buf bytes.Buffer
+	err := printResolvedBuild(&buf, &devcontainer.DevContainer{Image: "ubuntu:22.04"}, "/src/myapp", "/src/myapp/.devcontainer/devcontainer.json")
+	if err == nil {
+		t.Error("expected an error for a configuration without build")
+	}
+}
+
+func TestFormatCommandLine(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"docker", "build", "."}, want: "docker build ."},
+		{args: []string{"echo", "a b"}, want: "echo 'a b'"},
+		{args: []string{"echo", "it's"}, want: `echo 'it'\''s'`},
+		{args: []string{"echo", ""}, want: "echo ''"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCommandLine(tt.args); got != tt.want {
+			t.Errorf("formatCommandLine(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/garaemon/devgo/pkg/constants"
+)
+
+// loadLabelFiles reads every --label-file in order; later files override
+// earlier ones for the same key.
+func loadLabelFiles(paths []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read label file: %w", err)
+		}
+		pairs, err := parseKeyValueLines(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid label file %s: %w", path, err)
+		}
+		for key, value := range pairs {
+			labels[key] = value
+		}
+	}
+	return labels, nil
+}
+
+// mergeContainerLabels combines devgo's own labels with user labels. Keys
+// under constants.DevgoLabelPrefix (with or without --label-prefix) are
+// reserved: user values for them are dropped with a warning, since devgo
+// relies on them to find its containers.
+func mergeContainerLabels(reserved, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(reserved)+len(extra))
+	for _, key := range sortedEnvKeys(extra) {
+		if strings.HasPrefix(key, constants.DevgoLabelPrefix) || strings.HasPrefix(key, devgoLabel(constants.DevgoLabelPrefix)) {
+			warnf("label %s is reserved by devgo, ignoring it", key)
+			continue
+		}
+		merged[key] = extra[key]
+	}
+	for key, value := range reserved {
+		merged[key] = value
+	}
+	return merged
+}
+
+// labelPrefixEnv sets the label prefix when --label-prefix is not given.
+const labelPrefixEnv = "DEVGO_LABEL_PREFIX"
+
+var labelPrefixPattern = regexp.MustCompile(`^[a-z0-9]+([.-][a-z0-9]+)*$`)
+
+// validateLabelPrefix accepts lowercase label key components such as
+// "myteam" or "ci.runner-1".
+func validateLabelPrefix(prefix string) error {
+	if !labelPrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("invalid label prefix %q: use lowercase letters, digits, '.' and '-'", prefix)
+	}
+	return nil
+}
+
+// namespacedLabelKey puts a devgo label key under prefix, so "myteam" turns
+// devgo.managed into myteam.devgo.managed. An empty prefix keeps the key.
+func namespacedLabelKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// currentLabelPrefix returns --label-prefix, or DEVGO_LABEL_PREFIX without it.
+func currentLabelPrefix() string {
+	if labelPrefix != "" {
+		return labelPrefix
+	}
+	return os.Getenv(labelPrefixEnv)
+}
+
+// devgoLabel returns the key this invocation uses for a devgo label. Every
+// label devgo writes or looks up goes through it, so containers created
+// under one prefix are invisible to devgo running under another.
+func devgoLabel(key string) string {
+	return namespacedLabelKey(currentLabelPrefix(), key)
+}
+
+// managedLabelFilter returns the "key=value" label filter that selects the
+// containers devgo manages under the current prefix.
+func managedLabelFilter() string {
+	return fmt.Sprintf("%s=%s", devgoLabel(constants.DevgoManagedLabel), constants.DevgoManagedValue)
+}
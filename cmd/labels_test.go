@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/constants"
+)
+
+func TestLoadLabelFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "labels")
+	content := "# team labels\nteam=platform\n\ncost-center = 1234\nurl=https://example.com/?a=b\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write label file: %v", err)
+	}
+
+	labels, err := loadLabelFiles([]string{path})
+	if err != nil {
+		t.Fatalf("loadLabelFiles() error = %v", err)
+	}
+	want := map[string]string{"team": "platform", "cost-center": " 1234", "url": "https://example.com/?a=b"}
+	if len(labels) != len(want) {
+		t.Fatalf("labels = %v, want %v", labels, want)
+	}
+	for key, value := range want {
+		if labels[key] != value {
+			t.Errorf("labels[%q] = %q, want %q", key, labels[key], value)
+		}
+	}
+
+	badPath := filepath.Join(dir, "bad")
+	if err := os.WriteFile(badPath, []byte("no-separator\n"), 0644); err != nil {
+		t.Fatalf("failed to write label file: %v", err)
+	}
+	if _, err := loadLabelFiles([]string{badPath}); err == nil {
+		t.Error("expected an error for a line without '='")
+	}
+}
+
+func TestMergeContainerLabels_ReservedWin(t *testing.T) {
+	reserved := map[string]string{
+		constants.DevgoManagedLabel:   constants.DevgoManagedValue,
+		constants.DevgoWorkspaceLabel: "/workspace",
+	}
+	extra := map[string]string{
+		"team":                        "platform",
+		constants.DevgoWorkspaceLabel: "/somewhere/else",
+		"devgo.custom":                "x",
+	}
+
+	merged := mergeContainerLabels(reserved, extra)
+	if merged["team"] != "platform" {
+		t.Errorf("team label = %q, want file label kept", merged["team"])
+	}
+	if merged[constants.DevgoWorkspaceLabel] != "/workspace" {
+		t.Errorf("workspace label = %q, reserved label must not be overridden", merged[constants.DevgoWorkspaceLabel])
+	}
+	if _, ok := merged["devgo.custom"]; ok {
+		t.Error("user labels under the devgo. prefix should be dropped")
+	}
+	if merged[constants.DevgoManagedLabel] != constants.DevgoManagedValue {
+		t.Error("managed label missing from merged labels")
+	}
+}
+
+func TestNamespacedLabelKey(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    string
+		want   string
+	}{
+		{prefix: "", key: constants.DevgoManagedLabel, want: "devgo.managed"},
+		{prefix: "myteam", key: constants.DevgoManagedLabel, want: "myteam.devgo.managed"},
+		{prefix: "ci.runner-1", key: constants.DevgoWorkspaceLabel, want: "ci.runner-1.devgo.workspace"},
+	}
+
+	for _, tt := range tests {
+		if got := namespacedLabelKey(tt.prefix, tt.key); got != tt.want {
+			t.Errorf("namespacedLabelKey(%q, %q) = %q, want %q", tt.prefix, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLabelPrefix(t *testing.T) {
+	for _, prefix := range []string{"myteam", "ci.runner-1", "a1"} {
+		if err := validateLabelPrefix(prefix); err != nil {
+			t.Errorf("validateLabelPrefix(%q) error = %v", prefix, err)
+		}
+	}
+	for _, prefix := range []string{"", "MyTeam", "team.", ".team", "my team", "team=x"} {
+		if err := validateLabelPrefix(prefix); err == nil {
+			t.Errorf("validateLabelPrefix(%q) should fail", prefix)
+		}
+	}
+}
+
+func TestCurrentLabelPrefix(t *testing.T) {
+	originalPrefix := labelPrefix
+	defer func() { labelPrefix = originalPrefix }()
+
+	t.Setenv(labelPrefixEnv, "fromenv")
+	labelPrefix = ""
+	if got := currentLabelPrefix(); got != "fromenv" {
+		t.Errorf("currentLabelPrefix() = %q, want the environment value", got)
+	}
+	labelPrefix = "fromflag"
+	if got := currentLabelPrefix(); got != "fromflag" {
+		t.Errorf("currentLabelPrefix() = %q, want the flag to win", got)
+	}
+}
+
+func TestLabelPrefix_CreationAndDiscoveryAgree(t *testing.T) {
+	originalPrefix := labelPrefix
+	defer func() { labelPrefix = originalPrefix }()
+	t.Setenv(labelPrefixEnv, "")
+	t.Setenv("SSH_AUTH_SOCK", "")
+	labelPrefix = "myteam"
+
+	mockAPI := &mockDockerAPIClient{}
+	dockerClient := &realDockerClient{client: mockAPI}
+	err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+		Name:            "test-container",
+		Image:           "ubuntu:22.04",
+		WorkspaceDir:    "/host/workspace",
+		WorkspaceFolder: "/workspace",
+		Labels:          map[string]string{"myteam.devgo.managed": "false", "team": "a"},
+	})
+	if err != nil {
+		t.Fatalf("CreateAndStartContainer() error = %v", err)
+	}
+
+	created := mockAPI.createdConfig.Labels
+	wantLabels := map[string]string{
+		"myteam.devgo.managed":   "true",
+		"myteam.devgo.workspace": "/host/workspace",
+		"myteam.devgo.session":   constants.DefaultSessionName,
+		"team":                   "a",
+	}
+	for key, want := range wantLabels {
+		if created[key] != want {
+			t.Errorf("label %s = %q, want %q", key, created[key], want)
+		}
+	}
+	if _, ok := created[constants.DevgoManagedLabel]; ok {
+		t.Errorf("unprefixed %s should not be set", constants.DevgoManagedLabel)
+	}
+
+	listMock := &mockListClient{}
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err = listDevgoContainers(context.Background(), listMock)
+	_ = w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("listDevgoContainers() error = %v", err)
+	}
+	filter := listMock.lastOptions.Filters.Get("label")
+	if len(filter) != 1 || filter[0] != "myteam.devgo.managed=true" {
+		t.Errorf("list label filter = %v, want [myteam.devgo.managed=true]", filter)
+	}
+	if got := getWorkspaceFromLabels(created); got != "/host/workspace" {
+		t.Errorf("getWorkspaceFromLabels() = %q, want the prefixed workspace label", got)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/garaemon/devgo/pkg/constants"
+	devgolog "github.com/garaemon/devgo/pkg/log"
+)
+
+// legacyMigrationEnabled reports whether up looks for a container created
+// under an older naming scheme. An explicit --name names the container
+// exactly, so nothing is adopted then.
+func legacyMigrationEnabled() bool {
+	return containerName == ""
+}
+
+// legacyContainer picks the container up should adopt for the workspace from
+// containers, which all carry its workspace label. Containers of another
+// session and compose services are left alone; containers from before the
+// session label existed count as the default session. When several match,
+// the newest one wins. It returns "" when there is nothing to adopt.
+func legacyContainer(containers []container.Summary, currentName, session string) string {
+	var chosen string
+	var chosenCreated int64
+	for _, c := range containers {
+		if len(c.Names) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(c.Names[0], "/")
+		if name == currentName {
+			continue
+		}
+		if _, ok := c.Labels[constants.ComposeServiceLabel]; ok {
+			continue
+		}
+		containerSession, ok := c.Labels[devgoLabel(constants.DevgoSessionLabel)]
+		if !ok {
+			containerSession = constants.DefaultSessionName
+		}
+		if containerSession != session {
+			continue
+		}
+		if chosen == "" || c.Created > chosenCreated {
+			chosen = name
+			chosenCreated = c.Created
+		}
+	}
+	return chosen
+}
+
+// adoptLegacyContainer renames a container devgo created for workspaceDir
+// under an older name to containerName, so up reuses it instead of creating
+// a duplicate. It reports whether a container was adopted.
+func adoptLegacyContainer(ctx context.Context, dockerClient DockerClient, containerName, workspaceDir string) (bool, error) {
+	containers, err := dockerClient.WorkspaceContainers(ctx, workspaceDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to look for legacy containers: %w", err)
+	}
+
+	session := sessionName
+	if session == "" {
+		session = constants.DefaultSessionName
+	}
+	legacyName := legacyContainer(containers, containerName, session)
+	if legacyName == "" {
+		return false, nil
+	}
+
+	// Renaming someone's container is not something to do behind their
+	// back, so this is logged even without --debug.
+	logger.Logf(devgolog.LevelInfo, "Renaming container '%s', created for this workspace by an older devgo, to '%s' (pass --name to use another container)\n", legacyName, containerName)
+	if err := dockerClient.RenameContainer(ctx, legacyName, containerName); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WorkspaceContainers lists the devgo-managed containers, running or not,
+// whose workspace label is workspaceDir.
+func (r *realDockerClient) WorkspaceContainers(ctx context.Context, workspaceDir string) ([]container.Summary, error) {
+	filter := filters.NewArgs()
+	filter.Add("label", managedLabelFilter())
+	filter.Add("label", fmt.Sprintf("%s=%s", devgoLabel(constants.DevgoWorkspaceLabel), workspaceDir))
+
+	containers, err := r.client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return containers, nil
+}
+
+func (r *realDockerClient) RenameContainer(ctx context.Context, oldName, newName string) error {
+	if err := r.client.ContainerRename(ctx, oldName, newName); err != nil {
+		return fmt.Errorf("failed to rename container '%s' to '%s': %w", oldName, newName, err)
+	}
+	return nil
+}
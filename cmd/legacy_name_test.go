@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/garaemon/devgo/pkg/constants"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgolog "github.com/garaemon/devgo/pkg/log"
+)
+
+func TestLegacyContainer(t *testing.T) {
+	workspaceLabels := func(extra map[string]string) map[string]string {
+		labels := map[string]string{
+			constants.DevgoManagedLabel:   constants.DevgoManagedValue,
+			constants.DevgoWorkspaceLabel: "/src/myapp",
+		}
+		for key, value := range extra {
+			labels[key] = value
+		}
+		return labels
+	}
+
+	tests := []struct {
+		name       string
+		containers []container.Summary
+		session    string
+		want       string
+	}{
+		{
+			name: "legacy name without session label",
+			containers: []container.Summary{
+				{Names: []string{"/devgo-myapp"}, Labels: workspaceLabels(nil)},
+			},
+			session: constants.DefaultSessionName,
+			want:    "devgo-myapp",
+		},
+		{
+			name: "current name is not a legacy container",
+			containers: []container.Summary{
+				{Names: []string{"/myapp-default-abc123"}, Labels: workspaceLabels(nil)},
+			},
+			session: constants.DefaultSessionName,
+			want:    "",
+		},
+		{
+			name: "container of another session is left alone",
+			containers: []container.Summary{
+				{Names: []string{"/myapp-other-abc123"}, Labels: workspaceLabels(map[string]string{constants.DevgoSessionLabel: "other"})},
+			},
+			session: constants.DefaultSessionName,
+			want:    "",
+		},
+		{
+			name: "compose service is left alone",
+			containers: []container.Summary{
+				{Names: []string{"/myapp-app-1"}, Labels: workspaceLabels(map[string]string{constants.ComposeServiceLabel: "app"})},
+			},
+			session: constants.DefaultSessionName,
+			want:    "",
+		},
+		{
+			name: "newest legacy container wins",
+			containers: []container.Summary{
+				{Names: []string{"/devgo-myapp"}, Created: 100, Labels: workspaceLabels(nil)},
+				{Names: []string{"/devgo-myapp-default"}, Created: 200, Labels: workspaceLabels(nil)},
+			},
+			session: constants.DefaultSessionName,
+			want:    "devgo-myapp-default",
+		},
+		{
+			name: "legacy container without session label in a named session",
+			containers: []container.Summary{
+				{Names: []string{"/devgo-myapp"}, Labels: workspaceLabels(nil)},
+			},
+			session: "feature",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legacyContainer(tt.containers, "myapp-default-abc123", tt.session); got != tt.want {
+				t.Errorf("legacyContainer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartContainerWithDocker_AdoptsLegacyContainer(t *testing.T) {
+	originalReuse := reuseStopped
+	var out bytes.Buffer
+	oldLogger := logger
+	logger = devgolog.New(&out)
+	defer func() {
+		reuseStopped = originalReuse
+		logger = oldLogger
+	}()
+	reuseStopped = true
+
+	mock := newMockDockerClient()
+	mock.addImage("ubuntu:22.04")
+	mock.addContainer("devgo-myapp", false)
+	mock.workspaceContainers = []container.Summary{
+		{
+			Names: []string{"/devgo-myapp"},
+			Labels: map[string]string{
+				constants.DevgoManagedLabel:   constants.DevgoManagedValue,
+				constants.DevgoWorkspaceLabel: "/src/myapp",
+			},
+		},
+	}
+
+	devContainer := &devcontainer.DevContainer{Image: "ubuntu:22.04"}
+	if err := startContainerWithDocker(context.Background(), devContainer, "myapp-default-abc123", t.TempDir(), mock); err != nil {
+		t.Fatalf("startContainerWithDocker() error = %v", err)
+	}
+
+	if want := []string{"devgo-myapp->myapp-default-abc123"}; !reflect.DeepEqual(mock.renamedContainers, want) {
+		t.Errorf("renamed containers = %v, want %v", mock.renamedContainers, want)
+	}
+	if !mock.containers["myapp-default-abc123"] {
+		t.Error("expected the adopted container to be started under its new name")
+	}
+	if len(mock.createdContainers) != 0 {
+		t.Errorf("expected no new container, created %d", len(mock.createdContainers))
+	}
+	if !strings.Contains(out.String(), "Renaming container 'devgo-myapp'") {
+		t.Errorf("logged %q, want the rename announced", out.String())
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// lifecycleStateDir holds devgo's state inside the container. With a
+// read-only root filesystem it is an anonymous volume; see
+// lifecycleStateMount.
+const lifecycleStateDir = "/var/lib/devgo"
+
+// lifecycleMarkerDir holds, inside the container, a marker file for each
+// creation-time lifecycle command that completed. The markers go away with
+// the container, so a recreated container runs the commands again.
+const lifecycleMarkerDir = lifecycleStateDir + "/lifecycle"
+
+// lifecycleStateMount is the anonymous volume that keeps lifecycleStateDir
+// writable when the root filesystem is read-only. It is removed with the
+// container by `devgo down --volumes`.
+func lifecycleStateMount() mount.Mount {
+	return mount.Mount{Type: mount.TypeVolume, Target: lifecycleStateDir}
+}
+
+// markerNotice makes a failure to record a marker a single notice per run
+// rather than one warning per lifecycle command.
+var markerNotice sync.Once
+
+// noteMarkerFailure tells the user, once, that err kept the container from
+// recording a completed lifecycle command, so run-user-commands runs it again.
+func noteMarkerFailure(err error) {
+	markerNotice.Do(func() {
+		warnf("%v; run-user-commands will run it again", err)
+	})
+}
+
+// runsOnce reports whether commandType belongs to the creation of the
+// container (or, for updateContentCommand, to new content) and so is not
+// repeated by run-user-commands once it completed.
+func runsOnce(commandType string) bool {
+	switch commandType {
+	case devcontainer.WaitForOnCreateCommand, devcontainer.WaitForUpdateContentCommand, devcontainer.WaitForPostCreateCommand:
+		return true
+	}
+	return false
+}
+
+// lifecycleDigest identifies the commands configured for commandType, so
+// that editing a command makes its marker stale. For updateContentCommand it
+// also covers content, the workspaceContentID, so new content does too.
+func lifecycleDigest(devContainer *devcontainer.DevContainer, commandType, content string) string {
+	commands, err := devContainer.GetLifecycleCommands(commandType)
+	if err != nil {
+		return ""
+	}
+	data, err := json.Marshal(commands)
+	if err != nil {
+		return ""
+	}
+	if commandType == devcontainer.WaitForUpdateContentCommand && content != "" {
+		data = append(data, content...)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// workspaceContentID identifies the content of the workspace at workspaceDir
+// by its git HEAD commit, or "" when it is not a git checkout.
+func workspaceContentID(workspaceDir string) string {
+	out, err := exec.Command("git", "-C", workspaceDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func lifecycleMarkerPath(commandType string) string {
+	return path.Join(lifecycleMarkerDir, commandType)
+}
+
+// lifecycleCompleted reports whether the container holds a marker for
+// commandType written for the same commands as digest.
+func lifecycleCompleted(ctx context.Context, cli DockerExecClient, containerName, commandType, digest string) bool {
+	var out bytes.Buffer
+	opts := execOptions{Stdout: &out, Stderr: io.Discard, WorkingDir: "/"}
+	err := executeCommandInContainerWithOptions(ctx, cli, containerName, []string{"cat", lifecycleMarkerPath(commandType)}, rootDevContainer, opts)
+	return err == nil && strings.TrimSpace(out.String()) == digest
+}
+
+// markLifecycleCompleted records in the container that commandType ran to
+// completion with the commands identified by digest.
+func markLifecycleCompleted(ctx context.Context, cli DockerExecClient, containerName, commandType, digest string) error {
+	script := fmt.Sprintf("mkdir -p %s && echo %s > %s", lifecycleMarkerDir, digest, lifecycleMarkerPath(commandType))
+	opts := execOptions{Stdout: io.Discard, Stderr: io.Discard, WorkingDir: "/"}
+	if err := executeCommandInContainerWithOptions(ctx, cli, containerName, []string{"/bin/sh", "-c", script}, rootDevContainer, opts); err != nil {
+		return fmt.Errorf("failed to record %s as completed: %w", commandType, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgolog "github.com/garaemon/devgo/pkg/log"
+)
+
+func TestLifecycleCompleted(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{OnCreateCommand: "npm ci"}
+	digest := lifecycleDigest(devContainer, devcontainer.WaitForOnCreateCommand, "")
+
+	tests := []struct {
+		name     string
+		marker   string
+		exitCode int
+		want     bool
+	}{
+		{name: "same commands", marker: digest + "\n", want: true},
+		{name: "commands changed", marker: "0123abcd\n", want: false},
+		{name: "no marker", exitCode: 1, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newRunningExecMock(createMockHijackedResponseWithStreams(tt.marker, ""))
+			mock.exitCode = tt.exitCode
+			got := lifecycleCompleted(context.Background(), mock, "test-container", devcontainer.WaitForOnCreateCommand, digest)
+			if got != tt.want {
+				t.Errorf("lifecycleCompleted() = %t, want %t", got, tt.want)
+			}
+			if mock.lastExecConfig.User != "root" {
+				t.Errorf("marker read as %q, want root", mock.lastExecConfig.User)
+			}
+		})
+	}
+}
+
+func TestMarkLifecycleCompleted(t *testing.T) {
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("", ""))
+	if err := markLifecycleCompleted(context.Background(), mock, "test-container", devcontainer.WaitForPostCreateCommand, "abc123"); err != nil {
+		t.Fatalf("markLifecycleCompleted() error = %v", err)
+	}
+	script := strings.Join(mock.lastExecConfig.Cmd, " ")
+	if !strings.Contains(script, "echo abc123 > /var/lib/devgo/lifecycle/postCreateCommand") {
+		t.Errorf("marker command = %q, want it to write the digest to the postCreateCommand marker", script)
+	}
+}
+
+func TestLifecycleDigest(t *testing.T) {
+	digest := func(command, commandType, content string) string {
+		return lifecycleDigest(&devcontainer.DevContainer{UpdateContentCommand: command, PostCreateCommand: command}, commandType, content)
+	}
+	before := digest("make deps", devcontainer.WaitForUpdateContentCommand, "c1")
+	if same := digest("make deps", devcontainer.WaitForUpdateContentCommand, "c1"); before != same {
+		t.Errorf("lifecycleDigest() differs for the same command: %s, %s", before, same)
+	}
+	if after := digest("make deps tools", devcontainer.WaitForUpdateContentCommand, "c1"); before == after {
+		t.Error("lifecycleDigest() should change when the command changes")
+	}
+	if newContent := digest("make deps", devcontainer.WaitForUpdateContentCommand, "c2"); before == newContent {
+		t.Error("lifecycleDigest() of updateContentCommand should change when the content changes")
+	}
+	if digest("make deps", devcontainer.WaitForPostCreateCommand, "c1") != digest("make deps", devcontainer.WaitForPostCreateCommand, "c2") {
+		t.Error("lifecycleDigest() of postCreateCommand should not depend on the content")
+	}
+}
+
+func TestWorkspaceContentID(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	dir := t.TempDir()
+	if got := workspaceContentID(dir); got != "" {
+		t.Errorf("workspaceContentID() outside git = %q, want empty", got)
+	}
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+	first := workspaceContentID(dir)
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	if second := workspaceContentID(dir); first == "" || first == second {
+		t.Errorf("workspaceContentID() = %q then %q, want a new ID per commit", first, second)
+	}
+}
+
+func TestMarkLifecycleCompleted_ReadOnly(t *testing.T) {
+	// The marker write fails, as on a read-only root filesystem.
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("", "Read-only file system"))
+	mock.exitCode = 1
+	if err := markLifecycleCompleted(context.Background(), mock, "test-container", devcontainer.WaitForPostCreateCommand, "abc123"); err == nil {
+		t.Fatal("markLifecycleCompleted() error = nil, want the failed write")
+	}
+}
+
+func TestNoteMarkerFailure_Once(t *testing.T) {
+	var out bytes.Buffer
+	oldLogger := logger
+	logger = devgolog.New(&out)
+	markerNotice = sync.Once{}
+	defer func() {
+		logger = oldLogger
+		markerNotice = sync.Once{}
+	}()
+
+	noteMarkerFailure(errors.New("failed to record onCreateCommand as completed"))
+	noteMarkerFailure(errors.New("failed to record postCreateCommand as completed"))
+
+	if got := strings.Count(out.String(), "failed to record"); got != 1 {
+		t.Errorf("logged %q, want a single notice", out.String())
+	}
+	if !strings.Contains(out.String(), "run-user-commands will run it again") {
+		t.Errorf("notice = %q, want it to explain the consequence", out.String())
+	}
+}
+
+func TestRunsOnce(t *testing.T) {
+	for commandType, want := range map[string]bool{
+		devcontainer.WaitForOnCreateCommand:      true,
+		devcontainer.WaitForUpdateContentCommand: true,
+		devcontainer.WaitForPostCreateCommand:    true,
+		devcontainer.WaitForPostStartCommand:     false,
+		"postAttachCommand":                      false,
+	} {
+		if got := runsOnce(commandType); got != want {
+			t.Errorf("runsOnce(%s) = %t, want %t", commandType, got, want)
+		}
+	}
+}
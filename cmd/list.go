@@ -4,13 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/client"
 	"github.com/garaemon/devgo/pkg/constants"
 )
 
@@ -21,7 +21,7 @@ type DockerListClient interface {
 }
 
 func runListCommand(args []string) error {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -37,16 +37,22 @@ func runListCommand(args []string) error {
 
 func listDevgoContainers(ctx context.Context, cli DockerListClient) error {
 	filter := filters.NewArgs()
-	filter.Add("label", fmt.Sprintf("%s=%s", constants.DevgoManagedLabel, constants.DevgoManagedValue))
+	filter.Add("label", managedLabelFilter())
+	if listRunningOnly {
+		filter.Add("status", "running")
+	}
 
 	containers, err := cli.ContainerList(ctx, container.ListOptions{
 		All:     true,
+		Size:    listWithSize,
 		Filters: filter,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to list containers: %w", err)
 	}
 
+	containers = filterContainersByAge(containers, listNewerThan, listOlderThan, time.Now())
+
 	if len(containers) == 0 {
 		fmt.Println("No devgo containers found")
 		return nil
@@ -56,12 +62,18 @@ func listDevgoContainers(ctx context.Context, cli DockerListClient) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
 	// Print header
-	if _, err := fmt.Fprintln(w, "NAME\tSESSION\tSTATUS\tIMAGE\tCREATED\tWORKSPACE"); err != nil {
+	header := "NAME\tSESSION\tSTATUS\tIMAGE\tPORTS\tCREATED\t"
+	separator := strings.Repeat("-", 20) + "\t" + strings.Repeat("-", 12) + "\t" +
+		strings.Repeat("-", 15) + "\t" + strings.Repeat("-", 20) + "\t" +
+		strings.Repeat("-", 15) + "\t" + strings.Repeat("-", 10) + "\t"
+	if listWithSize {
+		header += "SIZE\t"
+		separator += strings.Repeat("-", 10) + "\t"
+	}
+	if _, err := fmt.Fprintln(w, header+"WORKSPACE"); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
-	if _, err := fmt.Fprintln(w, strings.Repeat("-", 20)+"\t"+strings.Repeat("-", 12)+"\t"+
-		strings.Repeat("-", 15)+"\t"+strings.Repeat("-", 20)+"\t"+
-		strings.Repeat("-", 10)+"\t"+strings.Repeat("-", 20)); err != nil {
+	if _, err := fmt.Fprintln(w, separator+strings.Repeat("-", 20)); err != nil {
 		return fmt.Errorf("failed to write separator: %w", err)
 	}
 
@@ -70,10 +82,18 @@ func listDevgoContainers(ctx context.Context, cli DockerListClient) error {
 		session := getSessionFromLabels(c.Labels)
 		status := c.Status
 		image := c.Image
+		ports := formatPorts(c.Ports)
 		created := time.Unix(c.Created, 0).Format("2006-01-02")
 		workspace := getWorkspaceFromLabels(c.Labels)
 
-		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, session, status, image, created, workspace); err != nil {
+		columns := []string{name, session, status, image, ports, created}
+		if listWithSize {
+			// SizeRw is the container's writable layer, what it added on top
+			// of its image.
+			columns = append(columns, formatImageSize(c.SizeRw))
+		}
+		columns = append(columns, workspace)
+		if _, err := fmt.Fprintln(w, strings.Join(columns, "\t")); err != nil {
 			return fmt.Errorf("failed to write container info: %w", err)
 		}
 	}
@@ -94,15 +114,78 @@ func getContainerName(names []string) string {
 }
 
 func getWorkspaceFromLabels(labels map[string]string) string {
-	if workspace, exists := labels[constants.DevgoWorkspaceLabel]; exists {
+	if workspace, exists := labels[devgoLabel(constants.DevgoWorkspaceLabel)]; exists {
 		return workspace
 	}
 	return "<unknown>"
 }
 
 func getSessionFromLabels(labels map[string]string) string {
-	if session, exists := labels[constants.DevgoSessionLabel]; exists {
+	if session, exists := labels[devgoLabel(constants.DevgoSessionLabel)]; exists {
 		return session
 	}
 	return "<unknown>"
 }
+
+// formatPorts summarizes a container's ports for the PORTS column, e.g.
+// "3000->3000/tcp, 5432/tcp". Published ports show the host side; the IPv4
+// and IPv6 bindings Docker reports for the same port are shown once.
+func formatPorts(ports []container.Port) string {
+	if len(ports) == 0 {
+		return "-"
+	}
+
+	sorted := make([]container.Port, len(ports))
+	copy(sorted, ports)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].PrivatePort != sorted[j].PrivatePort {
+			return sorted[i].PrivatePort < sorted[j].PrivatePort
+		}
+		return sorted[i].Type < sorted[j].Type
+	})
+
+	seen := make(map[string]bool)
+	var entries []string
+	for _, p := range sorted {
+		entry := fmt.Sprintf("%d/%s", p.PrivatePort, p.Type)
+		if p.PublicPort != 0 {
+			entry = fmt.Sprintf("%d->%s", p.PublicPort, entry)
+		}
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ", ")
+}
+
+// createdNewerThan reports whether c was created less than d before now.
+func createdNewerThan(c container.Summary, d time.Duration, now time.Time) bool {
+	return now.Sub(time.Unix(c.Created, 0)) < d
+}
+
+// createdOlderThan reports whether c was created at least d before now, the
+// same boundary prune --until uses.
+func createdOlderThan(c container.Summary, d time.Duration, now time.Time) bool {
+	return !createdNewerThan(c, d, now)
+}
+
+// filterContainersByAge keeps the containers matching --newer-than and
+// --older-than; a zero duration disables that filter.
+func filterContainersByAge(containers []container.Summary, newerThan, olderThan time.Duration, now time.Time) []container.Summary {
+	if newerThan == 0 && olderThan == 0 {
+		return containers
+	}
+	var filtered []container.Summary
+	for _, c := range containers {
+		if newerThan > 0 && !createdNewerThan(c, newerThan, now) {
+			continue
+		}
+		if olderThan > 0 && !createdOlderThan(c, olderThan, now) {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -109,11 +110,13 @@ func TestListCommandConstants(t *testing.T) {
 
 // mockListClient implements a mock Docker client for testing list functionality
 type mockListClient struct {
-	containers []container.Summary
-	listError  error
+	containers  []container.Summary
+	listError   error
+	lastOptions container.ListOptions
 }
 
 func (m *mockListClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	m.lastOptions = options
 	if m.listError != nil {
 		return nil, m.listError
 	}
@@ -318,3 +321,202 @@ func containsAny(s string, substrings []string) bool {
 	}
 	return false
 }
+
+func TestFormatPorts(t *testing.T) {
+	tests := []struct {
+		name     string
+		ports    []container.Port
+		expected string
+	}{
+		{
+			name:     "no ports",
+			ports:    nil,
+			expected: "-",
+		},
+		{
+			name: "published ports with IPv4 and IPv6 bindings",
+			ports: []container.Port{
+				{IP: "::", PrivatePort: 5432, PublicPort: 15432, Type: "tcp"},
+				{IP: "0.0.0.0", PrivatePort: 3000, PublicPort: 3000, Type: "tcp"},
+				{IP: "::", PrivatePort: 3000, PublicPort: 3000, Type: "tcp"},
+				{IP: "0.0.0.0", PrivatePort: 5432, PublicPort: 15432, Type: "tcp"},
+			},
+			expected: "3000->3000/tcp, 15432->5432/tcp",
+		},
+		{
+			name: "exposed but unpublished port",
+			ports: []container.Port{
+				{PrivatePort: 8080, Type: "tcp"},
+				{IP: "0.0.0.0", PrivatePort: 53, PublicPort: 5353, Type: "udp"},
+			},
+			expected: "5353->53/udp, 8080/tcp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatPorts(tt.ports); got != tt.expected {
+				t.Errorf("formatPorts() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListDevgoContainers_RunningOnly(t *testing.T) {
+	originalRunningOnly := listRunningOnly
+	defer func() { listRunningOnly = originalRunningOnly }()
+
+	tests := []struct {
+		name          string
+		runningOnly   bool
+		expectRunning bool
+	}{
+		{name: "default lists all containers", runningOnly: false, expectRunning: false},
+		{name: "running only adds a status filter", runningOnly: true, expectRunning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listRunningOnly = tt.runningOnly
+			mock := &mockListClient{}
+
+			oldStdout := os.Stdout
+			_, w, _ := os.Pipe()
+			os.Stdout = w
+			err := listDevgoContainers(context.Background(), mock)
+			_ = w.Close()
+			os.Stdout = oldStdout
+			if err != nil {
+				t.Fatalf("listDevgoContainers() error = %v", err)
+			}
+
+			status := mock.lastOptions.Filters.Get("status")
+			if got := len(status) == 1 && status[0] == "running"; got != tt.expectRunning {
+				t.Errorf("status=running filter applied = %t, want %t", got, tt.expectRunning)
+			}
+			if labels := mock.lastOptions.Filters.Get("label"); len(labels) != 1 || labels[0] != constants.DevgoManagedLabel+"="+constants.DevgoManagedValue {
+				t.Error("the devgo managed label filter must always be applied")
+			}
+		})
+	}
+}
+
+func TestCreatedAgePredicates(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		age       time.Duration
+		wantNewer bool
+		wantOlder bool
+	}{
+		{name: "just under the boundary", age: day - time.Second, wantNewer: true, wantOlder: false},
+		{name: "exactly at the boundary", age: day, wantNewer: false, wantOlder: true},
+		{name: "just over the boundary", age: day + time.Second, wantNewer: false, wantOlder: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := container.Summary{Created: now.Add(-tt.age).Unix()}
+			if got := createdNewerThan(c, day, now); got != tt.wantNewer {
+				t.Errorf("createdNewerThan() = %t, want %t", got, tt.wantNewer)
+			}
+			if got := createdOlderThan(c, day, now); got != tt.wantOlder {
+				t.Errorf("createdOlderThan() = %t, want %t", got, tt.wantOlder)
+			}
+		})
+	}
+}
+
+func TestFilterContainersByAge(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	containers := []container.Summary{
+		{ID: "hour", Created: now.Add(-time.Hour).Unix()},
+		{ID: "day", Created: now.Add(-24 * time.Hour).Unix()},
+		{ID: "week", Created: now.Add(-7 * 24 * time.Hour).Unix()},
+	}
+
+	tests := []struct {
+		name      string
+		newerThan time.Duration
+		olderThan time.Duration
+		wantIDs   []string
+	}{
+		{name: "no filters", wantIDs: []string{"hour", "day", "week"}},
+		{name: "newer than a day", newerThan: 24 * time.Hour, wantIDs: []string{"hour"}},
+		{name: "older than a day", olderThan: 24 * time.Hour, wantIDs: []string{"day", "week"}},
+		{name: "between", newerThan: 48 * time.Hour, olderThan: 2 * time.Hour, wantIDs: []string{"day"}},
+		{name: "nothing matches", newerThan: time.Minute, wantIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIDs []string
+			for _, c := range filterContainersByAge(containers, tt.newerThan, tt.olderThan, now) {
+				gotIDs = append(gotIDs, c.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("filterContainersByAge() = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestListDevgoContainers_WithSize(t *testing.T) {
+	originalWithSize := listWithSize
+	defer func() { listWithSize = originalWithSize }()
+
+	containers := []container.Summary{
+		{
+			Names:   []string{"/big-container"},
+			Image:   "ubuntu:22.04",
+			Status:  "Up 2 minutes",
+			Created: time.Date(2025, 6, 19, 10, 0, 0, 0, time.UTC).Unix(),
+			SizeRw:  1_500_000_000,
+			Labels: map[string]string{
+				constants.DevgoManagedLabel: constants.DevgoManagedValue,
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		withSize  bool
+		wantSize  bool
+		wantInRow string
+	}{
+		{name: "size column requested", withSize: true, wantSize: true, wantInRow: "1.5 GB"},
+		{name: "no size column by default", withSize: false, wantSize: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listWithSize = tt.withSize
+			mock := &mockListClient{containers: containers}
+
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			err := listDevgoContainers(context.Background(), mock)
+			_ = w.Close()
+			os.Stdout = oldStdout
+			var buf bytes.Buffer
+			_, _ = io.Copy(&buf, r)
+			if err != nil {
+				t.Fatalf("listDevgoContainers() error = %v", err)
+			}
+
+			if mock.lastOptions.Size != tt.wantSize {
+				t.Errorf("ListOptions.Size = %t, want %t", mock.lastOptions.Size, tt.wantSize)
+			}
+			output := buf.String()
+			if got := strings.Contains(output, "SIZE"); got != tt.wantSize {
+				t.Errorf("SIZE column present = %t, want %t\noutput:\n%s", got, tt.wantSize, output)
+			}
+			if tt.wantInRow != "" && !strings.Contains(output, tt.wantInRow) {
+				t.Errorf("output missing %q\noutput:\n%s", tt.wantInRow, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// logsDockerClient is the subset of the Docker API used by `devgo logs`.
+type logsDockerClient interface {
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error)
+}
+
+func runLogsCommand(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("logs takes no arguments, got %q", args)
+	}
+	if err := validateLogsTail(logsTail); err != nil {
+		return err
+	}
+
+	devcontainerPath, err := findDevcontainerConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to find devcontainer config: %w", err)
+	}
+
+	workspaceDir := determineWorkspaceFolder(devcontainerPath)
+
+	resolved, err := resolveConfig(devcontainerPath, configOverrides)
+	if err != nil {
+		return err
+	}
+	containerName := determineContainerName(resolved.DevContainer, workspaceDir)
+
+	cli, err := newEngineClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+
+	opts := container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     logsFollow,
+		Tail:       logsTail,
+		Since:      logsSince,
+	}
+	return streamContainerLogs(context.Background(), cli, containerName, opts, os.Stdout, os.Stderr)
+}
+
+// validateLogsTail accepts the --tail values `docker logs` does: a number
+// of lines or "all".
+func validateLogsTail(tail string) error {
+	if tail == "" || tail == "all" {
+		return nil
+	}
+	if n, err := strconv.Atoi(tail); err != nil || n < 0 {
+		return fmt.Errorf("invalid --tail value %q: want a number of lines or \"all\"", tail)
+	}
+	return nil
+}
+
+// streamContainerLogs copies the logs of containerName to stdout and
+// stderr. The container does not need to be running. Logs of a container
+// without a TTY are multiplexed and are split back into the two streams;
+// with a TTY they are a single raw stream.
+func streamContainerLogs(ctx context.Context, cli logsDockerClient, containerName string, opts container.LogsOptions, stdout, stderr io.Writer) error {
+	inspect, err := cli.ContainerInspect(ctx, containerName)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return fmt.Errorf("container '%s' does not exist. Use 'devgo up' to start it first", containerName)
+		}
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	logs, err := cli.ContainerLogs(ctx, containerName, opts)
+	if err != nil {
+		return fmt.Errorf("failed to read logs of container '%s': %w", containerName, err)
+	}
+	defer func() {
+		if closeErr := logs.Close(); closeErr != nil {
+			warnf("failed to close log stream: %v", closeErr)
+		}
+	}()
+
+	if inspect.Config != nil && inspect.Config.Tty {
+		_, err = io.Copy(stdout, logs)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, logs)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stream logs: %w", err)
+	}
+	return nil
+}
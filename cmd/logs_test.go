@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+type fakeLogsClient struct {
+	tty        bool
+	inspectErr error
+	logs       []byte
+	options    container.LogsOptions
+}
+
+func (f *fakeLogsClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	if f.inspectErr != nil {
+		return types.ContainerJSON{}, f.inspectErr
+	}
+	return types.ContainerJSON{Config: &container.Config{Tty: f.tty}}, nil
+}
+
+func (f *fakeLogsClient) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	f.options = options
+	return io.NopCloser(bytes.NewReader(f.logs)), nil
+}
+
+func multiplexedLogs(t *testing.T, stdout, stderr string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(stdout)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte(stderr)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStreamContainerLogs(t *testing.T) {
+	tests := []struct {
+		name       string
+		cli        *fakeLogsClient
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "streams are demultiplexed",
+			cli:        &fakeLogsClient{logs: multiplexedLogs(t, "server started\n", "deprecated option\n")},
+			wantStdout: "server started\n",
+			wantStderr: "deprecated option\n",
+		},
+		{
+			name:       "tty output is copied as is",
+			cli:        &fakeLogsClient{tty: true, logs: []byte("\x1b[32mready\x1b[0m\r\n")},
+			wantStdout: "\x1b[32mready\x1b[0m\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			opts := container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true, Tail: "100"}
+			if err := streamContainerLogs(context.Background(), tt.cli, "app", opts, &stdout, &stderr); err != nil {
+				t.Fatalf("streamContainerLogs() error = %v", err)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+			if tt.cli.options != opts {
+				t.Errorf("ContainerLogs options = %+v, want %+v", tt.cli.options, opts)
+			}
+		})
+	}
+}
+
+func TestStreamContainerLogs_MissingContainer(t *testing.T) {
+	cli := &fakeLogsClient{inspectErr: errdefs.NotFound(errors.New("no such container"))}
+	err := streamContainerLogs(context.Background(), cli, "app", container.LogsOptions{}, io.Discard, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "devgo up") {
+		t.Errorf("streamContainerLogs() error = %v, want a hint to run devgo up", err)
+	}
+}
+
+func TestValidateLogsTail(t *testing.T) {
+	tests := []struct {
+		tail    string
+		wantErr bool
+	}{
+		{tail: "", wantErr: false},
+		{tail: "all", wantErr: false},
+		{tail: "100", wantErr: false},
+		{tail: "0", wantErr: false},
+		{tail: "-1", wantErr: true},
+		{tail: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tail, func(t *testing.T) {
+			if err := validateLogsTail(tt.tail); (err != nil) != tt.wantErr {
+				t.Errorf("validateLogsTail(%q) error = %v, wantErr %v", tt.tail, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/docker/go-units"
+)
+
+// parseMemorySize parses a --memory or --memory-swap value such as "512m" or
+// "2g" into bytes. "-1" is kept as -1, which Docker reads as unlimited swap.
+func parseMemorySize(value string) (int64, error) {
+	if value == "-1" {
+		return -1, nil
+	}
+	size, err := units.RAMInBytes(value)
+	if err != nil {
+		return 0, err
+	}
+	if size <= 0 {
+		return 0, fmt.Errorf("size must be positive")
+	}
+	return size, nil
+}
+
+// parseMemorySwappiness parses a --memory-swappiness value, which must be
+// between 0 and 100.
+func parseMemorySwappiness(value string) (int64, error) {
+	swappiness, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if swappiness < 0 || swappiness > 100 {
+		return 0, fmt.Errorf("must be between 0 and 100")
+	}
+	return swappiness, nil
+}
+
+// validateMemoryFlags mirrors the checks `docker run` makes: a swap limit
+// (other than -1, unlimited) needs --memory and, since it counts memory plus
+// swap, may not be smaller than it.
+func validateMemoryFlags(memory, memorySwap int64) error {
+	if memorySwap <= 0 {
+		return nil
+	}
+	if memory == 0 {
+		return fmt.Errorf("--memory-swap requires --memory")
+	}
+	if memorySwap < memory {
+		return fmt.Errorf("--memory-swap must be at least --memory")
+	}
+	return nil
+}
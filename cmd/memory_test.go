@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestParseMemorySize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "512m", want: 512 * 1024 * 1024},
+		{value: "2g", want: 2 * 1024 * 1024 * 1024},
+		{value: "1048576", want: 1048576},
+		{value: "-1", want: -1},
+		{value: "0", wantErr: true},
+		{value: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseMemorySize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMemorySize(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseMemorySize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMemorySwappiness(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "60", want: 60},
+		{value: "100", want: 100},
+		{value: "101", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "high", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseMemorySwappiness(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMemorySwappiness(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseMemorySwappiness(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMemoryFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		memory     int64
+		memorySwap int64
+		wantErr    bool
+	}{
+		{name: "no limits", memory: 0, memorySwap: 0},
+		{name: "memory only", memory: 1024, memorySwap: 0},
+		{name: "unlimited swap without memory", memory: 0, memorySwap: -1},
+		{name: "swap above memory", memory: 1024, memorySwap: 2048},
+		{name: "swap without memory", memory: 0, memorySwap: 2048, wantErr: true},
+		{name: "swap below memory", memory: 2048, memorySwap: 1024, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMemoryFlags(tt.memory, tt.memorySwap)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMemoryFlags(%d, %d) error = %v, wantErr %t", tt.memory, tt.memorySwap, err, tt.wantErr)
+			}
+		})
+	}
+}
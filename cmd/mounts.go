@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// dockerMounts translates the "mounts" of devcontainer.json into Docker
+// mounts. A mount without a type is a bind mount, as the preflight checks
+// assume; a volume may omit its source to get an anonymous volume, and a
+// tmpfs mount takes no source.
+func dockerMounts(mounts []devcontainer.Mount) ([]mount.Mount, error) {
+	var result []mount.Mount
+	for _, m := range mounts {
+		if m.Target == "" {
+			return nil, fmt.Errorf("mount %q has no target", m.Source)
+		}
+
+		dm := mount.Mount{
+			Source:   m.Source,
+			Target:   m.Target,
+			ReadOnly: m.ReadOnly,
+		}
+		switch m.Type {
+		case "", "bind":
+			if m.Source == "" {
+				return nil, fmt.Errorf("bind mount %s has no source", m.Target)
+			}
+			dm.Type = mount.TypeBind
+			dm.Source = engineHostPath(m.Source, currentRuntime())
+		case "volume":
+			dm.Type = mount.TypeVolume
+		case "tmpfs":
+			if m.Source != "" {
+				return nil, fmt.Errorf("tmpfs mount %s cannot have a source", m.Target)
+			}
+			dm.Type = mount.TypeTmpfs
+		default:
+			return nil, fmt.Errorf("mount %s: unsupported type %q (want bind, volume or tmpfs)", m.Target, m.Type)
+		}
+
+		switch m.Consistency {
+		case "":
+		case string(mount.ConsistencyFull), string(mount.ConsistencyCached), string(mount.ConsistencyDelegated), string(mount.ConsistencyDefault):
+			dm.Consistency = mount.Consistency(m.Consistency)
+		default:
+			return nil, fmt.Errorf("mount %s: unsupported consistency %q", m.Target, m.Consistency)
+		}
+
+		result = append(result, dm)
+	}
+	return result, nil
+}
+
+// dockerWorkspaceMount translates the workspaceMount of devContainer into a
+// Docker mount, or returns nil to keep the default workspace bind.
+func dockerWorkspaceMount(devContainer *devcontainer.DevContainer) (*mount.Mount, error) {
+	spec, err := devContainer.GetWorkspaceMount()
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	mounts, err := dockerMounts([]devcontainer.Mount{*spec})
+	if err != nil {
+		return nil, fmt.Errorf("workspaceMount: %w", err)
+	}
+	return &mounts[0], nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestDockerMounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		mounts  []devcontainer.Mount
+		want    []mount.Mount
+		wantErr bool
+	}{
+		{
+			name:   "untyped mount is a bind",
+			mounts: []devcontainer.Mount{{Source: "/host/cache", Target: "/cache", Consistency: "cached"}},
+			want:   []mount.Mount{{Type: mount.TypeBind, Source: "/host/cache", Target: "/cache", Consistency: mount.ConsistencyCached}},
+		},
+		{
+			name: "volume and tmpfs",
+			mounts: []devcontainer.Mount{
+				{Type: "volume", Source: "node-cache", Target: "/root/.npm", ReadOnly: true},
+				{Type: "volume", Target: "/anonymous"},
+				{Type: "tmpfs", Target: "/scratch"},
+			},
+			want: []mount.Mount{
+				{Type: mount.TypeVolume, Source: "node-cache", Target: "/root/.npm", ReadOnly: true},
+				{Type: mount.TypeVolume, Target: "/anonymous"},
+				{Type: mount.TypeTmpfs, Target: "/scratch"},
+			},
+		},
+		{name: "no mounts", mounts: nil, want: nil},
+		{name: "missing target", mounts: []devcontainer.Mount{{Type: "volume", Source: "cache"}}, wantErr: true},
+		{name: "bind without source", mounts: []devcontainer.Mount{{Type: "bind", Target: "/cache"}}, wantErr: true},
+		{name: "tmpfs with source", mounts: []devcontainer.Mount{{Type: "tmpfs", Source: "/tmp", Target: "/scratch"}}, wantErr: true},
+		{name: "unknown type", mounts: []devcontainer.Mount{{Type: "npipe", Source: "x", Target: "/x"}}, wantErr: true},
+		{name: "unknown consistency", mounts: []devcontainer.Mount{{Source: "/a", Target: "/b", Consistency: "eventual"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dockerMounts(tt.mounts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dockerMounts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dockerMounts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+// networkClient is the subset of the Docker API used to check and recreate
+// the network given by --network.
+type networkClient interface {
+	NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error)
+	NetworkRemove(ctx context.Context, networkID string) error
+	NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error)
+}
+
+// validateNetworkFlags rejects --network-alias without --network: aliases
+// are registered on a user-defined network, not on the default bridge.
+func validateNetworkFlags(networkName string, aliases []string) error {
+	if len(aliases) > 0 && networkName == "" {
+		return fmt.Errorf("--network-alias requires --network")
+	}
+	return nil
+}
+
+// buildNetworkingConfig returns the endpoint settings that attach a new
+// container to networkName under the given DNS aliases, or nil when no
+// network was requested or networkName is a network mode such as "host".
+func buildNetworkingConfig(networkName string, aliases []string) *network.NetworkingConfig {
+	if networkName == "" || isNetworkMode(networkName) {
+		return nil
+	}
+	endpoint := &network.EndpointSettings{}
+	if len(aliases) > 0 {
+		endpoint.Aliases = append([]string(nil), aliases...)
+	}
+	return &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			networkName: endpoint,
+		},
+	}
+}
+
+// networkConflict returns why a standalone container cannot join net, or ""
+// when it can.
+func networkConflict(net network.Inspect) string {
+	switch {
+	case net.ConfigOnly:
+		return "it is a config-only network"
+	case net.Ingress:
+		return "it is the swarm ingress network"
+	case net.Scope == "swarm" && !net.Attachable:
+		return "it is a swarm network that is not attachable"
+	default:
+		return ""
+	}
+}
+
+// prepareNetwork makes sure the container can join networkName. A missing
+// network is left for container creation to report. An existing network
+// that cannot be joined is an error, unless recreate is set and no
+// container uses it: then it is removed and created again as a local bridge
+// network.
+func prepareNetwork(ctx context.Context, cli networkClient, networkName string, recreate bool) error {
+	net, err := cli.NetworkInspect(ctx, networkName, network.InspectOptions{})
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to inspect network '%s': %w", networkName, err)
+	}
+
+	reason := networkConflict(net)
+	if reason == "" {
+		return nil
+	}
+	if !recreate {
+		return fmt.Errorf("cannot join network '%s': %s (use --recreate-network-on-conflict to recreate it)", networkName, reason)
+	}
+	if len(net.Containers) > 0 {
+		return fmt.Errorf("cannot recreate network '%s': %s and %d container(s) still use it", networkName, reason, len(net.Containers))
+	}
+
+	debugf("Recreating network '%s' because %s\n", networkName, reason)
+	if err := cli.NetworkRemove(ctx, net.ID); err != nil {
+		return fmt.Errorf("failed to remove network '%s': %w", networkName, err)
+	}
+	if _, err := cli.NetworkCreate(ctx, networkName, network.CreateOptions{Driver: "bridge"}); err != nil {
+		return fmt.Errorf("failed to create network '%s': %w", networkName, err)
+	}
+	return nil
+}
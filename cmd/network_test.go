@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+// mockNetworkClient serves one network, or none when net is nil, and
+// records removals and creations.
+type mockNetworkClient struct {
+	net     *network.Inspect
+	removed []string
+	created []string
+}
+
+func (m *mockNetworkClient) NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error) {
+	if m.net == nil {
+		return network.Inspect{}, errdefs.NotFound(fmt.Errorf("network %s not found", networkID))
+	}
+	return *m.net, nil
+}
+
+func (m *mockNetworkClient) NetworkRemove(ctx context.Context, networkID string) error {
+	m.removed = append(m.removed, networkID)
+	return nil
+}
+
+func (m *mockNetworkClient) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
+	m.created = append(m.created, name+"/"+options.Driver)
+	return network.CreateResponse{ID: "new"}, nil
+}
+
+func TestBuildNetworkingConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		network       string
+		aliases       []string
+		expectNil     bool
+		expectAliases []string
+	}{
+		{
+			name:      "no network",
+			expectNil: true,
+		},
+		{
+			name:    "network without aliases",
+			network: "devnet",
+		},
+		{
+			name:          "network with aliases",
+			network:       "devnet",
+			aliases:       []string{"api", "backend"},
+			expectAliases: []string{"api", "backend"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := buildNetworkingConfig(tt.network, tt.aliases)
+			if tt.expectNil {
+				if config != nil {
+					t.Errorf("buildNetworkingConfig() = %+v, want nil", config)
+				}
+				return
+			}
+			endpoint, ok := config.EndpointsConfig[tt.network]
+			if !ok || len(config.EndpointsConfig) != 1 {
+				t.Fatalf("EndpointsConfig = %+v, want a single entry for %s", config.EndpointsConfig, tt.network)
+			}
+			if strings.Join(endpoint.Aliases, ",") != strings.Join(tt.expectAliases, ",") {
+				t.Errorf("Aliases = %v, want %v", endpoint.Aliases, tt.expectAliases)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkFlags(t *testing.T) {
+	if err := validateNetworkFlags("", []string{"api"}); err == nil {
+		t.Error("expected an error for --network-alias without --network")
+	}
+	if err := validateNetworkFlags("devnet", []string{"api"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateNetworkFlags("", nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNetworkConflict(t *testing.T) {
+	tests := []struct {
+		name         string
+		net          network.Inspect
+		wantConflict bool
+	}{
+		{name: "local bridge", net: network.Inspect{Scope: "local", Driver: "bridge"}, wantConflict: false},
+		{name: "attachable overlay", net: network.Inspect{Scope: "swarm", Driver: "overlay", Attachable: true}, wantConflict: false},
+		{name: "non-attachable overlay", net: network.Inspect{Scope: "swarm", Driver: "overlay"}, wantConflict: true},
+		{name: "ingress", net: network.Inspect{Scope: "swarm", Driver: "overlay", Attachable: true, Ingress: true}, wantConflict: true},
+		{name: "config-only", net: network.Inspect{Scope: "local", Driver: "null", ConfigOnly: true}, wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := networkConflict(tt.net) != ""; got != tt.wantConflict {
+				t.Errorf("networkConflict() conflict = %t, want %t", got, tt.wantConflict)
+			}
+		})
+	}
+}
+
+func TestPrepareNetwork(t *testing.T) {
+	swarmOnly := network.Inspect{ID: "net1", Name: "devnet", Scope: "swarm", Driver: "overlay"}
+	swarmInUse := swarmOnly
+	swarmInUse.Containers = map[string]network.EndpointResource{"c1": {Name: "other"}}
+
+	tests := []struct {
+		name        string
+		net         *network.Inspect
+		recreate    bool
+		wantErr     string
+		wantRemoved []string
+		wantCreated []string
+	}{
+		{name: "missing network is left to container creation", net: nil},
+		{name: "joinable network", net: &network.Inspect{ID: "net1", Scope: "local", Driver: "bridge"}, recreate: true},
+		{name: "conflict without the flag", net: &swarmOnly, wantErr: "--recreate-network-on-conflict"},
+		{name: "conflict recreated", net: &swarmOnly, recreate: true, wantRemoved: []string{"net1"}, wantCreated: []string{"devnet/bridge"}},
+		{name: "conflict in use", net: &swarmInUse, recreate: true, wantErr: "1 container(s) still use it"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &mockNetworkClient{net: tt.net}
+			err := prepareNetwork(context.Background(), cli, "devnet", tt.recreate)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("prepareNetwork() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("prepareNetwork() error = %v", err)
+			}
+			if !reflect.DeepEqual(cli.removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", cli.removed, tt.wantRemoved)
+			}
+			if !reflect.DeepEqual(cli.created, tt.wantCreated) {
+				t.Errorf("created = %v, want %v", cli.created, tt.wantCreated)
+			}
+		})
+	}
+}
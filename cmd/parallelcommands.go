@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// prefixWriter writes every line written to it to w with prefix in front.
+// prefixWriters sharing mu never interleave within a line.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// Flush writes out a last line that did not end in a newline.
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.writeLine(line)
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+	return err
+}
+
+// describeCommands renders commands for the debug log.
+func describeCommands(commands []devcontainer.NamedCommand) string {
+	var parts []string
+	for _, command := range commands {
+		if command.Name == "" {
+			parts = append(parts, strings.Join(command.Args, " "))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("[%s] %s", command.Name, strings.Join(command.Args, " ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// runNamedCommands runs commands with run. A single unnamed command writes
+// straight to stdout and stderr; named commands run in parallel, each line
+// of their output prefixed with [name]. All of them run to completion, and
+// the error names every command that failed.
+func runNamedCommands(commands []devcontainer.NamedCommand, stdout, stderr io.Writer, run func(args []string, stdout, stderr io.Writer) error) error {
+	if len(commands) == 1 && commands[0].Name == "" {
+		return run(commands[0].Args, stdout, stderr)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(commands))
+	for i, command := range commands {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prefix := "[" + command.Name + "] "
+			out := &prefixWriter{w: stdout, mu: &mu, prefix: prefix}
+			errOut := &prefixWriter{w: stderr, mu: &mu, prefix: prefix}
+			err := run(command.Args, out, errOut)
+			_ = out.Flush()
+			_ = errOut.Flush()
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", command.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
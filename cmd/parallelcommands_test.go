@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestRunNamedCommands(t *testing.T) {
+	// run echoes the command's args as two writes and fails for "false".
+	run := func(args []string, stdout, stderr io.Writer) error {
+		fmt.Fprint(stdout, "out ")
+		fmt.Fprint(stdout, strings.Join(args, " ")+"\nlast")
+		if args[0] == "false" {
+			fmt.Fprintln(stderr, "boom")
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		commands   []devcontainer.NamedCommand
+		wantStdout []string
+		wantStderr []string
+		wantErr    string
+	}{
+		{
+			name:       "single unnamed command",
+			commands:   []devcontainer.NamedCommand{{Args: []string{"make"}}},
+			wantStdout: []string{"last", "out make"},
+		},
+		{
+			name: "named commands are prefixed",
+			commands: []devcontainer.NamedCommand{
+				{Name: "server", Args: []string{"npm", "start"}},
+				{Name: "watch", Args: []string{"npm", "run", "watch"}},
+			},
+			wantStdout: []string{"[server] last", "[server] out npm start", "[watch] last", "[watch] out npm run watch"},
+		},
+		{
+			name: "failing command is reported",
+			commands: []devcontainer.NamedCommand{
+				{Name: "check", Args: []string{"false"}},
+				{Name: "server", Args: []string{"npm", "start"}},
+			},
+			wantStdout: []string{"[check] last", "[check] out false", "[server] last", "[server] out npm start"},
+			wantStderr: []string{"[check] boom"},
+			wantErr:    "check: exit status 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := runNamedCommands(tt.commands, &stdout, &stderr, run)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("runNamedCommands() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("runNamedCommands() error = %v, want %q", err, tt.wantErr)
+			}
+			if got := sortedLines(stdout.String()); strings.Join(got, "|") != strings.Join(tt.wantStdout, "|") {
+				t.Errorf("stdout lines = %q, want %q", got, tt.wantStdout)
+			}
+			if got := sortedLines(stderr.String()); strings.Join(got, "|") != strings.Join(tt.wantStderr, "|") {
+				t.Errorf("stderr lines = %q, want %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
+
+// sortedLines splits output into lines in a stable order, since parallel
+// commands interleave.
+func sortedLines(output string) []string {
+	lines := strings.FieldsFunc(output, func(r rune) bool { return r == '\n' })
+	sort.Strings(lines)
+	return lines
+}
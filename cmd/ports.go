@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// privilegedPortLimit is the first port that an unprivileged user can bind.
+const privilegedPortLimit = 1024
+
+// portPublishDecision describes how one forwarded port is published.
+type portPublishDecision struct {
+	ContainerPort int
+	// HostPort is the host side of the binding. 0 lets Docker pick a free
+	// port, which is the fallback when the requested one is taken.
+	HostPort int
+	// Warnings are messages for the user, empty when there is nothing to say.
+	Warnings []string
+}
+
+// portAttributesFor returns the portsAttributes entry for a port, or the zero
+// value when the configuration has none.
+func portAttributesFor(devContainer *devcontainer.DevContainer, port int) devcontainer.PortAttributes {
+	return devContainer.PortsAttributes[strconv.Itoa(port)]
+}
+
+// decidePortPublish picks the host port for a forwarded port. When hostPort
+// is free it is used as is. When it is taken the port is remapped to a
+// Docker-assigned one, unless requireLocalPort is set, which is an error.
+// elevateIfNeeded on a privileged port only produces a warning for non-root
+// users; devgo never elevates by itself. checkFree reports why a host port
+// cannot be bound (checkPortFree in production).
+func decidePortPublish(containerPort, hostPort int, attrs devcontainer.PortAttributes, checkFree func(int) error, isRoot bool) (portPublishDecision, error) {
+	decision := portPublishDecision{ContainerPort: containerPort, HostPort: hostPort}
+
+	if attrs.ElevateIfNeeded && hostPort < privilegedPortLimit && !isRoot {
+		decision.Warnings = append(decision.Warnings, fmt.Sprintf("port %d is privileged and elevateIfNeeded is set; binding it may require root or CAP_NET_BIND_SERVICE", hostPort))
+	}
+
+	if err := checkFree(hostPort); err != nil {
+		if attrs.RequireLocalPort {
+			return portPublishDecision{}, fmt.Errorf("requireLocalPort is set for port %d: %w", hostPort, err)
+		}
+		decision.HostPort = 0
+		decision.Warnings = append(decision.Warnings, fmt.Sprintf("host port %d is not available, publishing container port %d on a random host port", hostPort, containerPort))
+	}
+
+	return decision, nil
+}
+
+// forwardPort is one forwardPorts entry: a port of the dev container, given
+// as a number (3000) or "localhost:3000", or a port of another host the
+// container can reach, such as a compose service ("db:5432").
+type forwardPort struct {
+	Host string
+	Port int
+}
+
+// parseForwardPort parses a forwardPorts entry as decoded from JSON.
+func parseForwardPort(entry interface{}) (forwardPort, error) {
+	var p forwardPort
+	switch v := entry.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return forwardPort{}, fmt.Errorf("invalid forwardPorts entry %v: not a whole number", v)
+		}
+		p.Port = int(v)
+	case string:
+		portStr := v
+		if idx := strings.LastIndex(v, ":"); idx >= 0 {
+			p.Host, portStr = v[:idx], v[idx+1:]
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return forwardPort{}, fmt.Errorf("invalid forwardPorts entry %q: want PORT or HOST:PORT", v)
+		}
+		p.Port = port
+	default:
+		return forwardPort{}, fmt.Errorf("invalid forwardPorts entry %v: want a number or a \"host:port\" string", entry)
+	}
+	if p.Port < 1 || p.Port > 65535 {
+		return forwardPort{}, fmt.Errorf("invalid forwardPorts entry %v: port out of range", entry)
+	}
+	return p, nil
+}
+
+// isLocal reports whether the entry names a port of the dev container itself.
+func (p forwardPort) isLocal() bool {
+	return p.Host == "" || p.Host == "localhost" || p.Host == "127.0.0.1"
+}
+
+// appPort is an entry of the legacy appPort property, published like
+// `docker run -p`.
+type appPort struct {
+	// HostIP is the host address to bind, empty for the loopback interface.
+	HostIP        string
+	HostPort      int
+	ContainerPort int
+}
+
+// parseAppPort parses an appPort entry as decoded from JSON: a port number
+// (3000) or a "PORT", "HOST:CONTAINER" or "IP:HOST:CONTAINER" string.
+func parseAppPort(entry interface{}) (appPort, error) {
+	var fields []string
+	switch v := entry.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return appPort{}, fmt.Errorf("invalid appPort entry %v: not a whole number", v)
+		}
+		fields = []string{strconv.Itoa(int(v))}
+	case string:
+		fields = strings.Split(v, ":")
+	default:
+		return appPort{}, fmt.Errorf("invalid appPort entry %v: want a number or a \"host:container\" string", entry)
+	}
+	if len(fields) > 3 {
+		return appPort{}, fmt.Errorf("invalid appPort entry %v: want PORT, HOST:CONTAINER or IP:HOST:CONTAINER", entry)
+	}
+
+	var p appPort
+	if len(fields) == 3 {
+		p.HostIP = fields[0]
+		fields = fields[1:]
+	}
+	ports := make([]int, len(fields))
+	for i, field := range fields {
+		port, err := strconv.Atoi(field)
+		if err != nil || port < 1 || port > 65535 {
+			return appPort{}, fmt.Errorf("invalid appPort entry %v: %q is not a port", entry, field)
+		}
+		ports[i] = port
+	}
+	p.HostPort, p.ContainerPort = ports[0], ports[len(ports)-1]
+	return p, nil
+}
+
+// publishedPort is a port of the dev container as published on the host at
+// container creation.
+type publishedPort struct {
+	ContainerPort int
+	// HostPort is 0 when Docker picks the host port.
+	HostPort int
+	// HostIP is the host address the port is bound to; empty means the
+	// loopback interface.
+	HostIP string
+	// Label is the portsAttributes label, shown when forwarding starts.
+	Label string
+}
+
+// planPublishedPorts decides how the appPort and forwardPorts entries of
+// devContainer are published. appPort comes first since it may name a
+// different host port; a container port already published is skipped.
+// forwardPorts of other hosts cannot be published on the dev container and
+// are left to `devgo forward-ports`.
+func planPublishedPorts(devContainer *devcontainer.DevContainer, checkFree func(int) error, isRoot bool) ([]publishedPort, error) {
+	var ports []publishedPort
+	seen := make(map[int]bool)
+	for _, entry := range devContainer.GetAppPorts() {
+		p, err := parseAppPort(entry)
+		if err != nil {
+			return nil, err
+		}
+		if seen[p.ContainerPort] {
+			continue
+		}
+		seen[p.ContainerPort] = true
+
+		attrs := portAttributesFor(devContainer, p.ContainerPort)
+		decision, err := decidePortPublish(p.ContainerPort, p.HostPort, attrs, checkFree, isRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, warning := range decision.Warnings {
+			warnf("%s", warning)
+		}
+		ports = append(ports, publishedPort{ContainerPort: p.ContainerPort, HostPort: decision.HostPort, HostIP: p.HostIP, Label: attrs.Label})
+	}
+	for _, entry := range devContainer.ForwardPorts {
+		p, err := parseForwardPort(entry)
+		if err != nil {
+			return nil, err
+		}
+		if !p.isLocal() {
+			warnf("forwardPorts: %s:%d is not a port of the dev container; run 'devgo forward-ports' to forward it", p.Host, p.Port)
+			continue
+		}
+		if seen[p.Port] {
+			continue
+		}
+		seen[p.Port] = true
+
+		attrs := portAttributesFor(devContainer, p.Port)
+		decision, err := decidePortPublish(p.Port, p.Port, attrs, checkFree, isRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, warning := range decision.Warnings {
+			warnf("%s", warning)
+		}
+		ports = append(ports, publishedPort{ContainerPort: p.Port, HostPort: decision.HostPort, Label: attrs.Label})
+	}
+	return ports, nil
+}
+
+// portBindings returns the exposed ports and host bindings that publish
+// ports on 127.0.0.1 unless they name another address, so forwarded ports
+// are not reachable from other hosts.
+func portBindings(ports []publishedPort) (nat.PortSet, nat.PortMap) {
+	if len(ports) == 0 {
+		return nil, nil
+	}
+	exposed := make(nat.PortSet, len(ports))
+	bindings := make(nat.PortMap, len(ports))
+	for _, p := range ports {
+		port := nat.Port(fmt.Sprintf("%d/tcp", p.ContainerPort))
+		exposed[port] = struct{}{}
+		hostPort := ""
+		if p.HostPort != 0 {
+			hostPort = strconv.Itoa(p.HostPort)
+		}
+		hostIP := p.HostIP
+		if hostIP == "" {
+			hostIP = "127.0.0.1"
+		}
+		bindings[port] = []nat.PortBinding{{HostIP: hostIP, HostPort: hostPort}}
+	}
+	return exposed, bindings
+}
+
+// describeForwardedPorts returns one line per published port with the host
+// address Docker actually bound, which differs from the plan for remapped
+// ports, and the portsAttributes label when there is one.
+func describeForwardedPorts(ports []publishedPort, bound nat.PortMap) []string {
+	var lines []string
+	for _, p := range ports {
+		address := ""
+		for _, binding := range bound[nat.Port(fmt.Sprintf("%d/tcp", p.ContainerPort))] {
+			address = fmt.Sprintf("%s:%s", binding.HostIP, binding.HostPort)
+			break
+		}
+		if address == "" {
+			continue
+		}
+		name := strconv.Itoa(p.ContainerPort)
+		if p.Label != "" {
+			name = fmt.Sprintf("%s (%s)", name, p.Label)
+		}
+		lines = append(lines, fmt.Sprintf("Forwarding port %s to %s", name, address))
+	}
+	return lines
+}
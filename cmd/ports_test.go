@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestDecidePortPublish(t *testing.T) {
+	free := func(int) error { return nil }
+	taken := func(int) error { return errors.New("address already in use") }
+
+	tests := []struct {
+		name           string
+		hostPort       int
+		attrs          devcontainer.PortAttributes
+		checkFree      func(int) error
+		isRoot         bool
+		expectHostPort int
+		expectWarnings int
+		expectError    bool
+	}{
+		{
+			name:           "free port is published as is",
+			hostPort:       3000,
+			checkFree:      free,
+			expectHostPort: 3000,
+		},
+		{
+			name:           "taken port is remapped",
+			hostPort:       3000,
+			checkFree:      taken,
+			expectHostPort: 0,
+			expectWarnings: 1,
+		},
+		{
+			name:        "taken port with requireLocalPort fails",
+			hostPort:    3000,
+			attrs:       devcontainer.PortAttributes{RequireLocalPort: true},
+			checkFree:   taken,
+			expectError: true,
+		},
+		{
+			name:           "privileged port with elevateIfNeeded warns for non-root",
+			hostPort:       80,
+			attrs:          devcontainer.PortAttributes{ElevateIfNeeded: true},
+			checkFree:      free,
+			expectHostPort: 80,
+			expectWarnings: 1,
+		},
+		{
+			name:           "privileged port with elevateIfNeeded is silent for root",
+			hostPort:       80,
+			attrs:          devcontainer.PortAttributes{ElevateIfNeeded: true},
+			checkFree:      free,
+			isRoot:         true,
+			expectHostPort: 80,
+		},
+		{
+			name:           "taken privileged port with elevateIfNeeded keeps both warnings",
+			hostPort:       80,
+			attrs:          devcontainer.PortAttributes{ElevateIfNeeded: true},
+			checkFree:      taken,
+			expectHostPort: 0,
+			expectWarnings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := decidePortPublish(tt.hostPort, tt.hostPort, tt.attrs, tt.checkFree, tt.isRoot)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decision.HostPort != tt.expectHostPort {
+				t.Errorf("HostPort = %d, want %d", decision.HostPort, tt.expectHostPort)
+			}
+			if len(decision.Warnings) != tt.expectWarnings {
+				t.Errorf("Warnings = %q, want %d", decision.Warnings, tt.expectWarnings)
+			}
+		})
+	}
+}
+
+func TestParseForwardPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   interface{}
+		want    forwardPort
+		wantErr bool
+	}{
+		{name: "number", entry: float64(3000), want: forwardPort{Port: 3000}},
+		{name: "localhost string", entry: "localhost:8080", want: forwardPort{Host: "localhost", Port: 8080}},
+		{name: "other host", entry: "db:5432", want: forwardPort{Host: "db", Port: 5432}},
+		{name: "bare port string", entry: "9000", want: forwardPort{Port: 9000}},
+		{name: "not a port", entry: "db:postgres", wantErr: true},
+		{name: "out of range", entry: float64(70000), wantErr: true},
+		{name: "fraction", entry: 3000.5, wantErr: true},
+		{name: "wrong type", entry: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseForwardPort(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseForwardPort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseForwardPort() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAppPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   interface{}
+		want    appPort
+		wantErr bool
+	}{
+		{name: "number", entry: float64(3000), want: appPort{HostPort: 3000, ContainerPort: 3000}},
+		{name: "bare port string", entry: "9000", want: appPort{HostPort: 9000, ContainerPort: 9000}},
+		{name: "host and container", entry: "8080:80", want: appPort{HostPort: 8080, ContainerPort: 80}},
+		{name: "with address", entry: "0.0.0.0:8080:80", want: appPort{HostIP: "0.0.0.0", HostPort: 8080, ContainerPort: 80}},
+		{name: "not a port", entry: "8080:http", wantErr: true},
+		{name: "too many fields", entry: "a:1:2:3", wantErr: true},
+		{name: "fraction", entry: 3000.5, wantErr: true},
+		{name: "wrong type", entry: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAppPort(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAppPort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAppPort() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanPublishedPorts_AppPort(t *testing.T) {
+	dc := &devcontainer.DevContainer{
+		AppPort:      []interface{}{"8000:80", "0.0.0.0:9229:9229"},
+		ForwardPorts: []interface{}{float64(80), float64(3000)},
+	}
+	got, err := planPublishedPorts(dc, func(int) error { return nil }, false)
+	if err != nil {
+		t.Fatalf("planPublishedPorts() error = %v", err)
+	}
+	want := []publishedPort{
+		{ContainerPort: 80, HostPort: 8000},
+		{ContainerPort: 9229, HostPort: 9229, HostIP: "0.0.0.0"},
+		{ContainerPort: 3000, HostPort: 3000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planPublishedPorts() = %+v, want %+v", got, want)
+	}
+
+	single := &devcontainer.DevContainer{AppPort: float64(3000)}
+	if got, err := planPublishedPorts(single, func(int) error { return nil }, false); err != nil || len(got) != 1 || got[0].HostPort != 3000 {
+		t.Errorf("planPublishedPorts() with a single appPort = %+v, %v", got, err)
+	}
+}
+
+func TestPlanPublishedPorts(t *testing.T) {
+	dc := &devcontainer.DevContainer{
+		ForwardPorts: []interface{}{float64(3000), "localhost:8080", "db:5432", float64(3000)},
+		PortsAttributes: map[string]devcontainer.PortAttributes{
+			"3000": {Label: "Frontend"},
+			"8080": {RequireLocalPort: true},
+		},
+	}
+	takenPort := 0
+	checkFree := func(port int) error {
+		if port == takenPort {
+			return errors.New("address already in use")
+		}
+		return nil
+	}
+
+	got, err := planPublishedPorts(dc, checkFree, false)
+	if err != nil {
+		t.Fatalf("planPublishedPorts() error = %v", err)
+	}
+	want := []publishedPort{
+		{ContainerPort: 3000, HostPort: 3000, Label: "Frontend"},
+		{ContainerPort: 8080, HostPort: 8080},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planPublishedPorts() = %+v, want %+v", got, want)
+	}
+
+	takenPort = 3000
+	got, err = planPublishedPorts(dc, checkFree, false)
+	if err != nil {
+		t.Fatalf("planPublishedPorts() error = %v", err)
+	}
+	if got[0].HostPort != 0 {
+		t.Errorf("taken port 3000 published on %d, want a Docker-assigned port", got[0].HostPort)
+	}
+
+	takenPort = 8080
+	if _, err := planPublishedPorts(dc, checkFree, false); err == nil {
+		t.Error("expected an error for a taken port with requireLocalPort")
+	}
+}
+
+func TestPortBindings(t *testing.T) {
+	exposed, bindings := portBindings([]publishedPort{
+		{ContainerPort: 3000, HostPort: 3000},
+		{ContainerPort: 5000},
+		{ContainerPort: 80, HostPort: 8000, HostIP: "0.0.0.0"},
+	})
+
+	wantExposed := nat.PortSet{"3000/tcp": {}, "5000/tcp": {}, "80/tcp": {}}
+	if !reflect.DeepEqual(exposed, wantExposed) {
+		t.Errorf("exposed = %v, want %v", exposed, wantExposed)
+	}
+	wantBindings := nat.PortMap{
+		"3000/tcp": {{HostIP: "127.0.0.1", HostPort: "3000"}},
+		"5000/tcp": {{HostIP: "127.0.0.1", HostPort: ""}},
+		"80/tcp":   {{HostIP: "0.0.0.0", HostPort: "8000"}},
+	}
+	if !reflect.DeepEqual(bindings, wantBindings) {
+		t.Errorf("bindings = %v, want %v", bindings, wantBindings)
+	}
+
+	if exposed, bindings := portBindings(nil); exposed != nil || bindings != nil {
+		t.Error("no ports should leave the bindings unset")
+	}
+}
+
+func TestDescribeForwardedPorts(t *testing.T) {
+	ports := []publishedPort{
+		{ContainerPort: 3000, HostPort: 3000, Label: "Frontend"},
+		{ContainerPort: 5000},
+		{ContainerPort: 9000, HostPort: 9000},
+	}
+	bound := nat.PortMap{
+		"3000/tcp": {{HostIP: "127.0.0.1", HostPort: "3000"}},
+		"5000/tcp": {{HostIP: "127.0.0.1", HostPort: "49153"}},
+	}
+
+	got := describeForwardedPorts(ports, bound)
+	want := []string{
+		"Forwarding port 3000 (Frontend) to 127.0.0.1:3000",
+		"Forwarding port 5000 to 127.0.0.1:49153",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("describeForwardedPorts() = %q, want %q", got, want)
+	}
+}
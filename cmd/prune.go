@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/garaemon/devgo/pkg/constants"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+	"golang.org/x/term"
+)
+
+// PruneDockerClient interface for prune command Docker operations
+type PruneDockerClient interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error)
+	ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error)
+	Close() error
+}
+
+// prunePlan lists what `devgo prune` removes: stopped containers, containers
+// whose workspace directory is gone (Orphaned, running ones only with
+// --include-running) and dangling images built by devgo.
+type prunePlan struct {
+	Stopped  []container.Summary
+	Orphaned []container.Summary
+	Images   []image.Summary
+}
+
+func (p prunePlan) empty() bool {
+	return len(p.Stopped) == 0 && len(p.Orphaned) == 0 && len(p.Images) == 0
+}
+
+func runPruneCommand(args []string) error {
+	cli, err := newEngineClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+	}()
+
+	ctx := context.Background()
+	exists := workspaceExists
+	if !engineIsLocal(currentRuntime(), os.Getenv("DOCKER_HOST"), runtime.GOOS) {
+		debugf("The engine is not local; not checking for orphaned containers\n")
+		exists = nil
+	}
+	plan, err := planPrune(ctx, cli, pruneUntil, time.Now(), exists, pruneRunning)
+	if err != nil || plan.empty() {
+		return err
+	}
+	if !force {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			printPrunePlan(os.Stderr, plan)
+			return fmt.Errorf("refusing to prune without confirmation: run in a terminal or pass --force")
+		}
+		if !confirmPrune(os.Stdin, os.Stderr, plan) {
+			return nil
+		}
+	}
+	return applyPrune(ctx, cli, plan)
+}
+
+// parsePruneAge parses an --until value: a Go duration such as 168h, or a
+// number of days such as 7d.
+func parsePruneAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// workspaceExists reports whether the workspace directory recorded on a
+// container is still on the host. It only makes sense when engineIsLocal.
+func workspaceExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// engineIsLocal reports whether the engine shares this machine's filesystem,
+// so the workspace labels of its containers can be checked with os.Stat:
+// DOCKER_HOST is unset or a unix socket, and runtime is not Podman outside
+// Linux, where it runs in a Podman machine.
+func engineIsLocal(runtime devgoruntime.Runtime, dockerHost, goos string) bool {
+	if dockerHost != "" && !strings.HasPrefix(dockerHost, "unix://") {
+		return false
+	}
+	return runtime != devgoruntime.Podman || goos == "linux"
+}
+
+// planPrune collects the devgo containers and images to remove. until and
+// now limit stopped containers as in selectPruneCandidates; exists reports
+// whether a workspace directory is still on the host, and a nil exists skips
+// orphaned containers. Running orphans are only kept with includeRunning.
+func planPrune(ctx context.Context, cli PruneDockerClient, until time.Duration, now time.Time, exists func(string) bool, includeRunning bool) (prunePlan, error) {
+	filter := filters.NewArgs()
+	filter.Add("label", managedLabelFilter())
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filter,
+	})
+	if err != nil {
+		return prunePlan{}, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var plan prunePlan
+	plan.Stopped = selectPruneCandidates(containers, until, now)
+	if exists != nil {
+		plan.Orphaned = selectOrphanedContainers(containers, plan.Stopped, exists, includeRunning)
+	}
+
+	imageFilter := filters.NewArgs()
+	imageFilter.Add("dangling", "true")
+	imageFilter.Add("label", devgoLabel(constants.DevgoBuildHashLabel))
+	plan.Images, err = cli.ImageList(ctx, image.ListOptions{Filters: imageFilter})
+	if err != nil {
+		return prunePlan{}, fmt.Errorf("failed to list images: %w", err)
+	}
+	return plan, nil
+}
+
+// selectPruneCandidates returns the stopped containers that prune removes.
+// With a positive until, containers created less than until before now are
+// kept.
+func selectPruneCandidates(containers []container.Summary, until time.Duration, now time.Time) []container.Summary {
+	var candidates []container.Summary
+	for _, c := range containers {
+		if c.State == "running" {
+			continue
+		}
+		if until > 0 && now.Sub(time.Unix(c.Created, 0)) < until {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
+
+// selectOrphanedContainers returns the containers, other than those already
+// in selected, whose workspace label names a directory that exists reports
+// missing. Running containers are skipped unless includeRunning is set.
+func selectOrphanedContainers(containers, selected []container.Summary, exists func(string) bool, includeRunning bool) []container.Summary {
+	chosen := make(map[string]bool, len(selected))
+	for _, c := range selected {
+		chosen[c.ID] = true
+	}
+	var orphaned []container.Summary
+	for _, c := range containers {
+		workspace := c.Labels[devgoLabel(constants.DevgoWorkspaceLabel)]
+		if chosen[c.ID] || workspace == "" || (c.State == "running" && !includeRunning) || exists(workspace) {
+			continue
+		}
+		orphaned = append(orphaned, c)
+	}
+	return orphaned
+}
+
+// pruneContainerName returns the name Docker shows for c, or its ID.
+func pruneContainerName(c container.Summary) string {
+	if len(c.Names) > 0 {
+		return strings.TrimPrefix(c.Names[0], "/")
+	}
+	return c.ID
+}
+
+// printPrunePlan writes one line per resource in plan to w.
+func printPrunePlan(w io.Writer, plan prunePlan) {
+	for _, c := range plan.Stopped {
+		fmt.Fprintf(w, "container %s (%s)\n", pruneContainerName(c), c.State)
+	}
+	for _, c := range plan.Orphaned {
+		fmt.Fprintf(w, "container %s (workspace %s no longer exists)\n", pruneContainerName(c), c.Labels[devgoLabel(constants.DevgoWorkspaceLabel)])
+	}
+	for _, img := range plan.Images {
+		fmt.Fprintf(w, "image %s (dangling)\n", img.ID)
+	}
+}
+
+// confirmPrune shows plan on out and reads a yes/no answer from in. Anything
+// but y or yes declines.
+func confirmPrune(in io.Reader, out io.Writer, plan prunePlan) bool {
+	printPrunePlan(out, plan)
+	fmt.Fprintf(out, "Remove %d container(s) and %d image(s)? [y/N] ", len(plan.Stopped)+len(plan.Orphaned), len(plan.Images))
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// applyPrune removes everything in plan, containers before the images they
+// may hold, and prints the name of each removed container and the ID of
+// each removed image to stdout.
+func applyPrune(ctx context.Context, cli PruneDockerClient, plan prunePlan) error {
+	for _, c := range plan.Stopped {
+		if err := removePruneContainer(ctx, cli, c, false); err != nil {
+			return err
+		}
+	}
+	for _, c := range plan.Orphaned {
+		if err := removePruneContainer(ctx, cli, c, c.State == "running"); err != nil {
+			return err
+		}
+	}
+	for _, img := range plan.Images {
+		debugf("Removing image '%s'\n", img.ID)
+		if _, err := cli.ImageRemove(ctx, img.ID, image.RemoveOptions{PruneChildren: true}); err != nil {
+			return fmt.Errorf("failed to remove image '%s': %w", img.ID, err)
+		}
+		fmt.Println(img.ID)
+	}
+	return nil
+}
+
+// removePruneContainer removes c, killing it first when forceRemove is set.
+func removePruneContainer(ctx context.Context, cli PruneDockerClient, c container.Summary, forceRemove bool) error {
+	name := pruneContainerName(c)
+	debugf("Removing container '%s'\n", name)
+	if err := cli.ContainerRemove(ctx, c.ID, container.RemoveOptions{Force: forceRemove}); err != nil {
+		return fmt.Errorf("failed to remove container '%s': %w", name, err)
+	}
+	fmt.Println(name)
+	return nil
+}
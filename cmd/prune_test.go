@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/garaemon/devgo/pkg/constants"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+type fakePruneClient struct {
+	containers    []container.Summary
+	images        []image.Summary
+	imageOptions  image.ListOptions
+	removed       []string
+	forceRemoved  []string
+	removedImages []string
+}
+
+func (f *fakePruneClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	return f.containers, nil
+}
+
+func (f *fakePruneClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	if options.Force {
+		f.forceRemoved = append(f.forceRemoved, containerID)
+	} else {
+		f.removed = append(f.removed, containerID)
+	}
+	return nil
+}
+
+func (f *fakePruneClient) ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error) {
+	f.imageOptions = options
+	return f.images, nil
+}
+
+func (f *fakePruneClient) ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error) {
+	f.removedImages = append(f.removedImages, imageID)
+	return nil, nil
+}
+
+func (f *fakePruneClient) Close() error {
+	return nil
+}
+
+func TestSelectPruneCandidates(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	containers := []container.Summary{
+		{ID: "old-stopped", State: "exited", Created: now.Add(-200 * time.Hour).Unix()},
+		{ID: "new-stopped", State: "exited", Created: now.Add(-2 * time.Hour).Unix()},
+		{ID: "old-running", State: "running", Created: now.Add(-200 * time.Hour).Unix()},
+	}
+
+	tests := []struct {
+		name     string
+		until    time.Duration
+		expected []string
+	}{
+		{name: "no age filter", until: 0, expected: []string{"old-stopped", "new-stopped"}},
+		{name: "older than a week", until: 168 * time.Hour, expected: []string{"old-stopped"}},
+		{name: "threshold newer than everything", until: time.Hour, expected: []string{"old-stopped", "new-stopped"}},
+		{name: "threshold older than everything", until: 1000 * time.Hour, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectPruneCandidates(containers, tt.until, now)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("selected %d containers, want %v", len(got), tt.expected)
+			}
+			for i, c := range got {
+				if c.ID != tt.expected[i] {
+					t.Errorf("candidate %d = %s, want %s", i, c.ID, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlanPrune(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	workspace := devgoLabel(constants.DevgoWorkspaceLabel)
+	containers := []container.Summary{
+		{ID: "stopped", State: "exited", Labels: map[string]string{workspace: "/gone"}},
+		{ID: "orphan", State: "running", Labels: map[string]string{workspace: "/gone"}},
+		{ID: "alive", State: "running", Labels: map[string]string{workspace: "/src/app"}},
+		{ID: "unlabeled", State: "running"},
+	}
+	exists := func(path string) bool { return path == "/src/app" }
+
+	tests := []struct {
+		name             string
+		exists           func(string) bool
+		includeRunning   bool
+		wantOrphaned     []string
+		wantForceRemoved []string
+	}{
+		{name: "running orphans kept by default", exists: exists},
+		{name: "running orphans with --include-running", exists: exists, includeRunning: true, wantOrphaned: []string{"orphan"}, wantForceRemoved: []string{"orphan"}},
+		{name: "remote engine skips orphans", exists: nil, includeRunning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &fakePruneClient{containers: containers, images: []image.Summary{{ID: "sha256:old"}}}
+			plan, err := planPrune(context.Background(), cli, 0, now, tt.exists, tt.includeRunning)
+			if err != nil {
+				t.Fatalf("planPrune() error = %v", err)
+			}
+			if len(plan.Stopped) != 1 || plan.Stopped[0].ID != "stopped" {
+				t.Errorf("Stopped = %+v, want [stopped]", plan.Stopped)
+			}
+			var orphaned []string
+			for _, c := range plan.Orphaned {
+				orphaned = append(orphaned, c.ID)
+			}
+			if !reflect.DeepEqual(orphaned, tt.wantOrphaned) {
+				t.Errorf("Orphaned = %v, want %v", orphaned, tt.wantOrphaned)
+			}
+			if len(plan.Images) != 1 {
+				t.Errorf("Images = %+v, want the dangling image", plan.Images)
+			}
+			if !cli.imageOptions.Filters.ExactMatch("dangling", "true") || !cli.imageOptions.Filters.Contains("label") {
+				t.Errorf("image filters = %v, want dangling devgo-built images", cli.imageOptions.Filters)
+			}
+
+			if err := applyPrune(context.Background(), cli, plan); err != nil {
+				t.Fatalf("applyPrune() error = %v", err)
+			}
+			if !reflect.DeepEqual(cli.removed, []string{"stopped"}) || !reflect.DeepEqual(cli.forceRemoved, tt.wantForceRemoved) {
+				t.Errorf("removed %v, force removed %v, want [stopped] and %v", cli.removed, cli.forceRemoved, tt.wantForceRemoved)
+			}
+			if !reflect.DeepEqual(cli.removedImages, []string{"sha256:old"}) {
+				t.Errorf("removed images %v, want [sha256:old]", cli.removedImages)
+			}
+		})
+	}
+}
+
+func TestApplyPrune_StoppedOrphanNotForced(t *testing.T) {
+	cli := &fakePruneClient{}
+	plan := prunePlan{Orphaned: []container.Summary{{ID: "orphan", State: "exited"}}}
+	if err := applyPrune(context.Background(), cli, plan); err != nil {
+		t.Fatalf("applyPrune() error = %v", err)
+	}
+	if !reflect.DeepEqual(cli.removed, []string{"orphan"}) || cli.forceRemoved != nil {
+		t.Errorf("removed %v, force removed %v, want only a plain removal", cli.removed, cli.forceRemoved)
+	}
+}
+
+func TestEngineIsLocal(t *testing.T) {
+	tests := []struct {
+		name       string
+		runtime    devgoruntime.Runtime
+		dockerHost string
+		goos       string
+		want       bool
+	}{
+		{name: "default docker", runtime: devgoruntime.Docker, goos: "linux", want: true},
+		{name: "docker desktop", runtime: devgoruntime.Docker, goos: "darwin", want: true},
+		{name: "unix socket", runtime: devgoruntime.Docker, dockerHost: "unix:///var/run/docker.sock", goos: "linux", want: true},
+		{name: "tcp host", runtime: devgoruntime.Docker, dockerHost: "tcp://build:2376", goos: "linux", want: false},
+		{name: "ssh host", runtime: devgoruntime.Docker, dockerHost: "ssh://me@build", goos: "linux", want: false},
+		{name: "podman on linux", runtime: devgoruntime.Podman, goos: "linux", want: true},
+		{name: "podman machine", runtime: devgoruntime.Podman, goos: "darwin", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := engineIsLocal(tt.runtime, tt.dockerHost, tt.goos); got != tt.want {
+				t.Errorf("engineIsLocal(%v, %q, %q) = %t, want %t", tt.runtime, tt.dockerHost, tt.goos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmPrune(t *testing.T) {
+	plan := prunePlan{
+		Stopped: []container.Summary{{ID: "abc", Names: []string{"/devgo-app"}, State: "exited"}},
+		Images:  []image.Summary{{ID: "sha256:old"}},
+	}
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{answer: "y\n", want: true},
+		{answer: "YES\n", want: true},
+		{answer: "n\n", want: false},
+		{answer: "\n", want: false},
+		{answer: "", want: false},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := confirmPrune(strings.NewReader(tt.answer), &out, plan); got != tt.want {
+			t.Errorf("confirmPrune(%q) = %t, want %t", tt.answer, got, tt.want)
+		}
+		if !strings.Contains(out.String(), "container devgo-app (exited)") || !strings.Contains(out.String(), "1 container(s) and 1 image(s)") {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
+
+func TestParsePruneAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "168h", want: 168 * time.Hour},
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "0d", want: 0},
+		{value: "xd", wantErr: true},
+		{value: "-1d", wantErr: true},
+		{value: "week", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePruneAge(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePruneAge(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePruneAge(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -3,26 +3,137 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 
 	"github.com/garaemon/devgo/pkg/devcontainer"
 )
 
+// readConfigurationOutput is the JSON printed by read-configuration. It has
+// the shape of `devcontainer read-configuration` so tools written against
+// the devcontainer CLI can read it. FeaturesConfiguration is only filled
+// with --include-merged-features.
+type readConfigurationOutput struct {
+	Configuration         map[string]interface{}     `json:"configuration"`
+	Workspace             readConfigurationWorkspace `json:"workspace"`
+	FeaturesConfiguration *readConfigurationFeatures `json:"featuresConfiguration,omitempty"`
+}
+
+// readConfigurationWorkspace is where the workspace ends up in the
+// container. WorkspaceMount is empty for compose, where the compose files
+// mount it.
+type readConfigurationWorkspace struct {
+	WorkspaceFolder string `json:"workspaceFolder"`
+	WorkspaceMount  string `json:"workspaceMount,omitempty"`
+}
+
+// readConfigurationFeatures lists the features in resolved install order,
+// one feature set per feature as the devcontainer CLI does.
+type readConfigurationFeatures struct {
+	FeatureSets []readConfigurationFeatureSet `json:"featureSets"`
+}
+
+type readConfigurationFeatureSet struct {
+	Features          []readConfigurationFeature `json:"features"`
+	SourceInformation struct {
+		Type          string `json:"type"`
+		UserFeatureID string `json:"userFeatureId"`
+	} `json:"sourceInformation"`
+}
+
+type readConfigurationFeature struct {
+	ID       string                 `json:"id"`
+	Value    map[string]interface{} `json:"value"`
+	Included bool                   `json:"included"`
+}
+
+// configFilePath is the URI object the devcontainer CLI reports the
+// configuration file as.
+type configFilePath struct {
+	Mid    int    `json:"$mid"`
+	FsPath string `json:"fsPath"`
+	Path   string `json:"path"`
+	Scheme string `json:"scheme"`
+}
+
 func runReadConfigurationCommand(args []string) error {
 	devcontainerPath, err := findDevcontainerConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to find devcontainer config: %w", err)
 	}
 
-	devContainer, err := devcontainer.Parse(devcontainerPath)
+	resolved, err := resolveConfig(devcontainerPath, configOverrides)
 	if err != nil {
-		return fmt.Errorf("failed to parse devcontainer config: %w", err)
+		return err
 	}
 
-	jsonData, err := json.MarshalIndent(devContainer, "", "  ")
+	jsonData, err := marshalReadConfiguration(resolved.DevContainer, devcontainerPath, determineWorkspaceFolder(devcontainerPath), includeMergedFeatures)
 	if err != nil {
-		return fmt.Errorf("failed to marshal configuration to JSON: %w", err)
+		return err
 	}
 
 	fmt.Println(string(jsonData))
 	return nil
 }
+
+// marshalReadConfiguration renders the resolved configuration of
+// devcontainerPath with the defaults devgo applies filled in, adding the
+// features in resolved install order when includeFeatures is set. Object
+// keys are sorted, so the same configuration always prints the same JSON.
+func marshalReadConfiguration(devContainer *devcontainer.DevContainer, devcontainerPath, workspaceDir string, includeFeatures bool) ([]byte, error) {
+	configuration, err := readConfigurationFields(devContainer)
+	if err != nil {
+		return nil, err
+	}
+
+	absPath, err := filepath.Abs(devcontainerPath)
+	if err != nil {
+		absPath = devcontainerPath
+	}
+	configuration["configFilePath"] = configFilePath{Mid: 1, FsPath: absPath, Path: filepath.ToSlash(absPath), Scheme: "file"}
+	configuration["workspaceFolder"] = devContainer.GetWorkspaceFolder()
+	configuration["waitFor"] = devContainer.GetWaitFor()
+
+	output := readConfigurationOutput{
+		Configuration: configuration,
+		Workspace:     readConfigurationWorkspace{WorkspaceFolder: devContainer.GetWorkspaceFolder()},
+	}
+	if devContainer.HasDockerCompose() {
+		configuration["dockerComposeFile"] = composeFilePaths(devContainer.GetDockerComposeFiles(), workspaceDir)
+	} else if devContainer.WorkspaceMount != "" {
+		output.Workspace.WorkspaceMount = devContainer.WorkspaceMount
+	} else {
+		output.Workspace.WorkspaceMount = fmt.Sprintf("type=bind,source=%s,target=%s", workspaceDir, devContainer.GetWorkspaceFolder())
+	}
+
+	if includeFeatures {
+		output.FeaturesConfiguration = &readConfigurationFeatures{FeatureSets: []readConfigurationFeatureSet{}}
+		for _, feature := range devContainer.GetFeatures() {
+			set := readConfigurationFeatureSet{
+				Features: []readConfigurationFeature{{ID: feature.Ref, Value: feature.Options, Included: true}},
+			}
+			set.SourceInformation.Type = "oci"
+			set.SourceInformation.UserFeatureID = feature.Ref
+			output.FeaturesConfiguration.FeatureSets = append(output.FeaturesConfiguration.FeatureSets, set)
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration to JSON: %w", err)
+	}
+	return jsonData, nil
+}
+
+// readConfigurationFields turns devContainer into a JSON object so defaults
+// can be added next to the fields of the file.
+func readConfigurationFields(devContainer *devcontainer.DevContainer) (map[string]interface{}, error) {
+	data, err := json.Marshal(devContainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration to JSON: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to marshal configuration to JSON: %w", err)
+	}
+	return fields, nil
+}
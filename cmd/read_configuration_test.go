@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
 )
 
 func TestRunReadConfigurationCommand(t *testing.T) {
@@ -106,3 +110,121 @@ func TestReadConfigurationOutput(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestMarshalReadConfiguration(t *testing.T) {
+	workspaceDir := t.TempDir()
+	configFile := filepath.Join(workspaceDir, ".devcontainer", "devcontainer.json")
+
+	tests := []struct {
+		name               string
+		devContainer       *devcontainer.DevContainer
+		wantFields         map[string]interface{}
+		wantWorkspaceMount string
+	}{
+		{
+			name:         "image with defaults",
+			devContainer: &devcontainer.DevContainer{Image: "ubuntu:22.04"},
+			wantFields: map[string]interface{}{
+				"image":           "ubuntu:22.04",
+				"workspaceFolder": "/workspace",
+				"waitFor":         "updateContentCommand",
+			},
+			wantWorkspaceMount: "type=bind,source=" + workspaceDir + ",target=/workspace",
+		},
+		{
+			name: "compose files resolved",
+			devContainer: &devcontainer.DevContainer{
+				DockerComposeFile: []interface{}{"docker-compose.yml", "override.yml"},
+				Service:           "app",
+				WorkspaceFolder:   "/src",
+				WaitFor:           "postCreateCommand",
+			},
+			wantFields: map[string]interface{}{
+				"dockerComposeFile": []interface{}{
+					filepath.Join(workspaceDir, "docker-compose.yml"),
+					filepath.Join(workspaceDir, "override.yml"),
+				},
+				"workspaceFolder": "/src",
+				"waitFor":         "postCreateCommand",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := marshalReadConfiguration(tt.devContainer, configFile, workspaceDir, false)
+			if err != nil {
+				t.Fatalf("marshalReadConfiguration() error: %v", err)
+			}
+
+			var output struct {
+				Configuration map[string]interface{} `json:"configuration"`
+				Workspace     struct {
+					WorkspaceFolder string `json:"workspaceFolder"`
+					WorkspaceMount  string `json:"workspaceMount"`
+				} `json:"workspace"`
+				FeaturesConfiguration interface{} `json:"featuresConfiguration"`
+			}
+			if err := json.Unmarshal(data, &output); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+			for key, want := range tt.wantFields {
+				if got := output.Configuration[key]; !reflect.DeepEqual(got, want) {
+					t.Errorf("configuration.%s = %v, want %v", key, got, want)
+				}
+			}
+			configFilePath, _ := output.Configuration["configFilePath"].(map[string]interface{})
+			if configFilePath["fsPath"] != configFile || configFilePath["scheme"] != "file" {
+				t.Errorf("configuration.configFilePath = %v, want %s", configFilePath, configFile)
+			}
+			if output.Workspace.WorkspaceFolder != tt.wantFields["workspaceFolder"] || output.Workspace.WorkspaceMount != tt.wantWorkspaceMount {
+				t.Errorf("workspace = %+v, want folder %v and mount %q", output.Workspace, tt.wantFields["workspaceFolder"], tt.wantWorkspaceMount)
+			}
+			if output.FeaturesConfiguration != nil {
+				t.Error("featuresConfiguration should only be printed with --include-merged-features")
+			}
+		})
+	}
+}
+
+func TestMarshalReadConfiguration_MergedFeatures(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{
+		Image: "ubuntu:22.04",
+		Features: map[string]interface{}{
+			"ghcr.io/devcontainers/features/common-utils:2": map[string]interface{}{},
+			"ghcr.io/devcontainers/features/node:1":         map[string]interface{}{"version": "20"},
+		},
+		OverrideFeatureInstallOrder: []string{"ghcr.io/devcontainers/features/node:1"},
+	}
+
+	withFeatures, err := marshalReadConfiguration(devContainer, "devcontainer.json", t.TempDir(), true)
+	if err != nil {
+		t.Fatalf("marshalReadConfiguration() error: %v", err)
+	}
+
+	var output struct {
+		FeaturesConfiguration struct {
+			FeatureSets []struct {
+				Features []struct {
+					ID    string                 `json:"id"`
+					Value map[string]interface{} `json:"value"`
+				} `json:"features"`
+			} `json:"featureSets"`
+		} `json:"featuresConfiguration"`
+	}
+	if err := json.Unmarshal(withFeatures, &output); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	sets := output.FeaturesConfiguration.FeatureSets
+	if len(sets) != 2 || len(sets[0].Features) != 1 || len(sets[1].Features) != 1 {
+		t.Fatalf("featureSets = %+v, want 2 sets of one feature", sets)
+	}
+	if sets[0].Features[0].ID != "ghcr.io/devcontainers/features/node:1" ||
+		sets[1].Features[0].ID != "ghcr.io/devcontainers/features/common-utils:2" {
+		t.Errorf("featureSets order = [%s %s], want node first per overrideFeatureInstallOrder",
+			sets[0].Features[0].ID, sets[1].Features[0].ID)
+	}
+	if sets[0].Features[0].Value["version"] != "20" {
+		t.Errorf("node options = %v, want version 20", sets[0].Features[0].Value)
+	}
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/registry"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
+)
+
+// registryCredentialsFile is the part of the docker CLI config.json and of
+// Podman's auth.json that holds registry credentials.
+type registryCredentialsFile struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// registryAuthConfigs returns the registry credentials the CLI of runtime
+// would send with a build, so the engine can pull private base images.
+// Credentials that cannot be read are skipped with a debug message; the
+// build then fails the same way it would without them.
+func registryAuthConfigs(runtime devgoruntime.Runtime) map[string]registry.AuthConfig {
+	path := registryCredentialsPath(runtime)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debugf("Not sending registry credentials: %v\n", err)
+		}
+		return nil
+	}
+	var file registryCredentialsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		debugf("Not sending registry credentials: failed to parse %s: %v\n", path, err)
+		return nil
+	}
+	return parseRegistryCredentials(file, runCredentialHelper)
+}
+
+// baseImageCredentials returns the credentials, among those of runtime's
+// CLI, for the registries the Dockerfile at dockerfilePath pulls its base
+// images from. When a FROM line names its image through a build argument
+// every credential is returned, as the registry cannot be told.
+func baseImageCredentials(runtime devgoruntime.Runtime, dockerfilePath string) map[string]registry.AuthConfig {
+	data, err := os.ReadFile(dockerfilePath)
+	if err != nil {
+		return nil
+	}
+	registries, unresolved := dockerfileRegistries(data)
+	if len(registries) == 0 && !unresolved {
+		return nil
+	}
+	return credentialsFor(registryAuthConfigs(runtime), registries, unresolved)
+}
+
+// dockerfileRegistries returns the registries the FROM lines of dockerfile
+// pull base images from. A FROM of scratch or of an earlier stage pulls
+// nothing; one whose image uses a build argument sets unresolved.
+func dockerfileRegistries(dockerfile []byte) (registries []string, unresolved bool) {
+	stages := map[string]bool{"scratch": true}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(dockerfile), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+		args := fields[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+		image := args[0]
+		switch {
+		case stages[strings.ToLower(image)]:
+		case strings.Contains(image, "$"):
+			unresolved = true
+		default:
+			if host := imageRegistry(image); !seen[host] {
+				seen[host] = true
+				registries = append(registries, host)
+			}
+		}
+		if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+			stages[strings.ToLower(args[2])] = true
+		}
+	}
+	return registries, unresolved
+}
+
+// imageRegistry returns the registry host of an image reference: its first
+// path component when that looks like a host, docker.io otherwise.
+func imageRegistry(image string) string {
+	first, _, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first
+	}
+	return "docker.io"
+}
+
+// registryHost returns the registry host of a credentials key, which may be
+// a URL such as https://index.docker.io/v1/ for Docker Hub.
+func registryHost(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	server, _, _ = strings.Cut(server, "/")
+	switch server {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return server
+}
+
+// credentialsFor keeps the entries of configs for registries, or all of
+// them when all is set.
+func credentialsFor(configs map[string]registry.AuthConfig, registries []string, all bool) map[string]registry.AuthConfig {
+	if all {
+		return configs
+	}
+	wanted := map[string]bool{}
+	for _, host := range registries {
+		wanted[host] = true
+	}
+	filtered := map[string]registry.AuthConfig{}
+	for server, config := range configs {
+		if wanted[registryHost(server)] {
+			filtered[server] = config
+		}
+	}
+	return filtered
+}
+
+// registryCredentialsPath returns where runtime's CLI keeps credentials:
+// $DOCKER_CONFIG/config.json or ~/.docker/config.json for docker, and
+// $REGISTRY_AUTH_FILE or $XDG_RUNTIME_DIR/containers/auth.json for podman.
+func registryCredentialsPath(runtime devgoruntime.Runtime) string {
+	if runtime == devgoruntime.Podman {
+		if path := os.Getenv("REGISTRY_AUTH_FILE"); path != "" {
+			return path
+		}
+		return filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "containers", "auth.json")
+	}
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// parseRegistryCredentials resolves every registry of file, from its inline
+// "auth" or through its credential helper. getCredentials runs a helper.
+func parseRegistryCredentials(file registryCredentialsFile, getCredentials func(helper, server string) (registry.AuthConfig, error)) map[string]registry.AuthConfig {
+	servers := map[string]bool{}
+	for server := range file.Auths {
+		servers[server] = true
+	}
+	for server := range file.CredHelpers {
+		servers[server] = true
+	}
+
+	configs := map[string]registry.AuthConfig{}
+	for _, server := range sortedEnvKeys(servers) {
+		entry := file.Auths[server]
+		if entry.Auth != "" || entry.IdentityToken != "" {
+			config, err := decodeInlineAuth(server, entry.Auth)
+			if err != nil {
+				debugf("Skipping credentials of %s: %v\n", server, err)
+				continue
+			}
+			config.IdentityToken = entry.IdentityToken
+			configs[server] = config
+			continue
+		}
+
+		helper := file.CredHelpers[server]
+		if helper == "" {
+			helper = file.CredsStore
+		}
+		if helper == "" {
+			continue
+		}
+		config, err := getCredentials(helper, server)
+		if err != nil {
+			debugf("Skipping credentials of %s: %v\n", server, err)
+			continue
+		}
+		configs[server] = config
+	}
+	return configs
+}
+
+// decodeInlineAuth decodes the base64 "user:password" of an auths entry.
+func decodeInlineAuth(server, auth string) (registry.AuthConfig, error) {
+	config := registry.AuthConfig{ServerAddress: server}
+	if auth == "" {
+		return config, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return config, fmt.Errorf("invalid auth: %w", err)
+	}
+	user, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return config, fmt.Errorf("invalid auth: want user:password")
+	}
+	config.Username = user
+	config.Password = password
+	return config, nil
+}
+
+// runCredentialHelper asks docker-credential-<helper> for the credentials of
+// server, using the docker credential helper protocol.
+func runCredentialHelper(helper, server string) (registry.AuthConfig, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return registry.AuthConfig{}, fmt.Errorf("credential helper %s failed: %w", helper, err)
+	}
+
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return registry.AuthConfig{}, fmt.Errorf("credential helper %s returned invalid output: %w", helper, err)
+	}
+	config := registry.AuthConfig{ServerAddress: server}
+	// Helpers return identity tokens under the "<token>" user name.
+	if creds.Username == "<token>" {
+		config.IdentityToken = creds.Secret
+	} else {
+		config.Username = creds.Username
+		config.Password = creds.Secret
+	}
+	return config, nil
+}
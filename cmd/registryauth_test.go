@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/registry"
+)
+
+func TestParseRegistryCredentials(t *testing.T) {
+	file := registryCredentialsFile{CredsStore: "desktop", CredHelpers: map[string]string{"gcr.io": "gcloud"}}
+	file.Auths = map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	}{
+		"ghcr.io":      {Auth: base64.StdEncoding.EncodeToString([]byte("octocat:s3cret"))},
+		"registry.one": {},
+		"broken.io":    {Auth: "not base64!"},
+		"unknown.io":   {},
+	}
+
+	helpers := func(helper, server string) (registry.AuthConfig, error) {
+		switch {
+		case helper == "desktop" && server == "registry.one":
+			return registry.AuthConfig{ServerAddress: server, Username: "me", Password: "pw"}, nil
+		case helper == "gcloud" && server == "gcr.io":
+			return registry.AuthConfig{ServerAddress: server, IdentityToken: "token"}, nil
+		}
+		return registry.AuthConfig{}, errors.New("credentials not found")
+	}
+
+	got := parseRegistryCredentials(file, helpers)
+	want := map[string]registry.AuthConfig{
+		"ghcr.io":      {ServerAddress: "ghcr.io", Username: "octocat", Password: "s3cret"},
+		"registry.one": {ServerAddress: "registry.one", Username: "me", Password: "pw"},
+		"gcr.io":       {ServerAddress: "gcr.io", IdentityToken: "token"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRegistryCredentials() = %+v, want %+v", got, want)
+	}
+}
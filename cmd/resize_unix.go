@@ -0,0 +1,32 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchTerminalSize reports on the returned channel whenever the terminal
+// is resized (SIGWINCH), until done is closed.
+func watchTerminalSize(fd int, done <-chan struct{}) <-chan struct{} {
+	resized := make(chan struct{}, 1)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-signals:
+				select {
+				case resized <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return resized
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package cmd
+
+import (
+	"time"
+
+	"golang.org/x/term"
+)
+
+// consoleSizePollInterval is how often the console size is checked.
+const consoleSizePollInterval = 250 * time.Millisecond
+
+// watchTerminalSize reports on the returned channel whenever the console on
+// fd changes size, until done is closed. Windows consoles have no resize
+// signal, so the size is polled.
+func watchTerminalSize(fd int, done <-chan struct{}) <-chan struct{} {
+	resized := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(consoleSizePollInterval)
+		defer ticker.Stop()
+		width, height, _ := term.GetSize(fd)
+		for {
+			select {
+			case <-ticker.C:
+				w, h, err := term.GetSize(fd)
+				if err != nil || (w == width && h == height) {
+					continue
+				}
+				width, height = w, h
+				select {
+				case resized <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return resized
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// ResolvedConfig is a devcontainer.json after parsing, applying
+// --config-override values and substituting ${...} variables. It is shared
+// by every caller that resolves the same inputs, so callers must treat
+// DevContainer as read-only.
+type ResolvedConfig struct {
+	Path         string
+	DevContainer *devcontainer.DevContainer
+	// LocalFileErr is why a ${localFile:...} in containerEnv could not be
+	// read; see resolveEnvConfig.
+	LocalFileErr error
+}
+
+// resolvedConfigKey identifies one resolution. The modification time makes
+// an edited file resolve again; the overrides and the workspace folder are
+// part of the key because they change the result.
+type resolvedConfigKey struct {
+	path      string
+	modTime   time.Time
+	overrides string
+	workspace string
+}
+
+var (
+	resolvedConfigMu    sync.Mutex
+	resolvedConfigCache = make(map[resolvedConfigKey]*ResolvedConfig)
+
+	// parseDevContainer is the parser behind resolveConfig; tests replace it
+	// to count resolutions.
+	parseDevContainer = devcontainer.Parse
+)
+
+// resolveConfig returns the resolved config for path and overrides, reusing
+// an earlier result while the file is unchanged. Errors are not cached.
+func resolveConfig(path string, overrides []string) (*ResolvedConfig, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read devcontainer file: %w", err)
+	}
+	key := resolvedConfigKey{
+		path:      path,
+		modTime:   info.ModTime(),
+		overrides: strings.Join(overrides, "\x00"),
+		workspace: determineWorkspaceFolder(path),
+	}
+
+	resolvedConfigMu.Lock()
+	defer resolvedConfigMu.Unlock()
+	if cached, ok := resolvedConfigCache[key]; ok {
+		return cached, nil
+	}
+
+	devContainer, err := parseDevContainer(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse devcontainer.json: %w", err)
+	}
+	if err := applyConfigOverrides(devContainer, overrides); err != nil {
+		return nil, err
+	}
+	localFileErr := devContainer.ResolveLocalFiles(filepath.Dir(path))
+	devContainer.Substitute(devcontainer.Variables{
+		LocalWorkspaceFolder: key.workspace,
+		DevcontainerID:       devcontainerID(key.workspace),
+	})
+
+	resolved := &ResolvedConfig{Path: path, DevContainer: devContainer, LocalFileErr: localFileErr}
+	resolvedConfigCache[key] = resolved
+	return resolved, nil
+}
+
+// resolveEnvConfig is resolveConfig for the commands that hand containerEnv
+// to the container, which fail when a ${localFile:...} in it cannot be
+// read. The other commands go on without it, so a missing file never keeps
+// anyone from stopping or removing the container.
+func resolveEnvConfig(path string, overrides []string) (*ResolvedConfig, error) {
+	resolved, err := resolveConfig(path, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if resolved.LocalFileErr != nil {
+		return nil, resolved.LocalFileErr
+	}
+	return resolved, nil
+}
+
+// devcontainerID is the value of ${devcontainerId}: stable for a workspace
+// across rebuilds, like the hash in the container name.
+func devcontainerID(workspaceDir string) string {
+	return GeneratePathHash(workspaceDir)
+}
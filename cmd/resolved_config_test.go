@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func countingParser(t *testing.T) *int {
+	t.Helper()
+	originalParse := parseDevContainer
+	t.Cleanup(func() { parseDevContainer = originalParse })
+
+	calls := 0
+	parseDevContainer = func(path string) (*devcontainer.DevContainer, error) {
+		calls++
+		return originalParse(path)
+	}
+	return &calls
+}
+
+func writeResolvedConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "devcontainer.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveConfig_ReusesIdenticalInputs(t *testing.T) {
+	calls := countingParser(t)
+	path := writeResolvedConfigFile(t, `{"name": "app", "image": "ubuntu"}`)
+
+	first, err := resolveConfig(path, []string{"image=alpine"})
+	if err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+	second, err := resolveConfig(path, []string{"image=alpine"})
+	if err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+
+	if *calls != 1 {
+		t.Errorf("parser called %d times, want 1", *calls)
+	}
+	if first != second {
+		t.Error("identical inputs should return the cached config")
+	}
+	if first.DevContainer.Image != "alpine" {
+		t.Errorf("Image = %q, want override applied", first.DevContainer.Image)
+	}
+}
+
+func TestResolveConfig_ResolvesAgainOnChanges(t *testing.T) {
+	calls := countingParser(t)
+	path := writeResolvedConfigFile(t, `{"name": "app", "image": "ubuntu"}`)
+
+	if _, err := resolveConfig(path, nil); err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+	withOverride, err := resolveConfig(path, []string{"image=alpine"})
+	if err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("parser called %d times after an override change, want 2", *calls)
+	}
+	if withOverride.DevContainer.Image != "alpine" {
+		t.Errorf("Image = %q, want alpine", withOverride.DevContainer.Image)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"name": "app", "image": "debian"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	edited, err := resolveConfig(path, nil)
+	if err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("parser called %d times after an edit, want 3", *calls)
+	}
+	if edited.DevContainer.Image != "debian" {
+		t.Errorf("Image = %q, want debian", edited.DevContainer.Image)
+	}
+}
+
+func TestResolveConfig_DoesNotCacheErrors(t *testing.T) {
+	calls := countingParser(t)
+	path := writeResolvedConfigFile(t, `{"image": "ubuntu"}`)
+
+	for i := 0; i < 2; i++ {
+		if _, err := resolveConfig(path, []string{"invalid"}); err == nil {
+			t.Fatal("resolveConfig() should fail for an invalid override")
+		}
+	}
+	if *calls != 2 {
+		t.Errorf("parser called %d times, want 2", *calls)
+	}
+}
+
+func TestResolveConfig_SubstitutesVariables(t *testing.T) {
+	path := writeResolvedConfigFile(t, `{
+		"image": "ubuntu",
+		"workspaceFolder": "/workspaces/${localWorkspaceFolderBasename}",
+		"containerEnv": {"SRC": "${localWorkspaceFolder}", "DST": "${containerWorkspaceFolder}", "ID": "${devcontainerId}"}
+	}`)
+	workspaceDir := determineWorkspaceFolder(path)
+
+	resolved, err := resolveConfig(path, nil)
+	if err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+
+	dc := resolved.DevContainer
+	wantFolder := "/workspaces/" + filepath.Base(workspaceDir)
+	if dc.WorkspaceFolder != wantFolder {
+		t.Errorf("WorkspaceFolder = %q, want %q", dc.WorkspaceFolder, wantFolder)
+	}
+	want := map[string]string{"SRC": workspaceDir, "DST": wantFolder, "ID": GeneratePathHash(workspaceDir)}
+	for key, value := range want {
+		if dc.ContainerEnv[key] != value {
+			t.Errorf("containerEnv %s = %q, want %q", key, dc.ContainerEnv[key], value)
+		}
+	}
+}
+
+func TestResolveEnvConfig_MissingLocalFile(t *testing.T) {
+	path := writeResolvedConfigFile(t, `{"image": "ubuntu", "containerEnv": {"TOKEN": "${localFile:token.txt}"}}`)
+
+	// down, stop and the other commands that ignore containerEnv still work.
+	resolved, err := resolveConfig(path, nil)
+	if err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+	if resolved.LocalFileErr == nil {
+		t.Error("LocalFileErr = nil, want the missing token.txt")
+	}
+	if _, err := resolveEnvConfig(path, nil); err == nil {
+		t.Error("resolveEnvConfig() error = nil, want the missing token.txt")
+	}
+
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "token.txt"), []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err = resolveEnvConfig(path, []string{"image=alpine"})
+	if err != nil {
+		t.Fatalf("resolveEnvConfig() error = %v", err)
+	}
+	if got := resolved.DevContainer.ContainerEnv["TOKEN"]; got != "secret" {
+		t.Errorf("TOKEN = %q, want secret", got)
+	}
+}
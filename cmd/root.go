@@ -7,9 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/garaemon/devgo/pkg/constants"
 	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgolog "github.com/garaemon/devgo/pkg/log"
+	devgoruntime "github.com/garaemon/devgo/pkg/runtime"
 	// "github.com/garaemon/devgo/pkg/config"
 	// "github.com/garaemon/devgo/pkg/docker"
 )
@@ -33,6 +36,81 @@ var (
 	forceDotfiles          bool
 	shellOverride          string
 	shellEnvVars           []string
+	noStderr               bool
+	buildTags              []string
+	checkOnly              bool
+	autoStart              bool
+	createWorkdir          bool
+	upTimeout              time.Duration
+	includeMergedFeatures  bool
+	copyGitConfigFlag      bool
+	pruneUntil             time.Duration
+	pruneRunning           bool
+	envFromHost            []string
+	skipInitialize         bool
+	printID                bool
+	recreateIfImageChanged bool
+	noSizeEnv              bool
+	force                  bool
+	labelFiles             []string
+	noCache                bool
+	configOverrides        []string
+	networkName            string
+	networkAliases         []string
+	execService            string
+	cacheVolumes           []string
+	doctorFix              bool
+	pullTimeout            time.Duration
+	mountDockerSocket      bool
+	execGroups             []string
+	listRunningOnly        bool
+	readonlyRootfs         bool
+	resultJSON             bool
+	reuseStopped           bool
+	memoryLimit            int64
+	memorySwap             int64
+	memorySwappiness       *int64
+	cwdRelative            *bool
+	entrypointScriptFlag   bool
+	listNewerThan          time.Duration
+	listOlderThan          time.Duration
+	containerEngineFlag    string
+	noWorkspaceChown       bool
+	resolveBuild           bool
+	removeOnExitFlag       bool
+	execWorkdir            string
+	fmtCheck               bool
+	labelPrefix            string
+	deviceSpecs            []string
+	teeFile                string
+	buildSSH               *bool
+	execRaw                bool
+	recreateNetwork        bool
+	listWithSize           bool
+	copyDotfilesDir        string
+	idleTimeout            time.Duration
+	strictArch             bool
+	postAttachInForeground bool
+	execNamePrefix         string
+	waitHealthyService     bool
+	logsFollow             bool
+	logsTail               string
+	logsSince              string
+	downAll                bool
+	downVolumes            bool
+	rebuildContainer       bool
+	removeExisting         bool
+	detachKeys             string
+	gpus                   string
+	mountConsistency       string
+	execInteractive        bool
+	execTTY                bool
+	lifecycleErrorPolicy   string
+	quiet                  bool
+	logTimestamps          bool
+	logFormat              string
+	initTemplate           string
+	templateOptions        []string
 )
 
 // parseAllFlags parses all flags from the argument list, returning non-flag arguments
@@ -47,6 +125,16 @@ func parseAllFlags(args []string) ([]string, error) {
 			showVersion = true
 		} else if arg == "--debug" || arg == "--verbose" {
 			debug = true
+		} else if arg == "--quiet" {
+			quiet = true
+		} else if arg == "--log-timestamps" {
+			logTimestamps = true
+		} else if arg == "--log-format" && i+1 < len(args) {
+			if _, err := devgolog.ParseFormat(args[i+1]); err != nil {
+				return nil, err
+			}
+			logFormat = args[i+1]
+			i++
 		} else if arg == "--workspace-folder" && i+1 < len(args) {
 			workspaceFolder = args[i+1]
 			i++ // skip the next argument as it's the value
@@ -64,6 +152,81 @@ func parseAllFlags(args []string) ([]string, error) {
 			i++ // skip the next argument as it's the value
 		} else if arg == "--force-build" {
 			forceBuild = true
+		} else if arg == "--template" && i+1 < len(args) {
+			initTemplate = args[i+1]
+			i++
+		} else if arg == "--template-option" && i+1 < len(args) {
+			templateOptions = append(templateOptions, args[i+1])
+			i++
+		} else if arg == "--config-override" && i+1 < len(args) {
+			configOverrides = append(configOverrides, args[i+1])
+			i++
+		} else if arg == "--network" && i+1 < len(args) {
+			networkName = args[i+1]
+			i++
+		} else if arg == "--network-alias" && i+1 < len(args) {
+			networkAliases = append(networkAliases, args[i+1])
+			i++
+		} else if arg == "--service" && i+1 < len(args) {
+			execService = args[i+1]
+			i++
+		} else if arg == "--cache-volume" && i+1 < len(args) {
+			cacheVolumes = append(cacheVolumes, args[i+1])
+			i++
+		} else if arg == "--fix" {
+			doctorFix = true
+		} else if arg == "--mount-docker-socket" {
+			mountDockerSocket = true
+		} else if arg == "--group-add" && i+1 < len(args) {
+			execGroups = append(execGroups, args[i+1])
+			i++
+		} else if arg == "--running" || arg == "--all=false" {
+			listRunningOnly = true
+		} else if arg == "--with-size" {
+			listWithSize = true
+		} else if arg == "--all" || arg == "--all=true" {
+			listRunningOnly = false
+			downAll = true
+		} else if arg == "--volumes" {
+			downVolumes = true
+		} else if arg == "--readonly-rootfs" {
+			readonlyRootfs = true
+		} else if arg == "--result-json" {
+			resultJSON = true
+		} else if arg == "--cwd-relative" || arg == "--cwd-relative=true" {
+			relative := true
+			cwdRelative = &relative
+		} else if arg == "--cwd-relative=false" {
+			relative := false
+			cwdRelative = &relative
+		} else if arg == "--ssh" || arg == "--ssh=true" {
+			forward := true
+			buildSSH = &forward
+		} else if arg == "--ssh=false" {
+			forward := false
+			buildSSH = &forward
+		} else if arg == "--entrypoint-script" {
+			entrypointScriptFlag = true
+		} else if arg == "--no-workspace-chown" {
+			noWorkspaceChown = true
+		} else if arg == "--resolve-build" {
+			resolveBuild = true
+		} else if arg == "--remove-on-exit" {
+			removeOnExitFlag = true
+		} else if arg == "--check" {
+			fmtCheck = true
+		} else if arg == "--post-attach-in-foreground" {
+			postAttachInForeground = true
+		} else if arg == "--wait-for-healthy-service" {
+			waitHealthyService = true
+		} else if arg == "--strict-arch" {
+			strictArch = true
+		} else if arg == "--recreate-network-on-conflict" {
+			recreateNetwork = true
+		} else if arg == "--reuse-stopped" {
+			reuseStopped = true
+		} else if arg == "--no-cache" {
+			noCache = true
 		} else if arg == "--push" {
 			push = true
 		} else if arg == "--pull" {
@@ -77,6 +240,9 @@ func parseAllFlags(args []string) ([]string, error) {
 		} else if arg == "--dotfiles-install-command" && i+1 < len(args) {
 			dotfilesInstallCommand = args[i+1]
 			i++
+		} else if arg == "--copy-dotfiles" && i+1 < len(args) {
+			copyDotfilesDir = args[i+1]
+			i++
 		} else if arg == "--no-dotfiles" {
 			noDotfiles = true
 		} else if arg == "--force-dotfiles" {
@@ -87,6 +253,172 @@ func parseAllFlags(args []string) ([]string, error) {
 		} else if (arg == "--env" || arg == "-e") && i+1 < len(args) {
 			shellEnvVars = append(shellEnvVars, args[i+1])
 			i++
+		} else if interactive, tty, ok := parseExecTerminalFlag(arg, nonFlagArgs); ok {
+			execInteractive = execInteractive || interactive
+			execTTY = execTTY || tty
+		} else if (arg == "--tag" || (arg == "-t" && len(nonFlagArgs) == 1 && nonFlagArgs[0] == "build")) && i+1 < len(args) {
+			// Like -i of exec, -t is only taken right after "build", so that
+			// `devgo exec ls -t dir` still passes -t to ls.
+			buildTags = append(buildTags, args[i+1])
+			i++
+		} else if arg == "--label-file" && i+1 < len(args) {
+			labelFiles = append(labelFiles, args[i+1])
+			i++
+		} else if arg == "--force" {
+			force = true
+		} else if arg == "--no-size-env" {
+			noSizeEnv = true
+		} else if arg == "--raw" {
+			execRaw = true
+		} else if arg == "--no-stderr" {
+			noStderr = true
+		} else if arg == "--check-only" {
+			checkOnly = true
+		} else if arg == "--start" {
+			autoStart = true
+		} else if arg == "--create-workdir" {
+			createWorkdir = true
+		} else if arg == "--recreate-if-image-changed" {
+			recreateIfImageChanged = true
+		} else if arg == "--rebuild" {
+			rebuildContainer = true
+		} else if arg == "--remove-existing-container" {
+			removeExisting = true
+		} else if arg == "--print-id" {
+			printID = true
+		} else if arg == "--skip-initialize" {
+			skipInitialize = true
+		} else if arg == "--env-from-host" && i+1 < len(args) {
+			envFromHost = append(envFromHost, args[i+1])
+			i++
+		} else if arg == "--until" && i+1 < len(args) {
+			until, err := parsePruneAge(args[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid --until value %q: %w", args[i+1], err)
+			}
+			pruneUntil = until
+			i++
+		} else if arg == "--include-running" {
+			pruneRunning = true
+		} else if (arg == "--newer-than" || arg == "--older-than") && i+1 < len(args) {
+			age, err := time.ParseDuration(args[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s value %q: %w", arg, args[i+1], err)
+			}
+			if arg == "--newer-than" {
+				listNewerThan = age
+			} else {
+				listOlderThan = age
+			}
+			i++
+		} else if (arg == "--runtime" || arg == "--container-engine") && i+1 < len(args) {
+			if err := validateContainerEngine(args[i+1]); err != nil {
+				return nil, err
+			}
+			containerEngineFlag = args[i+1]
+			i++
+		} else if arg == "--workdir" && i+1 < len(args) {
+			execWorkdir = args[i+1]
+			i++
+		} else if arg == "--label-prefix" && i+1 < len(args) {
+			if err := validateLabelPrefix(args[i+1]); err != nil {
+				return nil, err
+			}
+			labelPrefix = args[i+1]
+			i++
+		} else if arg == "--device" && i+1 < len(args) {
+			if _, err := parseDeviceMapping(args[i+1]); err != nil {
+				return nil, err
+			}
+			deviceSpecs = append(deviceSpecs, args[i+1])
+			i++
+		} else if arg == "--gpus" && i+1 < len(args) {
+			if _, err := parseGPUs(args[i+1]); err != nil {
+				return nil, err
+			}
+			gpus = args[i+1]
+			i++
+		} else if arg == "--on-lifecycle-error" && i+1 < len(args) {
+			if args[i+1] != lifecycleErrorFail && args[i+1] != lifecycleErrorContinue {
+				return nil, fmt.Errorf("invalid --on-lifecycle-error %q: want fail or continue", args[i+1])
+			}
+			lifecycleErrorPolicy = args[i+1]
+			i++
+		} else if arg == "--mount-consistency" && i+1 < len(args) {
+			if !isMountConsistency(args[i+1]) {
+				return nil, fmt.Errorf("invalid --mount-consistency %q: want consistent, cached or delegated", args[i+1])
+			}
+			mountConsistency = args[i+1]
+			i++
+		} else if arg == "--follow" {
+			logsFollow = true
+		} else if arg == "--tail" && i+1 < len(args) {
+			logsTail = args[i+1]
+			i++
+		} else if arg == "--since" && i+1 < len(args) {
+			logsSince = args[i+1]
+			i++
+		} else if arg == "--name-prefix" && i+1 < len(args) {
+			execNamePrefix = args[i+1]
+			i++
+		} else if arg == "--tee" && i+1 < len(args) {
+			teeFile = args[i+1]
+			i++
+		} else if arg == "--copy-git-config" {
+			copyGitConfigFlag = true
+		} else if arg == "--include-merged-features" {
+			includeMergedFeatures = true
+		} else if arg == "--timeout" && i+1 < len(args) {
+			timeout, err := time.ParseDuration(args[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid --timeout value %q: %w", args[i+1], err)
+			}
+			upTimeout = timeout
+			i++
+		} else if arg == "--detach-keys" && i+1 < len(args) {
+			if err := validateDetachKeys(args[i+1]); err != nil {
+				return nil, err
+			}
+			detachKeys = args[i+1]
+			i++
+		} else if arg == "--idle-timeout" && i+1 < len(args) {
+			timeout, err := time.ParseDuration(args[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid --idle-timeout value %q: %w", args[i+1], err)
+			}
+			idleTimeout = timeout
+			i++
+		} else if arg == "--pull-timeout" && i+1 < len(args) {
+			timeout, err := time.ParseDuration(args[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid --pull-timeout value %q: %w", args[i+1], err)
+			}
+			pullTimeout = timeout
+			i++
+		} else if arg == "--memory" && i+1 < len(args) {
+			size, err := parseMemorySize(args[i+1])
+			if err == nil && size < 0 {
+				err = fmt.Errorf("-1 is only valid for --memory-swap")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid --memory value %q: %w", args[i+1], err)
+			}
+			memoryLimit = size
+			i++
+		} else if arg == "--memory-swap" && i+1 < len(args) {
+			size, err := parseMemorySize(args[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid --memory-swap value %q: %w", args[i+1], err)
+			}
+			memorySwap = size
+			i++
+		} else if arg == "--memory-swappiness" && i+1 < len(args) {
+			swappiness, err := parseMemorySwappiness(args[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid --memory-swappiness value %q: %w", args[i+1], err)
+			}
+			memorySwappiness = &swappiness
+			i++
 		} else if len(arg) > 2 && arg[:2] == "--" {
 			// Check if this is an unknown flag
 			return nil, fmt.Errorf("unknown option: %s", arg)
@@ -95,6 +427,12 @@ func parseAllFlags(args []string) ([]string, error) {
 		}
 	}
 
+	if env := os.Getenv(devgoruntime.EnvVar); env != "" {
+		if _, err := devgoruntime.Parse(env); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", devgoruntime.EnvVar, err)
+		}
+	}
+
 	return nonFlagArgs, nil
 }
 
@@ -112,6 +450,10 @@ func Execute() error {
 		return nil
 	}
 
+	if err := configureLogger(); err != nil {
+		return err
+	}
+
 	if showVersion {
 		showVersionInfo()
 		return nil
@@ -133,16 +475,30 @@ func Execute() error {
 		return runExecCommand(commandArgs)
 	case "shell":
 		return runShellCommand(commandArgs)
+	case "attach":
+		return runAttachCommand(commandArgs)
 	case "stop":
 		return runStopCommand(commandArgs)
 	case "down":
 		return runDownCommand(commandArgs)
+	case "logs":
+		return runLogsCommand(commandArgs)
+	case "forward-ports":
+		return runForwardPortsCommand(commandArgs)
 	case "list":
 		return runListCommand(commandArgs)
+	case "prune":
+		return runPruneCommand(commandArgs)
+	case "doctor":
+		return runDoctorCommand(commandArgs)
 	case "run-user-commands":
 		return runUserCommandsCommand(commandArgs)
 	case "read-configuration":
 		return runReadConfigurationCommand(commandArgs)
+	case "config":
+		return runConfigCommand(commandArgs)
+	case "inspect":
+		return runInspectCommand(commandArgs)
 	case "init":
 		return runInitCommand(commandArgs)
 	default:
@@ -150,31 +506,52 @@ func Execute() error {
 	}
 }
 
+// logger receives devgo's progress messages, warnings and lifecycle command
+// output. Execute configures it from --debug, --quiet, --log-format and
+// --log-timestamps.
+var logger = devgolog.New(nil)
+
+// configureLogger applies the logging flags to logger.
+func configureLogger() error {
+	if debug && quiet {
+		return fmt.Errorf("--debug and --quiet cannot be used together")
+	}
+	switch {
+	case debug:
+		logger.SetLevel(devgolog.LevelDebug)
+	case quiet:
+		logger.SetLevel(devgolog.LevelWarn)
+	default:
+		logger.SetLevel(devgolog.LevelInfo)
+	}
+	format := devgolog.FormatText
+	if logFormat != "" {
+		format = devgolog.Format(logFormat)
+	}
+	logger.SetFormat(format)
+	logger.SetTimestamps(logTimestamps)
+	return nil
+}
+
 // warnf prints a "Warning: ..." message to stderr. Use this for non-fatal
 // problems where the command continues; reserve stdout for the command's
 // real output so warnings don't pollute pipelines.
 func warnf(format string, args ...any) {
-	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...)
+	logger.Logf(devgolog.LevelWarn, format, args...)
 }
 
 // debugf writes a status/progress message to stderr only when --debug is
 // enabled. Use this for informational logs that would clutter normal output
 // (container lifecycle, image pulls, dotfiles progress, etc.).
 func debugf(format string, args ...any) {
-	if !debug {
-		return
-	}
-	fmt.Fprintf(os.Stderr, format, args...)
+	logger.Logf(devgolog.LevelDebug, format, args...)
 }
 
 // debugln writes a line of status/progress to stderr only when --debug is
 // enabled. Counterpart to debugf for callers that just want to emit a fixed
 // string without formatting.
 func debugln(args ...any) {
-	if !debug {
-		return
-	}
-	fmt.Fprintln(os.Stderr, args...)
+	logger.Logf(devgolog.LevelDebug, "%s", fmt.Sprintln(args...))
 }
 
 func showUsage() {
@@ -188,26 +565,62 @@ Commands:
   build [path]            Build a dev container image
   exec <cmd> [args...]    Execute command in running container
   shell                   Start interactive bash shell in container
+  attach [name]           Start or reattach to a persistent shell session
   stop                    Stop containers
   down                    Stop and delete containers
+  logs                    Print the output of the dev container
+  forward-ports           Forward the forwardPorts Docker does not publish
   list                    List all devgo containers
+  prune                   Remove stopped and orphaned devgo containers and images
+  doctor                  Report common setup problems (--fix remediates them)
   run-user-commands       Run user commands in container
   read-configuration      Output current workspace configuration
+  inspect --resolve-build
+                          Print the build command 'devgo build' would run
+  config diff <base> <head>
+                          Show field-level differences between two configs
+  config fmt [file]       Rewrite devcontainer.json in canonical form
   init [directory]        Initialize devcontainer.json template
 
 Flags:
   --config string
         Path to devcontainer.json file
+  --runtime docker|podman
+        Container runtime to use for the API, builds, pushes and compose
+        (alias --container-engine; DEVGO_RUNTIME sets it too; default: podman
+        when DOCKER_HOST points at a Podman socket, docker otherwise)
+  --label-prefix prefix
+        Namespace devgo's container labels (prefix.devgo.managed, ...) and
+        only see containers created with the same prefix; DEVGO_LABEL_PREFIX
+        sets it too. For shared hosts such as CI runners
   --debug
         Print container lifecycle, dotfiles, and other progress messages
         to stderr. Without this flag devgo stays quiet on success.
         --verbose is accepted as a deprecated alias.
+  --quiet
+        Print only warnings and errors, hiding lifecycle command output
+  --log-format text|json
+        Write progress, warnings and lifecycle command output to stderr as
+        text lines (the default) or as one JSON object per line for CI
+  --log-timestamps
+        Prefix each text log line with the time it was written
   --force-build
-        Force rebuild of container
+        Build the image even when its build inputs are unchanged
+  --no-cache
+        Pass --no-cache to docker build (for 'devgo build' and the image
+        build done by 'devgo up')
+  --ssh[=true|false]
+        Forward the host SSH agent to docker build (--ssh default, needs
+        BuildKit) for RUN --mount=type=ssh. On by default when SSH_AUTH_SOCK
+        points at a socket and the engine CLI is installed; other builds go
+        through the engine API
   --help
         Show help
   --image-name string
         Set image name and optional version
+  --tag, -t string
+        Tag the built image (may be repeated; the first tag is the image devgo runs;
+        -t only right after build)
   --name string
         Override container name
   --push
@@ -229,10 +642,23 @@ Flags:
         (relative to target path), remaining tokens are passed as arguments
   --no-dotfiles
         Disable dotfiles processing for this invocation
+  --copy-dotfiles dir
+        Make 'devgo up' copy the host directory dir into the container user's
+        home (e.g. ~/.dotfiles) and run its install.sh (or install,
+        bootstrap.sh, ...) when present; only when the container is created,
+        not when it is started again
   --force-dotfiles
         Re-clone the dotfiles repository even if the target path already exists
+  --idle-timeout duration
+        Close an interactive 'devgo shell' (or 'devgo exec' without a command)
+        after duration without input (e.g. 30m)
+  --detach-keys keys
+        Key sequence that detaches 'devgo shell' and 'devgo attach', in docker
+        syntax (e.g. ctrl-x,x; default ctrl-@)
   --shell string
         Program to launch for 'devgo shell' (overrides shell setting in user config; defaults to /bin/bash)
+        With 'devgo exec', run the first argument as a script with
+        "<program> -c" (multi-line scripts are passed through unchanged)
   --env, -e KEY=VALUE
         Set an environment variable in the 'devgo shell' session. Forms:
           KEY=VALUE   set an explicit value
@@ -243,15 +669,205 @@ Flags:
         one shot:
           devgo shell --env "$(aws configure export-credentials --format env)"
         May be repeated. User values override container values.
+  -i, --interactive, -t, --tty
+        Make 'devgo exec' attach stdin (-i) and allocate a TTY that follows
+        the host terminal size (-t), e.g. 'devgo exec -it python3'; the short
+        forms are only read right after 'exec'
+  --raw
+        Make 'devgo exec' copy the container output stream to stdout as is.
+        Docker's 8-byte frame headers separating stdout and stderr are kept,
+        so the reader must demultiplex the stream itself
+  --no-stderr
+        Discard the stderr of the command run by 'devgo exec' (stdout is kept)
+  --config-override key=value
+        Override a top-level scalar field of devcontainer.json for this command
+        (e.g. image=alpine:3.20; may be repeated)
+  --template ref
+        Make 'devgo init' expand the devcontainer Template at this OCI
+        reference (e.g. ghcr.io/devcontainers/templates/go:1) instead of
+        writing the built-in template
+  --template-option id=value
+        Set an option of --template (may be repeated); in a terminal the
+        options not given are asked for, elsewhere they keep their defaults
+  --network name
+        Attach the container created by 'devgo up' to this Docker network
+  --network-alias name
+        Add a DNS alias for the container on --network (may be repeated)
+  --post-attach-in-foreground
+        Make 'devgo up' run postAttachCommand as its last step, after
+        dotfiles, and fail if it fails (e.g. for a log viewer to watch)
+  --wait-for-healthy-service
+        Make 'devgo up' wait for the docker compose service container to be
+        healthy before running lifecycle commands (bounded by --timeout)
+  --strict-arch
+        Make 'devgo up' fail instead of warning when the image architecture
+        does not match the host (e.g. an amd64-only image on Apple Silicon)
+  --recreate-network-on-conflict
+        Make 'devgo up' remove and recreate the --network network as a local
+        bridge when it exists but cannot be joined (e.g. a swarm network that
+        is not attachable) and no container uses it
+  --entrypoint-script
+        Make 'devgo up' run a generated wrapper as the entrypoint that sets
+        self-referential containerEnv (e.g. PATH appends) inside the container
+  --no-workspace-chown
+        Keep the updateRemoteUserUID ownership fix of 'devgo up' out of a
+        workspace folder under the user's home (skips the slow recursive chown)
+  --resolve-build
+        Make 'devgo inspect' print the exact build command without running it
+  --remove-on-exit
+        Keep 'devgo up' in the foreground and stop and remove the container
+        it created when it is interrupted (Ctrl-C or SIGTERM) or fails
+  --check
+        Make 'devgo config fmt' only report (and fail) when the file is not
+        formatted, for CI
+  --device host[:container[:perms]]
+        Make a host device (e.g. /dev/ttyUSB0) available in the container
+        created by 'devgo up'; perms are letters from rwm (may be repeated)
+  --gpus all|N|device=ID[,ID...]
+        Give the container created by 'devgo up' GPUs, like hostRequirements.gpu
+        (needs the NVIDIA Container Toolkit with docker)
+  --on-lifecycle-error fail|continue
+        What 'devgo up' does when a lifecycle command it waits for exits
+        non-zero: fail (the default) or warn and continue
+  --mount-consistency consistent|cached|delegated
+        Set the consistency of the workspace bind mount for Docker Desktop on
+        macOS (overrides workspaceMountOptions)
+  --reuse-stopped
+        Make 'devgo up' start a stopped container again instead of recreating
+        it; only updateContentCommand, postStartCommand and postAttachCommand run
+  --readonly-rootfs
+        Create the 'devgo up' container with a read-only root filesystem
+        (pair it with runArgs "--tmpfs" for writable paths; devgo's own state
+        under /var/lib/devgo gets an anonymous volume)
+  --mount-docker-socket
+        Bind the host Docker socket (DOCKER_HOST or the platform default) to
+        /var/run/docker.sock in the container and add its group
+  --cache-volume target=path
+        Mount a named volume, kept per workspace, at path in the container
+        created by 'devgo up' so caches survive recreation (may be repeated)
+  --label-file path
+        Add the key=value lines of the file as labels on the container created
+        by 'devgo up' (may be repeated; devgo.* labels are reserved)
+  --running, --all=false
+        Make 'devgo list' show only running containers (default: all)
+  --with-size
+        Make 'devgo list' add a SIZE column with each container's writable
+        layer size
+  --newer-than duration, --older-than duration
+        Make 'devgo list' show only containers created less than / at least
+        duration ago (e.g. 24h)
+  --fix
+        Make 'devgo doctor' apply safe remediations and report each fix
+  --force
+        Make 'devgo down' kill and remove the container without a graceful
+        stop, 'devgo prune' remove without asking for confirmation, and
+        'devgo run-user-commands' rerun onCreateCommand, updateContentCommand
+        and postCreateCommand even when they already completed
+  --all
+        Make 'devgo down' remove every devgo container of every workspace
+        (only those of --session when it is given)
+  --volumes
+        Make 'devgo down' also remove the container's anonymous volumes
+  --no-size-env
+        Do not set COLUMNS/LINES from the host terminal size for 'devgo exec'
+  --check-only
+        Make 'devgo up' run read-only preflight checks (configuration, Docker,
+        image, ports, mount sources) and report PASS/FAIL without pulling or
+        creating anything
+  --start
+        Make 'devgo exec' and 'devgo shell' start a stopped container, or run
+        the 'devgo up' flow when none exists, instead of failing
+  --create-workdir
+        Create the working directory in the container for 'devgo exec' and
+        'devgo shell' when it does not exist (default: fail with an error)
+  --timeout duration
+        Bound the whole 'devgo up' (build, pull, create, lifecycle commands)
+        by a deadline such as 5m; a lifecycle command still running then is
+        killed, and the error names the phase that timed out
+  --pull-timeout duration
+        Bound only the image pull of 'devgo up' (e.g. 120s); the rest of the
+        command stays on --timeout
+  --memory size
+        Limit the memory of the 'devgo up' container (e.g. 512m, 2g)
+  --memory-swap size
+        Limit memory plus swap of the 'devgo up' container; needs --memory.
+        -1 allows unlimited swap
+  --memory-swappiness n
+        Set the container's swappiness (0-100)
+  --copy-git-config
+        Copy ~/.gitconfig into the container after it starts instead of
+        bind-mounting it read-only, so edits inside stay in the container
+  --recreate-if-image-changed
+        Make 'devgo up' recreate a running container whose image tag now points
+        to a different image (after a rebuild or pull) instead of failing
+  --rebuild
+        Make 'devgo up' remove the existing container, rebuild the image when
+        there is a build config (cached unless --force-build) and create the
+        container again, running onCreate and postCreate commands anew
+  --remove-existing-container
+        Make 'devgo up' recreate an existing container whose devcontainer
+        configuration or Dockerfile changed since it was created, instead of
+        warning about it
+  --print-id
+        Make 'devgo exec' print the running container's ID instead of running
+        a command (e.g. docker logs $(devgo exec --print-id))
+  --name-prefix prefix
+        Make 'devgo exec' run in the one running devgo container whose name
+        starts with prefix instead of the workspace's container, with the
+        container's default user and directory; it needs no devcontainer.json
+        and cannot be combined with --service
+  --follow
+        Make 'devgo logs' keep streaming new output
+  --tail n
+        Make 'devgo logs' start with the last n lines (or "all")
+  --since time
+        Make 'devgo logs' start at a timestamp or a relative time (e.g. 10m)
+  --tee file
+        Make 'devgo exec' also write the command output to file on the host;
+        cannot be combined with --result-json
+  --result-json
+        Make 'devgo exec' capture the command and print one JSON object
+        {"exit":N,"stdout":"...","stderr":"..."} instead of streaming
+  --workdir dir
+        Run 'devgo exec' in dir; a relative dir is taken from the workspace
+        folder (src -> /workspace/src). Overrides --cwd-relative
+  --cwd-relative[=true|false]
+        Make 'devgo exec' run in the workspace subdirectory matching the host
+        working directory (true) or always in the workspace folder (false).
+        Defaults to true when stdin is a terminal, false otherwise
+  --group-add group
+        Run the 'devgo exec' command with this supplementary group via sg
+        (may be repeated; the exec user must be a member of the group)
+  --service name
+        Make 'devgo exec' run in the container of this docker compose service
+        instead of the devcontainer's own service
+  --skip-initialize
+        Do not run initializeCommand on the host before 'devgo up' or
+        'devgo build'
+  --env-from-host NAME
+        Make 'devgo up' set the container variable NAME to its value on the
+        host (may be repeated; unset host variables are skipped with a warning)
+  --until duration
+        Make 'devgo prune' only remove stopped containers created longer ago
+        than the duration (e.g. 168h or 7d)
+  --include-running
+        Make 'devgo prune' also remove running containers whose workspace
+        directory no longer exists
+  --include-merged-features
+        Add the features in resolved install order to 'devgo read-configuration'
+        output as "featuresConfiguration"
 
 Examples:
   devgo up --workspace-folder .
   devgo build --image-name myapp:latest
+  devgo build -t myapp:1.0 -t myapp:latest
   devgo exec bash
+  devgo exec --no-stderr make lint
   devgo shell
   devgo shell --env FOO=bar -e PATH
   devgo shell --env "$(aws configure export-credentials --format env)"
   devgo stop
+  devgo logs --follow --tail 100
 `)
 }
 
@@ -340,6 +956,14 @@ func sanitizeDockerName(name string) string {
 	return result.String()
 }
 
+// composeServiceContainerName returns the name docker compose gives the first
+// container of service in the project for workspaceDir.
+func composeServiceContainerName(workspaceDir, service string) string {
+	projectName := sanitizeDockerName(filepath.Base(workspaceDir))
+	pathHash := GeneratePathHash(workspaceDir)
+	return fmt.Sprintf("%s-%s-%s-1", pathHash, projectName, service)
+}
+
 func determineContainerName(devContainer *devcontainer.DevContainer, workspaceDir string) string {
 	if containerName != "" {
 		return containerName
@@ -352,9 +976,7 @@ func determineContainerName(devContainer *devcontainer.DevContainer, workspaceDi
 
 	// For docker compose, use service name with project prefix
 	if devContainer.HasDockerCompose() && devContainer.GetService() != "" {
-		projectName := sanitizeDockerName(filepath.Base(workspaceDir))
-		pathHash := GeneratePathHash(workspaceDir)
-		return fmt.Sprintf("%s-%s-%s-1", pathHash, projectName, devContainer.GetService())
+		return composeServiceContainerName(workspaceDir, devContainer.GetService())
 	}
 
 	pathHash := GeneratePathHash(workspaceDir)
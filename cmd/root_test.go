@@ -3,8 +3,11 @@ package cmd
 import (
 	"bytes"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+
+	devgolog "github.com/garaemon/devgo/pkg/log"
 )
 
 func TestParseAllFlags(t *testing.T) {
@@ -265,6 +268,138 @@ func resetPersonalizationFlags() {
 	shellOverride = ""
 }
 
+func TestParseAllFlags_TagFlag(t *testing.T) {
+	buildTags = nil
+	defer func() { buildTags = nil }()
+
+	if _, err := parseAllFlags([]string{"build", "-t", "a:1", "--tag", "a:latest"}); err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if len(buildTags) != 2 || buildTags[0] != "a:1" || buildTags[1] != "a:latest" {
+		t.Errorf("buildTags = %v, want [a:1 a:latest]", buildTags)
+	}
+
+	buildTags = nil
+	got, err := parseAllFlags([]string{"exec", "ls", "-t", "dir"})
+	if err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if want := []string{"exec", "ls", "-t", "dir"}; !reflect.DeepEqual(got, want) || buildTags != nil {
+		t.Errorf("parseAllFlags(exec ls -t dir) = %v with buildTags %v, want %v and no tags", got, buildTags, want)
+	}
+}
+
+func TestParseAllFlags_RuntimeFlag(t *testing.T) {
+	containerEngineFlag = ""
+	defer func() { containerEngineFlag = "" }()
+
+	if _, err := parseAllFlags([]string{"--runtime", "podman", "up"}); err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if containerEngineFlag != "podman" {
+		t.Errorf("containerEngineFlag = %q, want podman", containerEngineFlag)
+	}
+
+	t.Setenv("DEVGO_RUNTIME", "nerdctl")
+	if _, err := parseAllFlags([]string{"up"}); err == nil || !strings.Contains(err.Error(), "DEVGO_RUNTIME") {
+		t.Errorf("parseAllFlags error = %v, want invalid DEVGO_RUNTIME", err)
+	}
+}
+
+func TestParseAllFlags_MountConsistencyFlag(t *testing.T) {
+	mountConsistency = ""
+	defer func() { mountConsistency = "" }()
+
+	if _, err := parseAllFlags([]string{"up", "--mount-consistency", "cached"}); err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if mountConsistency != "cached" {
+		t.Errorf("mountConsistency = %q, want cached", mountConsistency)
+	}
+	if _, err := parseAllFlags([]string{"up", "--mount-consistency", "fast"}); err == nil {
+		t.Error("parseAllFlags should reject --mount-consistency fast")
+	}
+}
+
+func TestParseAllFlags_OnLifecycleErrorFlag(t *testing.T) {
+	lifecycleErrorPolicy = ""
+	defer func() { lifecycleErrorPolicy = "" }()
+
+	if _, err := parseAllFlags([]string{"up", "--on-lifecycle-error", "continue"}); err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if lifecycleErrorPolicy != "continue" {
+		t.Errorf("lifecycleErrorPolicy = %q, want continue", lifecycleErrorPolicy)
+	}
+	if _, err := parseAllFlags([]string{"up", "--on-lifecycle-error", "ignore"}); err == nil {
+		t.Error("parseAllFlags should reject --on-lifecycle-error ignore")
+	}
+}
+
+func TestParseAllFlags_LogFlags(t *testing.T) {
+	debug, quiet, logTimestamps, logFormat = false, false, false, ""
+	defer func() {
+		debug, quiet, logTimestamps, logFormat = false, false, false, ""
+		_ = configureLogger()
+	}()
+
+	if _, err := parseAllFlags([]string{"up", "--quiet", "--log-format", "json", "--log-timestamps"}); err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if !quiet || !logTimestamps || logFormat != "json" {
+		t.Errorf("quiet = %t, logTimestamps = %t, logFormat = %q, want true, true, json", quiet, logTimestamps, logFormat)
+	}
+	if err := configureLogger(); err != nil {
+		t.Errorf("configureLogger() error = %v", err)
+	}
+	if logger.Enabled(devgolog.LevelInfo) {
+		t.Error("--quiet should drop info records")
+	}
+	if _, err := parseAllFlags([]string{"up", "--log-format", "yaml"}); err == nil {
+		t.Error("parseAllFlags should reject --log-format yaml")
+	}
+
+	debug = true
+	if err := configureLogger(); err == nil {
+		t.Error("configureLogger() should reject --debug with --quiet")
+	}
+}
+
+func TestParseAllFlags_TemplateFlags(t *testing.T) {
+	initTemplate, templateOptions = "", nil
+	defer func() { initTemplate, templateOptions = "", nil }()
+
+	args, err := parseAllFlags([]string{"init", "--template", "ghcr.io/devcontainers/templates/go:3", "--template-option", "imageVariant=1.23", "--template-option", "installTools=false", "app"})
+	if err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if initTemplate != "ghcr.io/devcontainers/templates/go:3" {
+		t.Errorf("initTemplate = %q", initTemplate)
+	}
+	if len(templateOptions) != 2 || templateOptions[1] != "installTools=false" {
+		t.Errorf("templateOptions = %v", templateOptions)
+	}
+	if len(args) != 2 || args[1] != "app" {
+		t.Errorf("non-flag args = %v, want [init app]", args)
+	}
+}
+
+func TestParseAllFlags_NoStderrFlag(t *testing.T) {
+	noStderr = false
+	defer func() { noStderr = false }()
+
+	args, err := parseAllFlags([]string{"exec", "--no-stderr", "ls"})
+	if err != nil {
+		t.Fatalf("parseAllFlags error = %v", err)
+	}
+	if !noStderr {
+		t.Error("noStderr = false, want true")
+	}
+	if len(args) != 2 || args[0] != "exec" || args[1] != "ls" {
+		t.Errorf("non-flag args = %v, want [exec ls]", args)
+	}
+}
+
 func TestParseAllFlags_ShellFlag(t *testing.T) {
 	resetPersonalizationFlags()
 	if _, err := parseAllFlags([]string{"--shell", "/usr/bin/zsh"}); err != nil {
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+// runArgsHostConfig holds the runArgs settings that map directly onto the
+// container's host configuration.
+type runArgsHostConfig struct {
+	Privileged  bool
+	Init        bool
+	CapAdd      []string
+	CapDrop     []string
+	SecurityOpt []string
+	// ShmSize is the size of /dev/shm in bytes, 0 for the daemon default.
+	ShmSize    int64
+	IpcMode    string
+	PidMode    string
+	UsernsMode string
+	ExtraHosts []string
+	Hostname   string
+}
+
+// planRunArgsHostConfig validates the runArgs settings of opts that have a
+// format devgo can check up front.
+func planRunArgsHostConfig(opts devcontainer.RunArgsHostOptions) (runArgsHostConfig, error) {
+	config := runArgsHostConfig{
+		Privileged:  opts.Privileged,
+		Init:        opts.Init,
+		CapAdd:      opts.CapAdd,
+		CapDrop:     opts.CapDrop,
+		SecurityOpt: opts.SecurityOpt,
+		IpcMode:     opts.IpcMode,
+		PidMode:     opts.PidMode,
+		UsernsMode:  opts.UsernsMode,
+		ExtraHosts:  opts.ExtraHosts,
+		Hostname:    opts.Hostname,
+	}
+	if opts.ShmSize != "" {
+		size, err := parseMemorySize(opts.ShmSize)
+		if err != nil || size < 0 {
+			return runArgsHostConfig{}, fmt.Errorf("invalid --shm-size %q in runArgs: want a size such as 2g", opts.ShmSize)
+		}
+		config.ShmSize = size
+	}
+	for _, host := range opts.ExtraHosts {
+		if name, ip, ok := strings.Cut(host, ":"); !ok || name == "" || ip == "" {
+			return runArgsHostConfig{}, fmt.Errorf("invalid --add-host %q in runArgs: want HOST:IP", host)
+		}
+	}
+	return config, nil
+}
+
+// apply sets the fields of hostConfig and config that come from runArgs.
+func (r runArgsHostConfig) apply(config *container.Config, hostConfig *container.HostConfig) {
+	hostConfig.Privileged = r.Privileged
+	if r.Init {
+		hostConfig.Init = &r.Init
+	}
+	hostConfig.CapAdd = strslice.StrSlice(r.CapAdd)
+	hostConfig.CapDrop = strslice.StrSlice(r.CapDrop)
+	hostConfig.SecurityOpt = r.SecurityOpt
+	hostConfig.ShmSize = r.ShmSize
+	hostConfig.IpcMode = container.IpcMode(r.IpcMode)
+	hostConfig.PidMode = container.PidMode(r.PidMode)
+	if r.UsernsMode != "" {
+		hostConfig.UsernsMode = container.UsernsMode(r.UsernsMode)
+	}
+	hostConfig.ExtraHosts = r.ExtraHosts
+	config.Hostname = r.Hostname
+}
+
+// isNetworkMode reports whether network is a network mode rather than a
+// network to join: the host's or another container's stack, or none.
+func isNetworkMode(network string) bool {
+	return network == "host" || network == "none" || strings.HasPrefix(network, "container:")
+}
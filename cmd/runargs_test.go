@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestPlanRunArgsHostConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    devcontainer.RunArgsHostOptions
+		want    runArgsHostConfig
+		wantErr bool
+	}{
+		{
+			name: "shm size",
+			opts: devcontainer.RunArgsHostOptions{ShmSize: "2g", Privileged: true},
+			want: runArgsHostConfig{ShmSize: 2 << 30, Privileged: true},
+		},
+		{
+			name: "extra hosts",
+			opts: devcontainer.RunArgsHostOptions{ExtraHosts: []string{"db:10.0.0.2", "host.docker.internal:host-gateway"}},
+			want: runArgsHostConfig{ExtraHosts: []string{"db:10.0.0.2", "host.docker.internal:host-gateway"}},
+		},
+		{name: "invalid shm size", opts: devcontainer.RunArgsHostOptions{ShmSize: "lots"}, wantErr: true},
+		{name: "unlimited shm size", opts: devcontainer.RunArgsHostOptions{ShmSize: "-1"}, wantErr: true},
+		{name: "extra host without IP", opts: devcontainer.RunArgsHostOptions{ExtraHosts: []string{"db"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planRunArgsHostConfig(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("planRunArgsHostConfig() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planRunArgsHostConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunArgsHostConfigApply(t *testing.T) {
+	options := runArgsHostConfig{
+		Privileged:  true,
+		Init:        true,
+		CapAdd:      []string{"SYS_PTRACE"},
+		CapDrop:     []string{"MKNOD"},
+		SecurityOpt: []string{"seccomp=unconfined"},
+		ShmSize:     1 << 30,
+		IpcMode:     "host",
+		PidMode:     "host",
+		ExtraHosts:  []string{"db:10.0.0.2"},
+		Hostname:    "devbox",
+	}
+	config := &container.Config{}
+	hostConfig := &container.HostConfig{UsernsMode: container.UsernsMode(keepIDUsernsMode)}
+	options.apply(config, hostConfig)
+
+	if !hostConfig.Privileged || hostConfig.Init == nil || !*hostConfig.Init {
+		t.Errorf("Privileged = %t, Init = %v, want both set", hostConfig.Privileged, hostConfig.Init)
+	}
+	if !reflect.DeepEqual(hostConfig.CapAdd, strslice.StrSlice{"SYS_PTRACE"}) || !reflect.DeepEqual(hostConfig.CapDrop, strslice.StrSlice{"MKNOD"}) {
+		t.Errorf("CapAdd = %v, CapDrop = %v", hostConfig.CapAdd, hostConfig.CapDrop)
+	}
+	if hostConfig.ShmSize != 1<<30 || hostConfig.IpcMode != "host" || hostConfig.PidMode != "host" {
+		t.Errorf("ShmSize = %d, IpcMode = %q, PidMode = %q", hostConfig.ShmSize, hostConfig.IpcMode, hostConfig.PidMode)
+	}
+	if hostConfig.UsernsMode != keepIDUsernsMode {
+		t.Errorf("UsernsMode = %q, want keep-id kept without --userns", hostConfig.UsernsMode)
+	}
+	if !reflect.DeepEqual(hostConfig.SecurityOpt, []string{"seccomp=unconfined"}) || !reflect.DeepEqual(hostConfig.ExtraHosts, []string{"db:10.0.0.2"}) {
+		t.Errorf("SecurityOpt = %v, ExtraHosts = %v", hostConfig.SecurityOpt, hostConfig.ExtraHosts)
+	}
+	if config.Hostname != "devbox" {
+		t.Errorf("Hostname = %q, want devbox", config.Hostname)
+	}
+
+	runArgsHostConfig{UsernsMode: "host"}.apply(config, hostConfig)
+	if hostConfig.UsernsMode != "host" {
+		t.Errorf("UsernsMode = %q, want --userns to win", hostConfig.UsernsMode)
+	}
+}
@@ -8,22 +8,28 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 	"github.com/garaemon/devgo/pkg/config"
 	"github.com/garaemon/devgo/pkg/devcontainer"
+	"github.com/garaemon/devgo/pkg/dotfiles"
 	"golang.org/x/term"
 )
 
-// DefaultShell is used when neither --shell nor user config provides a value.
-const DefaultShell = "/bin/bash"
+// DefaultShell is used when neither --shell, user config, nor devcontainer.json
+// provides a value.
+const DefaultShell = devcontainer.DefaultShell
 
 // resolveShellCommand returns the command to run for `devgo shell`. The
-// resolution order is: --shell flag > user config > DefaultShell. The shell
-// is always launched with -i for interactive mode.
-func resolveShellCommand(override string, userConfig *config.UserConfig) []string {
+// resolution order is: --shell flag > user config > devcontainer.json
+// "shell" > DefaultShell. The shell is always launched with -i for
+// interactive mode.
+func resolveShellCommand(override string, userConfig *config.UserConfig, devContainer *devcontainer.DevContainer) []string {
 	shell := DefaultShell
+	if devContainer != nil {
+		shell = devContainer.GetShell()
+	}
 	if userConfig != nil && userConfig.Shell != "" {
 		shell = userConfig.Shell
 	}
@@ -33,6 +39,20 @@ func resolveShellCommand(override string, userConfig *config.UserConfig) []strin
 	return []string{shell, "-i"}
 }
 
+// applyShellFallback swaps DefaultShell for devcontainer.FallbackShell when
+// the container has no bash, so minimal images still get a shell.
+func applyShellFallback(ctx context.Context, exec dotfiles.Executor, user string, shellCommand []string) []string {
+	if len(shellCommand) == 0 || shellCommand[0] != DefaultShell {
+		return shellCommand
+	}
+	_, _, exitCode, err := exec.Exec(ctx, user, []string{"test", "-x", DefaultShell})
+	if err != nil || exitCode == 0 {
+		return shellCommand
+	}
+	debugf("%s not found in container, using %s\n", DefaultShell, devcontainer.FallbackShell)
+	return append([]string{devcontainer.FallbackShell}, shellCommand[1:]...)
+}
+
 func runShellCommand(args []string) error {
 	devcontainerPath, err := findDevcontainerConfig(configPath)
 	if err != nil {
@@ -41,14 +61,15 @@ func runShellCommand(args []string) error {
 
 	workspaceDir := determineWorkspaceFolder(devcontainerPath)
 
-	devContainer, err := devcontainer.Parse(devcontainerPath)
+	resolved, err := resolveEnvConfig(devcontainerPath, configOverrides)
 	if err != nil {
-		return fmt.Errorf("failed to parse devcontainer.json: %w", err)
+		return err
 	}
+	devContainer := resolved.DevContainer
 
 	containerName := determineContainerName(devContainer, workspaceDir)
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -63,10 +84,35 @@ func runShellCommand(args []string) error {
 		warnf("failed to load user config: %v", err)
 		userConfig = &config.UserConfig{}
 	}
-	shellCommand := resolveShellCommand(shellOverride, userConfig)
+	shellCommand := resolveShellCommand(shellOverride, userConfig, devContainer)
 
 	ctx := context.Background()
-	return executeInteractiveShell(ctx, cli, containerName, devContainer, shellCommand, shellEnvVars)
+	if autoStart {
+		if err := ensureContainerRunning(ctx, cli, containerName, func() error { return runUpCommand(nil) }); err != nil {
+			return err
+		}
+	}
+	if err := checkContainerWorkdir(ctx, cli, containerName, devContainer.GetTargetUser(), devContainer.GetWorkspaceFolder()); err != nil {
+		return err
+	}
+	user := devContainer.GetTargetUser()
+	var executor dotfiles.Executor
+	holder := ""
+	if containerID, err := findRunningContainer(ctx, cli, containerName); err == nil && containerID != "" {
+		executor = newDotfilesExecutor(cli, containerID)
+		shellCommand = applyShellFallback(ctx, executor, user, shellCommand)
+		if attachSession != "" {
+			if holder, err = detectSessionHolder(ctx, executor, user); err != nil {
+				return err
+			}
+			shellCommand = attachShellCommand(holder, attachSession, shellCommand)
+		}
+	}
+	err = executeInteractiveShell(ctx, cli, containerName, devContainer, shellCommand, shellEnvVars)
+	if holder != "" && attachSessionAlive(ctx, executor, user, holder, attachSession) {
+		printReattachHint(attachSession)
+	}
+	return err
 }
 
 // resolveEnvVars parses --env/-e entries into a map of variables. A single
@@ -143,11 +189,7 @@ func buildShellEnv(expandedEnv map[string]string, extraEnv []string) []string {
 		merged[k] = v
 	}
 
-	env := make([]string, 0, len(merged))
-	for k, v := range merged {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
-	}
-	return env
+	return envMapToSlice(merged)
 }
 
 func executeInteractiveShell(ctx context.Context, cli DockerExecClient, containerName string, devContainer *devcontainer.DevContainer, shellCommand []string, extraEnv []string) error {
@@ -174,7 +216,7 @@ func executeInteractiveShell(ctx context.Context, cli DockerExecClient, containe
 		}
 	}
 
-	expandedEnv := devContainer.GetContainerEnv(baseEnv)
+	expandedEnv := remoteUserEnv(devContainer, baseEnv)
 	// TERM defaults to xterm-256color; user-supplied --env entries override
 	// container values.
 	env := buildShellEnv(expandedEnv, extraEnv)
@@ -203,7 +245,7 @@ func executeInteractiveShell(ctx context.Context, cli DockerExecClient, containe
 		WorkingDir:   workspaceFolder,
 		Env:          env,
 		ConsoleSize:  consoleSize,
-		DetachKeys:   "ctrl-@", // Use ctrl-@ instead of default ctrl-p,ctrl-q to allow ctrl-p for history
+		DetachKeys:   resolveDetachKeys(),
 	}
 
 	debugln("Creating exec instance with config:")
@@ -214,7 +256,7 @@ func executeInteractiveShell(ctx context.Context, cli DockerExecClient, containe
 	debugf("  AttachStderr: %v\n", execConfig.AttachStderr)
 	debugf("  Cmd: %v\n", execConfig.Cmd)
 	debugf("  Env: %v\n", execConfig.Env)
-	debugf("  DetachKeys: %s (allows ctrl-p for history)\n", execConfig.DetachKeys)
+	debugf("  DetachKeys: %s\n", execConfig.DetachKeys)
 	if consoleSize != nil {
 		debugf("  ConsoleSize: %dx%d\n", consoleSize[1], consoleSize[0])
 	}
@@ -282,13 +324,36 @@ func executeInteractiveShell(ctx context.Context, cli DockerExecClient, containe
 		}
 	}()
 
+	if term.IsTerminal(stdinFd) {
+		resizeDone := make(chan struct{})
+		defer close(resizeDone)
+		followTerminalSize(ctx, cli, execCreateResp.ID, stdinFd, resizeDone)
+	}
+
 	// Handle TTY I/O
 	debugln("Starting I/O operations")
 
+	var stdin io.Reader = os.Stdin
+	idled := make(chan struct{})
+	if idleTimeout > 0 {
+		// Close the session once no input arrived for --idle-timeout; the
+		// check runs a few times per timeout so it fires close to the limit.
+		timer := newIdleTimer(idleTimeout, time.Now)
+		stdin = &activityReader{r: os.Stdin, timer: timer}
+		ticker := time.NewTicker(idleCheckInterval(idleTimeout))
+		defer ticker.Stop()
+		done := make(chan struct{})
+		defer close(done)
+		go watchIdle(timer, ticker.C, done, func() {
+			close(idled)
+			execAttachResp.Close()
+		})
+	}
+
 	// Copy stdin to container in background
 	go func() {
 		debugln("Starting stdin -> container copy")
-		_, _ = io.Copy(execAttachResp.Conn, os.Stdin)
+		_, _ = io.Copy(execAttachResp.Conn, stdin)
 		debugln("Stdin copy completed")
 	}()
 
@@ -297,6 +362,11 @@ func executeInteractiveShell(ctx context.Context, cli DockerExecClient, containe
 	_, err = io.Copy(os.Stdout, execAttachResp.Reader)
 	debugf("Stdout copy completed: err=%v\n", err)
 
+	select {
+	case <-idled:
+		return fmt.Errorf("session closed after %s without input", idleTimeout)
+	default:
+	}
 	if err != nil && err != io.EOF {
 		return fmt.Errorf("failed to handle interactive session: %w", err)
 	}
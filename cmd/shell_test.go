@@ -556,7 +556,7 @@ func TestResolveShellCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := resolveShellCommand(tt.override, tt.userConfig)
+			got := resolveShellCommand(tt.override, tt.userConfig, nil)
 			if len(got) != len(tt.want) {
 				t.Fatalf("resolveShellCommand() = %v, want %v", got, tt.want)
 			}
@@ -864,3 +864,47 @@ func TestShellRespectsBashrc(t *testing.T) {
 		}
 	}
 }
+
+func TestResolveShellCommand_DevcontainerShell(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{Shell: "/usr/bin/zsh"}
+
+	if got := resolveShellCommand("", nil, devContainer); got[0] != "/usr/bin/zsh" {
+		t.Errorf("resolveShellCommand() = %v, want the devcontainer shell", got)
+	}
+	if got := resolveShellCommand("", &config.UserConfig{Shell: "fish"}, devContainer); got[0] != "fish" {
+		t.Errorf("resolveShellCommand() = %v, want user config to win over devcontainer", got)
+	}
+	if got := resolveShellCommand("/bin/dash", &config.UserConfig{Shell: "fish"}, devContainer); got[0] != "/bin/dash" {
+		t.Errorf("resolveShellCommand() = %v, want --shell to win", got)
+	}
+	if got := resolveShellCommand("", nil, &devcontainer.DevContainer{}); got[0] != DefaultShell {
+		t.Errorf("resolveShellCommand() = %v, want %s when nothing is set", got, DefaultShell)
+	}
+}
+
+// fakeShellExecutor reports whether `test -x` finds the probed program.
+type fakeShellExecutor struct {
+	present map[string]bool
+}
+
+func (f *fakeShellExecutor) Exec(ctx context.Context, user string, cmd []string) (string, string, int, error) {
+	if f.present[cmd[len(cmd)-1]] {
+		return "", "", 0, nil
+	}
+	return "", "", 1, nil
+}
+
+func TestApplyShellFallback(t *testing.T) {
+	withBash := &fakeShellExecutor{present: map[string]bool{"/bin/bash": true}}
+	withoutBash := &fakeShellExecutor{present: map[string]bool{}}
+
+	if got := applyShellFallback(context.Background(), withBash, "root", []string{"/bin/bash", "-i"}); got[0] != "/bin/bash" {
+		t.Errorf("applyShellFallback() = %v, want bash kept when present", got)
+	}
+	if got := applyShellFallback(context.Background(), withoutBash, "root", []string{"/bin/bash", "-i"}); got[0] != "/bin/sh" || got[1] != "-i" {
+		t.Errorf("applyShellFallback() = %v, want [/bin/sh -i] without bash", got)
+	}
+	if got := applyShellFallback(context.Background(), withoutBash, "root", []string{"/usr/bin/zsh", "-i"}); got[0] != "/usr/bin/zsh" {
+		t.Errorf("applyShellFallback() = %v, want explicit shells left alone", got)
+	}
+}
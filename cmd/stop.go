@@ -8,7 +8,6 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
-	"github.com/garaemon/devgo/pkg/devcontainer"
 )
 
 func runStopCommand(args []string) error {
@@ -19,14 +18,19 @@ func runStopCommand(args []string) error {
 
 	workspaceDir := determineWorkspaceFolder(devcontainerPath)
 
-	devContainer, err := devcontainer.Parse(devcontainerPath)
+	resolved, err := resolveConfig(devcontainerPath, configOverrides)
 	if err != nil {
-		return fmt.Errorf("failed to parse devcontainer.json: %w", err)
+		return err
+	}
+	devContainer := resolved.DevContainer
+
+	if devContainer.HasDockerCompose() {
+		return composeTeardown(context.Background(), devContainer, workspaceDir, "stop")
 	}
 
 	containerName := determineContainerName(devContainer, workspaceDir)
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -49,3 +52,21 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRunStopCommand_ComposeProject(t *testing.T) {
+	logPath := installFakeDocker(t)
+	workspace := useComposeWorkspace(t)
+
+	if err := runStopCommand(nil); err != nil {
+		t.Fatalf("runStopCommand() error = %v", err)
+	}
+
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read docker log: %v", err)
+	}
+	want := "compose -f " + filepath.Join(workspace, "compose.yml") + " -f " + filepath.Join(workspace, "compose.dev.yml") + " stop"
+	if got := strings.TrimSpace(string(logged)); got != want {
+		t.Errorf("docker called with %q, want %q", got, want)
+	}
+}
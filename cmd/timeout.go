@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	devgolog "github.com/garaemon/devgo/pkg/log"
+)
+
+// phaseTracker remembers which step of a long operation is running so a
+// timeout can say where it happened. It is safe for concurrent use because
+// background lifecycle commands update it from their own goroutine.
+type phaseTracker struct {
+	mu    sync.Mutex
+	phase string
+}
+
+func (p *phaseTracker) set(phase string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase = phase
+}
+
+func (p *phaseTracker) current() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phase
+}
+
+type phaseTrackerKey struct{}
+
+// setPhase records the current phase on the tracker carried by ctx, if any.
+// The phase stays with ctx rather than the logger, so nothing outside the
+// operation is tagged with it.
+func setPhase(ctx context.Context, phase string) {
+	if tracker, ok := ctx.Value(phaseTrackerKey{}).(*phaseTracker); ok {
+		tracker.set(phase)
+	}
+	logger.PhaseLogf(devgolog.LevelDebug, phase, "Phase: %s", phase)
+}
+
+// runWithDeadline runs fn with a context that carries a phase tracker and,
+// when timeout is positive, a deadline. If the deadline expires the returned
+// error names the phase that was in progress.
+func runWithDeadline(parent context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	tracker := &phaseTracker{}
+	ctx := context.WithValue(parent, phaseTrackerKey{}, tracker)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		phase := tracker.current()
+		if phase == "" {
+			phase = "setup"
+		}
+		return fmt.Errorf("timed out after %s during %s: %w", timeout, phase, err)
+	}
+	return err
+}
+
+// pullImageWithTimeout pulls imageName under its own deadline when timeout is
+// positive, so a hung pull fails on its own without touching the deadline of
+// the rest of `devgo up`.
+func pullImageWithTimeout(ctx context.Context, dockerClient DockerClient, imageName string, timeout time.Duration) error {
+	if timeout <= 0 {
+		return dockerClient.PullImage(ctx, imageName)
+	}
+
+	pullCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := dockerClient.PullImage(pullCtx, imageName)
+	if err != nil && ctx.Err() == nil && errors.Is(pullCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("pull did not finish within --pull-timeout %s: %w", timeout, err)
+	}
+	return err
+}
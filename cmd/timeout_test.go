@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+func TestRunWithDeadline_SlowPullTimesOut(t *testing.T) {
+	mock := newMockDockerClient()
+	mock.blockPull = true
+	devContainer := &devcontainer.DevContainer{Image: "ubuntu:22.04"}
+
+	err := runWithDeadline(context.Background(), 20*time.Millisecond, func(ctx context.Context) error {
+		return startContainerWithDocker(ctx, devContainer, "test-container", t.TempDir(), mock)
+	})
+
+	if err == nil {
+		t.Fatal("expected timeout error but got none")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap context.DeadlineExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "during pull") {
+		t.Errorf("expected error to name the pull phase, got %v", err)
+	}
+	if len(mock.createdContainers) != 0 {
+		t.Error("no container should be created after the timeout")
+	}
+}
+
+func TestRunWithDeadline_NoTimeout(t *testing.T) {
+	wantErr := errors.New("boom")
+	err := runWithDeadline(context.Background(), 0, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("expected no deadline when timeout is zero")
+		}
+		setPhase(ctx, "create")
+		return wantErr
+	})
+	if err != wantErr {
+		t.Errorf("expected the original error unchanged, got %v", err)
+	}
+}
+
+func TestPullImageWithTimeout_SlowPull(t *testing.T) {
+	mock := newMockDockerClient()
+	mock.blockPull = true
+
+	err := pullImageWithTimeout(context.Background(), mock, "ubuntu:22.04", 20*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected timeout error but got none")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap context.DeadlineExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "--pull-timeout") {
+		t.Errorf("expected error to name --pull-timeout, got %v", err)
+	}
+}
+
+func TestPullImageWithTimeout_LeavesParentContext(t *testing.T) {
+	mock := newMockDockerClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := pullImageWithTimeout(ctx, mock, "ubuntu:22.04", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("the pull deadline must not cancel the parent context")
+	}
+	if len(mock.pulledImages) != 1 {
+		t.Errorf("pulledImages = %v, want one pull", mock.pulledImages)
+	}
+}
+
+// hungExecMock returns an exec mock whose first command never produces
+// output until a signal exec is created, the way a hung command only goes
+// away once it is killed. The signal execs are recorded in order.
+func hungExecMock(t *testing.T) (*mockExecClient, *[]container.ExecOptions) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() { _ = serverConn.Close() })
+	var signals []container.ExecOptions
+	mock := newRunningExecMock(types.HijackedResponse{})
+	mock.attach = func(config container.ExecOptions) types.HijackedResponse {
+		if len(config.Cmd) > 2 && config.Cmd[2] == signalExecScript {
+			signals = append(signals, config)
+			_ = serverConn.Close()
+			return createMockHijackedResponseWithStreams("", "")
+		}
+		return types.HijackedResponse{Conn: clientConn, Reader: bufio.NewReader(clientConn)}
+	}
+	return mock, &signals
+}
+
+func TestRunWithDeadline_HungLifecycleCommand(t *testing.T) {
+	mock, signals := hungExecMock(t)
+	devContainer := &devcontainer.DevContainer{WorkspaceFolder: "/workspace"}
+	commands := []devcontainer.NamedCommand{{Args: []string{"sleep", "infinity"}}}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- runWithDeadline(context.Background(), 20*time.Millisecond, func(ctx context.Context) error {
+			setPhase(ctx, devcontainer.WaitForPostCreateCommand)
+			return runLifecycleInContainer(ctx, mock, "test-container", "", commands, devContainer, devcontainer.WaitForPostCreateCommand)
+		})
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lifecycle command outlived the deadline")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "during postCreateCommand") {
+		t.Errorf("error = %v, want a timeout during postCreateCommand", err)
+	}
+	if len(*signals) != 1 {
+		t.Fatalf("signal execs = %d, want one kill", len(*signals))
+	}
+	kill := (*signals)[0]
+	token := findEnv(mock.execConfigs[0].Env, execTokenEnv)
+	if kill.User != "root" || kill.Cmd[4] != token || kill.Cmd[5] != "KILL" {
+		t.Errorf("kill exec = user %q cmd %q, want KILL for %s as root", kill.User, kill.Cmd[4:], token)
+	}
+}
+
+// findEnv returns the NAME=value entry of env for name, or "".
+func findEnv(env []string, name string) string {
+	for _, e := range env {
+		if strings.HasPrefix(e, name+"=") {
+			return e
+		}
+	}
+	return ""
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -14,12 +15,15 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/garaemon/devgo/pkg/config"
 	"github.com/garaemon/devgo/pkg/constants"
 	"github.com/garaemon/devgo/pkg/devcontainer"
+	"github.com/garaemon/devgo/pkg/dockersocket"
 	"github.com/garaemon/devgo/pkg/dotfiles"
+	devgolog "github.com/garaemon/devgo/pkg/log"
 	"github.com/garaemon/devgo/pkg/sshagent"
 	"github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -31,6 +35,66 @@ type DockerRunArgs struct {
 	WorkspaceDir    string
 	WorkspaceFolder string
 	Env             map[string]string
+	// User runs the container process (containerUser); empty keeps the
+	// image's user. Execs pick their own user, see GetTargetUser.
+	User string
+	// KeepUserID maps the host user to the same UID in the container
+	// (Podman's --userns=keep-id) for rootless runtimes.
+	KeepUserID bool
+	// WorkspaceMount replaces the bind of WorkspaceDir when set
+	// (workspaceMount). Otherwise WorkspaceOptions are appended to that
+	// bind, such as "z,cached"; see planWorkspaceMountOptions.
+	WorkspaceMount   *mount.Mount
+	WorkspaceOptions string
+	// ExtraBinds are additional "source:target[:options]" bind mounts.
+	ExtraBinds []string
+	// Mounts are the "mounts" of devcontainer.json.
+	Mounts []mount.Mount
+	// Ports are the forwardPorts published on the host loopback interface.
+	Ports []publishedPort
+	// Labels are user labels added next to devgo's reserved labels.
+	Labels map[string]string
+	// ConfigHash is recorded as a label to detect stale containers, see
+	// devcontainerConfigHash.
+	ConfigHash string
+	// Network is a network to join instead of the default bridge, with
+	// NetworkAliases as extra DNS names on it.
+	Network        string
+	NetworkAliases []string
+	// StrictArch fails instead of warning when Image does not match the host
+	// architecture (--strict-arch).
+	StrictArch bool
+	// RecreateNetwork recreates an unused Network that the container cannot
+	// join (--recreate-network-on-conflict). See prepareNetwork.
+	RecreateNetwork bool
+	// GroupAdd lists supplementary groups for the container's processes.
+	GroupAdd []string
+	// ReadonlyRootfs mounts the container's root filesystem read-only;
+	// Tmpfs (container path -> options) provides writable scratch space.
+	ReadonlyRootfs bool
+	Tmpfs          map[string]string
+	// Memory and MemorySwap are limits in bytes (0 leaves them unset,
+	// MemorySwap -1 means unlimited swap); MemorySwappiness is nil for the
+	// daemon default.
+	Memory           int64
+	MemorySwap       int64
+	MemorySwappiness *int64
+	// Devices are host devices made available in the container.
+	Devices []container.DeviceMapping
+	// GPUs requests GPUs for the container, nil for none; GPUOptional
+	// starts it without them when the engine cannot provide any. See
+	// gpuDeviceRequests.
+	GPUs        *container.DeviceRequest
+	GPUOptional bool
+	// HostOptions are the runArgs devgo applies, such as --privileged and
+	// --cap-add.
+	HostOptions runArgsHostConfig
+	// EntrypointScript, when set, is copied to entrypointScriptPath and run
+	// as the entrypoint ahead of the container command.
+	EntrypointScript string
+	// KeepImageCommand runs the image's entrypoint and command instead of
+	// devgo's keep-alive loop (overrideCommand: false).
+	KeepImageCommand bool
 }
 
 // DockerClient interface for Docker operations
@@ -41,6 +105,17 @@ type DockerClient interface {
 	CreateAndStartContainer(ctx context.Context, args DockerRunArgs) error
 	ImageExists(ctx context.Context, imageName string) (bool, error)
 	PullImage(ctx context.Context, imageName string) error
+	// ContainerImageID returns the ID of the image the container was created from.
+	ContainerImageID(ctx context.Context, name string) (string, error)
+	// ImageID returns the ID of the local image that imageName points to now.
+	ImageID(ctx context.Context, imageName string) (string, error)
+	// ContainerConfigHash returns the devcontainer config hash label of the
+	// container, or "" when it has none.
+	ContainerConfigHash(ctx context.Context, name string) (string, error)
+	RemoveContainer(ctx context.Context, name string) error
+	// WorkspaceContainers lists the devgo containers labelled with workspaceDir.
+	WorkspaceContainers(ctx context.Context, workspaceDir string) ([]container.Summary, error)
+	RenameContainer(ctx context.Context, oldName, newName string) error
 	Close() error
 }
 
@@ -51,6 +126,13 @@ type dockerAPIClient interface {
 	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.CreateResponse, error)
 	ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error)
 	ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error)
+	ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error)
+	ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+	networkClient
+	systemInfoClient
+	ContainerRename(ctx context.Context, containerID, newContainerName string) error
 	Close() error
 }
 
@@ -59,9 +141,13 @@ type dockerClientFactory func() (dockerAPIClient, error)
 
 // defaultDockerClientFactory creates a real Docker client
 func defaultDockerClientFactory() (dockerAPIClient, error) {
-	return client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	return newEngineClient()
 }
 
+// keepIDUsernsMode is Podman's user namespace mode that maps the invoking
+// user to the same UID inside the container.
+const keepIDUsernsMode = "keep-id"
+
 // realDockerClient implements DockerClient using Docker SDK
 type realDockerClient struct {
 	client dockerAPIClient
@@ -85,11 +171,28 @@ func runUpCommand(args []string) error {
 		return fmt.Errorf("failed to find devcontainer config: %w", err)
 	}
 
+	if checkOnly {
+		return runUpCheckOnly(context.Background(), devcontainerPath)
+	}
+
 	workspaceDir := determineWorkspaceFolder(devcontainerPath)
 
-	devContainer, err := devcontainer.Parse(devcontainerPath)
+	resolved, err := resolveEnvConfig(devcontainerPath, configOverrides)
 	if err != nil {
-		return fmt.Errorf("failed to parse devcontainer.json: %w", err)
+		return err
+	}
+	// Copy the shared config: building an image records the result in Image.
+	config := *resolved.DevContainer
+	devContainer := &config
+	if err := validateMemoryFlags(memoryLimit, memorySwap); err != nil {
+		return err
+	}
+	if err := validateNetworkFlags(networkName, networkAliases); err != nil {
+		return err
+	}
+
+	if removeOnExitFlag && devContainer.HasDockerCompose() {
+		return fmt.Errorf("--remove-on-exit is not supported with dockerComposeFile")
 	}
 
 	containerName := determineContainerName(devContainer, workspaceDir)
@@ -103,13 +206,28 @@ func runUpCommand(args []string) error {
 		}
 	}()
 
-	ctx := context.Background()
-
-	if err := executeInitializeCommand(devContainer, workspaceDir); err != nil {
-		return fmt.Errorf("failed to execute initialize command: %w", err)
+	parent := context.Background()
+	var created *createdTracker
+	if removeOnExitFlag {
+		var stop context.CancelFunc
+		parent, created, stop = removeOnExitSession()
+		defer stop()
 	}
 
-	return startContainerWithDocker(ctx, devContainer, containerName, workspaceDir, dockerClient)
+	err = runWithDeadline(parent, upTimeout, func(ctx context.Context) error {
+		if !skipInitialize {
+			setPhase(ctx, "initializeCommand")
+			if err := executeInitializeCommand(ctx, devContainer, workspaceDir); err != nil {
+				return fmt.Errorf("failed to execute initialize command: %w", err)
+			}
+		}
+
+		return startContainerWithDocker(ctx, devContainer, containerName, workspaceDir, dockerClient)
+	})
+	if !removeOnExitFlag {
+		return err
+	}
+	return runRemoveOnExit(parent, created, containerName, err)
 }
 
 func startContainerWithDocker(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string, dockerClient DockerClient) error {
@@ -119,33 +237,37 @@ func startContainerWithDocker(ctx context.Context, devContainer *devcontainer.De
 
 	// Determine the image to use
 	imageName := devContainer.Image
+	dockerfilePath := ""
 
-	// If no image is specified but build configuration exists, build the image
-	if imageName == "" && devContainer.HasBuild() {
+	// When a build configuration exists, the Dockerfile always produces the
+	// image. If "image" is also set it only names the build output (as in the
+	// devcontainer spec), so it becomes the tag instead of something to pull.
+	if devContainer.HasBuild() {
 		devcontainerPath, err := findDevcontainerConfig(configPath)
 		if err != nil {
 			return fmt.Errorf("failed to find devcontainer config: %w", err)
 		}
 
-		debugln("No image specified, building from Dockerfile...")
-		if err := buildDevContainer(devContainer, workspaceDir, devcontainerPath); err != nil {
+		setPhase(ctx, "build")
+		if imageName == "" {
+			debugln("No image specified, building from Dockerfile...")
+		} else {
+			debugf("Building image '%s' from Dockerfile...\n", imageName)
+		}
+		if err := buildDevContainer(ctx, devContainer, workspaceDir, devcontainerPath); err != nil {
 			return fmt.Errorf("failed to build dev container: %w", err)
 		}
 
 		// Use the built image
-		imageName = determineImageTag(devContainer, workspaceDir)
+		imageName = determineImageTags(devContainer, workspaceDir)[0]
 		devContainer.Image = imageName
+		dockerfilePath = determineDockerfilePath(devContainer, devcontainerPath)
 	}
 
 	if imageName == "" {
 		return fmt.Errorf("devcontainer must specify an image, build configuration, or docker compose configuration")
 	}
 
-	// TODO: Add support for --container-name option similar to devcontainer-cli runArgs
-	// The official devcontainer-cli doesn't have a direct --name option for the up command,
-	// but supports container naming through runArgs in devcontainer.json.
-	// We should consider adding a --container-name option for command-line convenience.
-
 	// Check if we need to pull the image
 	shouldPullImage := pull
 	if !shouldPullImage {
@@ -159,12 +281,13 @@ func startContainerWithDocker(ctx context.Context, devContainer *devcontainer.De
 
 	// Pull image if needed
 	if shouldPullImage {
+		setPhase(ctx, "pull")
 		if pull {
 			debugf("Pulling image '%s'\n", devContainer.Image)
 		} else {
 			debugf("Image '%s' not found locally, pulling...\n", devContainer.Image)
 		}
-		if err := dockerClient.PullImage(ctx, devContainer.Image); err != nil {
+		if err := pullImageWithTimeout(ctx, dockerClient, devContainer.Image, pullTimeout); err != nil {
 			return fmt.Errorf("failed to pull image '%s': %w", devContainer.Image, err)
 		}
 	}
@@ -174,22 +297,67 @@ func startContainerWithDocker(ctx context.Context, devContainer *devcontainer.De
 	if err != nil {
 		return fmt.Errorf("failed to check if container exists: %w", err)
 	}
+	if !exists && legacyMigrationEnabled() {
+		// Containers from older devgo versions carry the workspace label
+		// under a different name; adopt one rather than create a duplicate.
+		exists, err = adoptLegacyContainer(ctx, dockerClient, containerName, workspaceDir)
+		if err != nil {
+			return err
+		}
+	}
 
-	if exists {
+	configHash, err := devcontainerConfigHash(devContainer, dockerfilePath)
+	if err != nil {
+		warnf("%v", err)
+	}
+	recreate := rebuildContainer
+	if exists && !recreate {
+		stale, err := containerConfigChanged(ctx, dockerClient, containerName, configHash)
+		if err != nil {
+			return err
+		}
+		if stale && removeExisting {
+			debugf("Configuration changed since container '%s' was created, recreating it\n", containerName)
+			recreate = true
+		} else if stale {
+			warnf("devcontainer configuration changed since container '%s' was created; use --remove-existing-container or --rebuild to recreate it", containerName)
+		}
+	}
+
+	if exists && recreate {
+		debugf("Removing container '%s' to recreate it\n", containerName)
+	} else if exists {
 		running, err := dockerClient.IsContainerRunning(ctx, containerName)
 		if err != nil {
 			return fmt.Errorf("failed to check if container is running: %w", err)
 		}
 		if running {
-			return fmt.Errorf("container '%s' is already running", containerName)
+			if !recreateIfImageChanged {
+				return fmt.Errorf("container '%s' is already running", containerName)
+			}
+			changed, err := containerImageChanged(ctx, dockerClient, containerName, devContainer.Image)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				debugf("Container '%s' already runs the current image '%s'\n", containerName, devContainer.Image)
+				return nil
+			}
+			debugf("Image '%s' changed since container '%s' was created, recreating it\n", devContainer.Image, containerName)
+		} else if reuseStopped {
+			debugf("Container '%s' exists but is stopped, starting it again\n", containerName)
+			setPhase(ctx, "start")
+			if err := dockerClient.StartExistingContainer(ctx, containerName); err != nil {
+				return err
+			}
+			return executeLifecycleCommands(ctx, devContainer, containerName, workspaceDir, true)
+		} else {
+			debugf("Container '%s' exists but is stopped, removing and recreating it to apply configuration changes\n", containerName)
 		}
-		debugf("Container '%s' exists but is stopped, removing and recreating it to apply configuration changes\n", containerName)
-		
-		// Use raw docker client to remove the container
-		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-		if err == nil {
-			_ = cli.ContainerRemove(ctx, containerName, container.RemoveOptions{Force: true})
-			_ = cli.Close()
+	}
+	if exists {
+		if err := dockerClient.RemoveContainer(ctx, containerName); err != nil {
+			return err
 		}
 	}
 
@@ -201,33 +369,177 @@ func startContainerWithDocker(ctx context.Context, devContainer *devcontainer.De
 	}
 
 	expandedEnv := devContainer.GetContainerEnv(baseEnv)
+	var script string
+	if entrypointScriptFlag {
+		script, expandedEnv = planEntrypointScript(devContainer.ContainerEnv, expandedEnv)
+	}
+	expandedEnv = applyEnvFromHost(expandedEnv, envFromHost, os.LookupEnv)
 
+	setPhase(ctx, "create")
 	debugf("Creating and starting container '%s' with image '%s'\n", containerName, devContainer.Image)
 
+	gitConfig := planGitConfig(hostGitConfigPath(), copyGitConfigFlag)
+
+	fileLabels, err := loadLabelFiles(labelFiles)
+	if err != nil {
+		return err
+	}
+
+	cacheBinds, err := cacheVolumeBinds(GeneratePathHash(workspaceDir), cacheVolumes)
+	if err != nil {
+		return err
+	}
+	extraBinds := append(gitConfig.Binds, cacheBinds...)
+
+	var groupAdd []string
+	if mountDockerSocket {
+		socketBinds, socketGroups := dockerSocketMount(dockersocket.GetHostSocket, dockersocket.GroupID)
+		extraBinds = append(extraBinds, socketBinds...)
+		groupAdd = socketGroups
+	}
+
+	runArgsOptions := devContainer.GetRunArgsHostOptions()
+	if len(runArgsOptions.Unsupported) > 0 {
+		warnf("ignoring unsupported runArgs: %s", strings.Join(runArgsOptions.Unsupported, " "))
+	}
+	devices, err := deviceMappings(append(runArgsOptions.Devices, deviceSpecs...))
+	if err != nil {
+		return err
+	}
+	gpuRequest, gpuOptional, err := planGPUs(devContainer, gpus, runArgsOptions.GPUs)
+	if err != nil {
+		return err
+	}
+	hostOptions, err := planRunArgsHostConfig(runArgsOptions)
+	if err != nil {
+		return err
+	}
+	network := networkName
+	if network == "" {
+		network = runArgsOptions.Network
+	}
+	mounts, err := dockerMounts(devContainer.Mounts)
+	if err != nil {
+		return err
+	}
+	workspaceMount, err := dockerWorkspaceMount(devContainer)
+	if err != nil {
+		return err
+	}
+	workspaceOptions, err := planWorkspaceMountOptions(devContainer.WorkspaceMountOptions, mountConsistency, selinuxEnforcing())
+	if err != nil {
+		return err
+	}
+	ports, err := planPublishedPorts(devContainer, checkPortFree, os.Geteuid() == 0)
+	if err != nil {
+		return err
+	}
+
 	dockerArgs := DockerRunArgs{
-		Name:            containerName,
-		Image:           devContainer.Image,
-		WorkspaceDir:    workspaceDir,
-		WorkspaceFolder: devContainer.GetWorkspaceFolder(),
-		Env:             expandedEnv,
+		Name:             containerName,
+		Image:            devContainer.Image,
+		WorkspaceDir:     workspaceDir,
+		WorkspaceFolder:  devContainer.GetWorkspaceFolder(),
+		WorkspaceMount:   workspaceMount,
+		WorkspaceOptions: workspaceOptions,
+		Env:              expandedEnv,
+		User:             devContainer.ContainerUser,
+		KeepUserID:       rootlessRuntime(),
+		ExtraBinds:       extraBinds,
+		Mounts:           mounts,
+		Ports:            ports,
+		Labels:           fileLabels,
+		ConfigHash:       configHash,
+		Network:          network,
+		NetworkAliases:   networkAliases,
+		RecreateNetwork:  recreateNetwork,
+		StrictArch:       strictArch,
+		GroupAdd:         groupAdd,
+		ReadonlyRootfs:   readonlyRootfs || runArgsOptions.ReadonlyRootfs,
+		Tmpfs:            runArgsOptions.Tmpfs,
+		Memory:           memoryLimit,
+		MemorySwap:       memorySwap,
+		MemorySwappiness: memorySwappiness,
+		EntrypointScript: script,
+		Devices:          devices,
+		GPUs:             gpuRequest,
+		GPUOptional:      gpuOptional,
+		HostOptions:      hostOptions,
+		KeepImageCommand: !devContainer.ShouldOverrideCommand(),
 	}
 
 	if err := dockerClient.CreateAndStartContainer(ctx, dockerArgs); err != nil {
 		return err
 	}
 
-	return executeLifecycleCommands(ctx, devContainer, containerName, workspaceDir)
+	if err := copyGitConfig(ctx, gitConfig, containerName, devContainer.GetTargetUser()); err != nil {
+		warnf("failed to copy git config: %v", err)
+	}
+
+	return executeLifecycleCommands(ctx, devContainer, containerName, workspaceDir, false)
+}
+
+// lifecycleExecOptions returns the exec options for a lifecycle command,
+// running it in its cwd when the command sets one.
+func lifecycleExecOptions(devContainer *devcontainer.DevContainer, commandType string) execOptions {
+	opts := defaultExecOptions()
+	if cwd := devContainer.GetCommandCwd(commandType); cwd != "" {
+		opts.WorkingDir = resolveCommandCwd(devContainer.GetWorkspaceFolder(), cwd)
+	}
+	return opts
+}
+
+// resolveCommandCwd joins a lifecycle command's cwd onto the container path
+// base unless it is already absolute.
+func resolveCommandCwd(base, cwd string) string {
+	if path.IsAbs(cwd) {
+		return cwd
+	}
+	return path.Join(base, cwd)
+}
+
+// runLifecycleInContainer runs the commands of a lifecycle command in the
+// container, in parallel when they are named. See runNamedCommands. Their
+// output is logged in the phase commandType. Commands that run once per
+// container are marked completed afterwards; see runUserLifecycleCommand.
+func runLifecycleInContainer(ctx context.Context, cli DockerExecClient, containerName, workspaceDir string, commands []devcontainer.NamedCommand, devContainer *devcontainer.DevContainer, commandType string) error {
+	opts := lifecycleExecOptions(devContainer, commandType)
+	stdout, stderr := lifecycleOutput(commandType)
+	defer stdout.Flush()
+	defer stderr.Flush()
+	err := runNamedCommands(commands, stdout, stderr, func(args []string, stdout, stderr io.Writer) error {
+		commandOpts := opts
+		commandOpts.Stdout, commandOpts.Stderr = stdout, stderr
+		return executeCommandInContainerWithOptions(ctx, cli, containerName, args, devContainer, commandOpts)
+	})
+	if err != nil || !runsOnce(commandType) {
+		return err
+	}
+	if err := markLifecycleCompleted(ctx, cli, containerName, commandType, lifecycleDigest(devContainer, commandType, workspaceContentID(workspaceDir))); err != nil {
+		noteMarkerFailure(err)
+	}
+	return nil
+}
+
+// lifecycleOutput returns the writers for the stdout and stderr of the
+// lifecycle command commandType, which log each line in that phase to
+// devgo's own stdout and stderr.
+func lifecycleOutput(commandType string) (stdout, stderr *devgolog.LineWriter) {
+	return logger.WriterTo(os.Stdout, devgolog.LevelInfo, commandType), logger.Writer(devgolog.LevelInfo, commandType)
 }
 
 func executeOnCreateCommand(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string) error {
-	onCreateArgs := devContainer.GetOnCreateCommandArgs()
-	if len(onCreateArgs) == 0 {
+	commands, err := devContainer.GetLifecycleCommands("onCreateCommand")
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
 		return nil
 	}
 
-	debugf("Running onCreateCommand: %s\n", strings.Join(onCreateArgs, " "))
+	debugf("Running onCreateCommand: %s\n", describeCommands(commands))
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client for onCreateCommand: %w", err)
 	}
@@ -237,7 +549,7 @@ func executeOnCreateCommand(ctx context.Context, devContainer *devcontainer.DevC
 		}
 	}()
 
-	if err := executeCommandInContainer(ctx, cli, containerName, onCreateArgs, devContainer); err != nil {
+	if err := runLifecycleInContainer(ctx, cli, containerName, workspaceDir, commands, devContainer, "onCreateCommand"); err != nil {
 		return err
 	}
 
@@ -246,14 +558,17 @@ func executeOnCreateCommand(ctx context.Context, devContainer *devcontainer.DevC
 }
 
 func executeUpdateContentCommand(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string) error {
-	updateContentArgs := devContainer.GetUpdateContentCommandArgs()
-	if len(updateContentArgs) == 0 {
+	commands, err := devContainer.GetLifecycleCommands("updateContentCommand")
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
 		return nil
 	}
 
-	debugf("Running updateContentCommand: %s\n", strings.Join(updateContentArgs, " "))
+	debugf("Running updateContentCommand: %s\n", describeCommands(commands))
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client for updateContentCommand: %w", err)
 	}
@@ -263,7 +578,7 @@ func executeUpdateContentCommand(ctx context.Context, devContainer *devcontainer
 		}
 	}()
 
-	if err := executeCommandInContainer(ctx, cli, containerName, updateContentArgs, devContainer); err != nil {
+	if err := runLifecycleInContainer(ctx, cli, containerName, workspaceDir, commands, devContainer, "updateContentCommand"); err != nil {
 		return err
 	}
 
@@ -272,14 +587,17 @@ func executeUpdateContentCommand(ctx context.Context, devContainer *devcontainer
 }
 
 func executePostCreateCommand(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string) error {
-	postCreateArgs := devContainer.GetPostCreateCommandArgs()
-	if len(postCreateArgs) == 0 {
+	commands, err := devContainer.GetLifecycleCommands("postCreateCommand")
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
 		return nil
 	}
 
-	debugf("Running postCreateCommand: %s\n", strings.Join(postCreateArgs, " "))
+	debugf("Running postCreateCommand: %s\n", describeCommands(commands))
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client for postCreateCommand: %w", err)
 	}
@@ -289,7 +607,7 @@ func executePostCreateCommand(ctx context.Context, devContainer *devcontainer.De
 		}
 	}()
 
-	if err := executeCommandInContainer(ctx, cli, containerName, postCreateArgs, devContainer); err != nil {
+	if err := runLifecycleInContainer(ctx, cli, containerName, workspaceDir, commands, devContainer, "postCreateCommand"); err != nil {
 		return err
 	}
 
@@ -298,14 +616,17 @@ func executePostCreateCommand(ctx context.Context, devContainer *devcontainer.De
 }
 
 func executePostStartCommand(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string) error {
-	postStartArgs := devContainer.GetPostStartCommandArgs()
-	if len(postStartArgs) == 0 {
+	commands, err := devContainer.GetLifecycleCommands("postStartCommand")
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
 		return nil
 	}
 
-	debugf("Running postStartCommand: %s\n", strings.Join(postStartArgs, " "))
+	debugf("Running postStartCommand: %s\n", describeCommands(commands))
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client for postStartCommand: %w", err)
 	}
@@ -315,7 +636,7 @@ func executePostStartCommand(ctx context.Context, devContainer *devcontainer.Dev
 		}
 	}()
 
-	if err := executeCommandInContainer(ctx, cli, containerName, postStartArgs, devContainer); err != nil {
+	if err := runLifecycleInContainer(ctx, cli, containerName, workspaceDir, commands, devContainer, "postStartCommand"); err != nil {
 		return err
 	}
 
@@ -324,14 +645,17 @@ func executePostStartCommand(ctx context.Context, devContainer *devcontainer.Dev
 }
 
 func executePostAttachCommand(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string) error {
-	postAttachArgs := devContainer.GetPostAttachCommandArgs()
-	if len(postAttachArgs) == 0 {
+	commands, err := devContainer.GetLifecycleCommands("postAttachCommand")
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
 		return nil
 	}
 
-	debugf("Running postAttachCommand: %s\n", strings.Join(postAttachArgs, " "))
+	debugf("Running postAttachCommand: %s\n", describeCommands(commands))
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client for postAttachCommand: %w", err)
 	}
@@ -341,7 +665,7 @@ func executePostAttachCommand(ctx context.Context, devContainer *devcontainer.De
 		}
 	}()
 
-	if err := executeCommandInContainer(ctx, cli, containerName, postAttachArgs, devContainer); err != nil {
+	if err := runLifecycleInContainer(ctx, cli, containerName, workspaceDir, commands, devContainer, "postAttachCommand"); err != nil {
 		return err
 	}
 
@@ -349,20 +673,36 @@ func executePostAttachCommand(ctx context.Context, devContainer *devcontainer.De
 	return nil
 }
 
-func executeInitializeCommand(devContainer *devcontainer.DevContainer, workspaceDir string) error {
-	initArgs := devContainer.GetInitializeCommandArgs()
-	if len(initArgs) == 0 {
+func executeInitializeCommand(ctx context.Context, devContainer *devcontainer.DevContainer, workspaceDir string) error {
+	commands, err := devContainer.GetLifecycleCommands(devcontainer.WaitForInitializeCommand)
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
 		return nil
 	}
 
-	debugf("Running initializeCommand: %s\n", strings.Join(initArgs, " "))
+	debugf("Running initializeCommand: %s\n", describeCommands(commands))
 
-	cmd := exec.Command(initArgs[0], initArgs[1:]...)
-	cmd.Dir = workspaceDir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	dir := workspaceDir
+	if cwd := devContainer.GetCommandCwd(devcontainer.WaitForInitializeCommand); cwd != "" {
+		dir = cwd
+		if !filepath.IsAbs(cwd) {
+			dir = filepath.Join(workspaceDir, cwd)
+		}
+	}
+	stdout, stderr := lifecycleOutput(devcontainer.WaitForInitializeCommand)
+	defer stdout.Flush()
+	defer stderr.Flush()
+	err = runNamedCommands(commands, stdout, stderr, func(args []string, stdout, stderr io.Writer) error {
+		initArgs := hostShellArgs(args, runtime.GOOS)
+		cmd := exec.CommandContext(ctx, initArgs[0], initArgs[1:]...)
+		cmd.Dir = dir
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return cmd.Run()
+	})
+	if err != nil {
 		return fmt.Errorf("initializeCommand failed: %w", err)
 	}
 
@@ -416,6 +756,40 @@ func (r *realDockerClient) IsContainerRunning(ctx context.Context, containerName
 	return false, nil
 }
 
+func (r *realDockerClient) ContainerImageID(ctx context.Context, containerName string) (string, error) {
+	inspect, err := r.client.ContainerInspect(ctx, containerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return inspect.Image, nil
+}
+
+func (r *realDockerClient) ImageID(ctx context.Context, imageName string) (string, error) {
+	inspect, err := r.client.ImageInspect(ctx, imageName)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image: %w", err)
+	}
+	return inspect.ID, nil
+}
+
+func (r *realDockerClient) ContainerConfigHash(ctx context.Context, containerName string) (string, error) {
+	inspect, err := r.client.ContainerInspect(ctx, containerName)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if inspect.Config == nil {
+		return "", nil
+	}
+	return inspect.Config.Labels[devgoLabel(constants.DevgoConfigHashLabel)], nil
+}
+
+func (r *realDockerClient) RemoveContainer(ctx context.Context, containerName string) error {
+	if err := r.client.ContainerRemove(ctx, containerName, container.RemoveOptions{Force: true}); err != nil {
+		return fmt.Errorf("failed to remove container '%s': %w", containerName, err)
+	}
+	return nil
+}
+
 func (r *realDockerClient) StartExistingContainer(ctx context.Context, containerName string) error {
 	err := r.client.ContainerStart(ctx, containerName, container.StartOptions{})
 	if err != nil {
@@ -427,12 +801,7 @@ func (r *realDockerClient) StartExistingContainer(ctx context.Context, container
 
 func (r *realDockerClient) CreateAndStartContainer(ctx context.Context, args DockerRunArgs) error {
 	// Prepare environment variables
-	var env []string
-	if args.Env != nil {
-		for key, value := range args.Env {
-			env = append(env, fmt.Sprintf("%s=%s", key, value))
-		}
-	}
+	env := envMapToSlice(args.Env)
 
 	// Determine session name
 	session := sessionName
@@ -441,14 +810,34 @@ func (r *realDockerClient) CreateAndStartContainer(ctx context.Context, args Doc
 	}
 
 	// Create container configuration with devgo labels
-	labels := map[string]string{
-		constants.DevgoManagedLabel:   constants.DevgoManagedValue,
-		constants.DevgoWorkspaceLabel: args.WorkspaceDir,
-		constants.DevgoSessionLabel:   session,
+	reserved := map[string]string{
+		devgoLabel(constants.DevgoManagedLabel):   constants.DevgoManagedValue,
+		devgoLabel(constants.DevgoWorkspaceLabel): args.WorkspaceDir,
+		devgoLabel(constants.DevgoSessionLabel):   session,
 	}
+	if args.ConfigHash != "" {
+		reserved[devgoLabel(constants.DevgoConfigHashLabel)] = args.ConfigHash
+	}
+	labels := mergeContainerLabels(reserved, args.Labels)
 
 	// Create host configuration with volume mounts
-	binds := []string{fmt.Sprintf("%s:%s", args.WorkspaceDir, args.WorkspaceFolder)}
+	var binds []string
+	mounts := args.Mounts
+	if args.WorkspaceMount != nil {
+		mounts = append([]mount.Mount{*args.WorkspaceMount}, mounts...)
+	} else {
+		workspaceBind := fmt.Sprintf("%s:%s", engineHostPath(args.WorkspaceDir, currentRuntime()), args.WorkspaceFolder)
+		if args.WorkspaceOptions != "" {
+			workspaceBind += ":" + args.WorkspaceOptions
+		}
+		binds = append(binds, workspaceBind)
+	}
+
+	if args.ReadonlyRootfs {
+		mounts = append(mounts, lifecycleStateMount())
+	}
+
+	binds = append(binds, args.ExtraBinds...)
 
 	// Add SSH agent forwarding if available
 	if sshagent.IsAvailable() {
@@ -458,32 +847,71 @@ func (r *realDockerClient) CreateAndStartContainer(ctx context.Context, args Doc
 			binds = append(binds, fmt.Sprintf("%s:%s", mount.Source, mount.Target))
 
 			// Add SSH_AUTH_SOCK environment variable
-			sshEnv := sshagent.GetContainerEnv()
-			for key, value := range sshEnv {
-				env = append(env, fmt.Sprintf("%s=%s", key, value))
-			}
+			env = append(env, envMapToSlice(sshagent.GetContainerEnv())...)
 
 			debugf("SSH agent forwarding enabled: %s -> %s\n",
 				hostSocket, mount.Target)
 		}
 	}
 
+	exposedPorts, portMap := portBindings(args.Ports)
 	config := &container.Config{
-		Image:  args.Image,
-		Cmd:    []string{"sleep", "infinity"},
-		Env:    env,
-		Labels: labels,
+		Image:        args.Image,
+		Env:          env,
+		User:         args.User,
+		Labels:       labels,
+		ExposedPorts: exposedPorts,
 	}
 
 	hostConfig := &container.HostConfig{
-		Binds: binds,
+		Binds:          binds,
+		Mounts:         mounts,
+		PortBindings:   portMap,
+		GroupAdd:       args.GroupAdd,
+		ReadonlyRootfs: args.ReadonlyRootfs,
+		Tmpfs:          args.Tmpfs,
+		Resources: container.Resources{
+			Memory:           args.Memory,
+			MemorySwap:       args.MemorySwap,
+			MemorySwappiness: args.MemorySwappiness,
+			Devices:          args.Devices,
+		},
+	}
+	if err := checkImageArch(ctx, r.client, args.Image, runtime.GOARCH, args.StrictArch); err != nil {
+		return err
+	}
+	hostConfig.DeviceRequests = gpuDeviceRequests(ctx, r.client, args.GPUs, args.GPUOptional, currentRuntime())
+	if args.KeepUserID {
+		hostConfig.UsernsMode = container.UsernsMode(keepIDUsernsMode)
 	}
+	args.HostOptions.apply(config, hostConfig)
+	if args.Network != "" {
+		if !isNetworkMode(args.Network) {
+			if err := prepareNetwork(ctx, r.client, args.Network, args.RecreateNetwork); err != nil {
+				return err
+			}
+		}
+		hostConfig.NetworkMode = container.NetworkMode(args.Network)
+	}
+	entrypoint, cmd, err := containerCommand(ctx, r.client, args.Image, args.KeepImageCommand, args.EntrypointScript != "")
+	if err != nil {
+		return err
+	}
+	config.Entrypoint = entrypoint
+	config.Cmd = cmd
 
 	// Create the container
-	resp, err := r.client.ContainerCreate(ctx, config, hostConfig, nil, nil, args.Name)
+	resp, err := r.client.ContainerCreate(ctx, config, hostConfig, buildNetworkingConfig(args.Network, args.NetworkAliases), nil, args.Name)
 	if err != nil {
 		return fmt.Errorf("failed to create container: %w", err)
 	}
+	markContainerCreated(ctx)
+
+	if args.EntrypointScript != "" {
+		if err := copyFileToContainer(ctx, r.client, resp.ID, entrypointScriptPath, []byte(args.EntrypointScript), 0755); err != nil {
+			return err
+		}
+	}
 
 	// Start the container
 	err = r.client.ContainerStart(ctx, resp.ID, container.StartOptions{})
@@ -492,9 +920,29 @@ func (r *realDockerClient) CreateAndStartContainer(ctx context.Context, args Doc
 	}
 
 	debugf("Container '%s' started successfully\n", args.Name)
+	if len(args.Ports) > 0 {
+		r.reportForwardedPorts(ctx, resp.ID, args.Ports)
+	}
 	return nil
 }
 
+// reportForwardedPorts logs where each forwarded port ended up on the
+// host, so --quiet hides it and --log-format applies. Docker only knows
+// remapped ports once the container runs.
+func (r *realDockerClient) reportForwardedPorts(ctx context.Context, containerID string, ports []publishedPort) {
+	inspect, err := r.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		warnf("failed to read forwarded ports: %v", err)
+		return
+	}
+	if inspect.NetworkSettings == nil {
+		return
+	}
+	for _, line := range describeForwardedPorts(ports, inspect.NetworkSettings.Ports) {
+		logger.Logf(devgolog.LevelInfo, "%s\n", line)
+	}
+}
+
 func (r *realDockerClient) ImageExists(ctx context.Context, imageName string) (bool, error) {
 	images, err := r.client.ImageList(ctx, image.ListOptions{})
 	if err != nil {
@@ -502,15 +950,50 @@ func (r *realDockerClient) ImageExists(ctx context.Context, imageName string) (b
 	}
 
 	for _, img := range images {
-		for _, tag := range img.RepoTags {
-			if tag == imageName {
-				return true, nil
-			}
+		if imageMatchesReference(img, imageName) {
+			return true, nil
 		}
 	}
 	return false, nil
 }
 
+// imageMatchesReference reports whether img is the image ref names: one of
+// its tags, one of its repo digests (so an image pulled by digest is found
+// without a tag), or its ID. A "name:tag@digest" reference matches the
+// digest, since Docker records repo digests without the tag.
+func imageMatchesReference(img image.Summary, ref string) bool {
+	if ref == "" {
+		return false
+	}
+	for _, tag := range img.RepoTags {
+		if tag == ref {
+			return true
+		}
+	}
+
+	digestRef := ref
+	if name, digest, found := strings.Cut(ref, "@"); found {
+		digestRef = stripImageTag(name) + "@" + digest
+	}
+	for _, repoDigest := range img.RepoDigests {
+		if repoDigest == digestRef {
+			return true
+		}
+	}
+
+	return img.ID != "" && (img.ID == ref || img.ID == "sha256:"+ref)
+}
+
+// stripImageTag removes a ":tag" suffix from an image name, leaving a
+// registry port ("localhost:5000/app") alone.
+func stripImageTag(name string) string {
+	colon := strings.LastIndex(name, ":")
+	if colon > strings.LastIndex(name, "/") {
+		return name[:colon]
+	}
+	return name
+}
+
 func (r *realDockerClient) PullImage(ctx context.Context, imageName string) error {
 	resp, err := r.client.ImagePull(ctx, imageName, image.PullOptions{})
 	if err != nil {
@@ -563,7 +1046,7 @@ func updateRemoteUserUID(ctx context.Context, devContainer *devcontainer.DevCont
 
 	debugf("Updating container user '%s' UID/GID to match host (%d:%d)\n", targetUser, hostUID, hostGID)
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -571,16 +1054,7 @@ func updateRemoteUserUID(ctx context.Context, devContainer *devcontainer.DevCont
 		_ = cli.Close()
 	}()
 
-	// Commands to update UID/GID
-	// We use || true to make commands non-failing
-	commands := [][]string{
-		// Update user's UID
-		{"/bin/sh", "-c", fmt.Sprintf("usermod -u %d %s 2>/dev/null || true", hostUID, targetUser)},
-		// Update user's primary group GID
-		{"/bin/sh", "-c", fmt.Sprintf("groupmod -g %d %s 2>/dev/null || true", hostGID, targetUser)},
-		// Fix ownership of user's home directory
-		{"/bin/sh", "-c", fmt.Sprintf("chown -R %d:%d /home/%s 2>/dev/null || true", hostUID, hostGID, targetUser)},
-	}
+	commands := uidSyncCommands(targetUser, hostUID, hostGID, devContainer.GetWorkspaceFolder(), noWorkspaceChown)
 
 	// Execute commands as root
 	tempDevContainer := &devcontainer.DevContainer{
@@ -597,66 +1071,208 @@ func updateRemoteUserUID(ctx context.Context, devContainer *devcontainer.DevCont
 	return nil
 }
 
-func executeLifecycleCommands(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string) error {
-	// Update remote user UID/GID before executing lifecycle commands
-	if err := updateRemoteUserUID(ctx, devContainer, containerName); err != nil {
-		// Only warn, don't fail the entire lifecycle
-		warnf("failed to update remote user UID/GID: %v", err)
+// uidSyncCommands returns the commands that move targetUser to hostUID and
+// hostGID and fix the ownership of the home directory. With skipWorkspace,
+// the mounted workspaceFolder is left out of the ownership fix when it lives
+// under the home directory, since a recursive chown of a large workspace is
+// slow (and reaches through the bind mount to the host files).
+func uidSyncCommands(targetUser string, hostUID, hostGID int, workspaceFolder string, skipWorkspace bool) [][]string {
+	homeDir := "/home/" + targetUser
+	chown := fmt.Sprintf("chown -R %d:%d %s 2>/dev/null || true", hostUID, hostGID, homeDir)
+	if skipWorkspace && (workspaceFolder == homeDir || strings.HasPrefix(workspaceFolder, homeDir+"/")) {
+		chown = fmt.Sprintf("find %s -path %s -prune -o -exec chown -h %d:%d {} + 2>/dev/null || true",
+			homeDir, shellJoin([]string{workspaceFolder}), hostUID, hostGID)
+	}
+
+	// We use || true to make commands non-failing
+	return [][]string{
+		// Update user's UID
+		{"/bin/sh", "-c", fmt.Sprintf("usermod -u %d %s 2>/dev/null || true", hostUID, targetUser)},
+		// Update user's primary group GID
+		{"/bin/sh", "-c", fmt.Sprintf("groupmod -g %d %s 2>/dev/null || true", hostGID, targetUser)},
+		// Fix ownership of user's home directory
+		{"/bin/sh", "-c", chown},
 	}
+}
+
+// lifecycleStep is a container-side lifecycle command and its executor.
+type lifecycleStep struct {
+	commandType string
+	executor    func(context.Context, *devcontainer.DevContainer, string, string) error
+}
 
-	commands := []struct {
-		commandType string
-		executor    func(context.Context, *devcontainer.DevContainer, string, string) error
-	}{
+// lifecycleSteps returns the lifecycle commands to run, in order. A created
+// container runs all of them; a restarted one skips onCreateCommand and
+// postCreateCommand, which belong to creation only, while
+// updateContentCommand refreshes content on every up.
+func lifecycleSteps(restarted bool) []lifecycleStep {
+	steps := []lifecycleStep{
 		{devcontainer.WaitForOnCreateCommand, executeOnCreateCommand},
 		{devcontainer.WaitForUpdateContentCommand, executeUpdateContentCommand},
 		{devcontainer.WaitForPostCreateCommand, executePostCreateCommand},
 		{devcontainer.WaitForPostStartCommand, executePostStartCommand},
 	}
+	if !restarted {
+		return steps
+	}
+
+	var restartSteps []lifecycleStep
+	for _, step := range steps {
+		if step.commandType == devcontainer.WaitForOnCreateCommand || step.commandType == devcontainer.WaitForPostCreateCommand {
+			continue
+		}
+		restartSteps = append(restartSteps, step)
+	}
+	return restartSteps
+}
+
+// splitLifecycleSteps sorts steps into the ones up waits for, the ones left
+// to run in the background and the command types that are not configured at
+// all. Absent stages are dropped, so a waitFor naming one simply waits for
+// the configured stages before it.
+func splitLifecycleSteps(devContainer *devcontainer.DevContainer, steps []lifecycleStep) (blocking, background []lifecycleStep, absent []string) {
+	for _, step := range steps {
+		// A malformed command counts as configured, so its step reports the
+		// error.
+		commands, err := devContainer.GetLifecycleCommands(step.commandType)
+		switch {
+		case err == nil && len(commands) == 0:
+			absent = append(absent, step.commandType)
+		case devContainer.ShouldWaitForCommand(step.commandType):
+			blocking = append(blocking, step)
+		default:
+			background = append(background, step)
+		}
+	}
+	return blocking, background, absent
+}
+
+func lifecycleStepNames(steps []lifecycleStep) []string {
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.commandType)
+	}
+	return names
+}
+
+// executeLifecycleCommands runs the lifecycle commands for a container that
+// was just created or, with restarted set, started again.
+func executeLifecycleCommands(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string, restarted bool) error {
+	// Update remote user UID/GID before executing lifecycle commands
+	if err := updateRemoteUserUID(ctx, devContainer, containerName); err != nil {
+		// Only warn, don't fail the entire lifecycle
+		warnf("failed to update remote user UID/GID: %v", err)
+	}
+
+	blocking, background, absent := splitLifecycleSteps(devContainer, lifecycleSteps(restarted))
 
 	waitFor := devContainer.GetWaitFor()
-	debugf("Executing lifecycle commands up to: %s\n", waitFor)
+	if len(absent) > 0 {
+		debugf("Skipping lifecycle commands that are not set: %s\n", strings.Join(absent, ", "))
+	}
+	if len(blocking) == 0 {
+		debugf("No lifecycle commands to wait for (waitFor: %s)\n", waitFor)
+	} else {
+		debugf("Executing lifecycle commands up to %s: %s\n", waitFor, strings.Join(lifecycleStepNames(blocking), ", "))
+	}
 
 	// Execute commands synchronously until waitFor
-	for _, cmd := range commands {
-		if devContainer.ShouldWaitForCommand(cmd.commandType) {
-			if err := cmd.executor(ctx, devContainer, containerName, workspaceDir); err != nil {
-				return fmt.Errorf("failed to execute %s: %w", cmd.commandType, err)
+	for _, cmd := range blocking {
+		setPhase(ctx, cmd.commandType)
+		if err := cmd.executor(ctx, devContainer, containerName, workspaceDir); err != nil {
+			if err := lifecycleFailure(cmd.commandType, err, lifecycleErrorPolicy); err != nil {
+				return err
 			}
 		}
 	}
 
 	debugf("Container is ready for use (waitFor: %s completed)\n", waitFor)
 
+	postAttach := lifecycleStep{"postAttachCommand", executePostAttachCommand}
+	personalSetup := func(ctx context.Context, devContainer *devcontainer.DevContainer, containerName string) {
+		applyPersonalSetup(ctx, devContainer, containerName, restarted)
+	}
+	return runLifecycleTail(ctx, devContainer, containerName, workspaceDir, background, postAttach, personalSetup, postAttachInForeground)
+}
+
+// runLifecycleTail runs what follows waitFor: the background steps, the
+// personal setup in finish, and postAttach. postAttach normally runs right
+// after the background steps and a failure is only logged. In the
+// foreground it runs after finish, as the very last step before up returns,
+// and a failure fails up.
+func runLifecycleTail(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir string, background []lifecycleStep, postAttach lifecycleStep, finish func(context.Context, *devcontainer.DevContainer, string), foreground bool) error {
 	// Execute remaining commands asynchronously
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for _, cmd := range commands {
-			if !devContainer.ShouldWaitForCommand(cmd.commandType) {
-				if err := cmd.executor(ctx, devContainer, containerName, workspaceDir); err != nil {
-					warnf("background command %s failed: %v", cmd.commandType, err)
-				}
+		for _, cmd := range background {
+			setPhase(ctx, cmd.commandType)
+			if err := cmd.executor(ctx, devContainer, containerName, workspaceDir); err != nil {
+				warnf("background command %s failed: %v", cmd.commandType, err)
 			}
 		}
 
+		if foreground {
+			return
+		}
 		// Always execute postAttachCommand last
-		if err := executePostAttachCommand(ctx, devContainer, containerName, workspaceDir); err != nil {
+		setPhase(ctx, postAttach.commandType)
+		if err := postAttach.executor(ctx, devContainer, containerName, workspaceDir); err != nil {
 			warnf("background postAttachCommand failed: %v", err)
 		}
 	}()
 
 	wg.Wait()
 
-	// Personal dotfiles run after every team-defined lifecycle command so
-	// that team setup always completes first. Failures are logged but do
-	// not fail the up command.
+	finish(ctx, devContainer, containerName)
+
+	if !foreground {
+		return nil
+	}
+	setPhase(ctx, postAttach.commandType)
+	if err := postAttach.executor(ctx, devContainer, containerName, workspaceDir); err != nil {
+		return lifecycleFailure(postAttach.commandType, err, lifecycleErrorPolicy)
+	}
+	return nil
+}
+
+// Policies for a failed lifecycle command that `devgo up` waits for
+// (--on-lifecycle-error).
+const (
+	lifecycleErrorFail     = "fail"
+	lifecycleErrorContinue = "continue"
+)
+
+// lifecycleFailure applies policy to the failure err of the lifecycle
+// command step: "continue" logs a warning and returns nil, anything else
+// fails up.
+func lifecycleFailure(step string, err error, policy string) error {
+	if policy == lifecycleErrorContinue {
+		warnf("%s failed, continuing: %v", step, err)
+		return nil
+	}
+	return fmt.Errorf("failed to execute %s: %w", step, err)
+}
+
+// applyPersonalSetup applies the user's dotfiles. Personal dotfiles run
+// after every team-defined lifecycle command so that team setup always
+// completes first. Failures are logged but do not fail the up command.
+// --copy-dotfiles only runs for a created container, like onCreateCommand:
+// on a restarted one the copy is already there, and extracting over it
+// would keep deleted files and run the install script again.
+func applyPersonalSetup(ctx context.Context, devContainer *devcontainer.DevContainer, containerName string, restarted bool) {
+	setPhase(ctx, "dotfiles")
 	if err := applyDotfiles(ctx, devContainer, containerName); err != nil {
 		warnf("dotfiles step failed for container %s: %v", containerName, err)
 	}
-
-	return nil
+	if copyDotfilesDir != "" && restarted {
+		debugf("Container %s was restarted, not copying dotfiles again\n", containerName)
+	} else if copyDotfilesDir != "" {
+		if err := applyCopyDotfiles(ctx, devContainer, containerName, copyDotfilesDir); err != nil {
+			warnf("copying dotfiles failed for container %s: %v", containerName, err)
+		}
+	}
 }
 
 // applyDotfiles loads the user's persistent dotfiles config, merges CLI
@@ -681,7 +1297,7 @@ func applyDotfiles(ctx context.Context, devContainer *devcontainer.DevContainer,
 		return nil
 	}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client for dotfiles: %w", err)
 	}
@@ -716,13 +1332,10 @@ func startContainerWithDockerCompose(ctx context.Context, devContainer *devconta
 	}
 
 	// Build docker compose command arguments
-	var composeArgs []string
-	for _, file := range composeFiles {
-		composeArgs = append(composeArgs, "-f", filepath.Join(workspaceDir, file))
-	}
+	composeArgs := composeFileArgs(composeFiles, workspaceDir)
 
 	// Create override file for containerEnv if needed
-	if len(devContainer.ContainerEnv) > 0 {
+	if len(devContainer.ContainerEnv) > 0 || len(envFromHost) > 0 {
 		// Get base environment variables for expansion
 		baseEnv, err := getComposeServiceEnv(workspaceDir, composeFiles, devContainer.GetService())
 		if err != nil {
@@ -731,6 +1344,7 @@ func startContainerWithDockerCompose(ctx context.Context, devContainer *devconta
 		}
 
 		expandedEnv := devContainer.GetContainerEnv(baseEnv)
+		expandedEnv = applyEnvFromHost(expandedEnv, envFromHost, os.LookupEnv)
 		overrideFile, err := createComposeOverrideFile(devContainer.GetService(), expandedEnv)
 		if err != nil {
 			return fmt.Errorf("failed to create compose override file: %w", err)
@@ -745,6 +1359,10 @@ func startContainerWithDockerCompose(ctx context.Context, devContainer *devconta
 		}
 	}
 
+	if gpus != "" || devContainer.GPURequirement() != devcontainer.GPUNone {
+		warnf("GPUs are not requested for compose services; reserve them under deploy.resources.reservations.devices in the compose file")
+	}
+
 	// Determine which services to run
 	runServices := devContainer.GetRunServices()
 	if len(runServices) == 0 {
@@ -752,8 +1370,15 @@ func startContainerWithDockerCompose(ctx context.Context, devContainer *devconta
 	}
 
 	// Start docker compose services
-	upArgs := append(composeArgs, append([]string{"up", "-d"}, runServices...)...)
-	upCmd := exec.Command("docker", append([]string{"compose"}, upArgs...)...)
+	upArgs := append(composeArgs, "up", "-d")
+	if rebuildContainer {
+		// Rebuild the service images and replace the containers, which
+		// runs onCreate and postCreate commands again.
+		upArgs = append(upArgs, "--build", "--force-recreate")
+	}
+	upArgs = append(upArgs, runServices...)
+	setPhase(ctx, "compose up")
+	upCmd := composeCommand(ctx, upArgs...)
 	upCmd.Dir = workspaceDir
 	upCmd.Stdout = os.Stdout
 	upCmd.Stderr = os.Stderr
@@ -764,11 +1389,16 @@ func startContainerWithDockerCompose(ctx context.Context, devContainer *devconta
 	}
 
 	debugf("Docker compose services started successfully\n")
-	return executeLifecycleCommands(ctx, devContainer, containerName, workspaceDir)
+	if waitHealthyService {
+		if err := waitForHealthyService(ctx, workspaceDir, devContainer.GetService()); err != nil {
+			return fmt.Errorf("service '%s' is not ready: %w", devContainer.GetService(), err)
+		}
+	}
+	return executeLifecycleCommands(ctx, devContainer, containerName, workspaceDir, false)
 }
 
 func getImageEnv(ctx context.Context, imageName string) (map[string]string, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return nil, err
 	}
@@ -791,6 +1421,26 @@ func getImageEnv(ctx context.Context, imageName string) (map[string]string, erro
 	return env, nil
 }
 
+// composeFileArgs returns the "-f FILE" arguments for the compose files,
+// which are relative to workspaceDir.
+func composeFileArgs(composeFiles []string, workspaceDir string) []string {
+	var args []string
+	for _, file := range composeFilePaths(composeFiles, workspaceDir) {
+		args = append(args, "-f", file)
+	}
+	return args
+}
+
+// composeFilePaths resolves the dockerComposeFile entries against the
+// workspace directory.
+func composeFilePaths(composeFiles []string, workspaceDir string) []string {
+	paths := make([]string, 0, len(composeFiles))
+	for _, file := range composeFiles {
+		paths = append(paths, filepath.Join(workspaceDir, file))
+	}
+	return paths
+}
+
 func getComposeServiceEnv(workspaceDir string, composeFiles []string, service string) (map[string]string, error) {
 	// Use docker compose config to get the environment
 	var args []string
@@ -799,7 +1449,7 @@ func getComposeServiceEnv(workspaceDir string, composeFiles []string, service st
 	}
 	args = append(args, "config", "--format", "json")
 
-	cmd := exec.Command("docker", append([]string{"compose"}, args...)...)
+	cmd := composeCommand(context.Background(), args...)
 	cmd.Dir = workspaceDir
 	output, err := cmd.Output()
 	if err != nil {
@@ -857,8 +1507,8 @@ func createComposeOverrideFile(service string, env map[string]string) (string, e
 	fmt.Fprintf(&content, "  %s:\n", service)
 	content.WriteString("    environment:\n")
 
-	for k, v := range env {
-		escapedVal := strings.ReplaceAll(v, "\\", "\\\\")
+	for _, k := range sortedEnvKeys(env) {
+		escapedVal := strings.ReplaceAll(env[k], "\\", "\\\\")
 		escapedVal = strings.ReplaceAll(escapedVal, "\"", "\\\"")
 		fmt.Fprintf(&content, "      %s: \"%s\"\n", k, escapedVal)
 	}
@@ -870,3 +1520,52 @@ func createComposeOverrideFile(service string, env map[string]string) (string, e
 
 	return file.Name(), nil
 }
+
+// applyEnvFromHost copies the host value of each --env-from-host name into
+// env under the same name and returns env, allocating it when nil. Names
+// unset on the host are skipped with a warning so a missing variable never
+// ends up as an empty string in the container.
+func applyEnvFromHost(env map[string]string, names []string, lookup func(string) (string, bool)) map[string]string {
+	if env == nil && len(names) > 0 {
+		env = make(map[string]string)
+	}
+	for _, name := range names {
+		value, ok := lookup(name)
+		if !ok {
+			warnf("--env-from-host %s: not set on the host, skipping", name)
+			continue
+		}
+		env[name] = value
+	}
+	return env
+}
+
+// applyConfigOverrides applies each --config-override "key=value" to
+// devContainer in order.
+func applyConfigOverrides(devContainer *devcontainer.DevContainer, overrides []string) error {
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --config-override %q: expected key=value", override)
+		}
+		if err := devContainer.ApplyOverride(key, value); err != nil {
+			return fmt.Errorf("invalid --config-override %q: %w", override, err)
+		}
+	}
+	return nil
+}
+
+// containerImageChanged reports whether imageName now resolves to a different
+// image ID than the one the container was created from, e.g. after a rebuild
+// or a pull moved the tag.
+func containerImageChanged(ctx context.Context, dockerClient DockerClient, containerName, imageName string) (bool, error) {
+	containerImage, err := dockerClient.ContainerImageID(ctx, containerName)
+	if err != nil {
+		return false, err
+	}
+	currentImage, err := dockerClient.ImageID(ctx, imageName)
+	if err != nil {
+		return false, err
+	}
+	return containerImage != currentImage, nil
+}
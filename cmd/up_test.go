@@ -1,17 +1,26 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/client"
 	"github.com/garaemon/devgo/pkg/devcontainer"
+	devgolog "github.com/garaemon/devgo/pkg/log"
 	"github.com/opencontainers/image-spec/specs-go/v1"
 )
 
@@ -27,8 +36,16 @@ type mockDockerClient struct {
 	isRunningError    error
 	imageExistsError  error
 	pullImageError    error
+	blockPull         bool              // PullImage waits until ctx is done
+	containerImageIDs map[string]string // container name -> image ID it was created from
+	imageIDs          map[string]string // image name -> current image ID
+	configHashes      map[string]string // container name -> config hash label
+	removedContainers []string
 	createdContainers []DockerRunArgs
 	pulledImages      []string
+	// workspaceContainers is what WorkspaceContainers returns.
+	workspaceContainers []container.Summary
+	renamedContainers   []string // "old->new"
 }
 
 func newMockDockerClient() *mockDockerClient {
@@ -83,6 +100,10 @@ func (m *mockDockerClient) ImageExists(ctx context.Context, imageName string) (b
 }
 
 func (m *mockDockerClient) PullImage(ctx context.Context, imageName string) error {
+	if m.blockPull {
+		<-ctx.Done()
+		return ctx.Err()
+	}
 	if m.pullImageError != nil {
 		return m.pullImageError
 	}
@@ -91,6 +112,39 @@ func (m *mockDockerClient) PullImage(ctx context.Context, imageName string) erro
 	return nil
 }
 
+func (m *mockDockerClient) ContainerImageID(ctx context.Context, name string) (string, error) {
+	return m.containerImageIDs[name], nil
+}
+
+func (m *mockDockerClient) ImageID(ctx context.Context, imageName string) (string, error) {
+	return m.imageIDs[imageName], nil
+}
+
+func (m *mockDockerClient) ContainerConfigHash(ctx context.Context, name string) (string, error) {
+	return m.configHashes[name], nil
+}
+
+func (m *mockDockerClient) RemoveContainer(ctx context.Context, name string) error {
+	delete(m.containers, name)
+	m.removedContainers = append(m.removedContainers, name)
+	return nil
+}
+
+func (m *mockDockerClient) WorkspaceContainers(ctx context.Context, workspaceDir string) ([]container.Summary, error) {
+	return m.workspaceContainers, nil
+}
+
+func (m *mockDockerClient) RenameContainer(ctx context.Context, oldName, newName string) error {
+	running, exists := m.containers[oldName]
+	if !exists {
+		return fmt.Errorf("container %s does not exist", oldName)
+	}
+	delete(m.containers, oldName)
+	m.containers[newName] = running
+	m.renamedContainers = append(m.renamedContainers, oldName+"->"+newName)
+	return nil
+}
+
 func (m *mockDockerClient) Close() error {
 	return nil
 }
@@ -316,6 +370,19 @@ type mockDockerAPIClient struct {
 	listError      error
 	imageListError error
 	pullError      error
+	// createdConfig, createdHostConfig and createdNetworkingConfig record the
+	// last ContainerCreate call.
+	createdConfig           *container.Config
+	createdHostConfig       *container.HostConfig
+	createdNetworkingConfig *network.NetworkingConfig
+	// copiedTo records the destination directory of each CopyToContainer.
+	copiedTo []string
+	// info is what Info reports about the engine.
+	info system.Info
+	// imageInspect is what ImageInspect returns for every image.
+	imageInspect image.InspectResponse
+	// startError is what ContainerStart returns.
+	startError error
 }
 
 func (m *mockDockerAPIClient) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
@@ -326,10 +393,13 @@ func (m *mockDockerAPIClient) ContainerList(ctx context.Context, options contain
 }
 
 func (m *mockDockerAPIClient) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
-	return nil
+	return m.startError
 }
 
 func (m *mockDockerAPIClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *v1.Platform, containerName string) (container.CreateResponse, error) {
+	m.createdConfig = config
+	m.createdHostConfig = hostConfig
+	m.createdNetworkingConfig = networkingConfig
 	return container.CreateResponse{}, nil
 }
 
@@ -348,6 +418,43 @@ func (m *mockDockerAPIClient) ImagePull(ctx context.Context, refStr string, opti
 	return io.NopCloser(strings.NewReader("")), nil
 }
 
+func (m *mockDockerAPIClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	return container.InspectResponse{}, nil
+}
+
+func (m *mockDockerAPIClient) ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error) {
+	return m.imageInspect, nil
+}
+
+func (m *mockDockerAPIClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	return nil
+}
+
+func (m *mockDockerAPIClient) CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+	m.copiedTo = append(m.copiedTo, dstPath)
+	return nil
+}
+
+func (m *mockDockerAPIClient) Info(ctx context.Context) (system.Info, error) {
+	return m.info, nil
+}
+
+func (m *mockDockerAPIClient) ContainerRename(ctx context.Context, containerID, newContainerName string) error {
+	return nil
+}
+
+func (m *mockDockerAPIClient) NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error) {
+	return network.Inspect{Name: networkID, Scope: "local", Driver: "bridge"}, nil
+}
+
+func (m *mockDockerAPIClient) NetworkRemove(ctx context.Context, networkID string) error {
+	return nil
+}
+
+func (m *mockDockerAPIClient) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
+	return network.CreateResponse{}, nil
+}
+
 func (m *mockDockerAPIClient) Close() error {
 	return nil
 }
@@ -701,6 +808,21 @@ func TestRealDockerClientImageExists(t *testing.T) {
 			expectedResult: false,
 			expectError:    false,
 		},
+		{
+			name:      "image pulled by digest has only repo digests",
+			imageName: "ubuntu@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			setupMock: func(m *mockDockerAPIClient) {
+				m.images = []image.Summary{
+					{
+						ID:          "sha256:feedface",
+						RepoTags:    nil,
+						RepoDigests: []string{"ubuntu@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+					},
+				}
+			},
+			expectedResult: true,
+			expectError:    false,
+		},
 		{
 			name:      "docker api error",
 			imageName: "ubuntu:22.04",
@@ -1611,3 +1733,904 @@ func TestUpdateRemoteUserUID(t *testing.T) {
 		})
 	}
 }
+
+func TestStartContainerWithDocker_BuildsAndTagsImageWhenBothSet(t *testing.T) {
+	fakeBuild := installFakeImageBuild(t)
+
+	tempDir := t.TempDir()
+	devcontainerDir := filepath.Join(tempDir, ".devcontainer")
+	if err := os.MkdirAll(devcontainerDir, 0755); err != nil {
+		t.Fatalf("failed to create devcontainer dir: %v", err)
+	}
+	devcontainerPath := filepath.Join(devcontainerDir, "devcontainer.json")
+	if err := os.WriteFile(devcontainerPath, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("failed to write devcontainer.json: %v", err)
+	}
+
+	originalConfigPath := configPath
+	originalImageName := imageName
+	defer func() {
+		configPath = originalConfigPath
+		imageName = originalImageName
+	}()
+	configPath = devcontainerPath
+	imageName = ""
+
+	devContainer := &devcontainer.DevContainer{
+		Image:           "myorg/app:dev",
+		Build:           &devcontainer.BuildConfig{Dockerfile: "Dockerfile"},
+		WorkspaceFolder: "/workspace",
+	}
+	mockDocker := newMockDockerClient()
+	// The build leaves the tagged image in the local store.
+	mockDocker.addImage("myorg/app:dev")
+
+	if err := startContainerWithDocker(context.Background(), devContainer, "test-container", tempDir, mockDocker); err != nil {
+		t.Fatalf("startContainerWithDocker() error = %v", err)
+	}
+
+	if len(fakeBuild.builds) != 1 || !reflect.DeepEqual(fakeBuild.builds[0].Tags, []string{"myorg/app:dev"}) {
+		t.Errorf("expected one build tagged with the image field, got %+v", fakeBuild.builds)
+	}
+	if len(mockDocker.pulledImages) != 0 {
+		t.Errorf("expected no pull for a built image, pulled %v", mockDocker.pulledImages)
+	}
+	if len(mockDocker.createdContainers) != 1 || mockDocker.createdContainers[0].Image != "myorg/app:dev" {
+		t.Errorf("expected container created from myorg/app:dev, got %+v", mockDocker.createdContainers)
+	}
+}
+
+func TestApplyEnvFromHost(t *testing.T) {
+	hostEnv := map[string]string{"GITHUB_TOKEN": "secret", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := hostEnv[name]
+		return value, ok
+	}
+
+	env := map[string]string{"EXISTING": "kept", "GITHUB_TOKEN": "from-config"}
+	env = applyEnvFromHost(env, []string{"GITHUB_TOKEN", "EMPTY", "UNSET_VAR"}, lookup)
+
+	if env["GITHUB_TOKEN"] != "secret" {
+		t.Errorf("GITHUB_TOKEN = %q, want the host value", env["GITHUB_TOKEN"])
+	}
+	if value, ok := env["EMPTY"]; !ok || value != "" {
+		t.Errorf("EMPTY should be forwarded as an empty string, got %q (present: %t)", value, ok)
+	}
+	if _, ok := env["UNSET_VAR"]; ok {
+		t.Error("UNSET_VAR is not set on the host and should be skipped")
+	}
+	if env["EXISTING"] != "kept" {
+		t.Errorf("EXISTING = %q, want unrelated entries untouched", env["EXISTING"])
+	}
+
+	// A configuration without containerEnv yields a nil map.
+	if got := applyEnvFromHost(nil, []string{"GITHUB_TOKEN"}, lookup); got["GITHUB_TOKEN"] != "secret" {
+		t.Errorf("applyEnvFromHost(nil) = %v, want GITHUB_TOKEN forwarded", got)
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_SortedEnv(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	mockAPI := &mockDockerAPIClient{}
+	dockerClient := &realDockerClient{client: mockAPI}
+
+	err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+		Name:            "test-container",
+		Image:           "ubuntu:22.04",
+		WorkspaceDir:    "/host/workspace",
+		WorkspaceFolder: "/workspace",
+		Env: map[string]string{
+			"PATH":    "/custom/bin:/usr/bin",
+			"APP_ENV": "dev",
+			"HOME":    "/root",
+			"ZED":     "last",
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateAndStartContainer() error = %v", err)
+	}
+
+	want := []string{"APP_ENV=dev", "HOME=/root", "PATH=/custom/bin:/usr/bin", "ZED=last"}
+	got := mockAPI.createdConfig.Env
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Env = %v, want %v", got, want)
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_User(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	tests := []struct {
+		name string
+		user string
+	}{
+		{name: "containerUser runs the container process", user: "vscode"},
+		{name: "no containerUser keeps the image user", user: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := &mockDockerAPIClient{}
+			dockerClient := &realDockerClient{client: mockAPI}
+
+			err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+				Name:            "test-container",
+				Image:           "ubuntu:22.04",
+				WorkspaceDir:    "/host/workspace",
+				WorkspaceFolder: "/workspace",
+				User:            tt.user,
+			})
+			if err != nil {
+				t.Fatalf("CreateAndStartContainer() error = %v", err)
+			}
+			if got := mockAPI.createdConfig.User; got != tt.user {
+				t.Errorf("User = %q, want %q", got, tt.user)
+			}
+		})
+	}
+}
+
+func TestStartContainerWithDocker_RecreateIfImageChanged(t *testing.T) {
+	originalRecreate := recreateIfImageChanged
+	defer func() { recreateIfImageChanged = originalRecreate }()
+	recreateIfImageChanged = true
+
+	tests := []struct {
+		name           string
+		containerImage string
+		currentImage   string
+		expectRecreate bool
+	}{
+		{name: "image unchanged", containerImage: "sha256:aaa", currentImage: "sha256:aaa", expectRecreate: false},
+		{name: "image rebuilt", containerImage: "sha256:aaa", currentImage: "sha256:bbb", expectRecreate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockDockerClient()
+			mock.addImage("ubuntu:22.04")
+			mock.addContainer("test-container", true)
+			mock.containerImageIDs = map[string]string{"test-container": tt.containerImage}
+			mock.imageIDs = map[string]string{"ubuntu:22.04": tt.currentImage}
+
+			changed, err := containerImageChanged(context.Background(), mock, "test-container", "ubuntu:22.04")
+			if err != nil {
+				t.Fatalf("containerImageChanged() error = %v", err)
+			}
+			if changed != tt.expectRecreate {
+				t.Errorf("containerImageChanged() = %t, want %t", changed, tt.expectRecreate)
+			}
+
+			devContainer := &devcontainer.DevContainer{Image: "ubuntu:22.04"}
+			if err := startContainerWithDocker(context.Background(), devContainer, "test-container", t.TempDir(), mock); err != nil {
+				t.Fatalf("startContainerWithDocker() error = %v", err)
+			}
+			recreated := len(mock.removedContainers) == 1 && len(mock.createdContainers) == 1
+			if recreated != tt.expectRecreate {
+				t.Errorf("recreated = %t (removed %v, created %d), want %t",
+					recreated, mock.removedContainers, len(mock.createdContainers), tt.expectRecreate)
+			}
+		})
+	}
+}
+
+func TestStartContainerWithDocker_Rebuild(t *testing.T) {
+	originalRebuild := rebuildContainer
+	originalReuse := reuseStopped
+	defer func() {
+		rebuildContainer = originalRebuild
+		reuseStopped = originalReuse
+	}()
+	rebuildContainer = true
+	// --rebuild wins over reusing a stopped container.
+	reuseStopped = true
+
+	for _, running := range []bool{true, false} {
+		t.Run(fmt.Sprintf("running=%t", running), func(t *testing.T) {
+			mock := newMockDockerClient()
+			mock.addImage("ubuntu:22.04")
+			mock.addContainer("test-container", running)
+
+			devContainer := &devcontainer.DevContainer{Image: "ubuntu:22.04"}
+			if err := startContainerWithDocker(context.Background(), devContainer, "test-container", t.TempDir(), mock); err != nil {
+				t.Fatalf("startContainerWithDocker() error = %v", err)
+			}
+			if len(mock.removedContainers) != 1 || len(mock.createdContainers) != 1 {
+				t.Errorf("removed %v, created %d, want the container removed and created again",
+					mock.removedContainers, len(mock.createdContainers))
+			}
+		})
+	}
+}
+
+func TestApplyConfigOverrides(t *testing.T) {
+	tests := []struct {
+		name            string
+		overrides       []string
+		expectImage     string
+		expectWorkspace string
+		expectError     bool
+	}{
+		{
+			name:            "override image",
+			overrides:       []string{"image=alpine:3.20"},
+			expectImage:     "alpine:3.20",
+			expectWorkspace: "/workspace",
+		},
+		{
+			name:            "override workspaceFolder",
+			overrides:       []string{"workspaceFolder=/src"},
+			expectImage:     "ubuntu:22.04",
+			expectWorkspace: "/src",
+		},
+		{
+			name:        "unknown field is rejected",
+			overrides:   []string{"nope=1"},
+			expectError: true,
+		},
+		{
+			name:        "non-scalar field is rejected",
+			overrides:   []string{"mounts=/a"},
+			expectError: true,
+		},
+		{
+			name:        "missing separator is rejected",
+			overrides:   []string{"image"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &devcontainer.DevContainer{Image: "ubuntu:22.04", WorkspaceFolder: "/workspace"}
+			err := applyConfigOverrides(dc, tt.overrides)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if dc.Image != tt.expectImage {
+				t.Errorf("Image = %q, want %q", dc.Image, tt.expectImage)
+			}
+			if dc.WorkspaceFolder != tt.expectWorkspace {
+				t.Errorf("WorkspaceFolder = %q, want %q", dc.WorkspaceFolder, tt.expectWorkspace)
+			}
+		})
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_Network(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	mockAPI := &mockDockerAPIClient{}
+	dockerClient := &realDockerClient{client: mockAPI}
+
+	err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+		Name:            "test-container",
+		Image:           "ubuntu:22.04",
+		WorkspaceDir:    "/host/workspace",
+		WorkspaceFolder: "/workspace",
+		Network:         "devnet",
+		NetworkAliases:  []string{"api"},
+	})
+	if err != nil {
+		t.Fatalf("CreateAndStartContainer() error = %v", err)
+	}
+
+	if mockAPI.createdHostConfig.NetworkMode != "devnet" {
+		t.Errorf("NetworkMode = %q, want %q", mockAPI.createdHostConfig.NetworkMode, "devnet")
+	}
+	if mockAPI.createdNetworkingConfig == nil {
+		t.Fatal("expected a networking config for --network")
+	}
+	endpoint := mockAPI.createdNetworkingConfig.EndpointsConfig["devnet"]
+	if endpoint == nil || strings.Join(endpoint.Aliases, ",") != "api" {
+		t.Errorf("endpoint = %+v, want aliases [api]", endpoint)
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_WorkspaceMount(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	mockAPI := &mockDockerAPIClient{}
+	dockerClient := &realDockerClient{client: mockAPI}
+
+	workspaceMount := mount.Mount{Type: mount.TypeVolume, Source: "app-src", Target: "/workspace"}
+	err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+		Name:             "test-container",
+		Image:            "ubuntu:22.04",
+		WorkspaceDir:     "/host/workspace",
+		WorkspaceFolder:  "/workspace",
+		WorkspaceMount:   &workspaceMount,
+		WorkspaceOptions: "z",
+		Mounts:           []mount.Mount{{Type: mount.TypeVolume, Source: "cache", Target: "/cache"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateAndStartContainer() error = %v", err)
+	}
+
+	for _, bind := range mockAPI.createdHostConfig.Binds {
+		if strings.HasPrefix(bind, "/host/workspace:") {
+			t.Errorf("Binds = %v, want no default workspace bind", mockAPI.createdHostConfig.Binds)
+		}
+	}
+	mounts := mockAPI.createdHostConfig.Mounts
+	if len(mounts) != 2 || mounts[0] != workspaceMount || mounts[1].Target != "/cache" {
+		t.Errorf("Mounts = %+v, want the workspace mount before /cache", mounts)
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_HostNetwork(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	mockAPI := &mockDockerAPIClient{}
+	dockerClient := &realDockerClient{client: mockAPI}
+
+	err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+		Name:            "test-container",
+		Image:           "ubuntu:22.04",
+		WorkspaceDir:    "/host/workspace",
+		WorkspaceFolder: "/workspace",
+		Network:         "host",
+		HostOptions:     runArgsHostConfig{Privileged: true, CapAdd: []string{"SYS_PTRACE"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateAndStartContainer() error = %v", err)
+	}
+
+	if mockAPI.createdHostConfig.NetworkMode != "host" || mockAPI.createdNetworkingConfig != nil {
+		t.Errorf("NetworkMode = %q, networking config = %+v, want host mode without endpoints",
+			mockAPI.createdHostConfig.NetworkMode, mockAPI.createdNetworkingConfig)
+	}
+	if !mockAPI.createdHostConfig.Privileged || len(mockAPI.createdHostConfig.CapAdd) != 1 {
+		t.Errorf("Privileged = %t, CapAdd = %v, want the runArgs applied", mockAPI.createdHostConfig.Privileged, mockAPI.createdHostConfig.CapAdd)
+	}
+}
+
+func TestLifecycleExecOptions_Cwd(t *testing.T) {
+	devContainer := &devcontainer.DevContainer{
+		WorkspaceFolder:   "/workspace",
+		PostCreateCommand: map[string]interface{}{"command": "npm i", "cwd": "frontend"},
+		PostStartCommand:  map[string]interface{}{"command": "make", "cwd": "/opt/tools"},
+		OnCreateCommand:   "echo hi",
+	}
+
+	tests := []struct {
+		commandType string
+		expectDir   string
+	}{
+		{devcontainer.WaitForPostCreateCommand, "/workspace/frontend"},
+		{devcontainer.WaitForPostStartCommand, "/opt/tools"},
+		{devcontainer.WaitForOnCreateCommand, "/workspace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.commandType, func(t *testing.T) {
+			mock := newRunningExecMock(createMockHijackedResponseWithStreams("", ""))
+			opts := lifecycleExecOptions(devContainer, tt.commandType)
+			opts.Stdout = io.Discard
+			opts.Stderr = io.Discard
+			if err := executeCommandInContainerWithOptions(context.Background(), mock, "test-container", []string{"true"}, devContainer, opts); err != nil {
+				t.Fatalf("executeCommandInContainerWithOptions() error = %v", err)
+			}
+			if mock.lastExecConfig.WorkingDir != tt.expectDir {
+				t.Errorf("WorkingDir = %q, want %q", mock.lastExecConfig.WorkingDir, tt.expectDir)
+			}
+		})
+	}
+}
+
+func TestRunLifecycleInContainer_LogsOutput(t *testing.T) {
+	var out bytes.Buffer
+	oldLogger := logger
+	logger = devgolog.New(&out)
+	defer func() { logger = oldLogger }()
+
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = oldStdout }()
+
+	devContainer := &devcontainer.DevContainer{WorkspaceFolder: "/workspace"}
+	mock := newRunningExecMock(createMockHijackedResponseWithStreams("installed\n", "no lockfile"))
+	commands := []devcontainer.NamedCommand{{Args: []string{"npm", "i"}}}
+	runErr := runLifecycleInContainer(context.Background(), mock, "test-container", "", commands, devContainer, devcontainer.WaitForPostCreateCommand)
+	_ = w.Close()
+	os.Stdout = oldStdout
+	stdout, _ := io.ReadAll(r)
+	if runErr != nil {
+		t.Fatalf("runLifecycleInContainer() error = %v", runErr)
+	}
+
+	// The command's stdout stays on stdout, so `devgo up | ...` still sees it.
+	if got, want := string(stdout), "[postCreateCommand] installed\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got, want := out.String(), "[postCreateCommand] no lockfile\n"; got != want {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_ReadonlyRootfs(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	mockAPI := &mockDockerAPIClient{}
+	dockerClient := &realDockerClient{client: mockAPI}
+
+	err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+		Name:            "test-container",
+		Image:           "ubuntu:22.04",
+		WorkspaceDir:    "/host/workspace",
+		WorkspaceFolder: "/workspace",
+		ReadonlyRootfs:  true,
+		Tmpfs:           map[string]string{"/tmp": "size=64m"},
+	})
+	if err != nil {
+		t.Fatalf("CreateAndStartContainer() error = %v", err)
+	}
+
+	if !mockAPI.createdHostConfig.ReadonlyRootfs {
+		t.Error("ReadonlyRootfs = false, want true")
+	}
+	if mockAPI.createdHostConfig.Tmpfs["/tmp"] != "size=64m" {
+		t.Errorf("Tmpfs = %v, want /tmp paired with the read-only rootfs", mockAPI.createdHostConfig.Tmpfs)
+	}
+	// Lifecycle markers need a writable place despite the read-only rootfs.
+	if mounts := mockAPI.createdHostConfig.Mounts; len(mounts) != 1 || mounts[0] != lifecycleStateMount() {
+		t.Errorf("Mounts = %+v, want the lifecycle state volume", mounts)
+	}
+}
+
+func TestLifecycleSteps(t *testing.T) {
+	tests := []struct {
+		name      string
+		restarted bool
+		want      []string
+	}{
+		{
+			name:      "created container runs every step",
+			restarted: false,
+			want: []string{
+				devcontainer.WaitForOnCreateCommand,
+				devcontainer.WaitForUpdateContentCommand,
+				devcontainer.WaitForPostCreateCommand,
+				devcontainer.WaitForPostStartCommand,
+			},
+		},
+		{
+			name:      "restarted container skips creation-only steps",
+			restarted: true,
+			want: []string{
+				devcontainer.WaitForUpdateContentCommand,
+				devcontainer.WaitForPostStartCommand,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, step := range lifecycleSteps(tt.restarted) {
+				got = append(got, step.commandType)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lifecycleSteps(%t) = %v, want %v", tt.restarted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitLifecycleSteps(t *testing.T) {
+	tests := []struct {
+		name           string
+		devContainer   *devcontainer.DevContainer
+		wantBlocking   []string
+		wantBackground []string
+		wantAbsent     []string
+	}{
+		{
+			name: "waitFor points at an absent command",
+			devContainer: &devcontainer.DevContainer{
+				WaitFor:          devcontainer.WaitForPostCreateCommand,
+				OnCreateCommand:  "make setup",
+				PostStartCommand: "make serve",
+			},
+			wantBlocking:   []string{devcontainer.WaitForOnCreateCommand},
+			wantBackground: []string{devcontainer.WaitForPostStartCommand},
+			wantAbsent:     []string{devcontainer.WaitForUpdateContentCommand, devcontainer.WaitForPostCreateCommand},
+		},
+		{
+			name: "no commands configured",
+			devContainer: &devcontainer.DevContainer{
+				WaitFor: devcontainer.WaitForPostStartCommand,
+			},
+			wantAbsent: []string{
+				devcontainer.WaitForOnCreateCommand,
+				devcontainer.WaitForUpdateContentCommand,
+				devcontainer.WaitForPostCreateCommand,
+				devcontainer.WaitForPostStartCommand,
+			},
+		},
+		{
+			name: "default waitFor with every command",
+			devContainer: &devcontainer.DevContainer{
+				OnCreateCommand:      "a",
+				UpdateContentCommand: "b",
+				PostCreateCommand:    "c",
+				PostStartCommand:     "d",
+			},
+			wantBlocking:   []string{devcontainer.WaitForOnCreateCommand, devcontainer.WaitForUpdateContentCommand},
+			wantBackground: []string{devcontainer.WaitForPostCreateCommand, devcontainer.WaitForPostStartCommand},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocking, background, absent := splitLifecycleSteps(tt.devContainer, lifecycleSteps(false))
+			if got := lifecycleStepNames(blocking); !reflect.DeepEqual(got, tt.wantBlocking) {
+				t.Errorf("blocking = %v, want %v", got, tt.wantBlocking)
+			}
+			if got := lifecycleStepNames(background); !reflect.DeepEqual(got, tt.wantBackground) {
+				t.Errorf("background = %v, want %v", got, tt.wantBackground)
+			}
+			if !reflect.DeepEqual(absent, tt.wantAbsent) {
+				t.Errorf("absent = %v, want %v", absent, tt.wantAbsent)
+			}
+		})
+	}
+}
+
+func TestStartContainerWithDocker_ReuseStopped(t *testing.T) {
+	originalReuse := reuseStopped
+	defer func() { reuseStopped = originalReuse }()
+	reuseStopped = true
+
+	mock := newMockDockerClient()
+	mock.addImage("ubuntu:22.04")
+	mock.addContainer("test-container", false)
+
+	devContainer := &devcontainer.DevContainer{Image: "ubuntu:22.04"}
+	if err := startContainerWithDocker(context.Background(), devContainer, "test-container", t.TempDir(), mock); err != nil {
+		t.Fatalf("startContainerWithDocker() error = %v", err)
+	}
+	if !mock.containers["test-container"] {
+		t.Error("expected stopped container to be started again")
+	}
+	if len(mock.removedContainers) != 0 || len(mock.createdContainers) != 0 {
+		t.Errorf("expected no recreation, removed %v, created %d", mock.removedContainers, len(mock.createdContainers))
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_Memory(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	swappiness := int64(10)
+
+	tests := []struct {
+		name             string
+		memory           int64
+		memorySwap       int64
+		memorySwappiness *int64
+	}{
+		{name: "no limits", memory: 0, memorySwap: 0, memorySwappiness: nil},
+		{name: "memory and swap", memory: 512 * 1024 * 1024, memorySwap: 1024 * 1024 * 1024, memorySwappiness: &swappiness},
+		{name: "unlimited swap", memory: 512 * 1024 * 1024, memorySwap: -1, memorySwappiness: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := &mockDockerAPIClient{}
+			dockerClient := &realDockerClient{client: mockAPI}
+
+			err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+				Name:             "test-container",
+				Image:            "ubuntu:22.04",
+				WorkspaceDir:     "/host/workspace",
+				WorkspaceFolder:  "/workspace",
+				Memory:           tt.memory,
+				MemorySwap:       tt.memorySwap,
+				MemorySwappiness: tt.memorySwappiness,
+			})
+			if err != nil {
+				t.Fatalf("CreateAndStartContainer() error = %v", err)
+			}
+
+			resources := mockAPI.createdHostConfig.Resources
+			if resources.Memory != tt.memory {
+				t.Errorf("Memory = %d, want %d", resources.Memory, tt.memory)
+			}
+			if resources.MemorySwap != tt.memorySwap {
+				t.Errorf("MemorySwap = %d, want %d", resources.MemorySwap, tt.memorySwap)
+			}
+			if !reflect.DeepEqual(resources.MemorySwappiness, tt.memorySwappiness) {
+				t.Errorf("MemorySwappiness = %v, want %v", resources.MemorySwappiness, tt.memorySwappiness)
+			}
+		})
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_EntrypointScript(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	tests := []struct {
+		name           string
+		script         string
+		wantEntrypoint []string
+		wantCmd        []string
+		wantCopies     int
+	}{
+		{name: "no script keeps the image entrypoint", script: "", wantCmd: []string{"/bin/sh", "-c", keepAliveScript}, wantCopies: 0},
+		{name: "with script", script: "#!/bin/sh\nexec \"$@\"\n", wantEntrypoint: []string{"/bin/sh", entrypointScriptPath}, wantCmd: []string{"/bin/sh", "-c", keepAliveScript}, wantCopies: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := &mockDockerAPIClient{}
+			dockerClient := &realDockerClient{client: mockAPI}
+
+			err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+				Name:             "test-container",
+				Image:            "ubuntu:22.04",
+				WorkspaceDir:     "/host/workspace",
+				WorkspaceFolder:  "/workspace",
+				EntrypointScript: tt.script,
+			})
+			if err != nil {
+				t.Fatalf("CreateAndStartContainer() error = %v", err)
+			}
+			if got := []string(mockAPI.createdConfig.Entrypoint); !reflect.DeepEqual(got, tt.wantEntrypoint) {
+				t.Errorf("Entrypoint = %v, want %v", got, tt.wantEntrypoint)
+			}
+			if got := []string(mockAPI.createdConfig.Cmd); !reflect.DeepEqual(got, tt.wantCmd) {
+				t.Errorf("Cmd = %v, want %v", got, tt.wantCmd)
+			}
+			if len(mockAPI.copiedTo) != tt.wantCopies {
+				t.Errorf("copies = %v, want %d", mockAPI.copiedTo, tt.wantCopies)
+			}
+		})
+	}
+}
+
+func TestUidSyncCommands(t *testing.T) {
+	tests := []struct {
+		name            string
+		workspaceFolder string
+		skipWorkspace   bool
+		wantChown       string
+	}{
+		{
+			name:            "default chowns the whole home",
+			workspaceFolder: "/home/vscode/project",
+			skipWorkspace:   false,
+			wantChown:       "chown -R 1000:1000 /home/vscode 2>/dev/null || true",
+		},
+		{
+			name:            "skip prunes a workspace under home",
+			workspaceFolder: "/home/vscode/project",
+			skipWorkspace:   true,
+			wantChown:       "find /home/vscode -path '/home/vscode/project' -prune -o -exec chown -h 1000:1000 {} + 2>/dev/null || true",
+		},
+		{
+			name:            "skip leaves a workspace outside home alone",
+			workspaceFolder: "/workspace",
+			skipWorkspace:   true,
+			wantChown:       "chown -R 1000:1000 /home/vscode 2>/dev/null || true",
+		},
+		{
+			name:            "skip with a home prefix that is not the home directory",
+			workspaceFolder: "/home/vscode2",
+			skipWorkspace:   true,
+			wantChown:       "chown -R 1000:1000 /home/vscode 2>/dev/null || true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := uidSyncCommands("vscode", 1000, 1000, tt.workspaceFolder, tt.skipWorkspace)
+			if len(commands) != 3 {
+				t.Fatalf("got %d commands, want 3", len(commands))
+			}
+			if got := commands[2][2]; got != tt.wantChown {
+				t.Errorf("chown command = %q, want %q", got, tt.wantChown)
+			}
+			if tt.skipWorkspace && strings.HasPrefix(tt.workspaceFolder, "/home/vscode/") {
+				for _, cmd := range commands {
+					if strings.Contains(cmd[2], "chown -R") {
+						t.Errorf("recursive chown %q should be omitted", cmd[2])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestImageMatchesReference(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	digestOnly := image.Summary{
+		ID:          "sha256:feedface",
+		RepoDigests: []string{"ubuntu@" + digest},
+	}
+	registryDigest := image.Summary{
+		ID:          "sha256:cafebabe",
+		RepoDigests: []string{"localhost:5000/team/app@" + digest},
+	}
+
+	tests := []struct {
+		name string
+		img  image.Summary
+		ref  string
+		want bool
+	}{
+		{name: "digest only image by digest", img: digestOnly, ref: "ubuntu@" + digest, want: true},
+		{name: "digest only image by tag and digest", img: digestOnly, ref: "ubuntu:22.04@" + digest, want: true},
+		{name: "digest only image by tag", img: digestOnly, ref: "ubuntu:22.04", want: false},
+		{name: "digest of another repository", img: digestOnly, ref: "debian@" + digest, want: false},
+		{name: "registry with port by digest", img: registryDigest, ref: "localhost:5000/team/app:v1@" + digest, want: true},
+		{name: "full image ID", img: digestOnly, ref: "sha256:feedface", want: true},
+		{name: "image ID without algorithm", img: digestOnly, ref: "feedface", want: true},
+		{name: "tag", img: image.Summary{RepoTags: []string{"ubuntu:22.04"}}, ref: "ubuntu:22.04", want: true},
+		{name: "empty reference", img: image.Summary{}, ref: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageMatchesReference(tt.img, tt.ref); got != tt.want {
+				t.Errorf("imageMatchesReference(%q) = %t, want %t", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_Devices(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	mockAPI := &mockDockerAPIClient{}
+	dockerClient := &realDockerClient{client: mockAPI}
+
+	devices := []container.DeviceMapping{
+		{PathOnHost: "/dev/ttyUSB0", PathInContainer: "/dev/ttyUSB0", CgroupPermissions: "rwm"},
+	}
+	err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+		Name:            "test-container",
+		Image:           "ubuntu:22.04",
+		WorkspaceDir:    "/host/workspace",
+		WorkspaceFolder: "/workspace",
+		Devices:         devices,
+	})
+	if err != nil {
+		t.Fatalf("CreateAndStartContainer() error = %v", err)
+	}
+	if !reflect.DeepEqual(mockAPI.createdHostConfig.Resources.Devices, devices) {
+		t.Errorf("Devices = %+v, want %+v", mockAPI.createdHostConfig.Resources.Devices, devices)
+	}
+}
+
+func TestRealDockerClient_CreateAndStartContainer_GPUs(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DEVGO_RUNTIME", "")
+	mockAPI := &mockDockerAPIClient{info: system.Info{Runtimes: map[string]system.RuntimeWithStatus{"nvidia": {}}}}
+	dockerClient := &realDockerClient{client: mockAPI}
+
+	request := container.DeviceRequest{Count: -1, Capabilities: [][]string{{"gpu"}}}
+	err := dockerClient.CreateAndStartContainer(context.Background(), DockerRunArgs{
+		Name:            "test-container",
+		Image:           "nvidia/cuda:12.4.0-base-ubuntu22.04",
+		WorkspaceDir:    "/host/workspace",
+		WorkspaceFolder: "/workspace",
+		GPUs:            &request,
+	})
+	if err != nil {
+		t.Fatalf("CreateAndStartContainer() error = %v", err)
+	}
+	want := []container.DeviceRequest{request}
+	if !reflect.DeepEqual(mockAPI.createdHostConfig.DeviceRequests, want) {
+		t.Errorf("DeviceRequests = %+v, want %+v", mockAPI.createdHostConfig.DeviceRequests, want)
+	}
+}
+
+func TestRunLifecycleTail(t *testing.T) {
+	tests := []struct {
+		name          string
+		foreground    bool
+		postAttachErr error
+		wantOrder     []string
+		wantErr       bool
+	}{
+		{
+			name:      "postAttach runs with the background steps by default",
+			wantOrder: []string{"postStartCommand", "postAttachCommand", "dotfiles"},
+		},
+		{
+			name:       "postAttach runs last in the foreground",
+			foreground: true,
+			wantOrder:  []string{"postStartCommand", "dotfiles", "postAttachCommand"},
+		},
+		{
+			name:          "background postAttach failure is only logged",
+			postAttachErr: fmt.Errorf("viewer crashed"),
+			wantOrder:     []string{"postStartCommand", "postAttachCommand", "dotfiles"},
+		},
+		{
+			name:          "foreground postAttach failure fails up",
+			foreground:    true,
+			postAttachErr: fmt.Errorf("viewer crashed"),
+			wantOrder:     []string{"postStartCommand", "dotfiles", "postAttachCommand"},
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var order []string
+			record := func(step string, err error) func(context.Context, *devcontainer.DevContainer, string, string) error {
+				return func(context.Context, *devcontainer.DevContainer, string, string) error {
+					mu.Lock()
+					defer mu.Unlock()
+					order = append(order, step)
+					return err
+				}
+			}
+			background := []lifecycleStep{{commandType: "postStartCommand", executor: record("postStartCommand", nil)}}
+			postAttach := lifecycleStep{commandType: "postAttachCommand", executor: record("postAttachCommand", tt.postAttachErr)}
+			finish := func(ctx context.Context, dc *devcontainer.DevContainer, name string) {
+				_ = record("dotfiles", nil)(ctx, dc, name, "")
+			}
+
+			err := runLifecycleTail(context.Background(), &devcontainer.DevContainer{}, "test-container", "/workspace", background, postAttach, finish, tt.foreground)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runLifecycleTail() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestApplyPersonalSetup_CopyDotfilesOnlyOnCreate(t *testing.T) {
+	oldLogger, oldNoDotfiles, oldCopyDir := logger, noDotfiles, copyDotfilesDir
+	defer func() { logger, noDotfiles, copyDotfilesDir = oldLogger, oldNoDotfiles, oldCopyDir }()
+	noDotfiles = true
+	copyDotfilesDir = t.TempDir()
+
+	var out bytes.Buffer
+	logger = devgolog.New(&out)
+	logger.SetLevel(devgolog.LevelDebug)
+	applyPersonalSetup(context.Background(), &devcontainer.DevContainer{}, "devgo-missing-container", true)
+	if strings.Contains(out.String(), "copying dotfiles failed") || !strings.Contains(out.String(), "not copying dotfiles again") {
+		t.Errorf("restarted container output = %q, want the copy skipped", out.String())
+	}
+
+	// A created container attempts the copy, which fails without a running
+	// container.
+	out.Reset()
+	applyPersonalSetup(context.Background(), &devcontainer.DevContainer{}, "devgo-missing-container", false)
+	if !strings.Contains(out.String(), "copying dotfiles failed") {
+		t.Errorf("created container output = %q, want the copy attempted", out.String())
+	}
+}
+
+func TestLifecycleFailure(t *testing.T) {
+	failure := &ExitError{Code: 2}
+	tests := []struct {
+		policy  string
+		wantErr bool
+	}{
+		{policy: "", wantErr: true},
+		{policy: lifecycleErrorFail, wantErr: true},
+		{policy: lifecycleErrorContinue, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		err := lifecycleFailure("postCreateCommand", failure, tt.policy)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("lifecycleFailure(%q) error = %v, wantErr %t", tt.policy, err, tt.wantErr)
+		}
+		if err != nil && (!errors.Is(err, failure) || !strings.Contains(err.Error(), "postCreateCommand")) {
+			t.Errorf("lifecycleFailure(%q) = %v, want it to name the step and wrap the exit", tt.policy, err)
+		}
+	}
+}
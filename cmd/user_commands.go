@@ -8,7 +8,6 @@ import (
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/client"
 	"github.com/garaemon/devgo/pkg/constants"
 	"github.com/garaemon/devgo/pkg/devcontainer"
 )
@@ -19,10 +18,11 @@ func runUserCommandsCommand(args []string) error {
 		return fmt.Errorf("failed to find devcontainer config: %w", err)
 	}
 
-	devContainer, err := devcontainer.Parse(devcontainerPath)
+	resolved, err := resolveEnvConfig(devcontainerPath, configOverrides)
 	if err != nil {
-		return fmt.Errorf("failed to parse devcontainer config: %w", err)
+		return err
 	}
+	devContainer := resolved.DevContainer
 
 	ctx := context.Background()
 	containerName, err := findRunningDevContainer(ctx, devContainer)
@@ -44,7 +44,7 @@ func runUserCommandsCommand(args []string) error {
 }
 
 func findRunningDevContainer(ctx context.Context, devContainer *devcontainer.DevContainer) (string, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, err := newEngineClient()
 	if err != nil {
 		return "", fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -55,7 +55,7 @@ func findRunningDevContainer(ctx context.Context, devContainer *devcontainer.Dev
 	}()
 
 	filter := filters.NewArgs()
-	filter.Add("label", fmt.Sprintf("%s=%s", constants.DevgoManagedLabel, constants.DevgoManagedValue))
+	filter.Add("label", managedLabelFilter())
 
 	containers, err := cli.ContainerList(ctx, container.ListOptions{
 		All:     false, // Only running containers
@@ -72,7 +72,7 @@ func findRunningDevContainer(ctx context.Context, devContainer *devcontainer.Dev
 	// If multiple containers found, use the first one or find the one matching current workspace
 	for _, container := range containers {
 		// Check if container has the workspace label matching current directory
-		if workspaceLabel, exists := container.Labels[constants.DevgoWorkspaceLabel]; exists {
+		if workspaceLabel, exists := container.Labels[devgoLabel(constants.DevgoWorkspaceLabel)]; exists {
 			currentDir, err := os.Getwd()
 			if err == nil && workspaceLabel == currentDir {
 				return container.Names[0][1:], nil // Remove leading '/'
@@ -89,19 +89,19 @@ func runLifecycleCommands(ctx context.Context, devContainer *devcontainer.DevCon
 
 	// Execute commands according to waitFor setting
 	if devContainer.ShouldWaitForCommand(devcontainer.WaitForOnCreateCommand) {
-		if err := executeOnCreateCommand(ctx, devContainer, containerName, workspaceDir); err != nil {
+		if err := runUserLifecycleCommand(ctx, devContainer, containerName, workspaceDir, devcontainer.WaitForOnCreateCommand, executeOnCreateCommand); err != nil {
 			return fmt.Errorf("onCreateCommand failed: %w", err)
 		}
 	}
 
 	if devContainer.ShouldWaitForCommand(devcontainer.WaitForUpdateContentCommand) {
-		if err := executeUpdateContentCommand(ctx, devContainer, containerName, workspaceDir); err != nil {
+		if err := runUserLifecycleCommand(ctx, devContainer, containerName, workspaceDir, devcontainer.WaitForUpdateContentCommand, executeUpdateContentCommand); err != nil {
 			return fmt.Errorf("updateContentCommand failed: %w", err)
 		}
 	}
 
 	if devContainer.ShouldWaitForCommand(devcontainer.WaitForPostCreateCommand) {
-		if err := executePostCreateCommand(ctx, devContainer, containerName, workspaceDir); err != nil {
+		if err := runUserLifecycleCommand(ctx, devContainer, containerName, workspaceDir, devcontainer.WaitForPostCreateCommand, executePostCreateCommand); err != nil {
 			return fmt.Errorf("postCreateCommand failed: %w", err)
 		}
 	}
@@ -120,3 +120,28 @@ func runLifecycleCommands(ctx context.Context, devContainer *devcontainer.DevCon
 	debugf("Successfully executed user commands up to %s\n", waitFor)
 	return nil
 }
+
+// runUserLifecycleCommand runs the lifecycle command commandType with
+// executor, unless it runs once per container and the container already
+// completed it with the same commands. --force runs it regardless.
+func runUserLifecycleCommand(ctx context.Context, devContainer *devcontainer.DevContainer, containerName, workspaceDir, commandType string, executor func(context.Context, *devcontainer.DevContainer, string, string) error) error {
+	commands, err := devContainer.GetLifecycleCommands(commandType)
+	if err != nil {
+		return err
+	}
+	if !force && runsOnce(commandType) && len(commands) > 0 {
+		cli, err := newEngineClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Docker client: %w", err)
+		}
+		completed := lifecycleCompleted(ctx, cli, containerName, commandType, lifecycleDigest(devContainer, commandType, workspaceContentID(workspaceDir)))
+		if closeErr := cli.Close(); closeErr != nil {
+			warnf("failed to close Docker client: %v", closeErr)
+		}
+		if completed {
+			debugf("Skipping %s: it already completed in this container (pass --force to run it again)\n", commandType)
+			return nil
+		}
+	}
+	return executor(ctx, devContainer, containerName, workspaceDir)
+}
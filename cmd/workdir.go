@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/garaemon/devgo/pkg/dotfiles"
+)
+
+// workdirDockerClient is what the working directory check needs: finding the
+// running container and running a short command with its exit code.
+type workdirDockerClient interface {
+	DockerExecClient
+	ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error)
+}
+
+// workdirCheckCommand returns the command that exits 0 when dir exists.
+func workdirCheckCommand(dir string) []string {
+	return []string{"test", "-d", dir}
+}
+
+// workdirCreateCommand returns the command that creates dir and its parents.
+func workdirCreateCommand(dir string) []string {
+	return []string{"mkdir", "-p", dir}
+}
+
+// ensureWorkdir verifies that dir exists inside the container before an exec
+// uses it as the working directory; otherwise ContainerExecCreate fails with
+// an unhelpful error. With create set, a missing dir is created instead.
+func ensureWorkdir(ctx context.Context, exec dotfiles.Executor, user, dir string, create bool) error {
+	_, _, exitCode, err := exec.Exec(ctx, user, workdirCheckCommand(dir))
+	if err != nil {
+		return fmt.Errorf("failed to check working directory %s: %w", dir, err)
+	}
+	if exitCode == 0 {
+		return nil
+	}
+
+	if !create {
+		return fmt.Errorf("working directory %s does not exist in the container. Use --create-workdir to create it", dir)
+	}
+
+	debugf("Creating working directory %s\n", dir)
+	_, stderr, exitCode, err := exec.Exec(ctx, user, workdirCreateCommand(dir))
+	if err != nil {
+		return fmt.Errorf("failed to create working directory %s: %w", dir, err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("failed to create working directory %s: %s", dir, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// checkContainerWorkdir runs ensureWorkdir as user against the running
+// container for dir. A container that is not running is left for the exec
+// itself to report.
+func checkContainerWorkdir(ctx context.Context, cli workdirDockerClient, containerName, user, dir string) error {
+	containerID, err := findRunningContainer(ctx, cli, containerName)
+	if err != nil || containerID == "" {
+		return nil
+	}
+	executor := newDotfilesExecutor(cli, containerID)
+	return ensureWorkdir(ctx, executor, user, dir, createWorkdir)
+}
+
+// resolveExecWorkdir returns the container directory for --workdir: an
+// absolute workdir is used as-is, a relative one is taken from
+// workspaceFolder.
+func resolveExecWorkdir(workspaceFolder, workdir string) string {
+	if path.IsAbs(workdir) {
+		return path.Clean(workdir)
+	}
+	return path.Join(workspaceFolder, workdir)
+}
+
+// resolveCwdRelative decides whether `devgo exec` maps the host working
+// directory into the workspace. An explicit --cwd-relative wins; otherwise
+// the mapping is on for interactive use (stdin is a terminal) and off for
+// scripts, whose working directory should not depend on where they run.
+func resolveCwdRelative(flag *bool, interactive bool) bool {
+	if flag != nil {
+		return *flag
+	}
+	return interactive
+}
+
+// hostCwdWorkdir returns the container directory matching hostCwd: with
+// relative set and hostCwd inside workspaceDir, the same subdirectory of
+// workspaceFolder; otherwise workspaceFolder itself.
+func hostCwdWorkdir(workspaceDir, workspaceFolder, hostCwd string, relative bool) string {
+	if !relative {
+		return workspaceFolder
+	}
+	rel, err := filepath.Rel(workspaceDir, hostCwd)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return workspaceFolder
+	}
+	return path.Join(workspaceFolder, filepath.ToSlash(rel))
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeWorkdirExecutor answers exec calls with a fixed exit code per command
+// name and records the commands it was asked to run.
+type fakeWorkdirExecutor struct {
+	exitCodes map[string]int
+	calls     [][]string
+}
+
+func (f *fakeWorkdirExecutor) Exec(ctx context.Context, user string, cmd []string) (string, string, int, error) {
+	f.calls = append(f.calls, cmd)
+	return "", "", f.exitCodes[cmd[0]], nil
+}
+
+func TestWorkdirCommands(t *testing.T) {
+	if got := strings.Join(workdirCheckCommand("/workspaces/app"), " "); got != "test -d /workspaces/app" {
+		t.Errorf("workdirCheckCommand() = %q", got)
+	}
+	if got := strings.Join(workdirCreateCommand("/workspaces/app"), " "); got != "mkdir -p /workspaces/app" {
+		t.Errorf("workdirCreateCommand() = %q", got)
+	}
+}
+
+func TestEnsureWorkdir(t *testing.T) {
+	tests := []struct {
+		name        string
+		exitCodes   map[string]int
+		create      bool
+		expectCalls []string
+		expectError bool
+	}{
+		{
+			name:        "existing directory",
+			exitCodes:   map[string]int{"test": 0},
+			expectCalls: []string{"test"},
+		},
+		{
+			name:        "missing directory without create",
+			exitCodes:   map[string]int{"test": 1},
+			expectCalls: []string{"test"},
+			expectError: true,
+		},
+		{
+			name:        "missing directory with create",
+			exitCodes:   map[string]int{"test": 1, "mkdir": 0},
+			create:      true,
+			expectCalls: []string{"test", "mkdir"},
+		},
+		{
+			name:        "create fails",
+			exitCodes:   map[string]int{"test": 1, "mkdir": 1},
+			create:      true,
+			expectCalls: []string{"test", "mkdir"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &fakeWorkdirExecutor{exitCodes: tt.exitCodes}
+			err := ensureWorkdir(context.Background(), exec, "vscode", "/workspaces/app", tt.create)
+			if tt.expectError && err == nil {
+				t.Error("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			var calls []string
+			for _, call := range exec.calls {
+				calls = append(calls, call[0])
+			}
+			if strings.Join(calls, ",") != strings.Join(tt.expectCalls, ",") {
+				t.Errorf("commands run = %v, want %v", calls, tt.expectCalls)
+			}
+		})
+	}
+}
+
+func TestResolveCwdRelative(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name        string
+		flag        *bool
+		interactive bool
+		want        bool
+	}{
+		{name: "default interactive", flag: nil, interactive: true, want: true},
+		{name: "default scripted", flag: nil, interactive: false, want: false},
+		{name: "explicit true in script", flag: &on, interactive: false, want: true},
+		{name: "explicit false on terminal", flag: &off, interactive: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveCwdRelative(tt.flag, tt.interactive); got != tt.want {
+				t.Errorf("resolveCwdRelative() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostCwdWorkdir(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostCwd  string
+		relative bool
+		want     string
+	}{
+		{name: "relative at workspace root", hostCwd: "/home/me/project", relative: true, want: "/workspace"},
+		{name: "relative in subdirectory", hostCwd: "/home/me/project/src/pkg", relative: true, want: "/workspace/src/pkg"},
+		{name: "relative outside workspace", hostCwd: "/home/me/other", relative: true, want: "/workspace"},
+		{name: "relative in sibling with shared prefix", hostCwd: "/home/me/project2", relative: true, want: "/workspace"},
+		{name: "disabled in subdirectory", hostCwd: "/home/me/project/src/pkg", relative: false, want: "/workspace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hostCwdWorkdir("/home/me/project", "/workspace", tt.hostCwd, tt.relative)
+			if got != tt.want {
+				t.Errorf("hostCwdWorkdir(%q, %t) = %q, want %q", tt.hostCwd, tt.relative, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveExecWorkdir(t *testing.T) {
+	tests := []struct {
+		name    string
+		workdir string
+		want    string
+	}{
+		{name: "relative", workdir: "src", want: "/workspace/src"},
+		{name: "relative nested with dot", workdir: "./src/pkg", want: "/workspace/src/pkg"},
+		{name: "relative parent", workdir: "../shared", want: "/shared"},
+		{name: "absolute", workdir: "/tmp", want: "/tmp"},
+		{name: "absolute uncleaned", workdir: "/tmp/../var/", want: "/var"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveExecWorkdir("/workspace", tt.workdir); got != tt.want {
+				t.Errorf("resolveExecWorkdir(%q) = %q, want %q", tt.workdir, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// selinuxEnforceFile reads "1" when SELinux is enforcing on the host.
+const selinuxEnforceFile = "/sys/fs/selinux/enforce"
+
+// selinuxEnforcing reports whether the host enforces SELinux, in which case a
+// bind mount without a relabel option is unreadable inside the container.
+var selinuxEnforcing = func() bool {
+	data, err := os.ReadFile(selinuxEnforceFile)
+	return err == nil && strings.TrimSpace(string(data)) == "1"
+}
+
+// isMountConsistency reports whether value is a bind mount consistency that
+// Docker Desktop on macOS understands.
+func isMountConsistency(value string) bool {
+	return value == "consistent" || value == "cached" || value == "delegated"
+}
+
+// planWorkspaceMountOptions returns the options appended to the workspace
+// bind: the workspaceMountOptions setting, with its consistency replaced by
+// consistency (--mount-consistency) when that is set. Without a z or Z
+// option the workspace is relabeled as shared (z) when SELinux enforces.
+func planWorkspaceMountOptions(setting, consistency string, enforcing bool) (string, error) {
+	var options []string
+	var label, mode string
+	if setting != "" {
+		for _, option := range strings.Split(setting, ",") {
+			switch {
+			case option == "z" || option == "Z":
+				if label != "" {
+					return "", fmt.Errorf("workspaceMountOptions %q: only one of z and Z may be set", setting)
+				}
+				label = option
+			case isMountConsistency(option):
+				if mode != "" {
+					return "", fmt.Errorf("workspaceMountOptions %q: only one consistency may be set", setting)
+				}
+				mode = option
+			default:
+				return "", fmt.Errorf("workspaceMountOptions %q: unsupported option %q (want z, Z, consistent, cached or delegated)", setting, option)
+			}
+		}
+	}
+	if consistency != "" {
+		mode = consistency
+	}
+
+	if label == "" && enforcing {
+		debugln("SELinux is enforcing, relabeling the workspace mount with :z")
+		label = "z"
+	}
+	for _, option := range []string{label, mode} {
+		if option != "" {
+			options = append(options, option)
+		}
+	}
+	return strings.Join(options, ","), nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import "testing"
+
+func TestPlanWorkspaceMountOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		setting     string
+		consistency string
+		enforcing   bool
+		want        string
+		wantErr     bool
+	}{
+		{name: "nothing set", want: ""},
+		{name: "selinux enforcing", enforcing: true, want: "z"},
+		{name: "private label kept under selinux", setting: "Z", enforcing: true, want: "Z"},
+		{name: "setting", setting: "z,cached", want: "z,cached"},
+		{name: "flag overrides consistency", setting: "delegated", consistency: "cached", want: "cached"},
+		{name: "flag with selinux", consistency: "consistent", enforcing: true, want: "z,consistent"},
+		{name: "both labels", setting: "z,Z", wantErr: true},
+		{name: "two consistencies", setting: "cached,delegated", wantErr: true},
+		{name: "unsupported option", setting: "ro", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planWorkspaceMountOptions(tt.setting, tt.consistency, tt.enforcing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("planWorkspaceMountOptions() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("planWorkspaceMountOptions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
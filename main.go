@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -9,6 +10,12 @@ import (
 
 func main() {
 	if err := cmd.Execute(); err != nil {
+		// A command that exited non-zero in the container already reported
+		// its failure; devgo only passes the exit code on.
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) && err == error(exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -2,6 +2,9 @@ package constants
 
 // Docker labels used by devgo to manage containers
 const (
+	// DevgoLabelPrefix is the prefix of every label key reserved by devgo
+	DevgoLabelPrefix = "devgo."
+
 	// DevgoManagedLabel is the label key used to identify containers managed by devgo
 	DevgoManagedLabel = "devgo.managed"
 
@@ -14,6 +17,20 @@ const (
 	// DevgoSessionLabel is the label key used to store the session name
 	DevgoSessionLabel = "devgo.session"
 
+	// DevgoBuildHashLabel is the image label key used to store the hash of the
+	// build inputs the image was built from
+	DevgoBuildHashLabel = "devgo.build-hash"
+
+	// DevgoConfigHashLabel is the container label key used to store the hash
+	// of the devcontainer configuration the container was created from
+	DevgoConfigHashLabel = "devgo.config-hash"
+
+	// ComposeServiceLabel is the label docker compose sets to the service name
+	ComposeServiceLabel = "com.docker.compose.service"
+
+	// ComposeWorkingDirLabel is the label docker compose sets to the project directory
+	ComposeWorkingDirLabel = "com.docker.compose.project.working_dir"
+
 	// DefaultSessionName is the default session name when not specified
 	DefaultSessionName = "default"
 )
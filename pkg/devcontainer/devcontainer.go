@@ -3,8 +3,12 @@ package devcontainer
 import (
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/titanous/json5"
 )
@@ -21,8 +25,32 @@ type BuildConfig struct {
 type PortAttributes struct {
 	Label         string `json:"label,omitempty"`
 	OnAutoForward string `json:"onAutoForward,omitempty"`
+	// RequireLocalPort fails publishing when the same host port is taken
+	// instead of falling back to another port.
+	RequireLocalPort bool `json:"requireLocalPort,omitempty"`
+	// ElevateIfNeeded marks a privileged port (< 1024) that may need elevated
+	// rights on the host to bind.
+	ElevateIfNeeded bool `json:"elevateIfNeeded,omitempty"`
 }
 
+// HostRequirements are the minimum host resources the configuration needs.
+// devgo only acts on GPU.
+type HostRequirements struct {
+	CPUs    int    `json:"cpus,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Storage string `json:"storage,omitempty"`
+	// GPU is true, false, "optional" or an object with "cores" and
+	// "memory"; see GPURequirement.
+	GPU interface{} `json:"gpu,omitempty"`
+}
+
+// GPU requirement levels returned by GPURequirement.
+const (
+	GPUNone     = ""
+	GPURequired = "required"
+	GPUOptional = "optional"
+)
+
 // waitFor lifecycle command constants
 const (
 	WaitForInitializeCommand    = "initializeCommand"
@@ -32,13 +60,84 @@ const (
 	WaitForPostStartCommand     = "postStartCommand"
 )
 
+// Mount is an entry of "mounts", given either as an object or as a
+// `docker run --mount` string such as
+// "source=cache,target=/cache,type=volume".
 type Mount struct {
 	Type   string `json:"type,omitempty"`
 	Source string `json:"source,omitempty"`
 	Target string `json:"target,omitempty"`
+	// ReadOnly and Consistency (a bind mount option for Docker Desktop:
+	// "consistent", "cached" or "delegated") are devgo extensions in the
+	// object form and standard options in the string form.
+	ReadOnly    bool   `json:"readonly,omitempty"`
+	Consistency string `json:"consistency,omitempty"`
+}
+
+// UnmarshalJSON accepts both the object and the string form of a mount.
+func (m *Mount) UnmarshalJSON(data []byte) error {
+	var spec string
+	if err := json5.Unmarshal(data, &spec); err == nil {
+		parsed, err := ParseMountString(spec)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	// The alias type has no UnmarshalJSON, so this does not recurse.
+	type mountObject Mount
+	var obj mountObject
+	if err := json5.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("invalid mount: %w", err)
+	}
+	*m = Mount(obj)
+	return nil
+}
+
+// ParseMountString parses a mount in the comma-separated key=value syntax
+// of `docker run --mount`. "src" and "dst"/"destination" are accepted as
+// aliases, and "readonly" or "ro" may be given without a value.
+func ParseMountString(spec string) (Mount, error) {
+	var m Mount
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(field, "=")
+		switch strings.ToLower(key) {
+		case "type":
+			m.Type = value
+		case "source", "src":
+			m.Source = value
+		case "target", "dst", "destination":
+			m.Target = value
+		case "consistency":
+			m.Consistency = value
+		case "readonly", "ro":
+			if !hasValue {
+				m.ReadOnly = true
+				continue
+			}
+			readOnly, err := strconv.ParseBool(value)
+			if err != nil {
+				return Mount{}, fmt.Errorf("invalid mount %q: %s must be true or false", spec, key)
+			}
+			m.ReadOnly = readOnly
+		default:
+			return Mount{}, fmt.Errorf("invalid mount %q: unsupported option %q", spec, key)
+		}
+	}
+	return m, nil
 }
 
 type DevContainer struct {
+	// Schema is the "$schema" URL editors add for completion. It is kept
+	// as a known field so the key is accepted explicitly and round-trips
+	// through read-configuration.
+	Schema               string                    `json:"$schema,omitempty"`
 	Name                 string                    `json:"name,omitempty"`
 	Image                string                    `json:"image,omitempty"`
 	Dockerfile           string                    `json:"dockerFile,omitempty"` // Legacy field
@@ -47,6 +146,7 @@ type DevContainer struct {
 	Service              string                    `json:"service,omitempty"`
 	RunServices          []string                  `json:"runServices,omitempty"`
 	WorkspaceFolder      string                    `json:"workspaceFolder,omitempty"`
+	WorkspaceMount       string                    `json:"workspaceMount,omitempty"`
 	ContainerUser        string                    `json:"containerUser,omitempty"`
 	RemoteUser           string                    `json:"remoteUser,omitempty"`
 	UpdateRemoteUserUID  *bool                     `json:"updateRemoteUserUID,omitempty"`
@@ -54,6 +154,7 @@ type DevContainer struct {
 	RemoteEnv            map[string]string         `json:"remoteEnv,omitempty"`
 	Mounts               []Mount                   `json:"mounts,omitempty"`
 	ForwardPorts         []interface{}             `json:"forwardPorts,omitempty"`
+	AppPort              interface{}               `json:"appPort,omitempty"` // legacy; see GetAppPorts
 	PortsAttributes      map[string]PortAttributes `json:"portsAttributes,omitempty"`
 	InitializeCommand    interface{}               `json:"initializeCommand,omitempty"`
 	OnCreateCommand      interface{}               `json:"onCreateCommand,omitempty"`
@@ -62,20 +163,34 @@ type DevContainer struct {
 	PostStartCommand     interface{}               `json:"postStartCommand,omitempty"`
 	PostAttachCommand    interface{}               `json:"postAttachCommand,omitempty"`
 	WaitFor              string                    `json:"waitFor,omitempty"`
+	OverrideCommand      *bool                     `json:"overrideCommand,omitempty"`
+	// RunArgs are extra `docker run` arguments. devgo creates containers
+	// through the API, so only the subset handled by GetRunArgsHostOptions
+	// takes effect.
+	RunArgs []string `json:"runArgs,omitempty"`
+	// HostRequirements lists the host resources the container needs; see
+	// GPURequirement.
+	HostRequirements *HostRequirements `json:"hostRequirements,omitempty"`
+	// Shell is the default program for `devgo shell` (devgo extension).
+	Shell string `json:"shell,omitempty"`
+	// WorkspaceMountOptions are comma-separated options for the workspace
+	// bind mount: z or Z to relabel it for SELinux, and consistent, cached
+	// or delegated for Docker Desktop on macOS (devgo extension).
+	WorkspaceMountOptions string `json:"workspaceMountOptions,omitempty"`
 	// Features maps a feature reference (e.g. "ghcr.io/devcontainers/features/node:1")
 	// to its options. The options value may be an object, a bare scalar, or empty.
 	Features map[string]interface{} `json:"features,omitempty"`
-	// OverrideFeatureInstallOrder is parsed but currently ignored (install order is
-	// derived from the sorted feature references).
+	// OverrideFeatureInstallOrder lists feature references that are installed
+	// first, in the given order. See GetFeatures.
 	OverrideFeatureInstallOrder []string `json:"overrideFeatureInstallOrder,omitempty"`
 }
 
 // FeatureSpec is a single feature declaration resolved from the features map.
 type FeatureSpec struct {
 	// Ref is the OCI reference of the feature.
-	Ref string
+	Ref string `json:"id"`
 	// Options holds the user-provided options for the feature.
-	Options map[string]interface{}
+	Options map[string]interface{} `json:"options"`
 }
 
 func Parse(filePath string) (*DevContainer, error) {
@@ -168,6 +283,23 @@ func (dc *DevContainer) GetWorkspaceFolder() string {
 	return "/workspace"
 }
 
+// GetWorkspaceMount parses workspaceMount, a `docker run --mount` string
+// that replaces the default bind mount of the workspace, such as a named
+// volume. It returns nil when workspaceMount is not set.
+func (dc *DevContainer) GetWorkspaceMount() (*Mount, error) {
+	if dc.WorkspaceMount == "" {
+		return nil, nil
+	}
+	m, err := ParseMountString(dc.WorkspaceMount)
+	if err != nil {
+		return nil, fmt.Errorf("workspaceMount: %w", err)
+	}
+	if m.Target == "" {
+		return nil, fmt.Errorf("workspaceMount %q has no target", dc.WorkspaceMount)
+	}
+	return &m, nil
+}
+
 func (dc *DevContainer) GetContainerUser() string {
 	if dc.ContainerUser != "" {
 		return dc.ContainerUser
@@ -175,6 +307,15 @@ func (dc *DevContainer) GetContainerUser() string {
 	return "root"
 }
 
+// ShouldOverrideCommand reports whether the image's command is replaced by
+// one that keeps the container running (overrideCommand, true by default).
+func (dc *DevContainer) ShouldOverrideCommand() bool {
+	if dc.OverrideCommand != nil {
+		return *dc.OverrideCommand
+	}
+	return true
+}
+
 func (dc *DevContainer) ShouldUpdateRemoteUserUID() bool {
 	// Only applicable on Linux
 	// On Windows/macOS, Docker Desktop handles UID/GID mapping automatically
@@ -271,25 +412,214 @@ func (dc *DevContainer) ShouldWaitForCommand(commandType string) bool {
 	}
 }
 
+// DefaultShell is the interactive shell used when nothing else is configured.
+// FallbackShell replaces it in images that do not ship bash.
+const (
+	DefaultShell  = "/bin/bash"
+	FallbackShell = "/bin/sh"
+)
+
+// RunArgsHostOptions holds the host settings devgo understands from runArgs.
+type RunArgsHostOptions struct {
+	ReadonlyRootfs bool
+	// Tmpfs maps a container path to its mount options ("" for defaults).
+	Tmpfs map[string]string
+	// Devices holds the HOST[:CONTAINER[:PERMISSIONS]] specs of --device.
+	Devices []string
+	// GPUs is the value of the last --gpus, "" without one.
+	GPUs       string
+	Privileged bool
+	Init       bool
+	// Network is the network or network mode ("host", "none",
+	// "container:NAME") of the last --network/--net.
+	Network     string
+	CapAdd      []string
+	CapDrop     []string
+	SecurityOpt []string
+	// ShmSize is the unparsed size of /dev/shm, such as "2g".
+	ShmSize    string
+	IpcMode    string
+	PidMode    string
+	UsernsMode string
+	Hostname   string
+	// ExtraHosts holds the HOST:IP entries of --add-host.
+	ExtraHosts []string
+	// Unsupported lists the runArgs devgo ignores.
+	Unsupported []string
+}
+
+// runArgsValueFlags are the runArgs taking a value that devgo applies,
+// mapped to the setter storing the value. Aliases share a setter.
+var runArgsValueFlags = map[string]func(opts *RunArgsHostOptions, value string){
+	"--tmpfs": func(opts *RunArgsHostOptions, v string) {
+		if opts.Tmpfs == nil {
+			opts.Tmpfs = make(map[string]string)
+		}
+		target, options, _ := strings.Cut(v, ":")
+		opts.Tmpfs[target] = options
+	},
+	"--device":       func(opts *RunArgsHostOptions, v string) { opts.Devices = append(opts.Devices, v) },
+	"--gpus":         func(opts *RunArgsHostOptions, v string) { opts.GPUs = v },
+	"--network":      func(opts *RunArgsHostOptions, v string) { opts.Network = v },
+	"--net":          func(opts *RunArgsHostOptions, v string) { opts.Network = v },
+	"--cap-add":      func(opts *RunArgsHostOptions, v string) { opts.CapAdd = append(opts.CapAdd, v) },
+	"--cap-drop":     func(opts *RunArgsHostOptions, v string) { opts.CapDrop = append(opts.CapDrop, v) },
+	"--security-opt": func(opts *RunArgsHostOptions, v string) { opts.SecurityOpt = append(opts.SecurityOpt, v) },
+	"--shm-size":     func(opts *RunArgsHostOptions, v string) { opts.ShmSize = v },
+	"--ipc":          func(opts *RunArgsHostOptions, v string) { opts.IpcMode = v },
+	"--pid":          func(opts *RunArgsHostOptions, v string) { opts.PidMode = v },
+	"--userns":       func(opts *RunArgsHostOptions, v string) { opts.UsernsMode = v },
+	"--hostname":     func(opts *RunArgsHostOptions, v string) { opts.Hostname = v },
+	"-h":             func(opts *RunArgsHostOptions, v string) { opts.Hostname = v },
+	"--add-host":     func(opts *RunArgsHostOptions, v string) { opts.ExtraHosts = append(opts.ExtraHosts, v) },
+}
+
+// runArgsBoolFlags are the boolean runArgs devgo applies.
+var runArgsBoolFlags = map[string]func(opts *RunArgsHostOptions, value bool){
+	"--read-only":  func(opts *RunArgsHostOptions, v bool) { opts.ReadonlyRootfs = v },
+	"--privileged": func(opts *RunArgsHostOptions, v bool) { opts.Privileged = v },
+	"--init":       func(opts *RunArgsHostOptions, v bool) { opts.Init = v },
+}
+
+// GetRunArgsHostOptions interprets the runArgs listed in runArgsValueFlags
+// (as "--flag value" or "--flag=value") and runArgsBoolFlags (as "--flag"
+// or "--flag=true|false"). Everything else is reported as unsupported.
+func (dc *DevContainer) GetRunArgsHostOptions() RunArgsHostOptions {
+	var opts RunArgsHostOptions
+	for i := 0; i < len(dc.RunArgs); i++ {
+		arg := dc.RunArgs[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if set, ok := runArgsValueFlags[name]; ok && (hasValue || i+1 < len(dc.RunArgs)) {
+			if !hasValue {
+				i++
+				value = dc.RunArgs[i]
+			}
+			set(&opts, value)
+			continue
+		}
+		if set, ok := runArgsBoolFlags[name]; ok {
+			enabled, err := strconv.ParseBool(value)
+			if !hasValue {
+				enabled, err = true, nil
+			}
+			if err == nil {
+				set(&opts, enabled)
+				continue
+			}
+		}
+		opts.Unsupported = append(opts.Unsupported, arg)
+	}
+	return opts
+}
+
+// GPURequirement returns how hostRequirements.gpu asks for GPUs: GPURequired
+// for true or an object of minimums, GPUOptional for "optional" (use GPUs
+// when the host has them), and GPUNone otherwise.
+func (dc *DevContainer) GPURequirement() string {
+	if dc.HostRequirements == nil {
+		return GPUNone
+	}
+	switch v := dc.HostRequirements.GPU.(type) {
+	case bool:
+		if v {
+			return GPURequired
+		}
+	case string:
+		if v == GPUOptional {
+			return GPUOptional
+		}
+	case map[string]interface{}:
+		return GPURequired
+	}
+	return GPUNone
+}
+
+// GetShell returns the configured interactive shell, or DefaultShell.
+// Callers fall back to FallbackShell when DefaultShell is missing in the
+// container.
+func (dc *DevContainer) GetShell() string {
+	if dc.Shell != "" {
+		return dc.Shell
+	}
+	return DefaultShell
+}
+
+// overrideSetters maps the top-level scalar fields that ApplyOverride can set
+// to a setter converting the string value to the field's type.
+var overrideSetters = map[string]func(dc *DevContainer, value string) error{
+	"name":            func(dc *DevContainer, v string) error { dc.Name = v; return nil },
+	"image":           func(dc *DevContainer, v string) error { dc.Image = v; return nil },
+	"dockerFile":      func(dc *DevContainer, v string) error { dc.Dockerfile = v; return nil },
+	"service":         func(dc *DevContainer, v string) error { dc.Service = v; return nil },
+	"workspaceFolder": func(dc *DevContainer, v string) error { dc.WorkspaceFolder = v; return nil },
+	"containerUser":   func(dc *DevContainer, v string) error { dc.ContainerUser = v; return nil },
+	"remoteUser":      func(dc *DevContainer, v string) error { dc.RemoteUser = v; return nil },
+	"waitFor":         func(dc *DevContainer, v string) error { dc.WaitFor = v; return nil },
+	"shell":           func(dc *DevContainer, v string) error { dc.Shell = v; return nil },
+	"updateRemoteUserUID": func(dc *DevContainer, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("updateRemoteUserUID must be true or false, got %q", v)
+		}
+		dc.UpdateRemoteUserUID = &b
+		return nil
+	},
+}
+
+// ApplyOverride sets the top-level field named key (its JSON name) to value.
+// Only scalar fields are supported; any other key is an error.
+func (dc *DevContainer) ApplyOverride(key, value string) error {
+	setter, ok := overrideSetters[key]
+	if !ok {
+		keys := make([]string, 0, len(overrideSetters))
+		for k := range overrideSetters {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("unknown or unsupported field %q (supported: %s)", key, strings.Join(keys, ", "))
+	}
+	return setter(dc, value)
+}
+
 // HasFeatures reports whether any devcontainer features are declared.
 func (dc *DevContainer) HasFeatures() bool {
 	return len(dc.Features) > 0
 }
 
-// GetFeatures returns the declared features as a slice of FeatureSpec.
-// Because Go maps are unordered, the features are returned sorted by reference
-// for reproducible install order (MVP: overrideFeatureInstallOrder and
-// installsAfter are not yet honored).
+// GetFeatures returns the declared features in install order. Features named
+// in overrideFeatureInstallOrder come first, in that order; the rest follow
+// sorted by reference, since Go maps are unordered (installsAfter is not yet
+// honored). As in the spec, override entries match features by ID without
+// the version or digest, so "node" ordering applies to "node:1" too. Override
+// entries that are not declared are ignored.
 func (dc *DevContainer) GetFeatures() []FeatureSpec {
 	if len(dc.Features) == 0 {
 		return nil
 	}
 
-	refs := make([]string, 0, len(dc.Features))
+	declared := make([]string, 0, len(dc.Features))
 	for ref := range dc.Features {
-		refs = append(refs, ref)
+		declared = append(declared, ref)
+	}
+	sort.Strings(declared)
+
+	refs := make([]string, 0, len(dc.Features))
+	seen := make(map[string]bool, len(dc.Features))
+	for _, override := range dc.OverrideFeatureInstallOrder {
+		id := featureIDWithoutVersion(override)
+		for _, ref := range declared {
+			if !seen[ref] && featureIDWithoutVersion(ref) == id {
+				refs = append(refs, ref)
+				seen[ref] = true
+			}
+		}
+	}
+
+	for _, ref := range declared {
+		if !seen[ref] {
+			refs = append(refs, ref)
+		}
 	}
-	sort.Strings(refs)
 
 	specs := make([]FeatureSpec, 0, len(refs))
 	for _, ref := range refs {
@@ -301,6 +631,19 @@ func (dc *DevContainer) GetFeatures() []FeatureSpec {
 	return specs
 }
 
+// featureIDWithoutVersion strips the tag (":1") or digest ("@sha256:...")
+// from a feature reference. A colon before the last slash belongs to a
+// registry port, not a tag.
+func featureIDWithoutVersion(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i]
+	}
+	return ref
+}
+
 // normalizeFeatureOptions converts the raw options value into a map.
 // Object values are returned as-is; any other form (bare scalar, bool, or
 // empty) yields an empty map so that feature defaults apply.
@@ -315,6 +658,19 @@ func (dc *DevContainer) HasDockerCompose() bool {
 	return dc.DockerComposeFile != nil
 }
 
+// GetAppPorts returns the entries of the legacy appPort property, which
+// holds a single port or string, or an array of them.
+func (dc *DevContainer) GetAppPorts() []interface{} {
+	switch v := dc.AppPort.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
 func (dc *DevContainer) GetDockerComposeFiles() []string {
 	if dc.DockerComposeFile == nil {
 		return nil
@@ -363,6 +719,22 @@ func (dc *DevContainer) GetContainerEnv(baseEnv map[string]string) map[string]st
 	return result
 }
 
+// GetRemoteEnv returns the remoteEnv variables with variable expansion.
+// baseEnv is the environment of the running container, which already holds
+// containerEnv, so a value such as "${containerEnv:PATH}:/opt/bin" extends
+// the container's PATH.
+func (dc *DevContainer) GetRemoteEnv(baseEnv map[string]string) map[string]string {
+	if dc.RemoteEnv == nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+	for k, v := range dc.RemoteEnv {
+		result[k] = dc.expandValue(v, baseEnv)
+	}
+	return result
+}
+
 func (dc *DevContainer) expandValue(value string, baseEnv map[string]string) string {
 	// Support ${containerEnv:VAR} and ${localEnv:VAR}
 	// We use a simple regex-based replacement
@@ -390,12 +762,281 @@ func (dc *DevContainer) expandValue(value string, baseEnv map[string]string) str
 	})
 }
 
+var localFilePattern = regexp.MustCompile(`\${localFile:([^}]+)}`)
+
+// ResolveLocalFiles replaces ${localFile:path} in containerEnv values with
+// the content of the host file, minus trailing newlines. Relative paths are
+// resolved against configDir, the directory of devcontainer.json. Unlike
+// the other variables, reading the file can fail: a file that cannot be
+// read is left as is and reported in the returned error, which only matters
+// to the commands that hand containerEnv to the container.
+func (dc *DevContainer) ResolveLocalFiles(configDir string) error {
+	keys := make([]string, 0, len(dc.ContainerEnv))
+	for key := range dc.ContainerEnv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var resolveErr error
+	for _, key := range keys {
+		dc.ContainerEnv[key] = localFilePattern.ReplaceAllStringFunc(dc.ContainerEnv[key], func(match string) string {
+			path := localFilePattern.FindStringSubmatch(match)[1]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(configDir, path)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				if resolveErr == nil {
+					resolveErr = fmt.Errorf("containerEnv %s: failed to read %s: %w", key, match, err)
+				}
+				return match
+			}
+			return strings.TrimRight(string(content), "\r\n")
+		})
+	}
+	return resolveErr
+}
+
+// Variables holds the host-side values for the ${...} variables of
+// devcontainer.json. LookupEnv resolves ${localEnv:VAR}; nil means
+// os.LookupEnv.
+type Variables struct {
+	LocalWorkspaceFolder string
+	DevcontainerID       string
+	LookupEnv            func(key string) (string, bool)
+}
+
+var variablePattern = regexp.MustCompile(`\${([^}]+)}`)
+
+// Substitute expands ${localWorkspaceFolder}, ${containerWorkspaceFolder},
+// their *Basename forms, ${devcontainerId} and ${localEnv:VAR[:default]} in
+// the fields that are used to create the container: image, workspaceFolder,
+// workspaceMount, containerEnv, remoteEnv, mounts, runArgs, build args and
+// the lifecycle commands. ${containerEnv:VAR} is left for GetContainerEnv,
+// which knows the image environment, and unknown variables are kept as they
+// are. workspaceFolder is expanded first so ${containerWorkspaceFolder} sees
+// the expanded path.
+func (dc *DevContainer) Substitute(vars Variables) {
+	if vars.LookupEnv == nil {
+		vars.LookupEnv = os.LookupEnv
+	}
+	dc.WorkspaceFolder = vars.expand(dc.WorkspaceFolder, "")
+	containerFolder := dc.GetWorkspaceFolder()
+	expand := func(value string) string {
+		return vars.expand(value, containerFolder)
+	}
+
+	dc.Image = expand(dc.Image)
+	dc.WorkspaceMount = expand(dc.WorkspaceMount)
+	for key, value := range dc.ContainerEnv {
+		dc.ContainerEnv[key] = expand(value)
+	}
+	for key, value := range dc.RemoteEnv {
+		dc.RemoteEnv[key] = expand(value)
+	}
+	for i := range dc.Mounts {
+		dc.Mounts[i].Source = expand(dc.Mounts[i].Source)
+		dc.Mounts[i].Target = expand(dc.Mounts[i].Target)
+	}
+	for i, arg := range dc.RunArgs {
+		dc.RunArgs[i] = expand(arg)
+	}
+	if dc.Build != nil {
+		for key, value := range dc.Build.Args {
+			dc.Build.Args[key] = substituteValue(value, expand)
+		}
+	}
+	dc.InitializeCommand = substituteValue(dc.InitializeCommand, expand)
+	dc.OnCreateCommand = substituteValue(dc.OnCreateCommand, expand)
+	dc.UpdateContentCommand = substituteValue(dc.UpdateContentCommand, expand)
+	dc.PostCreateCommand = substituteValue(dc.PostCreateCommand, expand)
+	dc.PostStartCommand = substituteValue(dc.PostStartCommand, expand)
+	dc.PostAttachCommand = substituteValue(dc.PostAttachCommand, expand)
+}
+
+// expand replaces the variables in value. containerFolder is "" while
+// workspaceFolder itself is expanded, which leaves the container variables
+// untouched there.
+func (vars Variables) expand(value, containerFolder string) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+	return variablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		name, arg, hasArg := strings.Cut(variablePattern.FindStringSubmatch(match)[1], ":")
+		switch {
+		case name == "localWorkspaceFolder" && !hasArg:
+			return vars.LocalWorkspaceFolder
+		case name == "localWorkspaceFolderBasename" && !hasArg:
+			return filepath.Base(vars.LocalWorkspaceFolder)
+		case name == "containerWorkspaceFolder" && !hasArg && containerFolder != "":
+			return containerFolder
+		case name == "containerWorkspaceFolderBasename" && !hasArg && containerFolder != "":
+			return path.Base(containerFolder)
+		case name == "devcontainerId" && !hasArg && vars.DevcontainerID != "":
+			return vars.DevcontainerID
+		case (name == "localEnv" || name == "env") && hasArg:
+			key, fallback, _ := strings.Cut(arg, ":")
+			if env, ok := vars.LookupEnv(key); ok {
+				return env
+			}
+			return fallback
+		}
+		return match
+	})
+}
+
+// substituteValue applies expand to the strings of a decoded JSON value:
+// a string, an array, or an object such as a lifecycle command with cwd.
+// It returns new slices and maps rather than modifying value.
+func substituteValue(value interface{}, expand func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return expand(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = substituteValue(item, expand)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = substituteValue(item, expand)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// GetCommandCwd returns the cwd of a lifecycle command given in the object
+// form {"command": ..., "cwd": "frontend"}, or "" when none is set. The path
+// is relative to the workspace folder. commandType is the JSON key of the
+// command, e.g. "postCreateCommand".
+func (dc *DevContainer) GetCommandCwd(commandType string) string {
+	if obj, ok := dc.lifecycleCommand(commandType).(map[string]interface{}); ok && isCwdCommand(obj) {
+		if cwd, ok := obj["cwd"].(string); ok {
+			return cwd
+		}
+	}
+	return ""
+}
+
+// NamedCommand is one command of a lifecycle command. Name is empty for the
+// string and array forms; the object form {"server": "npm start", "watch":
+// ["npm", "run", "watch"]} names commands that run in parallel.
+type NamedCommand struct {
+	Name string
+	Args []string
+}
+
+// GetLifecycleCommands returns the commands of the lifecycle command
+// commandType: a single unnamed one, the named ones sorted by name, or nil
+// when it is not set. A value of any other shape, such as a named command
+// that is itself an object, is an error.
+func (dc *DevContainer) GetLifecycleCommands(commandType string) ([]NamedCommand, error) {
+	cmd := dc.lifecycleCommand(commandType)
+	obj, ok := cmd.(map[string]interface{})
+	if !ok || isCwdCommand(obj) {
+		if ok {
+			cmd = obj["command"]
+		}
+		args, err := commandArgs(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", commandType, err)
+		}
+		if len(args) == 0 {
+			return nil, nil
+		}
+		return []NamedCommand{{Args: args}}, nil
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var commands []NamedCommand
+	for _, name := range names {
+		args, err := commandArgs(obj[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", commandType, name, err)
+		}
+		if len(args) > 0 {
+			commands = append(commands, NamedCommand{Name: name, Args: args})
+		}
+	}
+	return commands, nil
+}
+
+// commandArgs turns a string or an array of strings, the forms a single
+// command takes, into its arguments as parseCommand does.
+func commandArgs(cmd interface{}) ([]string, error) {
+	switch v := cmd.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return parseCommand(v), nil
+	case []interface{}:
+		for _, arg := range v {
+			if _, ok := arg.(string); !ok {
+				return nil, fmt.Errorf("command arguments must be strings, got %v", arg)
+			}
+		}
+		return parseCommand(v), nil
+	default:
+		return nil, fmt.Errorf("a command must be a string or an array of strings, got %v", cmd)
+	}
+}
+
+// lifecycleCommand returns the raw value of the lifecycle command whose JSON
+// key is commandType.
+func (dc *DevContainer) lifecycleCommand(commandType string) interface{} {
+	switch commandType {
+	case WaitForInitializeCommand:
+		return dc.InitializeCommand
+	case WaitForOnCreateCommand:
+		return dc.OnCreateCommand
+	case WaitForUpdateContentCommand:
+		return dc.UpdateContentCommand
+	case WaitForPostCreateCommand:
+		return dc.PostCreateCommand
+	case WaitForPostStartCommand:
+		return dc.PostStartCommand
+	case "postAttachCommand":
+		return dc.PostAttachCommand
+	}
+	return nil
+}
+
+// isCwdCommand reports whether obj is the devgo form {"command": ...,
+// "cwd": ...} rather than an object of named commands. Both keys must be
+// there: {"command": "npm start"} is a single named command called
+// "command".
+func isCwdCommand(obj map[string]interface{}) bool {
+	if len(obj) != 2 {
+		return false
+	}
+	_, hasCommand := obj["command"]
+	_, hasCwd := obj["cwd"]
+	return hasCommand && hasCwd
+}
+
 func parseCommand(cmd interface{}) []string {
 	if cmd == nil {
 		return nil
 	}
 
 	switch v := cmd.(type) {
+	case map[string]interface{}:
+		// Object commands carry the command plus an optional cwd (devgo
+		// extension). Example: {"command": "npm i", "cwd": "frontend"}
+		// Objects of named parallel commands have no single command; see
+		// GetLifecycleCommands.
+		if !isCwdCommand(v) {
+			return nil
+		}
+		return parseCommand(v["command"])
 	case string:
 		// String commands are executed through shell to support shell features
 		// like pipes, redirects, variable expansion, and command chaining (&&, ||)
@@ -3,8 +3,11 @@ package devcontainer
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/titanous/json5"
 )
 
 func TestParse_SimpleImage(t *testing.T) {
@@ -673,6 +676,16 @@ func TestParseCommand(t *testing.T) {
 			cmd:      123,
 			expected: nil,
 		},
+		{
+			name:     "object command with cwd",
+			cmd:      map[string]interface{}{"command": "npm i", "cwd": "frontend"},
+			expected: []string{"/bin/sh", "-c", "npm i"},
+		},
+		{
+			name:     "object command with array",
+			cmd:      map[string]interface{}{"command": []interface{}{"npm", "i"}, "cwd": "frontend"},
+			expected: []string{"npm", "i"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1275,6 +1288,27 @@ func TestShouldUpdateRemoteUserUID(t *testing.T) {
 	}
 }
 
+func TestShouldOverrideCommand(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name string
+		dc   DevContainer
+		want bool
+	}{
+		{name: "default", dc: DevContainer{}, want: true},
+		{name: "explicitly enabled", dc: DevContainer{OverrideCommand: &enabled}, want: true},
+		{name: "explicitly disabled", dc: DevContainer{OverrideCommand: &disabled}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dc.ShouldOverrideCommand(); got != tt.want {
+				t.Errorf("ShouldOverrideCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetTargetUser(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1656,6 +1690,32 @@ func TestDevContainer_GetContainerEnv(t *testing.T) {
 	}
 }
 
+func TestDevContainer_GetRemoteEnv(t *testing.T) {
+	t.Setenv("LOCAL_VAR", "local_value")
+
+	dc := &DevContainer{
+		RemoteEnv: map[string]string{
+			"PATH":   "${containerEnv:PATH}:/home/dev/.local/bin",
+			"EDITOR": "vim",
+			"HOST":   "${localEnv:LOCAL_VAR}",
+		},
+	}
+	env := dc.GetRemoteEnv(map[string]string{"PATH": "/usr/bin:/opt/tool/bin"})
+
+	want := map[string]string{
+		"PATH":   "/usr/bin:/opt/tool/bin:/home/dev/.local/bin",
+		"EDITOR": "vim",
+		"HOST":   "local_value",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("GetRemoteEnv() = %v, want %v", env, want)
+	}
+
+	if env := (&DevContainer{}).GetRemoteEnv(nil); env != nil {
+		t.Errorf("GetRemoteEnv() without remoteEnv = %v, want nil", env)
+	}
+}
+
 func TestHasBuild_WithLegacyDockerfile(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1783,3 +1843,575 @@ func TestHasFeatures(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_PortsAttributes(t *testing.T) {
+	fixturePath := filepath.Join("..", "..", "test", "fixtures", "ports-attributes.json")
+
+	dc, err := Parse(fixturePath)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	app := dc.PortsAttributes["3000"]
+	if app.Label != "App" || !app.RequireLocalPort || app.ElevateIfNeeded {
+		t.Errorf("PortsAttributes[3000] = %+v, want label App with requireLocalPort only", app)
+	}
+
+	web := dc.PortsAttributes["80"]
+	if web.Label != "Web" || web.RequireLocalPort || !web.ElevateIfNeeded {
+		t.Errorf("PortsAttributes[80] = %+v, want label Web with elevateIfNeeded only", web)
+	}
+}
+
+func TestGetFeatures_OverrideInstallOrder(t *testing.T) {
+	dc := DevContainer{
+		Features: map[string]interface{}{
+			"ghcr.io/devcontainers/features/common-utils:2": map[string]interface{}{},
+			"ghcr.io/devcontainers/features/git:1":          "latest",
+			"ghcr.io/devcontainers/features/node:1":         map[string]interface{}{},
+		},
+		OverrideFeatureInstallOrder: []string{
+			"ghcr.io/devcontainers/features/node:1",
+			"ghcr.io/devcontainers/features/not-declared:1",
+		},
+	}
+
+	specs := dc.GetFeatures()
+	var refs []string
+	for _, spec := range specs {
+		refs = append(refs, spec.Ref)
+	}
+	want := []string{
+		"ghcr.io/devcontainers/features/node:1",
+		"ghcr.io/devcontainers/features/common-utils:2",
+		"ghcr.io/devcontainers/features/git:1",
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("GetFeatures() refs = %v, want %v", refs, want)
+	}
+	for i := range want {
+		if refs[i] != want[i] {
+			t.Errorf("GetFeatures() refs = %v, want %v", refs, want)
+			break
+		}
+	}
+}
+
+func TestGetFeatures_OverrideInstallOrderWithoutVersion(t *testing.T) {
+	dc := DevContainer{
+		Features: map[string]interface{}{
+			"ghcr.io/devcontainers/features/common-utils:2":      map[string]interface{}{},
+			"ghcr.io/devcontainers/features/git@sha256:0123abcd": map[string]interface{}{},
+			"ghcr.io/devcontainers/features/node:1":              map[string]interface{}{},
+			"localhost:5000/features/python":                     map[string]interface{}{},
+		},
+		OverrideFeatureInstallOrder: []string{
+			"ghcr.io/devcontainers/features/node",
+			"localhost:5000/features/python:3",
+			"ghcr.io/devcontainers/features/git:1",
+		},
+	}
+
+	var refs []string
+	for _, spec := range dc.GetFeatures() {
+		refs = append(refs, spec.Ref)
+	}
+	want := []string{
+		"ghcr.io/devcontainers/features/node:1",
+		"localhost:5000/features/python",
+		"ghcr.io/devcontainers/features/git@sha256:0123abcd",
+		"ghcr.io/devcontainers/features/common-utils:2",
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("GetFeatures() refs = %v, want %v", refs, want)
+	}
+}
+
+func TestFeatureIDWithoutVersion(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "ghcr.io/devcontainers/features/node:1", want: "ghcr.io/devcontainers/features/node"},
+		{ref: "ghcr.io/devcontainers/features/node@sha256:0123abcd", want: "ghcr.io/devcontainers/features/node"},
+		{ref: "ghcr.io/devcontainers/features/node", want: "ghcr.io/devcontainers/features/node"},
+		{ref: "localhost:5000/features/python", want: "localhost:5000/features/python"},
+		{ref: "localhost:5000/features/python:3", want: "localhost:5000/features/python"},
+		{ref: "./local-feature", want: "./local-feature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := featureIDWithoutVersion(tt.ref); got != tt.want {
+				t.Errorf("featureIDWithoutVersion(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "version.txt"), []byte("1.2.3\n"), 0644); err != nil {
+		t.Fatalf("failed to write version.txt: %v", err)
+	}
+
+	configPath := filepath.Join(dir, "devcontainer.json")
+	config := `{
+		"image": "ubuntu:22.04",
+		"containerEnv": {
+			"APP_VERSION": "${localFile:./version.txt}",
+			"LABEL": "v${localFile:version.txt}-dev"
+		}
+	}`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	dc, err := Parse(configPath)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := dc.ResolveLocalFiles(dir); err != nil {
+		t.Fatalf("ResolveLocalFiles() error = %v", err)
+	}
+	if dc.ContainerEnv["APP_VERSION"] != "1.2.3" {
+		t.Errorf("APP_VERSION = %q, want %q", dc.ContainerEnv["APP_VERSION"], "1.2.3")
+	}
+	if dc.ContainerEnv["LABEL"] != "v1.2.3-dev" {
+		t.Errorf("LABEL = %q, want %q", dc.ContainerEnv["LABEL"], "v1.2.3-dev")
+	}
+}
+
+func TestResolveLocalFiles_Missing(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "devcontainer.json")
+	config := `{
+		"image": "ubuntu:22.04",
+		"containerEnv": {"APP_VERSION": "${localFile:./missing.txt}"}
+	}`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	// Parsing still works, so commands that do not use containerEnv, such
+	// as down, are not held up by the missing file.
+	dc, err := Parse(configPath)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	err = dc.ResolveLocalFiles(dir)
+	if err == nil {
+		t.Fatal("expected an error for a missing localFile")
+	}
+	if !strings.Contains(err.Error(), "missing.txt") || !strings.Contains(err.Error(), "APP_VERSION") {
+		t.Errorf("error %q should name the variable and the file", err)
+	}
+	if dc.ContainerEnv["APP_VERSION"] != "${localFile:./missing.txt}" {
+		t.Errorf("APP_VERSION = %q, want the reference left as is", dc.ContainerEnv["APP_VERSION"])
+	}
+}
+
+func TestParse_SchemaAnnotated(t *testing.T) {
+	fixturePath := filepath.Join("..", "..", "test", "fixtures", "schema-annotated.json")
+
+	dc, err := Parse(fixturePath)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if !strings.HasSuffix(dc.Schema, "devContainer.schema.json") {
+		t.Errorf("Schema = %q, want the devContainer schema URL", dc.Schema)
+	}
+	if dc.Image != "ubuntu:22.04" {
+		t.Errorf("Image = %q, want %q", dc.Image, "ubuntu:22.04")
+	}
+}
+
+func TestGetCommandCwd(t *testing.T) {
+	dc := &DevContainer{}
+	if err := json5.Unmarshal([]byte(`{
+		"postCreateCommand": {"command": "npm i", "cwd": "frontend"},
+		"postStartCommand": "npm start",
+	}`), dc); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if got := dc.GetCommandCwd(WaitForPostCreateCommand); got != "frontend" {
+		t.Errorf("GetCommandCwd(postCreateCommand) = %q, want %q", got, "frontend")
+	}
+	if got := dc.GetCommandCwd(WaitForPostStartCommand); got != "" {
+		t.Errorf("GetCommandCwd(postStartCommand) = %q, want empty", got)
+	}
+	if args := dc.GetPostCreateCommandArgs(); strings.Join(args, " ") != "/bin/sh -c npm i" {
+		t.Errorf("GetPostCreateCommandArgs() = %v, want the command from the object form", args)
+	}
+}
+
+func TestGetLifecycleCommands(t *testing.T) {
+	dc := &DevContainer{}
+	if err := json5.Unmarshal([]byte(`{
+		"onCreateCommand": "make",
+		"postCreateCommand": {"command": ["npm", "i"], "cwd": "frontend"},
+		"postStartCommand": {"watch": ["npm", "run", "watch"], "server": "npm start", "cwd": "api"},
+		"postAttachCommand": {"command": "npm start"},
+	}`), dc); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	tests := []struct {
+		commandType string
+		want        []NamedCommand
+	}{
+		{commandType: WaitForOnCreateCommand, want: []NamedCommand{{Args: []string{"/bin/sh", "-c", "make"}}}},
+		{commandType: WaitForPostCreateCommand, want: []NamedCommand{{Args: []string{"npm", "i"}}}},
+		{commandType: WaitForPostStartCommand, want: []NamedCommand{
+			{Name: "cwd", Args: []string{"/bin/sh", "-c", "api"}},
+			{Name: "server", Args: []string{"/bin/sh", "-c", "npm start"}},
+			{Name: "watch", Args: []string{"npm", "run", "watch"}},
+		}},
+		// Without a cwd, "command" is just the name of a command.
+		{commandType: "postAttachCommand", want: []NamedCommand{{Name: "command", Args: []string{"/bin/sh", "-c", "npm start"}}}},
+		{commandType: WaitForUpdateContentCommand},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.commandType, func(t *testing.T) {
+			got, err := dc.GetLifecycleCommands(tt.commandType)
+			if err != nil {
+				t.Fatalf("GetLifecycleCommands(%s) error = %v", tt.commandType, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetLifecycleCommands(%s) = %v, want %v", tt.commandType, got, tt.want)
+			}
+		})
+	}
+
+	if got := dc.GetCommandCwd(WaitForPostStartCommand); got != "" {
+		t.Errorf("GetCommandCwd(postStartCommand) = %q, want empty for named commands", got)
+	}
+	if args := dc.GetPostStartCommandArgs(); args != nil {
+		t.Errorf("GetPostStartCommandArgs() = %v, want nil for named commands", args)
+	}
+}
+
+func TestGetLifecycleCommands_UnsupportedShapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		command interface{}
+		wantErr string
+	}{
+		{name: "nested object", command: map[string]interface{}{"server": map[string]interface{}{"command": "npm start"}}, wantErr: "postCreateCommand.server"},
+		{name: "number", command: float64(1), wantErr: "postCreateCommand"},
+		{name: "non-string argument", command: []interface{}{"npm", float64(1)}, wantErr: "postCreateCommand"},
+		{name: "cwd form with an object command", command: map[string]interface{}{"command": map[string]interface{}{}, "cwd": "api"}, wantErr: "postCreateCommand"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &DevContainer{PostCreateCommand: tt.command}
+			got, err := dc.GetLifecycleCommands(WaitForPostCreateCommand)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr+":") {
+				t.Errorf("GetLifecycleCommands() = %v, %v, want an error for %s", got, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetWorkspaceMount(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    *Mount
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "volume", spec: "source=app-src,target=/workspace,type=volume", want: &Mount{Type: "volume", Source: "app-src", Target: "/workspace"}},
+		{name: "cached bind", spec: "source=/src/app,target=/work,type=bind,consistency=cached", want: &Mount{Type: "bind", Source: "/src/app", Target: "/work", Consistency: "cached"}},
+		{name: "no target", spec: "source=/src/app,type=bind", wantErr: true},
+		{name: "unknown option", spec: "source=/src/app,target=/work,bogus=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&DevContainer{WorkspaceMount: tt.spec}).GetWorkspaceMount()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetWorkspaceMount() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetWorkspaceMount() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRunArgsHostOptions(t *testing.T) {
+	dc := &DevContainer{RunArgs: []string{"--read-only", "--tmpfs", "/tmp:size=64m", "--tmpfs=/run", "--ulimit=nofile=1024",
+		"--device", "/dev/ttyUSB0", "--device=/dev/video0:/dev/camera:r", "--gpus", "all"}}
+
+	opts := dc.GetRunArgsHostOptions()
+	if !opts.ReadonlyRootfs {
+		t.Error("ReadonlyRootfs = false, want true for --read-only")
+	}
+	if len(opts.Tmpfs) != 2 || opts.Tmpfs["/tmp"] != "size=64m" || opts.Tmpfs["/run"] != "" {
+		t.Errorf("Tmpfs = %v, want /tmp with size=64m and /run with defaults", opts.Tmpfs)
+	}
+	if strings.Join(opts.Devices, " ") != "/dev/ttyUSB0 /dev/video0:/dev/camera:r" {
+		t.Errorf("Devices = %v, want both --device specs", opts.Devices)
+	}
+	if opts.GPUs != "all" {
+		t.Errorf("GPUs = %q, want all", opts.GPUs)
+	}
+	if strings.Join(opts.Unsupported, " ") != "--ulimit=nofile=1024" {
+		t.Errorf("Unsupported = %v, want [--ulimit=nofile=1024]", opts.Unsupported)
+	}
+}
+
+func TestGetRunArgsHostOptions_HostConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		runArgs []string
+		want    RunArgsHostOptions
+	}{
+		{
+			name:    "privileged and init",
+			runArgs: []string{"--privileged", "--init=true"},
+			want:    RunArgsHostOptions{Privileged: true, Init: true},
+		},
+		{
+			name:    "disabled boolean",
+			runArgs: []string{"--privileged=false"},
+			want:    RunArgsHostOptions{},
+		},
+		{
+			name:    "network forms",
+			runArgs: []string{"--net", "bridge", "--network=host"},
+			want:    RunArgsHostOptions{Network: "host"},
+		},
+		{
+			name:    "capabilities and security options",
+			runArgs: []string{"--cap-add=SYS_PTRACE", "--cap-add", "NET_ADMIN", "--cap-drop=MKNOD", "--security-opt", "seccomp=unconfined"},
+			want: RunArgsHostOptions{
+				CapAdd:      []string{"SYS_PTRACE", "NET_ADMIN"},
+				CapDrop:     []string{"MKNOD"},
+				SecurityOpt: []string{"seccomp=unconfined"},
+			},
+		},
+		{
+			name:    "namespaces and sizes",
+			runArgs: []string{"--shm-size=2g", "--ipc=host", "--pid", "host", "--userns=host", "-h", "devbox", "--add-host=db:10.0.0.2"},
+			want: RunArgsHostOptions{
+				ShmSize:    "2g",
+				IpcMode:    "host",
+				PidMode:    "host",
+				UsernsMode: "host",
+				Hostname:   "devbox",
+				ExtraHosts: []string{"db:10.0.0.2"},
+			},
+		},
+		{
+			name:    "unsupported and incomplete",
+			runArgs: []string{"-v", "/a:/b", "--privileged=maybe", "--cap-add"},
+			want:    RunArgsHostOptions{Unsupported: []string{"-v", "/a:/b", "--privileged=maybe", "--cap-add"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &DevContainer{RunArgs: tt.runArgs}
+			if got := dc.GetRunArgsHostOptions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRunArgsHostOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGPURequirement(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "no host requirements", content: `{"image": "ubuntu"}`, want: GPUNone},
+		{name: "gpu true", content: `{"hostRequirements": {"gpu": true}}`, want: GPURequired},
+		{name: "gpu false", content: `{"hostRequirements": {"gpu": false, "cpus": 4}}`, want: GPUNone},
+		{name: "gpu optional", content: `{"hostRequirements": {"gpu": "optional"}}`, want: GPUOptional},
+		{name: "gpu minimums", content: `{"hostRequirements": {"gpu": {"cores": 1000, "memory": "8gb"}}}`, want: GPURequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "devcontainer.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			dc, err := Parse(path)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := dc.GPURequirement(); got != tt.want {
+				t.Errorf("GPURequirement() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	env := map[string]string{"USER": "alice", "EMPTY": ""}
+	vars := Variables{
+		LocalWorkspaceFolder: "/home/alice/src/app",
+		DevcontainerID:       "abcd1234",
+		LookupEnv: func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		},
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "local workspace folder", value: "${localWorkspaceFolder}/.cache", want: "/home/alice/src/app/.cache"},
+		{name: "local basename", value: "${localWorkspaceFolderBasename}", want: "app"},
+		{name: "container workspace folder", value: "${containerWorkspaceFolder}/bin", want: "/workspaces/app/bin"},
+		{name: "container basename", value: "${containerWorkspaceFolderBasename}", want: "app"},
+		{name: "devcontainer id", value: "history-${devcontainerId}", want: "history-abcd1234"},
+		{name: "local env", value: "${localEnv:USER}", want: "alice"},
+		{name: "env alias", value: "${env:USER}", want: "alice"},
+		{name: "unset local env is empty", value: "x${localEnv:MISSING}y", want: "xy"},
+		{name: "local env default", value: "${localEnv:MISSING:guest}", want: "guest"},
+		{name: "set but empty local env ignores default", value: "${localEnv:EMPTY:guest}", want: ""},
+		{name: "containerEnv is kept", value: "${containerEnv:PATH}:/opt/bin", want: "${containerEnv:PATH}:/opt/bin"},
+		{name: "unknown variable is kept", value: "${unknown}", want: "${unknown}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &DevContainer{
+				WorkspaceFolder: "/workspaces/${localWorkspaceFolderBasename}",
+				ContainerEnv:    map[string]string{"VALUE": tt.value},
+			}
+			dc.Substitute(vars)
+			if got := dc.ContainerEnv["VALUE"]; got != tt.want {
+				t.Errorf("containerEnv VALUE = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstitute_Fields(t *testing.T) {
+	postCreate := []interface{}{"make", "-C", "${containerWorkspaceFolder}"}
+	dc := &DevContainer{
+		Image:          "app:${localEnv:TAG}",
+		WorkspaceMount: "source=${localWorkspaceFolderBasename}-src,target=${containerWorkspaceFolder},type=volume",
+		RemoteEnv:      map[string]string{"HOST_HOME": "${localEnv:HOME}"},
+		Mounts: []Mount{
+			{Type: "bind", Source: "${localWorkspaceFolder}/../shared", Target: "${containerWorkspaceFolder}/shared"},
+		},
+		RunArgs:           []string{"--label", "id=${devcontainerId}"},
+		Build:             &BuildConfig{Args: map[string]interface{}{"USER": "${localEnv:USER}"}},
+		OnCreateCommand:   "ls ${containerWorkspaceFolder}",
+		PostCreateCommand: postCreate,
+		PostStartCommand:  map[string]interface{}{"command": "echo ${devcontainerId}", "cwd": "frontend"},
+	}
+	env := map[string]string{"TAG": "dev", "HOME": "/home/alice", "USER": "alice"}
+	dc.Substitute(Variables{
+		LocalWorkspaceFolder: "/src/app",
+		DevcontainerID:       "abcd1234",
+		LookupEnv: func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		},
+	})
+
+	if dc.Image != "app:dev" {
+		t.Errorf("Image = %q", dc.Image)
+	}
+	if dc.WorkspaceMount != "source=app-src,target=/workspace,type=volume" {
+		t.Errorf("WorkspaceMount = %q", dc.WorkspaceMount)
+	}
+	if dc.RemoteEnv["HOST_HOME"] != "/home/alice" {
+		t.Errorf("remoteEnv HOST_HOME = %q", dc.RemoteEnv["HOST_HOME"])
+	}
+	wantMount := Mount{Type: "bind", Source: "/src/app/../shared", Target: "/workspace/shared"}
+	if dc.Mounts[0] != wantMount {
+		t.Errorf("Mounts[0] = %+v, want %+v", dc.Mounts[0], wantMount)
+	}
+	if dc.RunArgs[1] != "id=abcd1234" {
+		t.Errorf("RunArgs[1] = %q", dc.RunArgs[1])
+	}
+	if dc.Build.Args["USER"] != "alice" {
+		t.Errorf("build arg USER = %v", dc.Build.Args["USER"])
+	}
+	if got := dc.GetOnCreateCommandArgs(); !reflect.DeepEqual(got, []string{"/bin/sh", "-c", "ls /workspace"}) {
+		t.Errorf("onCreateCommand = %v", got)
+	}
+	if got := dc.GetPostCreateCommandArgs(); !reflect.DeepEqual(got, []string{"make", "-C", "/workspace"}) {
+		t.Errorf("postCreateCommand = %v", got)
+	}
+	if postCreate[2] != "${containerWorkspaceFolder}" {
+		t.Error("Substitute should not modify the decoded command slice")
+	}
+	if got := dc.GetPostStartCommandArgs(); !reflect.DeepEqual(got, []string{"/bin/sh", "-c", "echo abcd1234"}) {
+		t.Errorf("postStartCommand = %v", got)
+	}
+	if cwd := dc.GetCommandCwd("postStartCommand"); cwd != "frontend" {
+		t.Errorf("postStartCommand cwd = %q, want frontend", cwd)
+	}
+}
+
+func TestParse_Mounts(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "devcontainer.json")
+	config := `{
+		"image": "ubuntu:22.04",
+		// Both forms may be mixed.
+		"mounts": [
+			"source=node-cache,target=/root/.npm,type=volume",
+			"src=/var/run/docker.sock,dst=/var/run/docker.sock,type=bind,consistency=cached,readonly",
+			{"type": "tmpfs", "target": "/scratch"},
+		],
+	}`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	dc, err := Parse(configPath)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []Mount{
+		{Type: "volume", Source: "node-cache", Target: "/root/.npm"},
+		{Type: "bind", Source: "/var/run/docker.sock", Target: "/var/run/docker.sock", Consistency: "cached", ReadOnly: true},
+		{Type: "tmpfs", Target: "/scratch"},
+	}
+	if !reflect.DeepEqual(dc.Mounts, want) {
+		t.Errorf("Mounts = %+v, want %+v", dc.Mounts, want)
+	}
+}
+
+func TestParseMountString(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Mount
+		wantErr bool
+	}{
+		{name: "volume", spec: "type=volume,source=cache,target=/cache", want: Mount{Type: "volume", Source: "cache", Target: "/cache"}},
+		{name: "aliases", spec: "src=/a,destination=/b", want: Mount{Source: "/a", Target: "/b"}},
+		{name: "readonly with value", spec: "source=/a,target=/b,readonly=false", want: Mount{Source: "/a", Target: "/b"}},
+		{name: "ro flag", spec: "source=/a,target=/b,ro", want: Mount{Source: "/a", Target: "/b", ReadOnly: true}},
+		{name: "invalid readonly", spec: "source=/a,target=/b,readonly=maybe", wantErr: true},
+		{name: "unsupported option", spec: "source=/a,target=/b,bind-propagation=rshared", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMountString(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMountString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseMountString() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package dockersocket
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/garaemon/devgo/pkg/devcontainer"
+)
+
+const (
+	dockerHostEnv = "DOCKER_HOST"
+	unixScheme    = "unix://"
+
+	// ContainerSocketPath is where the socket is mounted inside the container
+	ContainerSocketPath = "/var/run/docker.sock"
+
+	defaultSocketPath = "/var/run/docker.sock"
+)
+
+// GetHostSocket returns the Docker daemon socket path on the host. A unix://
+// DOCKER_HOST wins; otherwise the usual locations for the platform are tried.
+func GetHostSocket() (string, error) {
+	home, _ := os.UserHomeDir()
+	return findHostSocket(os.Getenv(dockerHostEnv), candidatePaths(runtime.GOOS, home))
+}
+
+// candidatePaths lists the socket locations to try for goos, most specific
+// first. Docker Desktop on macOS keeps the socket in the home directory; on
+// Windows the daemon runs in a VM that exposes the Linux path to containers.
+func candidatePaths(goos, home string) []string {
+	switch goos {
+	case "darwin":
+		paths := []string{}
+		if home != "" {
+			paths = append(paths, filepath.Join(home, ".docker", "run", "docker.sock"))
+		}
+		return append(paths, defaultSocketPath)
+	case "windows":
+		return nil
+	default:
+		return []string{defaultSocketPath}
+	}
+}
+
+func findHostSocket(dockerHost string, candidates []string) (string, error) {
+	if strings.HasPrefix(dockerHost, unixScheme) {
+		socketPath := strings.TrimPrefix(dockerHost, unixScheme)
+		if _, err := os.Stat(socketPath); err != nil {
+			return "", fmt.Errorf("docker socket from DOCKER_HOST does not exist at %s", socketPath)
+		}
+		return socketPath, nil
+	}
+	if dockerHost != "" {
+		return "", fmt.Errorf("DOCKER_HOST %s is not a unix socket", dockerHost)
+	}
+
+	if candidates == nil {
+		// Docker Desktop on Windows serves the Linux socket path to containers.
+		return defaultSocketPath, nil
+	}
+	for _, socketPath := range candidates {
+		if _, err := os.Stat(socketPath); err == nil {
+			return socketPath, nil
+		}
+	}
+	return "", fmt.Errorf("docker socket not found (tried %s)", strings.Join(candidates, ", "))
+}
+
+// CreateMount creates a read-write bind mount of the host socket
+func CreateMount(hostSocketPath string) devcontainer.Mount {
+	return devcontainer.Mount{
+		Type:   "bind",
+		Source: hostSocketPath,
+		Target: ContainerSocketPath,
+	}
+}
@@ -0,0 +1,83 @@
+package dockersocket
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindHostSocket(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "docker.sock")
+	if err := os.WriteFile(existing, nil, 0600); err != nil {
+		t.Fatalf("failed to create socket stand-in: %v", err)
+	}
+	missing := filepath.Join(dir, "missing.sock")
+
+	tests := []struct {
+		name        string
+		dockerHost  string
+		candidates  []string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:       "DOCKER_HOST unix socket",
+			dockerHost: "unix://" + existing,
+			candidates: []string{missing},
+			expected:   existing,
+		},
+		{
+			name:        "DOCKER_HOST unix socket missing",
+			dockerHost:  "unix://" + missing,
+			expectError: true,
+		},
+		{
+			name:        "DOCKER_HOST over tcp cannot be mounted",
+			dockerHost:  "tcp://127.0.0.1:2375",
+			expectError: true,
+		},
+		{
+			name:       "first existing candidate",
+			candidates: []string{missing, existing},
+			expected:   existing,
+		},
+		{
+			name:        "no candidate exists",
+			candidates:  []string{missing},
+			expectError: true,
+		},
+		{
+			name:     "no candidates means the Docker Desktop path",
+			expected: "/var/run/docker.sock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findHostSocket(tt.dockerHost, tt.candidates)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("findHostSocket() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCandidatePaths(t *testing.T) {
+	darwin := candidatePaths("darwin", "/Users/me")
+	if len(darwin) != 2 || darwin[0] != "/Users/me/.docker/run/docker.sock" {
+		t.Errorf("candidatePaths(darwin) = %v, want the Docker Desktop socket first", darwin)
+	}
+	if linux := candidatePaths("linux", "/home/me"); len(linux) != 1 || linux[0] != "/var/run/docker.sock" {
+		t.Errorf("candidatePaths(linux) = %v, want [/var/run/docker.sock]", linux)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package dockersocket
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// GroupID returns the numeric group owning the socket, which the container
+// needs as a supplementary group to use the socket without root.
+func GroupID(socketPath string) (string, bool) {
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		return "", false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(stat.Gid), 10), true
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package dockersocket
+
+// GroupID reports no group on Windows, where the socket lives inside the
+// Docker Desktop VM.
+func GroupID(socketPath string) (string, bool) {
+	return "", false
+}
@@ -26,16 +26,22 @@ const DefaultTargetPath = "~/dotfiles"
 
 // DefaultInstallScripts is the ordered list of script names devgo searches
 // for when installCommand is unset. Mirrors the convention used by VS Code's
-// dev containers extension.
+// dev containers extension and GitHub Codespaces.
 var DefaultInstallScripts = []string{
 	"install.sh",
 	"install",
 	"bootstrap.sh",
 	"bootstrap",
+	"script/bootstrap",
 	"setup.sh",
 	"setup",
+	"script/setup",
 }
 
+// linkExcludes are the top-level dot entries of a dotfiles repository that
+// belong to the repository itself and are never linked into $HOME.
+var linkExcludes = []string{".git", ".github", ".gitignore", ".gitmodules"}
+
 // Config is the resolved per-invocation dotfiles configuration.
 type Config struct {
 	Repository     string
@@ -117,8 +123,8 @@ func Resolve(fileCfg *config.DotfilesConfig, override Override, disabled bool) *
 //   - If force is true and targetPath does not exist, force has no effect
 //     and the clone proceeds as it would otherwise.
 //   - When InstallCommand is empty, devgo probes DefaultInstallScripts in
-//     order and runs the first match. If none match, the clone is left in
-//     place (clone-only mode).
+//     order and runs the first match. If none match, the top-level dotfiles
+//     of the repository are symlinked into $HOME, as Codespaces does.
 //
 // logf receives human-readable progress messages. Pass [Discard] to suppress
 // them; a nil callback is treated the same way.
@@ -133,7 +139,7 @@ func Apply(ctx context.Context, exec Executor, user string, cfg *Config, force b
 		logf = Discard
 	}
 
-	target, err := resolveHome(ctx, exec, user, cfg.TargetPath)
+	target, err := ResolveHome(ctx, exec, user, cfg.TargetPath)
 	if err != nil {
 		return fmt.Errorf("failed to resolve target path %s: %w", cfg.TargetPath, err)
 	}
@@ -163,7 +169,14 @@ func Apply(ctx context.Context, exec Executor, user string, cfg *Config, force b
 		return fmt.Errorf("failed to resolve install script: %w", err)
 	}
 	if script == "" {
-		logf("dotfiles cloned; no install script found, skipping install step\n")
+		skipped, err := linkDotfiles(ctx, exec, user, target)
+		if err != nil {
+			return fmt.Errorf("failed to link dotfiles: %w", err)
+		}
+		for _, name := range skipped {
+			logf("~/%s is a directory, not linking %s over it\n", name, path.Join(target, name))
+		}
+		logf("dotfiles cloned; no install script found, linked them into $HOME\n")
 		return nil
 	}
 
@@ -175,12 +188,12 @@ func Apply(ctx context.Context, exec Executor, user string, cfg *Config, force b
 	return nil
 }
 
-// resolveHome replaces a leading "~" or "~/" in p with the target user's
+// ResolveHome replaces a leading "~" or "~/" in p with the target user's
 // $HOME, queried inside the container. Paths that do not start with "~" are
 // returned unchanged. The "~user" form (other-user expansion) is rejected
 // explicitly so it does not silently fall through and become a literal
 // directory name.
-func resolveHome(ctx context.Context, exec Executor, user, p string) (string, error) {
+func ResolveHome(ctx context.Context, exec Executor, user, p string) (string, error) {
 	if p != "~" && !strings.HasPrefix(p, "~/") {
 		if strings.HasPrefix(p, "~") {
 			return "", fmt.Errorf("targetPath %q uses unsupported ~user form; only ~ and ~/ are expanded", p)
@@ -275,6 +288,30 @@ func resolveInstallScript(ctx context.Context, exec Executor, user, targetPath,
 	return "", nil
 }
 
+// linkDotfiles symlinks every top-level entry of targetPath whose name
+// starts with "." into the user's $HOME, replacing files and links that are
+// there, except the repository's own files in linkExcludes. A real directory
+// in $HOME, such as ~/.config, is left alone: ln would put the link inside
+// it. The names of the directories it skipped are returned.
+func linkDotfiles(ctx context.Context, exec Executor, user, targetPath string) ([]string, error) {
+	var skip []string
+	for _, name := range linkExcludes {
+		skip = append(skip, fmt.Sprintf(`[ "$f" = %s ]`, name))
+	}
+	script := fmt.Sprintf(`cd %s && for f in .[!.]* ..?*; do test -e "$f" || continue; %s && continue; `+
+		`if [ -d "$HOME/$f" ] && [ ! -L "$HOME/$f" ]; then echo "$f"; continue; fi; `+
+		`ln -sfn "$PWD/$f" "$HOME/$f" || exit 1; done`,
+		shellQuote(targetPath), strings.Join(skip, " || "))
+	stdout, stderr, exitCode, err := exec.Exec(ctx, user, []string{"sh", "-c", script})
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("linking exited with %d: %s", exitCode, stderr)
+	}
+	return strings.Fields(stdout), nil
+}
+
 func runInstallScript(ctx context.Context, exec Executor, user, targetPath, command string) error {
 	// command is the resolved installCommand, which may include arguments
 	// (e.g. "install.sh --tool"). It is run as a shell command line so the
@@ -1,7 +1,12 @@
 package dotfiles
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -327,12 +332,12 @@ func TestResolveHome(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			exec := &fakeExec{rules: []fakeRule{{contains: "printf", stdout: "/home/u"}}}
-			got, err := resolveHome(context.Background(), exec, "u", tt.path)
+			got, err := ResolveHome(context.Background(), exec, "u", tt.path)
 			if err != nil {
-				t.Fatalf("resolveHome error = %v", err)
+				t.Fatalf("ResolveHome error = %v", err)
 			}
 			if got != tt.want {
-				t.Errorf("resolveHome(%q) = %q, want %q", tt.path, got, tt.want)
+				t.Errorf("ResolveHome(%q) = %q, want %q", tt.path, got, tt.want)
 			}
 		})
 	}
@@ -376,7 +381,7 @@ func TestCloneRepo_RedactsCredentialsInErrorMessage(t *testing.T) {
 
 func TestResolveHome_RejectsOtherUserForm(t *testing.T) {
 	exec := &fakeExec{}
-	_, err := resolveHome(context.Background(), exec, "u", "~someone/dotfiles")
+	_, err := ResolveHome(context.Background(), exec, "u", "~someone/dotfiles")
 	if err == nil {
 		t.Fatalf("expected error for ~someone/... path, got nil")
 	}
@@ -531,3 +536,121 @@ func TestShellQuote_HandlesEmbeddedQuote(t *testing.T) {
 	}
 }
 
+
+func TestApply_NoInstallScriptFound_LinksDotfilesIntoHome(t *testing.T) {
+	exec := &fakeExec{
+		rules: []fakeRule{
+			{contains: "[ -e '", exitCode: 1}, // no target, no install script
+		},
+	}
+	cfg := &Config{Repository: "https://example.com/x", TargetPath: "/home/u/df"}
+	if err := Apply(context.Background(), exec, "u", cfg, false, nil); err != nil {
+		t.Fatalf("Apply error = %v", err)
+	}
+	if exec.commandsContaining(`cd '/home/u/df' && for f in`) != 1 {
+		t.Fatalf("expected the dotfiles to be linked from the clone, got calls=%v", exec.calls)
+	}
+	link := exec.calls[len(exec.calls)-1]
+	if link.user != "u" || !strings.Contains(strings.Join(link.cmd, " "), `ln -sfn "$PWD/$f" "$HOME/$f"`) {
+		t.Errorf("link call = %+v, want ln -sfn into $HOME as u", link)
+	}
+}
+
+func TestApply_LinkFailureIsReported(t *testing.T) {
+	exec := &fakeExec{
+		rules: []fakeRule{
+			{contains: "[ -e '", exitCode: 1},
+			{contains: "ln -sfn", stderr: "read-only file system", exitCode: 1},
+		},
+	}
+	cfg := &Config{Repository: "https://example.com/x", TargetPath: "/home/u/df"}
+	err := Apply(context.Background(), exec, "u", cfg, false, nil)
+	if err == nil || !strings.Contains(err.Error(), "read-only file system") {
+		t.Errorf("Apply error = %v, want the link failure", err)
+	}
+}
+
+// shellExec runs commands on the host with $HOME set to home, so the
+// scripts Apply generates can be checked against a real file system.
+type shellExec struct {
+	home string
+}
+
+func (e shellExec) Exec(ctx context.Context, _ string, cmd []string) (string, string, int, error) {
+	c := osexec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	c.Env = append(os.Environ(), "HOME="+e.home)
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	err := c.Run()
+	var exitErr *osexec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), stderr.String(), exitErr.ExitCode(), nil
+	}
+	return stdout.String(), stderr.String(), 0, err
+}
+
+func TestLinkDotfiles_RunsScript(t *testing.T) {
+	if _, err := osexec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	repo := t.TempDir()
+	home := t.TempDir()
+	for _, dir := range []string{".config/nvim", ".git", ".vim"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{".bashrc", ".config/nvim/init.lua"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte("repo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// ~/.config is a real directory, ~/.bashrc a file and ~/.vim an old link.
+	if err := os.MkdirAll(filepath.Join(home, ".config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".bashrc"), []byte("home\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(home, ".vim")); err != nil {
+		t.Fatal(err)
+	}
+
+	skipped, err := linkDotfiles(context.Background(), shellExec{home: home}, "u", repo)
+	if err != nil {
+		t.Fatalf("linkDotfiles error = %v", err)
+	}
+	if len(skipped) != 1 || skipped[0] != ".config" {
+		t.Errorf("skipped = %v, want [.config]", skipped)
+	}
+
+	for _, name := range []string{".bashrc", ".vim"} {
+		got, err := os.Readlink(filepath.Join(home, name))
+		if err != nil || got != filepath.Join(repo, name) {
+			t.Errorf("~/%s links to %q (%v), want %s", name, got, err, filepath.Join(repo, name))
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(home, ".config"))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("~/.config = %v (%v), want it left empty", entries, err)
+	}
+	if _, err := os.Lstat(filepath.Join(home, ".git")); !os.IsNotExist(err) {
+		t.Errorf("~/.git exists (%v), want .git excluded", err)
+	}
+}
+
+func TestApply_DetectsScriptDirectoryInstallScript(t *testing.T) {
+	exec := &fakeExec{}
+	exec.rules = []fakeRule{
+		{contains: "[ -e '/home/u/df/script/bootstrap'", exitCode: 0},
+		{contains: "[ -e", exitCode: 1},
+	}
+	cfg := &Config{Repository: "https://example.com/x", TargetPath: "/home/u/df"}
+	if err := Apply(context.Background(), exec, "u", cfg, false, nil); err != nil {
+		t.Fatalf("Apply error = %v", err)
+	}
+	if exec.commandsContaining("&& ./script/bootstrap") != 1 {
+		t.Errorf("expected script/bootstrap to run, got calls=%v", exec.calls)
+	}
+}
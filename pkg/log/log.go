@@ -0,0 +1,219 @@
+// Package log writes devgo's progress messages, warnings and lifecycle
+// command output to stderr, one record per line, as plain text or as JSON
+// for CI. Each record can carry the phase that produced it, such as
+// "postCreateCommand", and a timestamp.
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level orders records by importance. A logger drops records below its
+// level.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Format is how records are rendered.
+type Format string
+
+const (
+	// FormatText writes "[time] [phase] Warning: message" lines.
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per record.
+	FormatJSON Format = "json"
+)
+
+// ParseFormat parses a --log-format value.
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case FormatText, FormatJSON:
+		return Format(value), nil
+	}
+	return "", fmt.Errorf("invalid log format %q: want text or json", value)
+}
+
+// Logger writes records to its output. It is safe for concurrent use.
+type Logger struct {
+	mu         sync.Mutex
+	out        io.Writer
+	level      Level
+	format     Format
+	timestamps bool
+	now        func() time.Time
+}
+
+// New returns a text logger at LevelInfo writing to out, or to the
+// os.Stderr of the moment each record is written when out is nil.
+func New(out io.Writer) *Logger {
+	return &Logger{out: out, level: LevelInfo, format: FormatText, now: time.Now}
+}
+
+// SetLevel drops records below level from then on.
+func (l *Logger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// SetFormat switches the rendering of records.
+func (l *Logger) SetFormat(format Format) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.format = format
+}
+
+// SetTimestamps turns timestamps on text records on or off. JSON records
+// always carry one.
+func (l *Logger) SetTimestamps(timestamps bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timestamps = timestamps
+}
+
+// Enabled reports whether records at level are written.
+func (l *Logger) Enabled(level Level) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return level >= l.level
+}
+
+// Logf writes a record without a phase. A trailing newline in the message
+// is dropped, since every record ends a line.
+func (l *Logger) Logf(level Level, format string, args ...any) {
+	l.PhaseLogf(level, "", format, args...)
+}
+
+// PhaseLogf writes a record tagged with phase, like Logf.
+func (l *Logger) PhaseLogf(level Level, phase, format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.write(nil, level, phase, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
+
+// Writer returns a writer that logs each line written to it as a record at
+// level in phase. Call Flush when done to log a last line that lacks a
+// newline.
+func (l *Logger) Writer(level Level, phase string) *LineWriter {
+	return &LineWriter{logger: l, level: level, phase: phase}
+}
+
+// WriterTo is Writer with the records written to out instead of the
+// logger's output, e.g. to keep the stdout of a command on stdout.
+func (l *Logger) WriterTo(out io.Writer, level Level, phase string) *LineWriter {
+	return &LineWriter{logger: l, out: out, level: level, phase: phase}
+}
+
+// write renders one record to out, or to the logger's output when out is
+// nil. The caller holds l.mu.
+func (l *Logger) write(out io.Writer, level Level, phase, message string) {
+	if level < l.level {
+		return
+	}
+	if out == nil {
+		out = l.out
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+
+	if l.format == FormatJSON {
+		record := struct {
+			Time    string `json:"time"`
+			Level   string `json:"level"`
+			Phase   string `json:"phase,omitempty"`
+			Message string `json:"message"`
+		}{l.now().Format(time.RFC3339Nano), level.String(), phase, message}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+		_, _ = out.Write(append(data, '\n'))
+		return
+	}
+
+	var line strings.Builder
+	if l.timestamps {
+		line.WriteString("[" + l.now().Format(time.RFC3339) + "] ")
+	}
+	if phase != "" {
+		line.WriteString("[" + phase + "] ")
+	}
+	switch level {
+	case LevelWarn:
+		line.WriteString("Warning: ")
+	case LevelError:
+		line.WriteString("Error: ")
+	}
+	line.WriteString(message)
+	line.WriteByte('\n')
+	_, _ = io.WriteString(out, line.String())
+}
+
+// LineWriter turns the output of a command into log records, one per line.
+type LineWriter struct {
+	logger *Logger
+	out    io.Writer
+	level  Level
+	phase  string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.log(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush logs what is left of a line that did not end in a newline.
+func (w *LineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) == 0 {
+		return
+	}
+	w.log(string(w.buf))
+	w.buf = nil
+}
+
+func (w *LineWriter) log(line string) {
+	w.logger.mu.Lock()
+	defer w.logger.mu.Unlock()
+	w.logger.write(w.out, w.level, w.phase, line)
+}
@@ -0,0 +1,93 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestLogger(out *bytes.Buffer) *Logger {
+	l := New(out)
+	l.now = func() time.Time { return time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC) }
+	return l
+}
+
+func TestLogger_Logf(t *testing.T) {
+	tests := []struct {
+		name       string
+		level      Level
+		format     Format
+		timestamps bool
+		phase      string
+		logLevel   Level
+		message    string
+		want       string
+	}{
+		{name: "warning", level: LevelInfo, format: FormatText, logLevel: LevelWarn, message: "disk low\n", want: "Warning: disk low\n"},
+		{name: "debug dropped", level: LevelInfo, format: FormatText, logLevel: LevelDebug, message: "noise", want: ""},
+		{name: "debug shown", level: LevelDebug, format: FormatText, logLevel: LevelDebug, message: "noise", want: "noise\n"},
+		{name: "quiet drops info", level: LevelWarn, format: FormatText, logLevel: LevelInfo, message: "npm ci", want: ""},
+		{name: "phase and time", level: LevelInfo, format: FormatText, timestamps: true, phase: "build", logLevel: LevelError, message: "failed", want: "[2024-05-01T12:30:00Z] [build] Error: failed\n"},
+		{name: "json", level: LevelInfo, format: FormatJSON, phase: "postCreateCommand", logLevel: LevelInfo, message: "done", want: `{"time":"2024-05-01T12:30:00Z","level":"info","phase":"postCreateCommand","message":"done"}` + "\n"},
+		{name: "json without phase", level: LevelInfo, format: FormatJSON, logLevel: LevelWarn, message: "a \"quote\"", want: `{"time":"2024-05-01T12:30:00Z","level":"warn","message":"a \"quote\""}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			l := newTestLogger(&out)
+			l.SetLevel(tt.level)
+			l.SetFormat(tt.format)
+			l.SetTimestamps(tt.timestamps)
+			l.PhaseLogf(tt.logLevel, tt.phase, "%s", tt.message)
+			if got := out.String(); got != tt.want {
+				t.Errorf("Logf() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var out bytes.Buffer
+	l := newTestLogger(&out)
+	w := l.Writer(LevelInfo, "postStartCommand")
+
+	fmt.Fprint(w, "first li")
+	fmt.Fprint(w, "ne\r\nsecond\n")
+	fmt.Fprint(w, "partial")
+	if got, want := out.String(), "[postStartCommand] first line\n[postStartCommand] second\n"; got != want {
+		t.Fatalf("before Flush wrote %q, want %q", got, want)
+	}
+	w.Flush()
+	w.Flush()
+	if got, want := out.String(), "[postStartCommand] first line\n[postStartCommand] second\n[postStartCommand] partial\n"; got != want {
+		t.Errorf("after Flush wrote %q, want %q", got, want)
+	}
+}
+
+func TestLogger_WriterTo(t *testing.T) {
+	var out, stdout bytes.Buffer
+	l := newTestLogger(&out)
+	w := l.WriterTo(&stdout, LevelInfo, "postCreateCommand")
+
+	fmt.Fprint(w, "added 120 packages\n")
+	l.Logf(LevelWarn, "slow disk")
+	if got, want := stdout.String(), "[postCreateCommand] added 120 packages\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got, want := out.String(), "Warning: slow disk\n"; got != want {
+		t.Errorf("log output = %q, want %q", got, want)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, value := range []string{"text", "json"} {
+		if got, err := ParseFormat(value); err != nil || string(got) != value {
+			t.Errorf("ParseFormat(%q) = %q, %v", value, got, err)
+		}
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("ParseFormat(yaml) should fail")
+	}
+}
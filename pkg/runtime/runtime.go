@@ -0,0 +1,104 @@
+// Package runtime selects the container engine devgo drives and connects to
+// its API. Docker and Podman both serve the Docker API, so one SDK client
+// works for either once it points at the right socket; what differs is the
+// socket location, the CLI used for builds and compose, and how user
+// namespaces map container users to the host.
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// Runtime is a container engine devgo can drive.
+type Runtime string
+
+const (
+	Docker Runtime = "docker"
+	Podman Runtime = "podman"
+)
+
+// EnvVar selects the runtime when no --runtime flag is given.
+const EnvVar = "DEVGO_RUNTIME"
+
+// rootfulPodmanSocket is where a system-wide Podman service listens.
+const rootfulPodmanSocket = "/run/podman/podman.sock"
+
+// windowsPodmanPipe is the named pipe the default Podman machine serves on
+// Windows hosts.
+const windowsPodmanPipe = "npipe:////./pipe/podman-machine-default"
+
+// Supported lists the runtimes in the order they are shown to users.
+var Supported = []Runtime{Docker, Podman}
+
+// Parse returns the runtime called name.
+func Parse(name string) (Runtime, error) {
+	for _, r := range Supported {
+		if string(r) == name {
+			return r, nil
+		}
+	}
+	names := make([]string, len(Supported))
+	for i, r := range Supported {
+		names[i] = string(r)
+	}
+	return "", fmt.Errorf("unsupported container engine %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// Detect guesses the runtime from DOCKER_HOST: a Podman socket means Podman,
+// anything else Docker.
+func Detect(dockerHost string) Runtime {
+	if strings.Contains(dockerHost, "podman") {
+		return Podman
+	}
+	return Docker
+}
+
+// Binary returns the CLI devgo runs for builds, pushes and compose.
+func (r Runtime) Binary() string {
+	return string(r)
+}
+
+// Host returns the API endpoint to connect to. DOCKER_HOST always wins;
+// without it Docker uses the SDK default (the docker_engine named pipe on
+// Windows) and Podman its rootless socket under XDG_RUNTIME_DIR, or the
+// system socket when running as root.
+func (r Runtime) Host(dockerHost, xdgRuntimeDir string, uid int) string {
+	return r.host(goruntime.GOOS, dockerHost, xdgRuntimeDir, uid)
+}
+
+// host is Host for goos. On Windows Podman runs in a machine reached through
+// a named pipe.
+func (r Runtime) host(goos, dockerHost, xdgRuntimeDir string, uid int) string {
+	if dockerHost != "" || r != Podman {
+		return dockerHost
+	}
+	if goos == "windows" {
+		return windowsPodmanPipe
+	}
+	if uid != 0 && xdgRuntimeDir != "" {
+		return "unix://" + filepath.Join(xdgRuntimeDir, "podman", "podman.sock")
+	}
+	return "unix://" + rootfulPodmanSocket
+}
+
+// Rootless reports whether containers run in a user namespace owned by the
+// invoking user, where container root already is the host user. That is
+// the case for Podman run by a regular user.
+func (r Runtime) Rootless(uid int) bool {
+	return r == Podman && uid != 0
+}
+
+// NewClient connects an SDK client to host, or to the environment's default
+// when host is empty.
+func NewClient(host string) (*client.Client, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	return client.NewClientWithOpts(opts...)
+}
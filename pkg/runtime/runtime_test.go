@@ -0,0 +1,102 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Runtime
+		wantErr bool
+	}{
+		{name: "docker", want: Docker},
+		{name: "podman", want: Podman},
+		{name: "nerdctl", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.name)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "supported: docker, podman") {
+					t.Fatalf("Parse(%q) error = %v, want unsupported error", tt.name, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Parse(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		dockerHost string
+		want       Runtime
+	}{
+		{"", Docker},
+		{"unix:///var/run/docker.sock", Docker},
+		{"unix:///run/user/1000/podman/podman.sock", Podman},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(tt.dockerHost); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.dockerHost, got, tt.want)
+		}
+	}
+}
+
+func TestRuntimeHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		runtime    Runtime
+		goos       string
+		dockerHost string
+		xdg        string
+		uid        int
+		want       string
+	}{
+		{name: "docker default", runtime: Docker, xdg: "/run/user/1000", uid: 1000, want: ""},
+		{name: "DOCKER_HOST wins", runtime: Podman, dockerHost: "tcp://remote:2375", xdg: "/run/user/1000", uid: 1000, want: "tcp://remote:2375"},
+		{name: "rootless podman", runtime: Podman, xdg: "/run/user/1000", uid: 1000, want: "unix:///run/user/1000/podman/podman.sock"},
+		{name: "rootful podman", runtime: Podman, xdg: "/run/user/0", uid: 0, want: "unix:///run/podman/podman.sock"},
+		{name: "podman without XDG_RUNTIME_DIR", runtime: Podman, uid: 1000, want: "unix:///run/podman/podman.sock"},
+		{name: "docker on windows", runtime: Docker, goos: "windows", want: ""},
+		{name: "podman on windows", runtime: Podman, goos: "windows", want: "npipe:////./pipe/podman-machine-default"},
+		{name: "DOCKER_HOST wins on windows", runtime: Podman, goos: "windows", dockerHost: "npipe:////./pipe/other", want: "npipe:////./pipe/other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goos := tt.goos
+			if goos == "" {
+				goos = "linux"
+			}
+			if got := tt.runtime.host(goos, tt.dockerHost, tt.xdg, tt.uid); got != tt.want {
+				t.Errorf("host(%q) = %q, want %q", goos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeRootless(t *testing.T) {
+	tests := []struct {
+		runtime Runtime
+		uid     int
+		want    bool
+	}{
+		{Docker, 1000, false},
+		{Podman, 1000, true},
+		{Podman, 0, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.runtime.Rootless(tt.uid); got != tt.want {
+			t.Errorf("%s.Rootless(%d) = %v, want %v", tt.runtime, tt.uid, got, tt.want)
+		}
+	}
+}
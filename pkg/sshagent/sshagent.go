@@ -3,6 +3,7 @@ package sshagent
 import (
 	"fmt"
 	"os"
+	"runtime"
 
 	"github.com/garaemon/devgo/pkg/devcontainer"
 )
@@ -14,6 +15,12 @@ const (
 
 // GetHostSocket returns the SSH agent socket path from the host environment
 func GetHostSocket() (string, error) {
+	// The Windows OpenSSH agent listens on a named pipe, which cannot be
+	// bind-mounted into a Linux container.
+	if runtime.GOOS == "windows" {
+		return "", fmt.Errorf("SSH agent forwarding is not supported on Windows hosts")
+	}
+
 	socketPath := os.Getenv(sshAuthSockEnv)
 	if socketPath == "" {
 		return "", fmt.Errorf("SSH_AUTH_SOCK environment variable is not set")
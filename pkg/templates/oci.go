@@ -0,0 +1,263 @@
+package templates
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	manifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	// layerMediaType marks the tarball of a Template in its manifest.
+	layerMediaType = "application/vnd.devcontainers.layer.v1+tar"
+	// maxBlobSize bounds what Fetch downloads for a single Template.
+	maxBlobSize = 64 << 20
+)
+
+// Ref is a parsed Template reference such as
+// ghcr.io/devcontainers/templates/go:1. Reference is the tag, or the digest
+// when the reference pins one with @sha256:... .
+type Ref struct {
+	Registry   string
+	Repository string
+	Reference  string
+}
+
+func (r Ref) String() string {
+	if strings.HasPrefix(r.Reference, "sha256:") {
+		return r.Registry + "/" + r.Repository + "@" + r.Reference
+	}
+	return r.Registry + "/" + r.Repository + ":" + r.Reference
+}
+
+var repositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*(?:/[a-z0-9]+(?:[._-][a-z0-9]+)*)*$`)
+
+// ParseRef parses an OCI reference to a Template. The registry is required,
+// as in the devcontainer CLI, and the tag defaults to latest.
+func ParseRef(value string) (Ref, error) {
+	registry, rest, ok := strings.Cut(value, "/")
+	if !ok || registry == "" || !strings.ContainsAny(registry, ".:") && registry != "localhost" {
+		return Ref{}, fmt.Errorf("invalid template reference %q: want <registry>/<path>[:tag], e.g. ghcr.io/devcontainers/templates/go:1", value)
+	}
+
+	ref := Ref{Registry: registry, Reference: "latest"}
+	if repo, digest, ok := strings.Cut(rest, "@"); ok {
+		if !strings.HasPrefix(digest, "sha256:") {
+			return Ref{}, fmt.Errorf("invalid template reference %q: unsupported digest %q", value, digest)
+		}
+		ref.Repository, ref.Reference = repo, digest
+	} else if i := strings.LastIndex(rest, ":"); i >= 0 {
+		ref.Repository, ref.Reference = rest[:i], rest[i+1:]
+	} else {
+		ref.Repository = rest
+	}
+	if !repositoryPattern.MatchString(ref.Repository) || ref.Reference == "" {
+		return Ref{}, fmt.Errorf("invalid template reference %q", value)
+	}
+	return ref, nil
+}
+
+// Credentials returns the username and password for registry, or empty
+// strings to pull anonymously.
+type Credentials func(registry string) (username, password string)
+
+// Client downloads Templates from OCI registries.
+type Client struct {
+	HTTP        *http.Client
+	Credentials Credentials
+}
+
+// Fetch downloads the Template tarball ref points at. Registries on
+// localhost are spoken to over plain HTTP, every other one over HTTPS.
+func (c *Client) Fetch(ctx context.Context, ref Ref) ([]byte, error) {
+	base := "https://" + ref.Registry + "/v2/" + ref.Repository
+	if host := strings.Split(ref.Registry, ":")[0]; host == "localhost" || host == "127.0.0.1" {
+		base = "http://" + ref.Registry + "/v2/" + ref.Repository
+	}
+
+	data, err := c.get(ctx, ref, base+"/manifests/"+ref.Reference, manifestMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the manifest of %s: %w", ref, err)
+	}
+	var manifest struct {
+		Layers []struct {
+			MediaType string `json:"mediaType"`
+			Digest    string `json:"digest"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse the manifest of %s: %w", ref, err)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("manifest of %s has no layers", ref)
+	}
+	layer := manifest.Layers[0]
+	for _, l := range manifest.Layers {
+		if l.MediaType == layerMediaType {
+			layer = l
+			break
+		}
+	}
+
+	blob, err := c.get(ctx, ref, base+"/blobs/"+layer.Digest, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template %s: %w", ref, err)
+	}
+	sum := sha256.Sum256(blob)
+	if got := "sha256:" + hex.EncodeToString(sum[:]); got != layer.Digest {
+		return nil, fmt.Errorf("template %s does not match its digest %s (got %s)", ref, layer.Digest, got)
+	}
+	return blob, nil
+}
+
+// get fetches target, answering a 401 with the credentials or token the
+// registry asks for.
+func (c *Client) get(ctx context.Context, ref Ref, target, accept string) ([]byte, error) {
+	resp, err := c.do(ctx, target, accept, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		auth, err := c.authorize(ctx, ref, challenge)
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = c.do(ctx, target, accept, auth); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBlobSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", target, err)
+	}
+	if len(data) > maxBlobSize {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", target, maxBlobSize)
+	}
+	return data, nil
+}
+
+func (c *Client) do(ctx context.Context, target, accept, auth string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	return c.httpClient().Do(req)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return http.DefaultClient
+}
+
+// authorize returns the Authorization header for challenge: the basic
+// credentials of the registry, or a bearer token from the realm named in
+// the challenge, requested with those credentials when there are any.
+func (c *Client) authorize(ctx context.Context, ref Ref, challenge string) (string, error) {
+	var username, password string
+	if c.Credentials != nil {
+		username, password = c.Credentials(ref.Registry)
+	}
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if username == "" {
+			return "", fmt.Errorf("registry %s requires credentials", ref.Registry)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("registry %s sent an unsupported auth challenge %q", ref.Registry, challenge)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("registry %s sent an invalid token realm %q", ref.Registry, params["realm"])
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get a token for %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get a token for %s: %s", ref, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse the token for %s: %w", ref, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge splits a WWW-Authenticate value such as
+// `Bearer realm="https://ghcr.io/token",service="ghcr.io"` into its scheme
+// and parameters.
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(rest, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		rest = strings.TrimLeft(rest, ", ")
+		if key != "" {
+			params[key] = value
+		}
+	}
+	return scheme, params
+}
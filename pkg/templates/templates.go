@@ -0,0 +1,218 @@
+// Package templates downloads dev container Templates from OCI registries
+// and expands them into a workspace, substituting ${templateOption:id}
+// placeholders with the chosen option values. See
+// https://containers.dev/implementors/templates-distribution/.
+package templates
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MetadataFile describes a Template and its options at the root of its
+// tarball.
+const MetadataFile = "devcontainer-template.json"
+
+// skippedFiles are the top-level files of a Template tarball that document
+// the Template and are not copied into the workspace.
+var skippedFiles = map[string]bool{
+	MetadataFile: true,
+	"README.md":  true,
+	"NOTES.md":   true,
+}
+
+// Option is one entry of the "options" of devcontainer-template.json.
+type Option struct {
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Proposals   []string    `json:"proposals,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+// DefaultValue returns the default of o as it is substituted.
+func (o Option) DefaultValue() string {
+	switch v := o.Default.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Validate checks that value is acceptable for o: true or false for a
+// boolean option and one of the enum values when o has an enum.
+func (o Option) Validate(value string) error {
+	if o.Type == "boolean" && value != "true" && value != "false" {
+		return fmt.Errorf("%q is not a boolean (want true or false)", value)
+	}
+	if len(o.Enum) > 0 {
+		for _, allowed := range o.Enum {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(o.Enum, ", "))
+	}
+	return nil
+}
+
+// Template is a downloaded Template: its metadata and the files it adds to
+// a workspace.
+type Template struct {
+	ID          string            `json:"id"`
+	Version     string            `json:"version"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Options     map[string]Option `json:"options"`
+
+	files []templateFile
+}
+
+type templateFile struct {
+	name string
+	mode os.FileMode
+	data []byte
+}
+
+// Load reads a Template from its tarball.
+func Load(archive []byte) (*Template, error) {
+	var metadata []byte
+	var files []templateFile
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, err := cleanArchivePath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from template archive: %w", name, err)
+		}
+		if name == MetadataFile {
+			metadata = data
+		}
+		if skippedFiles[name] {
+			continue
+		}
+		files = append(files, templateFile{name: name, mode: os.FileMode(header.Mode).Perm(), data: data})
+	}
+
+	if metadata == nil {
+		return nil, fmt.Errorf("template archive has no %s", MetadataFile)
+	}
+	t := &Template{}
+	if err := json.Unmarshal(metadata, t); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", MetadataFile, err)
+	}
+	t.files = files
+	return t, nil
+}
+
+// cleanArchivePath returns the slash-separated relative path of a tarball
+// entry, rejecting entries that would land outside the workspace.
+func cleanArchivePath(name string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(name, "./"))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("template archive entry %q is outside the template", name)
+	}
+	return cleaned, nil
+}
+
+// OptionIDs returns the IDs of the options of t, sorted.
+func (t *Template) OptionIDs() []string {
+	ids := make([]string, 0, len(t.Options))
+	for id := range t.Options {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Files returns the paths, relative to the workspace, that Apply writes.
+func (t *Template) Files() []string {
+	var names []string
+	for _, f := range t.files {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+// ResolveOptions completes values with the defaults of the options it does
+// not set and checks every value. Setting an option the Template does not
+// have is an error.
+func (t *Template) ResolveOptions(values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(t.Options))
+	for id, value := range values {
+		option, ok := t.Options[id]
+		if !ok {
+			return nil, fmt.Errorf("template %s has no option %q (options: %s)", t.ID, id, strings.Join(t.OptionIDs(), ", "))
+		}
+		if err := option.Validate(value); err != nil {
+			return nil, fmt.Errorf("option %s: %w", id, err)
+		}
+		resolved[id] = value
+	}
+	for id, option := range t.Options {
+		if _, ok := resolved[id]; !ok {
+			resolved[id] = option.DefaultValue()
+		}
+	}
+	return resolved, nil
+}
+
+// Apply writes the files of t under dir with every ${templateOption:id}
+// replaced by options[id]. Nothing is written when one of the files already
+// exists. It returns the paths written.
+func (t *Template) Apply(dir string, options map[string]string) ([]string, error) {
+	var pairs []string
+	for id, value := range options {
+		pairs = append(pairs, "${templateOption:"+id+"}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	for _, f := range t.files {
+		target := filepath.Join(dir, filepath.FromSlash(f.name))
+		if _, err := os.Lstat(target); err == nil {
+			return nil, fmt.Errorf("%s already exists", target)
+		}
+	}
+
+	var written []string
+	for _, f := range t.files {
+		target := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return written, fmt.Errorf("failed to create directory for %s: %w", target, err)
+		}
+		mode := f.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(target, []byte(replacer.Replace(string(f.data))), mode); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		written = append(written, target)
+	}
+	return written, nil
+}
@@ -0,0 +1,226 @@
+package templates
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const goTemplateMetadata = `{
+	"id": "go",
+	"version": "3.0.0",
+	"name": "Go",
+	"options": {
+		"imageVariant": {"type": "string", "proposals": ["1.22", "1.23"], "default": "1.23"},
+		"installTools": {"type": "boolean", "default": true},
+		"distro": {"type": "string", "enum": ["bookworm", "bullseye"], "default": "bookworm"}
+	}
+}`
+
+// buildArchive returns a tarball holding files, keyed by name.
+func buildArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		mode := int64(0644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0755
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: mode, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func goTemplateArchive(t *testing.T) []byte {
+	return buildArchive(t, map[string]string{
+		"./devcontainer-template.json":      goTemplateMetadata,
+		"./README.md":                       "# Go",
+		"./.devcontainer/devcontainer.json": `{"image": "mcr.microsoft.com/devcontainers/go:${templateOption:imageVariant}-${templateOption:distro}"}`,
+		"./.devcontainer/install-tools.sh":  "#!/bin/sh\n[ ${templateOption:installTools} = true ] && go install golang.org/x/tools/gopls@latest\n",
+		"./.github/dependabot.yml":          "version: 2\n",
+	})
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Ref
+		wantErr bool
+	}{
+		{value: "ghcr.io/devcontainers/templates/go:3", want: Ref{Registry: "ghcr.io", Repository: "devcontainers/templates/go", Reference: "3"}},
+		{value: "ghcr.io/devcontainers/templates/go", want: Ref{Registry: "ghcr.io", Repository: "devcontainers/templates/go", Reference: "latest"}},
+		{value: "localhost:5000/me/tpl:1.2", want: Ref{Registry: "localhost:5000", Repository: "me/tpl", Reference: "1.2"}},
+		{value: "ghcr.io/me/tpl@sha256:abcd", want: Ref{Registry: "ghcr.io", Repository: "me/tpl", Reference: "sha256:abcd"}},
+		{value: "devcontainers/templates/go", wantErr: true},
+		{value: "go", wantErr: true},
+		{value: "ghcr.io/Me/tpl", wantErr: true},
+		{value: "ghcr.io/me/tpl@md5:abcd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRef(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRef(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRef(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAndApply(t *testing.T) {
+	tpl, err := Load(goTemplateArchive(t))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if tpl.ID != "go" || !reflect.DeepEqual(tpl.OptionIDs(), []string{"distro", "imageVariant", "installTools"}) {
+		t.Fatalf("Load() = %s with options %v", tpl.ID, tpl.OptionIDs())
+	}
+
+	options, err := tpl.ResolveOptions(map[string]string{"imageVariant": "1.22"})
+	if err != nil {
+		t.Fatalf("ResolveOptions() error = %v", err)
+	}
+	want := map[string]string{"imageVariant": "1.22", "installTools": "true", "distro": "bookworm"}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("ResolveOptions() = %v, want %v", options, want)
+	}
+
+	dir := t.TempDir()
+	written, err := tpl.Apply(dir, options)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if len(written) != 3 {
+		t.Errorf("Apply() wrote %v, want devcontainer.json, install-tools.sh and dependabot.yml", written)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".devcontainer", "devcontainer.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != `{"image": "mcr.microsoft.com/devcontainers/go:1.22-bookworm"}` {
+		t.Errorf("devcontainer.json = %s", got)
+	}
+	if info, err := os.Stat(filepath.Join(dir, ".devcontainer", "install-tools.sh")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("install-tools.sh should keep its executable bit: %v, %v", info, err)
+	}
+	for _, skipped := range []string{"README.md", MetadataFile} {
+		if _, err := os.Stat(filepath.Join(dir, skipped)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be copied into the workspace", skipped)
+		}
+	}
+
+	if _, err := tpl.Apply(dir, options); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Apply() over existing files error = %v, want already exists", err)
+	}
+}
+
+func TestResolveOptions_Invalid(t *testing.T) {
+	tpl, err := Load(goTemplateArchive(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, values := range []map[string]string{
+		{"installTools": "yes"},
+		{"distro": "alpine"},
+		{"goVersion": "1.23"},
+	} {
+		if _, err := tpl.ResolveOptions(values); err == nil {
+			t.Errorf("ResolveOptions(%v) should fail", values)
+		}
+	}
+}
+
+func TestLoad_RejectsEscapingPaths(t *testing.T) {
+	archive := buildArchive(t, map[string]string{
+		MetadataFile:    `{"id": "evil"}`,
+		"../../.bashrc": "rm -rf ~",
+	})
+	if _, err := Load(archive); err == nil {
+		t.Error("Load() should reject entries outside the template")
+	}
+	if _, err := Load(buildArchive(t, map[string]string{"a.txt": "x"})); err == nil {
+		t.Error("Load() should require " + MetadataFile)
+	}
+}
+
+func TestClientFetch(t *testing.T) {
+	archive := goTemplateArchive(t)
+	sum := sha256.Sum256(archive)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if user, pass, ok := r.BasicAuth(); !ok || user != "me" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:devcontainers/templates/go:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token": "t0k3n"}`)
+		case r.Header.Get("Authorization") != "Bearer t0k3n":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/devcontainers/templates/go/manifests/3":
+			fmt.Fprintf(w, `{"layers": [{"mediaType": "application/vnd.devcontainers.layer.v1+tar", "digest": %q}]}`, digest)
+		case r.URL.Path == "/v2/devcontainers/templates/go/blobs/"+digest:
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+	client := &Client{Credentials: func(host string) (string, string) {
+		if host == registry {
+			return "me", "secret"
+		}
+		return "", ""
+	}}
+	got, err := client.Fetch(context.Background(), Ref{Registry: registry, Repository: "devcontainers/templates/go", Reference: "3"})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if !bytes.Equal(got, archive) {
+		t.Error("Fetch() returned a different archive")
+	}
+
+	_, err = client.Fetch(context.Background(), Ref{Registry: registry, Repository: "devcontainers/templates/go", Reference: "9"})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Fetch() of a missing tag error = %v, want 404", err)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:a/b:pull"`)
+	want := map[string]string{"realm": "https://ghcr.io/token", "service": "ghcr.io", "scope": "repository:a/b:pull"}
+	if scheme != "Bearer" || !reflect.DeepEqual(params, want) {
+		t.Errorf("parseChallenge() = %q, %v, want Bearer, %v", scheme, params, want)
+	}
+}